@@ -0,0 +1,135 @@
+// Package assets holds the files the scenes load: images, fonts, maps,
+// levels and scripts. They are embedded in the binary, which runs from any
+// directory on its own; a file at the same path under the assets directory
+// of the working directory is read instead, to edit an asset without
+// rebuilding.
+//
+// Images and font faces are decoded once and cached, the other files are
+// read each time they're asked for, so that restarting a scene picks up an
+// edited file. Like ebiten's images, the cache is for the game's goroutine
+// only.
+package assets
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"image"
+	// The images are PNG.
+	_ "image/png"
+	"io/fs"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+)
+
+// Dir is the directory, relative to the working directory, whose files are
+// read instead of the embedded ones.
+const Dir = "assets"
+
+//go:embed fonts maps scripts
+var embedded embed.FS
+
+// overlay is a file system that opens the files of a directory when they
+// exist there, and those of a fallback otherwise.
+type overlay struct {
+	dir      fs.FS
+	fallback fs.FS
+}
+
+func (o overlay) Open(name string) (fs.File, error) {
+	f, err := o.dir.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.fallback.Open(name)
+	}
+	return f, err
+}
+
+// FS is the assets' file system, for the loaders that read a file and the
+// files it refers to, e.g. a map and its tilesets. Paths are slash
+// separated, e.g. "maps/demo.tmx".
+var FS fs.FS = overlay{dir: os.DirFS(Dir), fallback: embedded}
+
+var (
+	decoded = map[string]image.Image{}
+	images  = map[string]*ebiten.Image{}
+	fonts   = map[string]*opentype.Font{}
+	faces   = map[faceKey]font.Face{}
+)
+
+type faceKey struct {
+	name string
+	size float64
+}
+
+// Bytes returns the content of the file name.
+func Bytes(name string) ([]byte, error) {
+	return fs.ReadFile(FS, name)
+}
+
+// Text returns the content of the text file name, e.g. a script.
+func Text(name string) (string, error) {
+	data, err := Bytes(name)
+	return string(data), err
+}
+
+// Decoded returns the image file name decoded, for the code that reads its
+// pixels, e.g. to trace its outline.
+func Decoded(name string) (image.Image, error) {
+	if img, ok := decoded[name]; ok {
+		return img, nil
+	}
+	f, err := FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	decoded[name] = img
+	return img, nil
+}
+
+// Image returns the image file name, ready to be drawn.
+func Image(name string) (*ebiten.Image, error) {
+	if img, ok := images[name]; ok {
+		return img, nil
+	}
+	src, err := Decoded(name)
+	if err != nil {
+		return nil, err
+	}
+	img := ebiten.NewImageFromImage(src)
+	images[name] = img
+	return img, nil
+}
+
+// Font returns a face of the font file name, a TrueType or OpenType font,
+// at size points on a 72 DPI screen, i.e. size pixels.
+func Font(name string, size float64) (font.Face, error) {
+	key := faceKey{name, size}
+	if face, ok := faces[key]; ok {
+		return face, nil
+	}
+	f, ok := fonts[name]
+	if !ok {
+		data, err := Bytes(name)
+		if err != nil {
+			return nil, err
+		}
+		if f, err = opentype.Parse(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		fonts[name] = f
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	faces[key] = face
+	return face, nil
+}
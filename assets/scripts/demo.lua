@@ -0,0 +1,43 @@
+-- A pendulum knocking down a tower of boxes off a shelf. Boxes turn gold
+-- when the pendulum hits them, and are removed once they fell off the shelf.
+
+gravity(0, 300)
+wall(0, 560, 800, 560)
+wall(500, 400, 780, 400)
+
+local PENDULUM, BOX = 1, 2
+
+local bob = ball(150, 80, 25, 10)
+color(bob, "tomato")
+collision_type(bob, PENDULUM)
+pin(bob, nil, 450, 80)
+
+local boxes = {}
+for row = 0, 5 do
+  for col = 0, 2 do
+    local b = box(520 + col * 32, 385 - row * 30, 30, 30, 1)
+    color(b, "steelblue")
+    collision_type(b, BOX)
+    table.insert(boxes, b)
+  end
+end
+
+local hit, fallen = 0, 0
+on_collision(PENDULUM, BOX, function(_, b)
+  hit = hit + 1
+  color(b, "gold")
+end)
+
+status = ""
+
+function update(dt)
+  for i = #boxes, 1, -1 do
+    local _, y = position(boxes[i])
+    if y > 500 then
+      remove(boxes[i])
+      table.remove(boxes, i)
+      fallen = fallen + 1
+    end
+  end
+  status = string.format("Pendulum hits: %d, boxes fallen: %d.", hit, fallen)
+end
@@ -0,0 +1,68 @@
+// Command replay prints a session recorded with -record: its header, then a
+// line for each step the input changed at, with the actions held, the
+// cursor, the mouse buttons and the scene.
+//
+//	go run ./cmd/replay session.rec
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/replay"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: replay session.rec")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	r, err := replay.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer r.Close()
+	h := r.Header
+	fmt.Printf("Version %d, seed %d, config %016x, step %gs\n", h.Version, h.Seed, h.ConfigHash, h.Step)
+
+	var last replay.Frame
+	step := 0
+	for ; ; step++ {
+		f, err := r.ReadFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if step > 0 && f == last {
+			continue
+		}
+		last = f
+		var held []string
+		for i, name := range h.Actions {
+			if f.Actions&(1<<uint(i)) != 0 {
+				held = append(held, name)
+			}
+		}
+		scene := fmt.Sprint(f.Scene)
+		if int(f.Scene) < len(h.Scenes) {
+			scene = h.Scenes[f.Scene]
+		}
+		fmt.Printf("%6d %9s  %-14s cursor %4d,%-4d buttons %03b  %s\n",
+			step, time.Duration(float64(step)*h.Step*float64(time.Second)).Round(time.Millisecond),
+			scene, f.Cursor[0], f.Cursor[1], f.Buttons, strings.Join(held, " "))
+	}
+	fmt.Printf("%d steps, %s\n", step, time.Duration(float64(step)*h.Step*float64(time.Second)).Round(time.Second))
+}
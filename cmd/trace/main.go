@@ -0,0 +1,50 @@
+// Command trace prints the physics outlines of a PNG image, traced from its
+// alpha channel, as JSON: a list of closed contours, each a list of points
+// in pixels from the image's top left corner.
+//
+//	go run ./cmd/trace -threshold 128 -tolerance 1 assets/maps/rock.png
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/trace"
+)
+
+func main() {
+	threshold := flag.Uint("threshold", 128, "alpha from which a pixel is solid, 1 to 255")
+	tolerance := flag.Float64("tolerance", 1, "how far in pixels the simplified outline may stray from the traced one")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: trace [flags] image.png")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *threshold < 1 || *threshold > 255 || *tolerance < 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir, name := filepath.Split(flag.Arg(0))
+	if dir == "" {
+		dir = "."
+	}
+	contours, _, err := trace.Load(os.DirFS(dir), name, uint8(*threshold), *tolerance)
+	if err != nil {
+		log.Fatal(err)
+	}
+	points := 0
+	for _, c := range contours {
+		points += len(c) - 1
+	}
+	log.Printf("%d contours, %d points", len(contours), points)
+	e := json.NewEncoder(os.Stdout)
+	e.SetIndent("", "  ")
+	if err := e.Encode(contours); err != nil {
+		log.Fatal(err)
+	}
+}
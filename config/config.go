@@ -0,0 +1,120 @@
+// Package config reads the simulation parameters and the window size from
+// the config file. It doesn't depend on ebiten, so the headless build uses
+// it too.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/storage"
+)
+
+// File is where the simulation parameters are read from.
+const File = "config.json"
+
+// Config holds the parameters of the Hello Chipmunk simulation and the size
+// of the window, to experiment without recompiling. The screen the scenes
+// draw on stays the same size, scaled to the window.
+type Config struct {
+	physics.HelloParams
+	// SimulateMaxSeconds is how long a round of Hello Chipmunk lasts, and
+	// how long the headless build simulates by default.
+	SimulateMaxSeconds float64    `json:"simulate_max_seconds"`
+	BallColor          color.RGBA `json:"ball_color"`
+	GroundColor        color.RGBA `json:"ground_color"`
+	WindowWidth        int        `json:"window_width"`
+	WindowHeight       int        `json:"window_height"`
+	Fullscreen         bool       `json:"fullscreen"`
+	VSync              bool       `json:"vsync"`
+	// ShowContacts draws the contact points of the scenes.
+	ShowContacts bool `json:"show_contacts"`
+	// Iterations is how many times the spaces of the scenes iterate to
+	// solve their constraints and collisions per step, cp's 10 by default.
+	// The scenes that need more for their chains or stacks keep theirs.
+	Iterations int `json:"iterations"`
+	// AdaptiveSteps merges the fixed steps of the physics while they take
+	// most of the frame budget, for the game to keep up.
+	AdaptiveSteps bool `json:"adaptive_steps"`
+	// MaxBodies caps the bodies of the sandbox, the ones over it removed,
+	// those out of view first; 0 doesn't cap them.
+	MaxBodies int `json:"max_bodies"`
+	// RedrawRegions redraws only the regions of the screen where shapes
+	// moved, for the scenes where few do.
+	RedrawRegions bool `json:"redraw_regions"`
+	// VideoFormat is the format of the videos F10 records with ffmpeg, mp4
+	// or webm.
+	VideoFormat string `json:"video_format"`
+}
+
+// Default is the configuration without a config file, for the 800x600
+// screen of the game.
+func Default() Config {
+	return Config{
+		HelloParams: physics.HelloParams{
+			Gravity:    cp.Vector{Y: 100},
+			Damping:    1,
+			BallRadius: 5,
+			BallMass:   1,
+			Friction:   0.7,
+			Ground:     [2]cp.Vector{{}, {X: 800, Y: 600}},
+		},
+		SimulateMaxSeconds: 6,
+		BallColor:          color.RGBA{R: 200, G: 200, B: 200, A: 255},
+		GroundColor:        color.RGBA{R: 255, G: 255, B: 255, A: 255},
+		WindowWidth:        800,
+		WindowHeight:       600,
+		VSync:              true,
+		Iterations:         10,
+		AdaptiveSteps:      true,
+		MaxBodies:          1000,
+		VideoFormat:        "mp4",
+	}
+}
+
+// Load reads the config file at path, see storage, over the defaults, so it only needs
+// the values it changes. A missing file is not an error.
+func Load(path string) (Config, error) {
+	c := Default()
+	data, err := storage.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Default(), fmt.Errorf("%s: %w", path, err)
+	}
+	if c.BallRadius <= 0 || c.BallMass <= 0 || c.WindowWidth <= 0 || c.WindowHeight <= 0 {
+		return Default(), fmt.Errorf("%s: ball radius and mass, and window size, must be positive", path)
+	}
+	if c.Damping < 0 || c.Damping > 1 {
+		return Default(), fmt.Errorf("%s: damping must be between 0 and 1", path)
+	}
+	if c.Iterations < 1 {
+		return Default(), fmt.Errorf("%s: iterations must be at least 1", path)
+	}
+	if c.MaxBodies < 0 {
+		return Default(), fmt.Errorf("%s: max bodies can't be negative", path)
+	}
+	if c.VideoFormat != "mp4" && c.VideoFormat != "webm" {
+		return Default(), fmt.Errorf("%s: video format must be mp4 or webm", path)
+	}
+	return c, nil
+}
+
+// Save writes the config to the file at path.
+func Save(path string, c Config) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(path, append(data, '\n'))
+}
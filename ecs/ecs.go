@@ -0,0 +1,80 @@
+// Package ecs keeps game objects in a donburi world: an entity is a set of
+// components, and systems run over the entities that have the components
+// they need. A cp body is just one more component, the physics sync system
+// copies where cp moved it into the Position the other systems use.
+package ecs
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+	"github.com/yohamta/donburi"
+)
+
+// PositionData is where an entity is in the world, and how much it's turned.
+type PositionData struct {
+	Point cp.Vector
+	Angle float64
+}
+
+// SpriteData is the image drawn for an entity, centered on its position.
+type SpriteData struct {
+	Image *ebiten.Image
+}
+
+// PhysicsBodyData is the body simulating an entity and the shapes attached
+// to it. Body is the space's static body for static shapes.
+type PhysicsBodyData struct {
+	Body   *cp.Body
+	Shapes []*cp.Shape
+}
+
+var (
+	Position    = donburi.NewComponentType[PositionData]()
+	Sprite      = donburi.NewComponentType[SpriteData]()
+	PhysicsBody = donburi.NewComponentType[PhysicsBodyData]()
+)
+
+// NewWorld returns a world whose entities' bodies live in space. Removing an
+// entity takes its shapes and body out of the space, so the space must not be
+// stepping then.
+func NewWorld(space *cp.Space) donburi.World {
+	world := donburi.NewWorld()
+	world.OnRemove(func(world donburi.World, entity donburi.Entity) {
+		entry := world.Entry(entity)
+		if !entry.HasComponent(PhysicsBody) {
+			return
+		}
+		pb := PhysicsBody.Get(entry)
+		for _, shape := range pb.Shapes {
+			space.RemoveShape(shape)
+		}
+		if pb.Body != space.StaticBody {
+			space.RemoveBody(pb.Body)
+		}
+	})
+	return world
+}
+
+// SpawnBody adds the body and its shapes to the space and creates the entity
+// for them, at the body's position. A nil img makes an entity that isn't
+// drawn.
+func SpawnBody(world donburi.World, space *cp.Space, body *cp.Body, shapes []*cp.Shape, img *ebiten.Image) *donburi.Entry {
+	if body != space.StaticBody {
+		space.AddBody(body)
+	}
+	for _, shape := range shapes {
+		space.AddShape(shape)
+	}
+
+	components := []donburi.IComponentType{PhysicsBody, Position}
+	if img != nil {
+		components = append(components, Sprite)
+	}
+	entry := world.Entry(world.Create(components...))
+	PhysicsBody.SetValue(entry, PhysicsBodyData{Body: body, Shapes: shapes})
+	Position.SetValue(entry, PositionData{Point: body.Position(), Angle: body.Angle()})
+	if img != nil {
+		Sprite.SetValue(entry, SpriteData{Image: img})
+	}
+	return entry
+}
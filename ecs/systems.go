@@ -0,0 +1,56 @@
+package ecs
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+	"github.com/yohamta/donburi"
+	"github.com/yohamta/donburi/filter"
+)
+
+var (
+	simulated = donburi.NewQuery(filter.Contains(PhysicsBody, Position))
+	drawn     = donburi.NewQuery(filter.Contains(Position, Sprite))
+)
+
+// SyncPhysics copies the position and angle of every body into its entity's
+// Position. Run it after stepping the space, before the systems reading
+// positions.
+func SyncPhysics(world donburi.World) {
+	simulated.Each(world, func(entry *donburi.Entry) {
+		body := PhysicsBody.Get(entry).Body
+		Position.SetValue(entry, PositionData{Point: body.Position(), Angle: body.Angle()})
+	})
+}
+
+// RemoveOutside removes the entities whose position left bounds, and
+// returns how many it removed.
+func RemoveOutside(world donburi.World, bounds cp.BB) int {
+	// Removing moves entities around in their storage, so it can't be done
+	// while the query walks it.
+	var outside []donburi.Entity
+	simulated.Each(world, func(entry *donburi.Entry) {
+		if !bounds.ContainsVect(Position.Get(entry).Point) {
+			outside = append(outside, entry.Entity())
+		}
+	})
+	for _, entity := range outside {
+		world.Remove(entity)
+	}
+	return len(outside)
+}
+
+// Render draws the sprite of every entity that has one, centered on its
+// position and turned with it.
+func Render(world donburi.World, screen *ebiten.Image) {
+	op := &ebiten.DrawImageOptions{}
+	drawn.Each(world, func(entry *donburi.Entry) {
+		pos := Position.Get(entry)
+		img := Sprite.Get(entry).Image
+		w, h := img.Size()
+		op.GeoM.Reset()
+		op.GeoM.Translate(-float64(w)/2, -float64(h)/2)
+		op.GeoM.Rotate(pos.Angle)
+		op.GeoM.Translate(pos.Point.X, pos.Point.Y)
+		screen.DrawImage(img, op)
+	})
+}
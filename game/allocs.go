@@ -0,0 +1,101 @@
+package game
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"text/tabwriter"
+)
+
+// allocPart is a part of a frame whose allocations the report counts apart.
+type allocPart int
+
+const (
+	// allocPhysics is the scenes' Update, their steps.
+	allocPhysics allocPart = iota
+	// allocBookkeeping is around the steps: the recordings, the stats, the
+	// heatmap, the stream and the plot.
+	allocBookkeeping
+	// allocUpdate is the rest of the updates: the input, the overlays' keys,
+	// the menus and the tools.
+	allocUpdate
+	// allocScene is the scenes' Draw.
+	allocScene
+	// allocOverlays is the drawing over the scene: the HUD, the overlays,
+	// the screenshots and captures.
+	allocOverlays
+	// allocEngine is between frames, ebiten's own.
+	allocEngine
+	allocParts
+)
+
+var allocPartNames = [allocParts]string{"physics", "bookkeeping", "update", "scene drawing", "overlays", "engine"}
+
+// allocReport counts the allocations of each part of the frames, for the
+// summary printed after the run with -allocreport: the memory of a big
+// scene is tuned by where its allocations are, not only by how many there
+// are, which the F4 overlay has. The runtime's statistics are read at every
+// change of part, which stops the world each time: the frames are slower
+// while it counts.
+type allocReport struct {
+	// part is the part being counted since mem was read.
+	part allocPart
+	mem  runtime.MemStats
+	// mallocs and bytes are the allocations of each part, over frames.
+	mallocs, bytes [allocParts]uint64
+	frames         int
+}
+
+// allocs is the report, nil unless ReportAllocations was called.
+var allocs *allocReport
+
+// ReportAllocations starts counting the allocations of each part of the
+// frames, for PrintAllocations.
+func ReportAllocations() {
+	allocs = &allocReport{part: allocEngine}
+	runtime.ReadMemStats(&allocs.mem)
+}
+
+// enter counts the allocations since the last change of part as the
+// part's, and starts counting those of p.
+func (r *allocReport) enter(p allocPart) {
+	if r == nil {
+		return
+	}
+	before := r.mem
+	runtime.ReadMemStats(&r.mem)
+	r.mallocs[r.part] += r.mem.Mallocs - before.Mallocs
+	r.bytes[r.part] += r.mem.TotalAlloc - before.TotalAlloc
+	r.part = p
+}
+
+// frameDone ends a frame, once drawn.
+func (r *allocReport) frameDone() {
+	if r == nil {
+		return
+	}
+	r.enter(allocEngine)
+	r.frames++
+}
+
+// PrintAllocations prints the allocations a frame of each part, and all
+// of them, counted since ReportAllocations.
+func PrintAllocations(w io.Writer) {
+	r := allocs
+	if r == nil || r.frames == 0 {
+		return
+	}
+	r.enter(r.part)
+	fmt.Fprintf(w, "Allocations a frame over %d frames:\n", r.frames)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "\tallocs\tbytes\t")
+	var mallocs, bytes uint64
+	for p := allocPart(0); p < allocParts; p++ {
+		mallocs += r.mallocs[p]
+		bytes += r.bytes[p]
+		fmt.Fprintf(tw, "%s\t%.1f\t%.0f\t\n", allocPartNames[p], float64(r.mallocs[p])/float64(r.frames), float64(r.bytes[p])/float64(r.frames))
+	}
+	fmt.Fprintf(tw, "total\t%.1f\t%.0f\t\n", float64(mallocs)/float64(r.frames), float64(bytes)/float64(r.frames))
+	tw.Flush()
+	fmt.Fprintf(w, "Heap in use %.1f MB, %d collections, pauses %.2f ms\n", float64(r.mem.HeapInuse)/(1<<20), r.mem.NumGC, float64(r.mem.PauseTotalNs)/1e6)
+}
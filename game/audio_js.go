@@ -0,0 +1,65 @@
+package game
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// audioUnlockEvents are the page's events a browser lets a suspended audio
+// context resume from, a tap, a click or a key.
+var audioUnlockEvents = []string{"pointerdown", "touchend", "keydown"}
+
+// audioContext is the page's Web Audio context, for the sounds to play on.
+// Browsers start it suspended until the user interacts with the page, see
+// unlockAudio.
+var audioContext js.Value
+
+// unlockAudio makes the page's audio context and, as the browser started it
+// suspended, resumes it on the first tap, click or key press. It's resumed
+// from the listener itself, in the event, which is when browsers allow it;
+// the listeners are removed then. The listener doesn't log, which could
+// block the page's event loop.
+func unlockAudio() {
+	if audioContext.Truthy() {
+		return
+	}
+	if err := newAudioContext(); err != nil {
+		logGame.Warnf("Audio: %v, the game is silent", err)
+		return
+	}
+	if audioContext.Get("state").String() != "suspended" {
+		return
+	}
+	var unlock js.Func
+	unlock = js.FuncOf(func(js.Value, []js.Value) interface{} {
+		audioContext.Call("resume")
+		for _, event := range audioUnlockEvents {
+			js.Global().Call("removeEventListener", event, unlock, true)
+		}
+		unlock.Release()
+		return nil
+	})
+	for _, event := range audioUnlockEvents {
+		js.Global().Call("addEventListener", event, unlock, true)
+	}
+}
+
+// newAudioContext sets audioContext to a new context of the page, an error
+// if the browser has no Web Audio or refuses it.
+func newAudioContext() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	constructor := js.Global().Get("AudioContext")
+	if !constructor.Truthy() {
+		// Older Safaris have it prefixed.
+		constructor = js.Global().Get("webkitAudioContext")
+	}
+	if !constructor.Truthy() {
+		return fmt.Errorf("no Web Audio")
+	}
+	audioContext = constructor.New()
+	return nil
+}
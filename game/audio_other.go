@@ -0,0 +1,7 @@
+//go:build !js
+
+package game
+
+// unlockAudio does nothing: out of a browser, sounds play without waiting
+// for the user to interact first.
+func unlockAudio() {}
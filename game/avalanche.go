@@ -0,0 +1,208 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	avalancheGravity = 300
+	avalancheBodies  = 1500
+	// avalancheBurst is how many bodies a click adds, up to avalancheMax.
+	avalancheBurst = 200
+	avalancheMax   = 4000
+	avalancheSize  = 8
+)
+
+// avalancheSlopes zigzag down the screen, the last one stopping short of
+// the right wall for the bodies to fall off the bottom.
+var avalancheSlopes = [][2]cp.Vector{
+	{{X: 0, Y: 0}, {X: 0, Y: ScreenHeight}},
+	{{X: ScreenWidth, Y: 0}, {X: ScreenWidth, Y: ScreenHeight}},
+	{{X: 0, Y: 150}, {X: 600, Y: 260}},
+	{{X: ScreenWidth, Y: 300}, {X: 200, Y: 410}},
+	{{X: 0, Y: 450}, {X: 650, Y: 550}},
+}
+
+// Avalanche pours more bodies than the game steps comfortably in a frame
+// down slopes, stepped by a physics.Runner on a goroutine of its own: Update
+// only tells it to step, and Draw draws the sprites where the latest
+// snapshot has the bodies, never touching the space. The bodies that fall
+// off the bottom start again from the top.
+type Avalanche struct {
+	runner *physics.Runner
+	// sprites is the image of each body, made when the body is, on the
+	// game's goroutine: the body is only a key of it there.
+	sprites map[*cp.Body]*ebiten.Image
+	box     *ebiten.Image
+	ball    *ebiten.Image
+	// rand is the game goroutine's random numbers, recycleRand the
+	// runner's.
+	rand        *rand.Rand
+	recycleRand *rand.Rand
+}
+
+func init() {
+	RegisterDemo("Avalanche", "thousands of bodies stepped on their own goroutine", func() Scene { return NewAvalanche() })
+}
+
+func NewAvalanche() *Avalanche {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: avalancheGravity})
+
+	a := &Avalanche{
+		runner:      physics.NewRunner(space, physicsStep),
+		sprites:     map[*cp.Body]*ebiten.Image{},
+		box:         render.NewBoxSprite(avalancheSize, avalancheSize, colornames.Steelblue),
+		ball:        render.NewBallSprite(avalancheSize/2, colornames.Orange),
+		rand:        rand.New(rand.NewSource(1)),
+		recycleRand: rand.New(rand.NewSource(2)),
+	}
+	for _, s := range avalancheSlopes {
+		physics.AddStaticSegment(space, s[0], s[1], 0).SetFriction(0.5)
+	}
+	for i := 0; i < avalancheBodies; i++ {
+		body, shape := a.newBody(cp.Vector{X: 20 + a.rand.Float64()*560, Y: 140 - a.rand.Float64()*400})
+		space.AddBody(body)
+		space.AddShape(shape)
+	}
+	a.runner.OnStep = a.recycle
+	return a
+}
+
+// Init starts stepping the space on the runner's goroutine, from then on
+// the space is the runner's.
+func (a *Avalanche) Init() {
+	a.runner.Start()
+}
+
+// Dispose stops the runner's goroutine.
+func (a *Avalanche) Dispose() {
+	a.runner.Stop()
+}
+
+// newBody returns a small box or ball at pos, not added to the space yet,
+// with its sprite.
+func (a *Avalanche) newBody(pos cp.Vector) (*cp.Body, *cp.Shape) {
+	var mass float64 = 1
+	var body *cp.Body
+	var shape *cp.Shape
+	if a.rand.Intn(2) == 0 {
+		body = cp.NewBody(mass, cp.MomentForBox(mass, avalancheSize, avalancheSize))
+		shape = cp.NewBox(body, avalancheSize, avalancheSize, 0)
+		a.sprites[body] = a.box
+	} else {
+		body = cp.NewBody(mass, cp.MomentForCircle(mass, 0, avalancheSize/2, cp.Vector{}))
+		shape = cp.NewCircle(body, avalancheSize/2, cp.Vector{})
+		a.sprites[body] = a.ball
+	}
+	body.SetPosition(pos)
+	shape.SetFriction(0.5)
+	return body, shape
+}
+
+// spawn adds a burst of bodies around pos. They are made here, with their
+// sprites, and added to the space by the runner.
+func (a *Avalanche) spawn(pos cp.Vector) {
+	n := avalancheBurst
+	if left := avalancheMax - len(a.sprites); n > left {
+		n = left
+	}
+	for i := 0; i < n; i++ {
+		body, shape := a.newBody(pos.Add(cp.Vector{X: a.rand.Float64()*80 - 40, Y: a.rand.Float64()*80 - 40}))
+		a.runner.Do(func(space *cp.Space) {
+			space.AddBody(body)
+			space.AddShape(shape)
+		})
+	}
+}
+
+// recycle puts the bodies that fell off the bottom back on top, on the
+// runner's goroutine after each step.
+func (a *Avalanche) recycle(space *cp.Space) {
+	space.EachBody(func(body *cp.Body) {
+		if body.Position().Y > ScreenHeight+avalancheSize {
+			body.SetPosition(cp.Vector{X: 20 + a.recycleRand.Float64()*560, Y: -avalancheSize})
+			body.SetVelocity(0, 0)
+		}
+	})
+}
+
+// physicsStats counts the space from the snapshot: the space is the
+// runner's.
+func (a *Avalanche) physicsStats() physics.Stats {
+	var stats physics.Stats
+	a.runner.View(func(s *physics.Snapshot) { stats = s.Stats })
+	return stats
+}
+
+// inSpace calls f on the runner's goroutine, between two steps, and waits
+// for it. A runner stopped by a panic runs nothing any more: the space is
+// the game's again, f is called on it directly, e.g. to dump it.
+func (a *Avalanche) inSpace(f func(space *cp.Space)) {
+	if a.runner.Err() != nil {
+		f(a.runner.Space())
+		return
+	}
+	a.runner.Do(f)
+	a.runner.Sync()
+}
+
+// consoleCommand pours bodies for spawn, a body needing a sprite made on the
+// game's goroutine.
+func (a *Avalanche) consoleCommand(name string, args []string) (string, bool, error) {
+	if name != "spawn" {
+		return "", false, nil
+	}
+	a.spawn(input.CursorPosition())
+	return fmt.Sprintf("Poured up to %d bodies at the cursor", avalancheBurst), true, nil
+}
+
+// Update has the runner make dt's worth of the steps of physicsStep it was
+// made with, one, or two of merged steps.
+func (a *Avalanche) Update(dt float64) error {
+	// A panic of the space stops the runner, and the scene with it.
+	if err := a.runner.Err(); err != nil {
+		return err
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		a.spawn(input.CursorPosition())
+	}
+	a.runner.Advance(int(math.Round(dt / physicsStep)))
+	return nil
+}
+
+func (a *Avalanche) Draw(screen *ebiten.Image) {
+	for _, s := range avalancheSlopes {
+		ebitenutil.DrawLine(screen, s[0].X, s[0].Y, s[1].X, s[1].Y, colornames.White)
+	}
+	op := &ebiten.DrawImageOptions{}
+	var step, bodies int
+	var stepTime string
+	a.runner.View(func(s *physics.Snapshot) {
+		for _, b := range s.Bodies {
+			op.GeoM.Reset()
+			op.GeoM.Translate(-avalancheSize/2, -avalancheSize/2)
+			op.GeoM.Rotate(b.Angle)
+			op.GeoM.Translate(b.Position.X, b.Position.Y)
+			screen.DrawImage(a.sprites[b.Body], op)
+		}
+		step, bodies, stepTime = s.Step, len(s.Bodies), s.StepTime.String()
+	})
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Bodies: %d, step %d took %s on the physics goroutine.\nClick to pour %d more.",
+		bodies, step, stepTime, avalancheBurst,
+	))
+}
@@ -0,0 +1,127 @@
+package game
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	balloonsGravity = 300
+	balloonRadius   = 20
+	balloonMass     = 0.5
+	// balloonLift is the buoyant force in multiples of the balloon's weight.
+	balloonLift = 3
+	// balloonDrag is the fraction of velocity a balloon keeps after one
+	// second, air resistance that keeps it from rising ever faster.
+	balloonDrag    = 0.3
+	balloonTether  = 150
+	balloonCutDist = 6
+)
+
+// tether is a string between a balloon and what holds it. The bodies are
+// kept because a constraint doesn't expose them.
+type tether struct {
+	joint            *cp.Constraint
+	a, b             *cp.Body
+	anchorA, anchorB cp.Vector
+}
+
+// Balloons floats balloons whose own velocity function replaces gravity by a
+// stronger upward force, next to boxes that fall normally. Each balloon is
+// held by a string the user can click to cut.
+type Balloons struct {
+	baseScene
+
+	space   *cp.Space
+	tethers []tether
+	cut     int
+}
+
+func init() {
+	RegisterDemo("Balloons", "buoyant balloons with their own velocity function", func() Scene { return NewBalloons() })
+}
+
+func NewBalloons() *Balloons {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: balloonsGravity})
+
+	b := &Balloons{space: space}
+
+	physics.AddWalls(space, cp.BB{R: ScreenWidth, T: groundY})
+
+	// Balloons tied to the ground.
+	for _, x := range []float64{100, 180, 260} {
+		anchor := cp.Vector{X: x, Y: groundY}
+		balloon := b.addBalloon(anchor.Add(cp.Vector{Y: -balloonTether}))
+		b.tie(space.StaticBody, balloon, anchor, cp.Vector{})
+	}
+
+	// A weight too heavy to lift and a box light enough to be carried away.
+	for _, box := range []struct{ x, size float64 }{{450, 30}, {650, 10}} {
+		weight, _ := physics.AddBox(space, cp.Vector{X: box.x, Y: groundY - box.size/2}, box.size, box.size)
+		balloon := b.addBalloon(cp.Vector{X: box.x, Y: groundY - box.size - balloonTether})
+		b.tie(weight, balloon, cp.Vector{Y: -box.size / 2}, cp.Vector{})
+	}
+
+	return b
+}
+
+func (b *Balloons) addBalloon(pos cp.Vector) *cp.Body {
+	moment := cp.MomentForCircle(balloonMass, 0, balloonRadius, cp.Vector{})
+	body := b.space.AddBody(cp.NewBody(balloonMass, moment))
+	body.SetPosition(pos)
+	body.SetVelocityUpdateFunc(balloonVelocity)
+	shape := b.space.AddShape(cp.NewCircle(body, balloonRadius, cp.Vector{}))
+	shape.SetElasticity(0.5)
+	shape.SetFriction(0.2)
+	shape.UserData = colornames.Red
+	return body
+}
+
+// balloonVelocity integrates a balloon with buoyancy instead of gravity, and
+// with its own drag instead of the space's damping.
+func balloonVelocity(body *cp.Body, gravity cp.Vector, _, dt float64) {
+	body.UpdateVelocity(gravity.Mult(1-balloonLift), math.Pow(balloonDrag, dt), dt)
+}
+
+// tie attaches a string from a to b. A slide joint with no minimum goes slack
+// like a string would.
+func (b *Balloons) tie(a, balloon *cp.Body, anchorA, anchorB cp.Vector) {
+	joint := b.space.AddConstraint(cp.NewSlideJoint(a, balloon, anchorA, anchorB, 0, balloonTether))
+	b.tethers = append(b.tethers, tether{joint: joint, a: a, b: balloon, anchorA: anchorA, anchorB: anchorB})
+}
+
+func (b *Balloons) Update(dt float64) error {
+	if input.Pointer.IsJustPressed() {
+		cursor := input.Pointer.Position()
+		for i, t := range b.tethers {
+			if physics.SegmentDistance(cursor, t.a.LocalToWorld(t.anchorA), t.b.LocalToWorld(t.anchorB)) < balloonCutDist {
+				b.space.RemoveConstraint(t.joint)
+				b.tethers = append(b.tethers[:i], b.tethers[i+1:]...)
+				b.cut++
+				break
+			}
+		}
+	}
+
+	b.space.Step(dt)
+	return nil
+}
+
+func (b *Balloons) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, b.space, colornames.Burlywood)
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Strings cut: %d. Lift: %dx a balloon's weight.\nClick a string to cut it.",
+		b.cut, balloonLift,
+	))
+}
@@ -0,0 +1,207 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	billiardsBallRadius   = 10
+	billiardsBallMass     = 1
+	billiardsPocketRadius = 22
+	billiardsMaxPower     = 900
+	billiardsPowerScale   = 6
+	// billiardsFelt is the fraction of velocity kept after one second, the
+	// felt's rolling resistance.
+	billiardsFelt = 0.6
+	// billiardsResting is the speed under which a ball counts as stopped.
+	billiardsResting = 2
+)
+
+const (
+	billiardsBallType cp.CollisionType = iota + 1
+	billiardsPocketType
+)
+
+var (
+	billiardsTable    = cp.BB{L: 50, B: 125, R: 750, T: 475}
+	billiardsCueStart = cp.Vector{X: 200, Y: 300}
+	billiardsApex     = cp.Vector{X: 550, Y: 300}
+	billiardsColors   = []color.Color{
+		colornames.Gold, colornames.Blue, colornames.Red, colornames.Purple,
+		colornames.Orange, colornames.Green, colornames.Brown, colornames.Dimgray,
+	}
+)
+
+// Billiards is a top-down pool table: no gravity, elastic balls, damping for
+// the felt and sensors in the pockets.
+type Billiards struct {
+	baseScene
+
+	space  *cp.Space
+	cue    *cp.Body
+	aiming bool
+	potted int
+	shots  int
+}
+
+func init() {
+	RegisterDemo("Billiards", "a top-down pool table", func() Scene { return NewBilliards() })
+}
+
+func NewBilliards() *Billiards {
+	space := newSpace()
+	space.SetDamping(billiardsFelt)
+
+	b := &Billiards{space: space}
+
+	t := billiardsTable
+	mid := t.Center().X
+	gap := float64(billiardsPocketRadius)
+	cushions := [][2]cp.Vector{
+		{{X: t.L + gap, Y: t.B}, {X: mid - gap, Y: t.B}},
+		{{X: mid + gap, Y: t.B}, {X: t.R - gap, Y: t.B}},
+		{{X: t.L + gap, Y: t.T}, {X: mid - gap, Y: t.T}},
+		{{X: mid + gap, Y: t.T}, {X: t.R - gap, Y: t.T}},
+		{{X: t.L, Y: t.B + gap}, {X: t.L, Y: t.T - gap}},
+		{{X: t.R, Y: t.B + gap}, {X: t.R, Y: t.T - gap}},
+	}
+	for _, seg := range cushions {
+		cushion := space.AddShape(cp.NewSegment(space.StaticBody, seg[0], seg[1], 2))
+		cushion.SetElasticity(0.9)
+		cushion.SetFriction(0.2)
+	}
+	// Walls behind the pockets catch balls that miss the sensors.
+	outer := cp.BB{L: t.L - 20, B: t.B - 20, R: t.R + 20, T: t.T + 20}
+	for _, seg := range [][2]cp.Vector{
+		{{X: outer.L, Y: outer.B}, {X: outer.R, Y: outer.B}},
+		{{X: outer.R, Y: outer.B}, {X: outer.R, Y: outer.T}},
+		{{X: outer.R, Y: outer.T}, {X: outer.L, Y: outer.T}},
+		{{X: outer.L, Y: outer.T}, {X: outer.L, Y: outer.B}},
+	} {
+		wall := space.AddShape(cp.NewSegment(space.StaticBody, seg[0], seg[1], 0))
+		wall.SetElasticity(0.5)
+		wall.UserData = colornames.Dimgray
+	}
+
+	for _, pos := range []cp.Vector{
+		{X: t.L, Y: t.B}, {X: mid, Y: t.B - 5}, {X: t.R, Y: t.B},
+		{X: t.L, Y: t.T}, {X: mid, Y: t.T + 5}, {X: t.R, Y: t.T},
+	} {
+		pocket := space.AddShape(cp.NewCircle(space.StaticBody, billiardsPocketRadius, pos))
+		pocket.SetSensor(true)
+		pocket.SetCollisionType(billiardsPocketType)
+		pocket.UserData = colornames.Darkgreen
+	}
+	handler := space.NewCollisionHandler(billiardsBallType, billiardsPocketType)
+	handler.BeginFunc = b.pocket
+
+	// Rack the fifteen balls in a triangle pointing at the cue ball.
+	n := 0
+	for row := 0; row < 5; row++ {
+		for i := 0; i <= row; i++ {
+			pos := billiardsApex.Add(cp.Vector{
+				X: float64(row) * billiardsBallRadius * math.Sqrt(3),
+				Y: (float64(i) - float64(row)/2) * billiardsBallRadius * 2,
+			})
+			b.addBall(pos, billiardsColors[n%len(billiardsColors)])
+			n++
+		}
+	}
+	b.cue = b.addBall(billiardsCueStart, colornames.White)
+
+	return b
+}
+
+func (b *Billiards) addBall(pos cp.Vector, clr color.Color) *cp.Body {
+	moment := cp.MomentForCircle(billiardsBallMass, 0, billiardsBallRadius, cp.Vector{})
+	body := b.space.AddBody(cp.NewBody(billiardsBallMass, moment))
+	body.SetPosition(pos)
+	shape := b.space.AddShape(cp.NewCircle(body, billiardsBallRadius, cp.Vector{}))
+	shape.SetElasticity(0.95)
+	shape.SetFriction(0.05)
+	shape.SetCollisionType(billiardsBallType)
+	shape.UserData = clr
+	return body
+}
+
+// pocket removes a ball that dropped in a pocket. Bodies can't be removed
+// while the space is stepping, so the work is deferred to a post-step callback.
+func (b *Billiards) pocket(arb *cp.Arbiter, space *cp.Space, _ interface{}) bool {
+	ball, _ := arb.Shapes()
+	space.AddPostStepCallback(func(space *cp.Space, key, _ interface{}) {
+		shape := key.(*cp.Shape)
+		body := shape.Body()
+		if body == b.cue {
+			// Scratch: the cue ball goes back to its spot.
+			body.SetPosition(billiardsCueStart)
+			body.SetVelocityVector(cp.Vector{})
+			return
+		}
+		space.RemoveShape(shape)
+		space.RemoveBody(body)
+		b.potted++
+	}, ball, nil)
+	return false
+}
+
+// resting reports whether every ball has stopped rolling.
+func (b *Billiards) resting() bool {
+	resting := true
+	b.space.EachBody(func(body *cp.Body) {
+		if body.Velocity().Length() > billiardsResting {
+			resting = false
+		}
+	})
+	return resting
+}
+
+// shot returns the impulse the cue gives when released at the cursor: away
+// from the cursor, proportional to how far back it was pulled.
+func (b *Billiards) shot() cp.Vector {
+	pull := b.cue.Position().Sub(input.Pointer.Position())
+	return pull.Mult(billiardsPowerScale).Clamp(billiardsMaxPower).Mult(billiardsBallMass)
+}
+
+func (b *Billiards) Update(dt float64) error {
+	switch {
+	case input.Pointer.IsJustPressed():
+		b.aiming = b.resting()
+		if b.aiming {
+			input.Pointer.Capture()
+		}
+	case b.aiming && input.Pointer.IsJustReleased():
+		b.aiming = false
+		b.cue.ApplyImpulseAtWorldPoint(b.shot(), b.cue.Position())
+		b.shots++
+	}
+
+	b.space.Step(dt)
+	return nil
+}
+
+func (b *Billiards) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, b.space, colornames.Forestgreen)
+
+	if b.aiming {
+		cue, cursor := b.cue.Position(), input.Pointer.Position()
+		ebitenutil.DrawLine(screen, cursor.X, cursor.Y, cue.X, cue.Y, colornames.Burlywood)
+		aim := cue.Add(b.shot().Normalize().Mult(200))
+		ebitenutil.DrawLine(screen, cue.X, cue.Y, aim.X, aim.Y, colornames.Lightgray)
+	}
+
+	msg := fmt.Sprintf("Shots: %d. Potted: %d/15.\n", b.shots, b.potted)
+	if b.resting() {
+		msg += "Click and drag back from the cue ball, release to shoot."
+	}
+	ebitenutil.DebugPrint(screen, msg)
+}
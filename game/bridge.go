@@ -0,0 +1,138 @@
+package game
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	bridgeGravity     = 300
+	bridgePlanks      = 10
+	bridgePlankMass   = 2
+	bridgePlankHeight = 10
+	// bridgeSlack is how much longer than the gap the bridge is.
+	bridgeSlack      = 1.1
+	bridgeBreakForce = 120000
+	bridgeCrateSize  = 40
+	bridgeCrateMass  = 20
+)
+
+var (
+	bridgeLeft  = cp.Vector{X: 150, Y: 300}
+	bridgeRight = cp.Vector{X: 650, Y: 300}
+)
+
+// Bridge is a suspension bridge of planks linked by pivot joints between two
+// cliffs. The links break when the force they carry gets too large.
+type Bridge struct {
+	baseScene
+
+	space  *cp.Space
+	crates int
+	broken int
+}
+
+func init() {
+	RegisterDemo("Plank bridge", "links that snap under too much force", func() Scene { return NewBridge() })
+}
+
+func NewBridge() *Bridge {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: bridgeGravity})
+	space.Iterations = 30
+
+	b := &Bridge{space: space}
+	// The planks overlap each other and the cliffs at the links, so they all
+	// share a group and don't collide.
+	filter := cp.NewShapeFilter(4, cp.ALL_CATEGORIES, cp.ALL_CATEGORIES)
+
+	for _, cliff := range []cp.BB{
+		{L: 0, B: bridgeLeft.Y, R: bridgeLeft.X, T: ScreenHeight},
+		{L: bridgeRight.X, B: bridgeRight.Y, R: ScreenWidth, T: ScreenHeight},
+	} {
+		shape := space.AddShape(cp.NewBox2(space.StaticBody, cliff, 0))
+		shape.SetFriction(1)
+		shape.SetFilter(filter)
+		shape.UserData = colornames.Sienna
+	}
+
+	// Lay the planks in a V so that their ends meet both cliffs exactly: each
+	// plank is tilted by the angle that makes the slack disappear.
+	gap := bridgeRight.Sub(bridgeLeft).Length()
+	length := gap * bridgeSlack / bridgePlanks
+	tilt := math.Acos(1 / bridgeSlack)
+
+	prev, end := space.StaticBody, bridgeLeft
+	for i := 0; i < bridgePlanks; i++ {
+		angle := tilt
+		if i >= bridgePlanks/2 {
+			angle = -tilt
+		}
+		next := end.Add(cp.ForAngle(angle).Mult(length))
+
+		moment := cp.MomentForBox(bridgePlankMass, length, bridgePlankHeight)
+		plank := space.AddBody(cp.NewBody(bridgePlankMass, moment))
+		plank.SetPosition(end.Lerp(next, 0.5))
+		plank.SetAngle(angle)
+		shape := space.AddShape(cp.NewBox(plank, length, bridgePlankHeight, 0))
+		shape.SetFriction(1)
+		shape.SetFilter(filter)
+		shape.UserData = colornames.Burlywood
+
+		b.link(prev, plank, end)
+		prev, end = plank, next
+	}
+	b.link(prev, space.StaticBody, bridgeRight)
+
+	return b
+}
+
+// link pins two bodies together at pivot with a joint that breaks under load.
+func (b *Bridge) link(bodyA, bodyB *cp.Body, pivot cp.Vector) {
+	joint := b.space.AddConstraint(cp.NewPivotJoint(bodyA, bodyB, pivot))
+	joint.PostSolve = func(joint *cp.Constraint, space *cp.Space) {
+		force := joint.Class.GetImpulse() / space.TimeStep()
+		if force > bridgeBreakForce {
+			// Constraints can't be removed during the step.
+			space.AddPostStepCallback(func(space *cp.Space, key, _ interface{}) {
+				space.RemoveConstraint(key.(*cp.Constraint))
+				b.broken++
+			}, joint, nil)
+		}
+	}
+}
+
+func (b *Bridge) dropCrate(pos cp.Vector) {
+	moment := cp.MomentForBox(bridgeCrateMass, bridgeCrateSize, bridgeCrateSize)
+	crate := b.space.AddBody(cp.NewBody(bridgeCrateMass, moment))
+	crate.SetPosition(pos)
+	shape := b.space.AddShape(cp.NewBox(crate, bridgeCrateSize, bridgeCrateSize, 0))
+	shape.SetFriction(0.8)
+	b.crates++
+}
+
+func (b *Bridge) Update(dt float64) error {
+	if input.Pointer.IsJustPressed() {
+		b.dropCrate(input.Pointer.Position())
+	}
+
+	b.space.Step(dt)
+	return nil
+}
+
+func (b *Bridge) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, b.space, colornames.White)
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Crates: %d (%d kg each). Broken links: %d.\nClick to drop a crate on the bridge.",
+		b.crates, bridgeCrateMass, b.broken,
+	))
+}
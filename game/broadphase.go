@@ -0,0 +1,121 @@
+package game
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	// spatialHashDim is the side of the spatial hash's cells, about the
+	// size of the scenes' bodies, and spatialHashCount its number of cells.
+	spatialHashDim   = 32
+	spatialHashCount = 2000
+	// broadphaseSteps is how many steps from the start of the scene each
+	// index is measured over, for both to be measured over the same run.
+	broadphaseSteps = 600
+)
+
+// broadphase is the index a space finds the pairs of shapes that may touch
+// with, before the collisions are checked shape against shape.
+type broadphase int
+
+const (
+	// bbTree is cp's default, a tree of bounding boxes, for shapes of any
+	// size.
+	bbTree broadphase = iota
+	// spatialHash is a grid of cells, for many shapes of about the same size.
+	spatialHash
+)
+
+func (b broadphase) String() string {
+	if b == spatialHash {
+		return "spatial hash"
+	}
+	return "BB tree"
+}
+
+// spacesBroadphase is the index of the spaces made from now on. cp can't
+// turn a spatial hash back into a tree, the scene is made anew to change
+// it.
+var spacesBroadphase = bbTree
+
+// useBroadphase gives space the index of the spaces made.
+func useBroadphase(space *cp.Space) {
+	if spacesBroadphase == spatialHash {
+		space.UseSpatialHash(spatialHashDim, spatialHashCount)
+	}
+}
+
+// broadphaseComparison measures the steps of a scene under both indexes,
+// each over the first steps from its start, for which suits the scene to
+// be seen: the spatial hash wins in dense scenes of bodies alike, the
+// avalanche, the tree where the sizes vary.
+type broadphaseComparison struct {
+	// visible is set by switching the index, for the scene.
+	visible bool
+	// scene is the index of the scene measured, total and steps its steps'
+	// time and number under each index.
+	scene int
+	total [2]time.Duration
+	steps [2]int
+	// text is the comparison's, written anew every frame.
+	text render.TextBuffer
+}
+
+// started starts measuring the scene at index anew under the index in use,
+// and forgets the other index's measure for another scene.
+func (c *broadphaseComparison) started(index int) {
+	if index != c.scene {
+		*c = broadphaseComparison{scene: index, text: c.text}
+	}
+	c.total[spacesBroadphase], c.steps[spacesBroadphase] = 0, 0
+}
+
+// stepped measures a step of the scene that took d.
+func (c *broadphaseComparison) stepped(d time.Duration) {
+	if c.steps[spacesBroadphase] < broadphaseSteps {
+		c.total[spacesBroadphase] += d
+		c.steps[spacesBroadphase]++
+	}
+}
+
+// average is the average step under b, 0 before any.
+func (c *broadphaseComparison) average(b broadphase) time.Duration {
+	if c.steps[b] == 0 {
+		return 0
+	}
+	return c.total[b] / time.Duration(c.steps[b])
+}
+
+// toggleBroadphase makes the scene anew with the other index.
+func (g *Game) toggleBroadphase() {
+	spacesBroadphase = 1 - spacesBroadphase
+	g.switchScene(g.index)
+	g.broadphase.visible = true
+	g.toast.show("Broadphase: " + spacesBroadphase.String())
+}
+
+// draw draws the comparison at y, under the other overlays, and returns
+// the y under it.
+func (c *broadphaseComparison) draw(screen *ebiten.Image, y int) int {
+	if !c.visible {
+		return y
+	}
+	t := c.text.Reset().Text("Broadphase: ").Text(spacesBroadphase.String()).Text(" (I)")
+	for _, b := range []broadphase{bbTree, spatialHash} {
+		t.Text("\n").Text(b.String()).Text(" ").Float(milliseconds(c.average(b)), 2).Text(" ms, ").Int(c.steps[b]).Text("/").Int(broadphaseSteps)
+	}
+	if tree, hash := c.average(bbTree), c.average(spatialHash); c.steps[bbTree] == broadphaseSteps && c.steps[spatialHash] == broadphaseSteps && tree > 0 && hash > 0 {
+		faster, ratio := spatialHash, float64(tree)/float64(hash)
+		if hash > tree {
+			faster, ratio = bbTree, float64(hash)/float64(tree)
+		}
+		t.Text("\n").Text(faster.String()).Text(" ").Float(ratio, 2).Text("x faster")
+	}
+	drawHUD(screen, c.text, overlayX, y)
+	return y + 5*overlayLineHeight
+}
@@ -0,0 +1,108 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	// budgetFrames is how many frames in a row over the budget show the
+	// warning, and how many in a row within it hide it again.
+	budgetFrames = 10
+	// budgetY is the top of the warning, under the scenes' text.
+	budgetY = 48
+)
+
+// frameTimes is where the time of a frame went: the physics steps of its
+// updates, its drawing, and the rest of its updates, the input and the
+// game's bookkeeping.
+type frameTimes struct {
+	physics, render, other time.Duration
+}
+
+func (t frameTimes) total() time.Duration {
+	return t.physics + t.render + t.other
+}
+
+// budgetWarning warns when the frames take longer than the frame budget to
+// update and draw, several in a row, with where their time went: a scene
+// grown too heavy, a sandbox filled with bodies, shows before it stutters
+// for good.
+type budgetWarning struct {
+	// update and physics are the time of the updates since the last frame,
+	// and of their steps.
+	update, physics time.Duration
+	// over and under are the frames in a row over and within the budget.
+	over, under int
+	shown       bool
+	// sum is the time of the last frames in a row over the budget, the
+	// shown breakdown their average.
+	sum    frameTimes
+	frames int
+}
+
+// updated counts an update that took d.
+func (b *budgetWarning) updated(d time.Duration) {
+	b.update += d
+}
+
+// stepped counts a physics step of an update that took d.
+func (b *budgetWarning) stepped(d time.Duration) {
+	b.physics += d
+}
+
+// frameDone ends the frame whose drawing started at start, and weighs it
+// and its updates against budget.
+func (b *budgetWarning) frameDone(start time.Time, budget time.Duration) {
+	t := frameTimes{physics: b.physics, render: time.Since(start), other: b.update - b.physics}
+	b.update, b.physics = 0, 0
+	if t.total() <= budget {
+		b.over = 0
+		b.under++
+		if b.under >= budgetFrames {
+			b.shown = false
+		}
+		return
+	}
+	if b.over == 0 {
+		b.sum, b.frames = frameTimes{}, 0
+	}
+	b.over++
+	b.under = 0
+	b.sum.physics += t.physics
+	b.sum.render += t.render
+	b.sum.other += t.other
+	b.frames++
+	if b.over >= budgetFrames {
+		if !b.shown {
+			logGame.Warnf("%d frames in a row over the budget of %v, %v on average", b.over, budget, b.sum.total()/time.Duration(b.frames))
+		}
+		b.shown = true
+	}
+}
+
+func (b *budgetWarning) draw(screen *ebiten.Image) {
+	if !b.shown || b.frames == 0 {
+		return
+	}
+	n := time.Duration(b.frames)
+	lines := [...]string{
+		fmt.Sprintf("Frames over budget: %.1f ms for %.1f, %d in a row", milliseconds(b.sum.total()/n), milliseconds(frameBudget()), b.frames),
+		fmt.Sprintf("physics %.1f ms, render %.1f ms, other %.1f ms", milliseconds(b.sum.physics/n), milliseconds(b.sum.render/n), milliseconds(b.sum.other/n)),
+	}
+	width := 0
+	for _, line := range lines {
+		if w := len(line) * 6; w > width {
+			width = w
+		}
+	}
+	x := (ScreenWidth - width) / 2
+	ebitenutil.DrawRect(screen, float64(x-8), budgetY-4, float64(width+16), float64(len(lines)*overlayLineHeight+8), color.RGBA{R: 0x80, A: 0xc0})
+	for i, line := range lines {
+		ebitenutil.DebugPrintAt(screen, line, x, budgetY+i*overlayLineHeight)
+	}
+}
@@ -0,0 +1,179 @@
+package game
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	catapultGravity   = 300
+	catapultArmLength = 220
+	catapultArmMass   = 5
+
+	// cp measures a rotary spring's angle from B to A, hence the sign: the
+	// spring pulls the arm towards +2.5 rad, far past the stop.
+	catapultRestAngle   = -2.5
+	catapultLoadedAngle = -0.5
+	catapultBallRadius  = 8
+	catapultBallMass    = 1
+
+	catapultMinTension = 20000
+	catapultMaxTension = 400000
+)
+
+var catapultPivot = cp.Vector{X: 150, Y: 480}
+
+// Catapult is a lever arm on a pivot, wound by a rotary spring and held by a
+// latch. Releasing the latch flings the ball sitting in the arm's cup; the
+// arm then hits a stop and the ball carries on alone.
+type Catapult struct {
+	baseScene
+
+	space  *cp.Space
+	arm    *cp.Body
+	ball   *cp.Body
+	spring *cp.DampedRotarySpring
+	stop   *cp.RotaryLimitJoint
+	latch  *cp.Constraint
+
+	time     float64
+	launched bool
+	launch   cp.Vector
+}
+
+func init() {
+	RegisterDemo("Catapult", "a spring-loaded lever arm", func() Scene { return NewCatapult() })
+}
+
+func NewCatapult() *Catapult {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: catapultGravity})
+
+	// Ground and walls
+	for _, seg := range [][2]cp.Vector{
+		{{X: 0, Y: groundY}, {X: ScreenWidth, Y: groundY}},
+		{{X: 0, Y: 0}, {X: 0, Y: groundY}},
+		{{X: ScreenWidth, Y: 0}, {X: ScreenWidth, Y: groundY}},
+	} {
+		physics.AddStaticSegment(space, seg[0], seg[1], 0).SetElasticity(0.5)
+	}
+
+	// The frame and the arm share a group so that the arm swings through it.
+	frame := cp.NewShapeFilter(2, cp.ALL_CATEGORIES, cp.ALL_CATEGORIES)
+	stand := space.AddShape(cp.NewPolyShape(space.StaticBody, 3, []cp.Vector{
+		{X: catapultPivot.X - 20, Y: groundY}, catapultPivot, {X: catapultPivot.X + 20, Y: groundY},
+	}, cp.NewTransformIdentity(), 0))
+	stand.SetFilter(frame)
+
+	// The arm is a long box with a lip at its loaded end to cup the ball.
+	moment := cp.MomentForBox(catapultArmMass, catapultArmLength, 10)
+	arm := space.AddBody(cp.NewBody(catapultArmMass, moment))
+	arm.SetPosition(catapultPivot)
+	arm.SetAngle(catapultLoadedAngle)
+	armShape := space.AddShape(cp.NewBox(arm, catapultArmLength, 10, 0))
+	armShape.SetFilter(frame)
+	armShape.SetFriction(1)
+	lip := space.AddShape(cp.NewBox2(arm, cp.BB{
+		L: -catapultArmLength / 2, B: -25, R: -catapultArmLength/2 + 6, T: -5,
+	}, 0))
+	lip.SetFilter(frame)
+
+	space.AddConstraint(cp.NewPivotJoint(space.StaticBody, arm, catapultPivot))
+	spring := space.AddConstraint(cp.NewDampedRotarySpring(space.StaticBody, arm, catapultRestAngle, 120000, 1000))
+	stop := space.AddConstraint(cp.NewRotaryLimitJoint(space.StaticBody, arm, catapultLoadedAngle, math.Pi/4))
+	latch := space.AddConstraint(cp.NewRotaryLimitJoint(space.StaticBody, arm, catapultLoadedAngle, catapultLoadedAngle))
+
+	ballMoment := cp.MomentForCircle(catapultBallMass, 0, catapultBallRadius, cp.Vector{})
+	ball := space.AddBody(cp.NewBody(catapultBallMass, ballMoment))
+	ball.SetPosition(arm.LocalToWorld(cp.Vector{X: -catapultArmLength/2 + 6 + catapultBallRadius, Y: -5 - catapultBallRadius}))
+	ballShape := space.AddShape(cp.NewCircle(ball, catapultBallRadius, cp.Vector{}))
+	ballShape.SetFriction(0.7)
+	ballShape.SetElasticity(0.5)
+
+	return &Catapult{
+		space:  space,
+		arm:    arm,
+		ball:   ball,
+		spring: spring.Class.(*cp.DampedRotarySpring),
+		stop:   stop.Class.(*cp.RotaryLimitJoint),
+		latch:  latch,
+	}
+}
+
+func (c *Catapult) released() bool {
+	return c.latch == nil
+}
+
+func (c *Catapult) Update(dt float64) error {
+	if !c.released() {
+		switch {
+		case input.IsActionPressed(input.ActionUp):
+			c.spring.Stiffness = math.Min(c.spring.Stiffness*1.02, catapultMaxTension)
+		case input.IsActionPressed(input.ActionDown):
+			c.spring.Stiffness = math.Max(c.spring.Stiffness/1.02, catapultMinTension)
+		case input.IsActionPressed(input.ActionLeft):
+			c.stop.Max = math.Min(c.stop.Max+0.01, math.Pi/2)
+		case input.IsActionPressed(input.ActionRight):
+			c.stop.Max = math.Max(c.stop.Max-0.01, 0.2)
+		}
+		if input.IsActionJustPressed(input.ActionLaunch) {
+			c.space.RemoveConstraint(c.latch)
+			c.latch = nil
+			c.arm.Activate()
+		}
+	}
+
+	c.time += dt
+	c.space.Step(dt)
+
+	// The ball is launched the first step it no longer touches the arm.
+	if c.released() && !c.launched && !c.touchesArm() {
+		c.launched = true
+		c.launch = c.ball.Velocity()
+	}
+	return nil
+}
+
+func (c *Catapult) touchesArm() bool {
+	touching := false
+	c.ball.EachArbiter(func(arb *cp.Arbiter) {
+		a, b := arb.Bodies()
+		if a == c.arm || b == c.arm {
+			touching = true
+		}
+	})
+	return touching
+}
+
+func (c *Catapult) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, c.space, colornames.White)
+
+	if !c.released() {
+		// Show where the arm will stop, hence the launch direction.
+		tip := catapultPivot.Add(cp.ForAngle(c.stop.Max + math.Pi).Mult(catapultArmLength / 2))
+		ebitenutil.DrawLine(screen, catapultPivot.X, catapultPivot.Y, tip.X, tip.Y, colornames.Dimgray)
+	}
+
+	msg := fmt.Sprintf(
+		"Time is %5.2f. Tension is %6.0f, arm stops at %5.1f deg.\n",
+		c.time, c.spring.Stiffness, c.stop.Max*180/math.Pi,
+	)
+	if c.launched {
+		msg += fmt.Sprintf(
+			"Launched at %5.1f deg, %6.1f px/s.",
+			math.Atan2(-c.launch.Y, c.launch.X)*180/math.Pi, c.launch.Length(),
+		)
+	} else {
+		msg += "Up/Down: tension, Left/Right: stop angle, Space: release."
+	}
+	ebitenutil.DebugPrint(screen, msg)
+}
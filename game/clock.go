@@ -0,0 +1,67 @@
+package game
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// physicsStep is the fixed time step the scenes are updated by, whatever
+	// the ticks per second.
+	physicsStep = 1.0 / 60
+	// maxSteps caps the steps of one tick: after a long hitch the simulation
+	// falls behind instead of freezing the game while it catches up.
+	maxSteps = 5
+	// tickJitter is how far from a tick a measured frame time still counts
+	// as one, as a fraction of the tick.
+	tickJitter = 0.05
+)
+
+// fixedClock turns the measured time between ticks into a number of fixed
+// steps, carrying the remainder over to the next tick.
+type fixedClock struct {
+	last        time.Time
+	accumulator float64
+}
+
+// steps returns how many fixed steps the time since the last tick makes, at
+// now, sped up by scale. tick is the time of a tick at the current ticks per
+// second.
+func (c *fixedClock) steps(now time.Time, tick, scale float64) int {
+	elapsed := tick
+	if !c.last.IsZero() {
+		elapsed = now.Sub(c.last).Seconds()
+	}
+	c.last = now
+	// Frame timing jitters around the tick, which would step 0 or 2 times
+	// instead of once now and then.
+	if math.Abs(elapsed-tick) < tick*tickJitter {
+		elapsed = tick
+	}
+
+	return c.advance(elapsed * scale)
+}
+
+// fixed returns the steps of a tick that lasts a step whatever time it took,
+// sped up by scale, for the frames dumped to be the same every run.
+func (c *fixedClock) fixed(scale float64) int {
+	return c.advance(physicsStep * scale)
+}
+
+// advance adds elapsed to the time not stepped yet and returns how many
+// steps it makes.
+func (c *fixedClock) advance(elapsed float64) int {
+	c.accumulator += elapsed
+	n := int(c.accumulator / physicsStep)
+	c.accumulator -= float64(n) * physicsStep
+	if n > maxSteps {
+		n = maxSteps
+		c.accumulator = 0
+	}
+	return n
+}
+
+// reset forgets the time since the last tick, after a pause.
+func (c *fixedClock) reset() {
+	*c = fixedClock{}
+}
@@ -0,0 +1,101 @@
+package game
+
+import (
+	"time"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/config"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/storage"
+)
+
+// configPollInterval is how often the config file is checked for changes.
+const configPollInterval = time.Second
+
+// cfg is the configuration in use.
+var cfg = config.Default()
+
+// LoadConfig loads the config file, falling back to the defaults when it is
+// broken, and returns the configuration now in use.
+func LoadConfig() config.Config {
+	c, err := config.Load(config.File)
+	if err != nil {
+		logConfig.Warnf("%v, using the defaults", err)
+	}
+	cfg = c
+	configWatch.modTime = configModTime()
+	return c
+}
+
+// SetConfig replaces the configuration in use, e.g. by one with values from
+// the command line. It applies to the scenes made from then on.
+func SetConfig(c config.Config) {
+	cfg = c
+}
+
+// configOverrides sets the values of the command line in a reloaded config,
+// see SetConfigOverrides.
+var configOverrides func(c *config.Config)
+
+// SetConfigOverrides has f set the values given on the command line in the
+// config file's, when it is reloaded, for them to go on overriding it.
+func SetConfigOverrides(f func(c *config.Config)) {
+	configOverrides = f
+}
+
+// newSpace returns an empty space for a scene, iterating as many times as
+// the config says, tracked for the statistics overlay.
+func newSpace() *cp.Space {
+	space := trackSpace(cp.NewSpace())
+	space.Iterations = uint(cfg.Iterations)
+	logPhysics.Debugf("New space, %d iterations", cfg.Iterations)
+	return space
+}
+
+// configurable is a scene that applies a reloaded configuration to itself
+// while it runs.
+type configurable interface {
+	applyConfig(c config.Config)
+}
+
+// configWatcher reloads the config file when it was modified since it was
+// last loaded. The file is polled, there is no file system notification
+// that works on every platform.
+type configWatcher struct {
+	modTime time.Time
+	checked time.Time
+}
+
+var configWatch configWatcher
+
+// configModTime returns when the config file was modified, the zero time
+// when there is none.
+func configModTime() time.Time {
+	return storage.ModTime(config.File)
+}
+
+// poll reloads the config file if it changed, and reports whether it did.
+// A broken file is logged and the configuration in use is kept, to fix it
+// and save again.
+func (w *configWatcher) poll(now time.Time) bool {
+	if now.Sub(w.checked) < configPollInterval {
+		return false
+	}
+	w.checked = now
+	modTime := configModTime()
+	if modTime.Equal(w.modTime) {
+		return false
+	}
+	w.modTime = modTime
+	c, err := config.Load(config.File)
+	if err != nil {
+		logConfig.Warnf("%v, not reloaded", err)
+		return false
+	}
+	logConfig.Infof("%s reloaded", config.File)
+	if configOverrides != nil {
+		configOverrides(&c)
+	}
+	cfg = c
+	return true
+}
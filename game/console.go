@@ -0,0 +1,466 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+const (
+	// consoleLines is how many lines of the console show above its prompt,
+	// and consoleKept how many it keeps.
+	consoleLines = 12
+	consoleKept  = 100
+	// consoleRepeatDelay and consoleRepeatTicks are when a held key
+	// starts repeating, and how often then, in ticks.
+	consoleRepeatDelay = 30
+	consoleRepeatTicks = 3
+)
+
+// consoleCommand is a command of the developer console.
+type consoleCommand struct {
+	usage string
+	// run runs the command on the game with the words after its name, and
+	// returns what to print.
+	run func(g *Game, args []string) (string, error)
+}
+
+// consoleCommands are the commands of the console by name, see init.
+var consoleCommands map[string]consoleCommand
+
+// consoleHandler is a scene that runs some of the console's commands its own
+// way, e.g. spawning bodies with their sprites: handled is false for the
+// console to run the command itself.
+type consoleHandler interface {
+	consoleCommand(name string, args []string) (out string, handled bool, err error)
+}
+
+// spaceOwner is a scene whose space is stepped on another goroutine: the
+// console changes it with inSpace, between two steps, rather than directly.
+type spaceOwner interface {
+	inSpace(f func(space *cp.Space))
+}
+
+// devConsole drops down over the game to run commands against it, typed
+// one line at a time: the game doesn't step while it is open, nor take any
+// other key.
+type devConsole struct {
+	open bool
+	line []rune
+	// lines are the commands run and what they printed.
+	lines []string
+	// history are the commands run, which the arrows recall, recall the
+	// one recalled, len(history) for the line being typed.
+	history []string
+	recall  int
+}
+
+func init() {
+	consoleCommands = map[string]consoleCommand{
+		"help": {"help", func(g *Game, args []string) (string, error) {
+			names := make([]string, 0, len(consoleCommands))
+			for name := range consoleCommands {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			usages := make([]string, len(names))
+			for i, name := range names {
+				usages[i] = "  " + consoleCommands[name].usage
+			}
+			return strings.Join(usages, "\n"), nil
+		}},
+		"clear": {"clear", func(g *Game, args []string) (string, error) {
+			g.console.lines = nil
+			return "", nil
+		}},
+		"scene": {"scene [number or name]: switch scenes, list them without one", consoleScene},
+		"spawn": {"spawn circle|box [size]: add a body at the cursor, size its radius or side", consoleSpawn},
+		"gravity": {"gravity x y: set the gravity of the space", func(g *Game, args []string) (string, error) {
+			v, err := consoleNumbers(args, 2)
+			if err != nil {
+				return "", err
+			}
+			err = g.inSpace(func(space *cp.Space) {
+				space.SetGravity(cp.Vector{X: v[0], Y: v[1]})
+			})
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Gravity %g, %g", v[0], v[1]), nil
+		}},
+		"set": {"set parameter value: set a parameter of the tuning panel, e.g. friction, time_scale", consoleSet},
+		"save": {"save name: save the space as a level, to name.json", func(g *Game, args []string) (string, error) {
+			path, err := consoleLevelPath(args)
+			if err != nil {
+				return "", err
+			}
+			var level physics.Level
+			err = g.inSpace(func(space *cp.Space) {
+				level = physics.NewLevel(space, levelBodies(space))
+			})
+			if err != nil {
+				return "", err
+			}
+			return writeLevel(path, level)
+		}},
+		"load": {"load name: load the level of name.json, in the sandbox", func(g *Game, args []string) (string, error) {
+			return "", errors.New("only the sandbox loads levels")
+		}},
+		"export": {"export name: export the space to name.json in the portable format, for other tools", consoleExport},
+		"import": {"import name: import name.json in the portable format, in the sandbox", consoleImport},
+		"dump": {"dump: dump the spaces and the last inputs to a file, as a crash does", func(g *Game, args []string) (string, error) {
+			path, err := g.dump("on demand", "")
+			if err != nil {
+				return "", err
+			}
+			return "Dumped to " + path, nil
+		}},
+		"restore": {"restore file [space]: load a space of a dump, the first by default, in the sandbox", consoleRestore},
+		"shards":  {"shards 1|2|4: split the Shards scene's world into as many spaces", consoleShards},
+	}
+}
+
+// consoleExport writes the scene's space as a portable scene, without the
+// mice's bodies, which have no shapes, nor their joints.
+func consoleExport(g *Game, args []string) (string, error) {
+	path, err := consoleLevelPath(args)
+	if err != nil {
+		return "", err
+	}
+	var scene physics.PortableScene
+	err = g.inSpace(func(space *cp.Space) {
+		scene = physics.ExportScene(space, exportBodies(space))
+	})
+	if err != nil {
+		return "", err
+	}
+	return writeScene(path, scene)
+}
+
+// levelBodies are the bodies of space that save writes: all but the static
+// ones.
+func levelBodies(space *cp.Space) []*cp.Body {
+	var bodies []*cp.Body
+	space.EachBody(func(body *cp.Body) {
+		if body.GetType() != cp.BODY_STATIC {
+			bodies = append(bodies, body)
+		}
+	})
+	return bodies
+}
+
+// exportBodies are the bodies of space that export writes: the dynamic
+// ones, and the kinematic ones with shapes, which the mice's have none of.
+func exportBodies(space *cp.Space) []*cp.Body {
+	var bodies []*cp.Body
+	space.EachBody(func(body *cp.Body) {
+		shapes := 0
+		body.EachShape(func(*cp.Shape) { shapes++ })
+		if body.GetType() == cp.BODY_DYNAMIC || body.GetType() == cp.BODY_KINEMATIC && shapes > 0 {
+			bodies = append(bodies, body)
+		}
+	})
+	return bodies
+}
+
+// writeLevel writes level to path for save, and tells where.
+func writeLevel(path string, level physics.Level) (string, error) {
+	if err := physics.WriteLevel(path, level); err != nil {
+		return "", err
+	}
+	return "Saved to " + path, nil
+}
+
+// writeScene writes scene to path for export, and tells what.
+func writeScene(path string, scene physics.PortableScene) (string, error) {
+	if err := physics.WriteScene(path, scene); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Exported %d bodies and %d joints to %s", len(scene.Bodies)-1, len(scene.Joints), path), nil
+}
+
+// consoleImport loads the portable scene file of args in the sandbox, as
+// restore does a dump's space.
+func consoleImport(g *Game, args []string) (string, error) {
+	path, err := consoleLevelPath(args)
+	if err != nil {
+		return "", err
+	}
+	scene, err := physics.ReadScene(path)
+	if err != nil {
+		return "", err
+	}
+	index, err := FindScene("sandbox")
+	if err != nil {
+		return "", err
+	}
+	if g.index != index || g.state == stateCrashed {
+		g.switchScene(index)
+	}
+	s, ok := g.scene.(*Sandbox)
+	if !ok {
+		return "", errors.New("the sandbox didn't start")
+	}
+	s.replace(scene.Build, path)
+	return fmt.Sprintf("Imported %d bodies and %d joints from %s", len(scene.Bodies)-1, len(scene.Joints), path), nil
+}
+
+// consoleScene switches to the scene of args, or lists the scenes.
+func consoleScene(g *Game, args []string) (string, error) {
+	if len(args) == 0 {
+		names := SceneNames()
+		for i, name := range names {
+			names[i] = fmt.Sprintf("  %2d. %s", i+1, name)
+		}
+		return strings.Join(names, "\n"), nil
+	}
+	index, err := FindScene(strings.Join(args, " "))
+	if err != nil {
+		return "", err
+	}
+	g.switchScene(index)
+	return "Scene: " + scenes[g.index].name, nil
+}
+
+// consoleSpawn adds a ball or a box at the cursor, through the scene's
+// camera if it has one.
+func consoleSpawn(g *Game, args []string) (string, error) {
+	kind, size, err := consoleShape(args)
+	if err != nil {
+		return "", err
+	}
+	pos := input.CursorPosition()
+	if scene, ok := g.scene.(viewer); ok && scene.camera() != nil {
+		pos = scene.camera().ToWorld(pos)
+	}
+	err = g.inSpace(func(space *cp.Space) {
+		if kind == "circle" {
+			physics.AddBall(space, pos, size)
+		} else {
+			physics.AddBox(space, pos, size, size)
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Spawned a %s at %.0f, %.0f", kind, pos.X, pos.Y), nil
+}
+
+// consoleShape reads the arguments of spawn: circle or box, and the size,
+// the sandbox's by default.
+func consoleShape(args []string) (kind string, size float64, err error) {
+	if len(args) == 0 || len(args) > 2 || (args[0] != "circle" && args[0] != "box") {
+		return "", 0, errors.New("usage: " + consoleCommands["spawn"].usage)
+	}
+	kind, size = args[0], sandboxBoxSize
+	if kind == "circle" {
+		size = sandboxBallSize
+	}
+	if len(args) == 2 {
+		v, err := consoleNumbers(args[1:], 1)
+		if err != nil {
+			return "", 0, err
+		}
+		if v[0] <= 0 {
+			return "", 0, errors.New("the size must be positive")
+		}
+		size = v[0]
+	}
+	return kind, size, nil
+}
+
+// consoleSet sets a parameter of the tuning panel, see tuningSliders,
+// beyond the ends of its slider if need be.
+func consoleSet(g *Game, args []string) (string, error) {
+	if len(args) != 2 {
+		return "", errors.New("usage: " + consoleCommands["set"].usage)
+	}
+	s, ok := findSlider(args[0])
+	if !ok {
+		return "", fmt.Errorf("no parameter %q", args[0])
+	}
+	v, err := consoleNumbers(args[1:], 1)
+	if err != nil {
+		return "", err
+	}
+	value := v[0]
+	switch {
+	case s.whole && (value < s.min || value != math.Trunc(value)):
+		return "", fmt.Errorf("the %s must be a whole number from %g", s.name, s.min)
+	case value < 0 && s.min >= 0:
+		return "", fmt.Errorf("the %s can't be negative", s.name)
+	}
+	if err := g.inSpace(func(space *cp.Space) { s.set(g, space, value) }); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Set the %s to %g", s.name, value), nil
+}
+
+// consoleNumbers parses the n numbers of args, finite ones: a NaN or an
+// infinity would get past the commands' checks into the space.
+func consoleNumbers(args []string, n int) ([]float64, error) {
+	if len(args) != n {
+		return nil, fmt.Errorf("want %d numbers, got %d", n, len(args))
+	}
+	v := make([]float64, n)
+	for i, arg := range args {
+		f, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("not a number: %q", arg)
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("not a finite number: %q", arg)
+		}
+		v[i] = f
+	}
+	return v, nil
+}
+
+// consoleLevelPath is the file of the level named by args, in the working
+// directory, with .json added if it has no extension.
+func consoleLevelPath(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("want the name of the level")
+	}
+	path := args[0]
+	if filepath.Ext(path) == "" {
+		path += ".json"
+	}
+	return path, nil
+}
+
+// inSpace calls f with the scene's space, through the scene if it owns it,
+// or the first one it made otherwise.
+func (g *Game) inSpace(f func(space *cp.Space)) error {
+	if scene, ok := g.scene.(spaceOwner); ok {
+		scene.inSpace(f)
+		return nil
+	}
+	if len(g.spaces) == 0 {
+		return errors.New("the scene has no space")
+	}
+	f(g.spaces[0])
+	return nil
+}
+
+// eachSpace calls f with every space of the scene, through the scene if it
+// owns them.
+func (g *Game) eachSpace(f func(space *cp.Space)) {
+	if scene, ok := g.scene.(spaceOwner); ok {
+		scene.inSpace(f)
+		return
+	}
+	for _, space := range g.spaces {
+		f(space)
+	}
+}
+
+// consoleRepeated reports whether key was just pressed, or is held long
+// enough to repeat.
+func consoleRepeated(key ebiten.Key) bool {
+	d := inpututil.KeyPressDuration(key)
+	return d == 1 || d >= consoleRepeatDelay && (d-consoleRepeatDelay)%consoleRepeatTicks == 0
+}
+
+// update edits the line, and runs it on Enter. Esc or the console's key
+// close the console.
+func (c *devConsole) update(g *Game) {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || input.IsActionJustPressed(input.ActionConsole) {
+		c.open = false
+		return
+	}
+	for _, r := range ebiten.AppendInputChars(nil) {
+		if r >= ' ' && r != '`' {
+			c.line = append(c.line, r)
+		}
+	}
+	switch {
+	case consoleRepeated(ebiten.KeyBackspace) && len(c.line) > 0:
+		c.line = c.line[:len(c.line)-1]
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) && c.recall > 0:
+		c.recall--
+		c.line = []rune(c.history[c.recall])
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) && c.recall < len(c.history):
+		c.recall++
+		c.line = nil
+		if c.recall < len(c.history) {
+			c.line = []rune(c.history[c.recall])
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter), inpututil.IsKeyJustPressed(ebiten.KeyNumpadEnter):
+		line := strings.TrimSpace(string(c.line))
+		c.line = nil
+		if line != "" {
+			c.history = append(c.history, line)
+			c.print("> " + line)
+			c.run(g, line)
+		}
+		c.recall = len(c.history)
+	}
+}
+
+// run runs a line typed: the scene's way if it has one, the console's
+// otherwise. A panic of the scene crashes it, not the game.
+func (c *devConsole) run(g *Game, line string) {
+	words := strings.Fields(line)
+	name, args := strings.ToLower(words[0]), words[1:]
+	command, ok := consoleCommands[name]
+	if !ok {
+		c.print(fmt.Sprintf("No command %q, see help", name))
+		return
+	}
+	var out string
+	var err error
+	g.safely(func() {
+		if scene, ok := g.scene.(consoleHandler); ok {
+			var handled bool
+			if out, handled, err = scene.consoleCommand(name, args); handled {
+				return
+			}
+		}
+		out, err = command.run(g, args)
+	})
+	if err != nil {
+		c.print("Error: " + err.Error())
+		return
+	}
+	if out != "" {
+		c.print(out)
+	}
+}
+
+// print adds the lines of s to the console, forgetting the oldest ones.
+func (c *devConsole) print(s string) {
+	c.lines = append(c.lines, strings.Split(s, "\n")...)
+	if len(c.lines) > consoleKept {
+		c.lines = c.lines[len(c.lines)-consoleKept:]
+	}
+}
+
+// draw draws the console over the top of the screen, the last lines above
+// the line being typed.
+func (c *devConsole) draw(screen *ebiten.Image) {
+	ebitenutil.DrawRect(screen, 0, 0, ScreenWidth, (consoleLines+1)*overlayLineHeight+4, color.RGBA{A: 0xe0})
+	lines := c.lines
+	if len(lines) > consoleLines {
+		lines = lines[len(lines)-consoleLines:]
+	}
+	y := (consoleLines - len(lines)) * overlayLineHeight
+	for _, line := range lines {
+		ebitenutil.DebugPrintAt(screen, line, 4, y)
+		y += overlayLineHeight
+	}
+	ebitenutil.DebugPrintAt(screen, "> "+string(c.line)+"_", 4, y)
+}
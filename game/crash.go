@@ -0,0 +1,106 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"runtime/debug"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	// crashMaxLines and crashMaxColumns are how much of the stack fits on
+	// the overlay in the debug font, the rest is in the log.
+	crashMaxLines   = 30
+	crashMaxColumns = ScreenWidth / 6
+)
+
+// crashReport is why the scene stopped: the value it panicked with, or the
+// error its Update returned, and where.
+type crashReport struct {
+	scene string
+	value string
+	// stack is the stack where the scene panicked, empty for an error.
+	stack string
+	// dump is the file the scene's spaces were dumped to, if they were.
+	dump string
+}
+
+// brokenScene stands in for a scene that panicked while being made: it
+// does nothing, for the game to go on around it.
+type brokenScene struct {
+	baseScene
+}
+
+func (brokenScene) Update(float64) error { return nil }
+func (brokenScene) Draw(*ebiten.Image)   {}
+
+// safely calls f, a call into the scene, and reports whether it returned:
+// a panic stops the scene with an overlay instead of the game, for an
+// experiment gone wrong not to close the window.
+func (g *Game) safely(f func()) (ok bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			g.crash(v, string(debug.Stack()))
+			ok = false
+		}
+	}()
+	f()
+	return true
+}
+
+// crash stops the scene, from any state, with v, a panic value or an error,
+// shown over the scene with the stack.
+func (g *Game) crash(v interface{}, stack string) {
+	g.crashed = crashReport{scene: scenes[g.index].name, value: fmt.Sprint(v), stack: stack}
+	if stack == "" {
+		logGame.Errorf("%s stopped: %v", g.crashed.scene, v)
+	} else {
+		logGame.Errorf("%s crashed: %v\n%s", g.crashed.scene, v, stack)
+	}
+	path, err := g.dump(g.crashed.value, stack)
+	if err != nil {
+		logGame.Errorf("Dump: %v", err)
+	}
+	g.crashed.dump = path
+	g.state = stateCrashed
+	g.clock.reset()
+}
+
+// draw draws the report on the screen, the scene left out: drawing it
+// may be what panics.
+func (r *crashReport) draw(screen *ebiten.Image) {
+	ebitenutil.DrawRect(screen, 0, 0, ScreenWidth, ScreenHeight, color.RGBA{R: 0x40, A: 0xff})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s stopped: %s\n", r.scene, r.value)
+	b.WriteString("Esc: menu, Backspace: restart it. The full report is in the log.\n")
+	if r.dump != "" {
+		b.WriteString("The spaces and the last inputs are in " + r.dump + ".\n")
+	}
+	b.WriteString("\n")
+	// The stack starts with the recovering, then the panic itself, it's
+	// shown from the function that panicked.
+	stack := r.stack
+	if i := strings.Index(stack, "\npanic("); i >= 0 {
+		stack = stack[i+1:]
+		for n := 0; n < 2 && strings.Contains(stack, "\n"); n++ {
+			stack = stack[strings.Index(stack, "\n")+1:]
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(stack), "\n")
+	if len(lines) > crashMaxLines {
+		lines = append(lines[:crashMaxLines], "...")
+	}
+	for _, line := range lines {
+		// Tabs aren't in the debug font.
+		line = strings.ReplaceAll(line, "\t", "    ")
+		if len(line) > crashMaxColumns {
+			line = line[:crashMaxColumns-3] + "..."
+		}
+		b.WriteString(line + "\n")
+	}
+	ebitenutil.DebugPrint(screen, b.String())
+}
@@ -0,0 +1,63 @@
+package game
+
+import (
+	"sort"
+
+	"github.com/jakecoffman/cp"
+)
+
+// despawnCandidate is a body the cap may remove, and how it ranks.
+type despawnCandidate struct {
+	body *cp.Body
+	id   int
+	// offScreen is set for a body out of the camera's view, distance how
+	// far from its center, on the screen.
+	offScreen bool
+	distance  float64
+}
+
+// despawn removes the bodies over the cap of the config, max_bodies, for a
+// sandbox spammed with clicks to keep stepping: the bodies out of view
+// first, the farthest first, then the oldest. They're removed in post-step
+// callbacks, once the next step is done, and not recorded to be undone,
+// the cap not being an edit: undoing an edit brings back a body it
+// removed, which the cap may remove again. The grabbed body is kept.
+func (s *Sandbox) despawn() {
+	excess := len(s.ids) - cfg.MaxBodies
+	if cfg.MaxBodies <= 0 || excess <= 0 {
+		return
+	}
+	center := cp.Vector{X: ScreenWidth / 2, Y: ScreenHeight / 2}
+	candidates := make([]despawnCandidate, 0, len(s.ids))
+	for body, id := range s.ids {
+		if body == s.mouse.Grabbed() {
+			continue
+		}
+		p := s.cam.ToScreen(body.Position())
+		candidates = append(candidates, despawnCandidate{
+			body:      body,
+			id:        id,
+			offScreen: p.X < 0 || p.X > ScreenWidth || p.Y < 0 || p.Y > ScreenHeight,
+			distance:  p.Distance(center),
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch {
+		case a.offScreen != b.offScreen:
+			return a.offScreen
+		case a.offScreen && a.distance != b.distance:
+			return a.distance > b.distance
+		}
+		return a.id < b.id
+	})
+	if excess > len(candidates) {
+		excess = len(candidates)
+	}
+	for _, c := range candidates[:excess] {
+		s.space.AddPostStepCallback(func(space *cp.Space, key, _ interface{}) {
+			s.remove(key.(*cp.Body))
+			s.despawned++
+		}, c.body, nil)
+	}
+}
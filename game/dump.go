@@ -0,0 +1,201 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/replay"
+)
+
+// dumpInputs is how many of the last steps' inputs a dump has, 5 s.
+const dumpInputs = 300
+
+// crashDump is what a dump file has: the spaces of the scene as they were
+// when it crashed, or when the dump was asked for, and the input of the
+// steps that led there, for a blowup that is hard to reproduce to be
+// looked into later. The console's restore loads a space of it in the
+// sandbox.
+type crashDump struct {
+	Time  time.Time `json:"time"`
+	Scene string    `json:"scene"`
+	// Reason is what the scene panicked with, the error it returned, or
+	// "on demand"; Stack where it panicked.
+	Reason     string       `json:"reason"`
+	Stack      string       `json:"stack,omitempty"`
+	ConfigHash uint64       `json:"config_hash"`
+	Spaces     []dumpSpace  `json:"spaces"`
+	Inputs     []dumpedStep `json:"inputs"`
+}
+
+// dumpSpace is a space of a dump: its static shapes and all its other
+// bodies as a level, and the parameters a level doesn't have. The bodies
+// blown up, whose state isn't finite, are apart in Broken, see
+// physics.FiniteLevel. The constraints aren't kept.
+type dumpSpace struct {
+	physics.Level
+	Damping    float64              `json:"damping"`
+	Iterations uint                 `json:"iterations"`
+	Broken     []physics.BrokenBody `json:"broken,omitempty"`
+}
+
+// dumpedStep is the input of a step, see replay.Frame, with the names of
+// the actions held and of the scene.
+type dumpedStep struct {
+	Step    int      `json:"step"`
+	Actions []string `json:"actions,omitempty"`
+	Cursor  [2]int32 `json:"cursor"`
+	Buttons uint8    `json:"buttons,omitempty"`
+	Scene   string   `json:"scene"`
+}
+
+// inputHistory keeps the input of the last dumpInputs steps, whether the
+// session is recorded or not.
+type inputHistory struct {
+	frames [dumpInputs]replay.Frame
+	// steps is the steps kept so far, the last dumpInputs of them still
+	// in frames.
+	steps int
+}
+
+func (h *inputHistory) add(f replay.Frame) {
+	h.frames[h.steps%dumpInputs] = f
+	h.steps++
+}
+
+// last returns the steps kept, the oldest first.
+func (h *inputHistory) last() []dumpedStep {
+	first := h.steps - dumpInputs
+	if first < 0 {
+		first = 0
+	}
+	actions := input.Actions()
+	steps := make([]dumpedStep, 0, h.steps-first)
+	for i := first; i < h.steps; i++ {
+		f := h.frames[i%dumpInputs]
+		step := dumpedStep{Step: i + 1, Cursor: f.Cursor, Buttons: f.Buttons}
+		for bit, a := range actions {
+			if bit < 64 && f.Actions&(1<<uint(bit)) != 0 {
+				step.Actions = append(step.Actions, string(a))
+			}
+		}
+		if int(f.Scene) < len(scenes) {
+			step.Scene = scenes[f.Scene].name
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// dump writes the scene's spaces and the last inputs to a dump file named
+// after the time, in the working directory, and returns its path. A space
+// too broken to be walked is left out, the rest is written.
+func (g *Game) dump(reason, stack string) (string, error) {
+	d := crashDump{
+		Time:       time.Now(),
+		Scene:      scenes[g.index].name,
+		Reason:     reason,
+		Stack:      stack,
+		ConfigHash: configHash(),
+		Inputs:     g.inputs.last(),
+	}
+	if err := g.dumpSpaces(&d); err != nil {
+		logGame.Errorf("Dump: %v", err)
+	}
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := "crash-" + d.Time.Format("20060102-150405.000") + ".json"
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return "", err
+	}
+	logGame.Infof("Dumped %d spaces and %d steps of input to %s", len(d.Spaces), len(d.Inputs), path)
+	return path, nil
+}
+
+// dumpSpaces adds the scene's spaces to d, through the scene if it owns
+// them.
+func (g *Game) dumpSpaces(d *crashDump) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("space not dumped: %v", v)
+		}
+	}()
+	add := func(space *cp.Space) {
+		var bodies []*cp.Body
+		space.EachBody(func(body *cp.Body) {
+			if body.GetType() != cp.BODY_STATIC {
+				bodies = append(bodies, body)
+			}
+		})
+		level, broken := physics.FiniteLevel(space, bodies)
+		d.Spaces = append(d.Spaces, dumpSpace{
+			Level:      level,
+			Damping:    space.Damping(),
+			Iterations: space.Iterations,
+			Broken:     broken,
+		})
+	}
+	if scene, ok := g.scene.(spaceOwner); ok {
+		scene.inSpace(add)
+		return nil
+	}
+	for _, space := range g.spaces {
+		add(space)
+	}
+	return nil
+}
+
+// consoleRestore loads a space of the dump file of args, the first one
+// without a number, in the sandbox.
+func consoleRestore(g *Game, args []string) (string, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return "", errors.New("want the dump file and the number of the space")
+	}
+	n := 1
+	if len(args) == 2 {
+		var err error
+		if n, err = strconv.Atoi(args[1]); err != nil {
+			return "", fmt.Errorf("not a number: %s", args[1])
+		}
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return "", err
+	}
+	var d crashDump
+	if err := json.Unmarshal(data, &d); err != nil {
+		return "", fmt.Errorf("%s: %w", args[0], err)
+	}
+	if n < 1 || n > len(d.Spaces) {
+		return "", fmt.Errorf("no space %d in %s, it has %d", n, args[0], len(d.Spaces))
+	}
+	index, err := FindScene("sandbox")
+	if err != nil {
+		return "", err
+	}
+	if g.index != index || g.state == stateCrashed {
+		g.switchScene(index)
+	}
+	s, ok := g.scene.(*Sandbox)
+	if !ok {
+		return "", errors.New("the sandbox didn't start")
+	}
+	space := d.Spaces[n-1]
+	s.setLevel(space.Level, args[0])
+	s.space.SetDamping(space.Damping)
+	s.space.Iterations = space.Iterations
+	msg := fmt.Sprintf("Restored space %d of %s, dumped from %s: %s", n, args[0], d.Scene, d.Reason)
+	if len(space.Broken) > 0 {
+		msg += fmt.Sprintf("; %d bodies weren't finite, and are left out", len(space.Broken))
+	}
+	return msg, nil
+}
@@ -0,0 +1,111 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"github.com/yohamta/donburi"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/ecs"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	ecsBoxSize    = 24
+	ecsBallRadius = 12
+)
+
+var (
+	// ecsGround is a shelf shorter than the screen, for bodies to fall off.
+	ecsGround = [2]cp.Vector{{X: 100, Y: 420}, {X: 700, Y: 480}}
+	// ecsBounds is where entities live, they are removed once outside.
+	ecsBounds = cp.BB{L: -100, B: -200, R: ScreenWidth + 100, T: ScreenHeight + 50}
+)
+
+// ECSDemo keeps its bodies as entities of a donburi world instead of in
+// scene fields: each step the physics sync system copies the bodies into
+// the entities' positions, which the render system draws the sprites at.
+type ECSDemo struct {
+	baseScene
+
+	space   *cp.Space
+	world   donburi.World
+	box     *ebiten.Image
+	ball    *ebiten.Image
+	removed int
+}
+
+func init() {
+	RegisterDemo("ECS", "bodies as entities of a donburi world", func() Scene { return NewECSDemo() })
+}
+
+func NewECSDemo() *ECSDemo {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: 300})
+
+	e := &ECSDemo{
+		space: space,
+		world: ecs.NewWorld(space),
+		box:   render.NewBoxSprite(ecsBoxSize, ecsBoxSize, colornames.Steelblue),
+		ball:  render.NewBallSprite(ecsBallRadius, colornames.Orange),
+	}
+
+	// The ground is an entity too, with a body but no sprite: the render
+	// system skips it.
+	ground := cp.NewSegment(space.StaticBody, ecsGround[0], ecsGround[1], 0)
+	ground.SetFriction(1)
+	ecs.SpawnBody(e.world, space, space.StaticBody, []*cp.Shape{ground}, nil)
+
+	for i := 0; i < 5; i++ {
+		e.spawnBox(cp.Vector{X: 250 + float64(i)*60, Y: 100})
+	}
+	return e
+}
+
+func (e *ECSDemo) spawnBox(pos cp.Vector) {
+	var mass float64 = 1
+	body := cp.NewBody(mass, cp.MomentForBox(mass, ecsBoxSize, ecsBoxSize))
+	body.SetPosition(pos)
+	shape := cp.NewBox(body, ecsBoxSize, ecsBoxSize, 0)
+	shape.SetFriction(0.7)
+	ecs.SpawnBody(e.world, e.space, body, []*cp.Shape{shape}, e.box)
+}
+
+func (e *ECSDemo) spawnBall(pos cp.Vector) {
+	var mass float64 = 1
+	body := cp.NewBody(mass, cp.MomentForCircle(mass, 0, ecsBallRadius, cp.Vector{}))
+	body.SetPosition(pos)
+	shape := cp.NewCircle(body, ecsBallRadius, cp.Vector{})
+	shape.SetFriction(0.7)
+	shape.SetElasticity(0.5)
+	ecs.SpawnBody(e.world, e.space, body, []*cp.Shape{shape}, e.ball)
+}
+
+func (e *ECSDemo) Update(dt float64) error {
+	if input.Pointer.IsJustPressed() {
+		e.spawnBox(input.Pointer.Position())
+	}
+	if input.IsActionJustPressed(input.ActionSpawn) || input.IsGamepadButtonJustPressed(input.GamepadSpawn) {
+		e.spawnBall(cp.Vector{X: ecsGround[0].X + rand.Float64()*(ecsGround[1].X-ecsGround[0].X), Y: 50})
+	}
+
+	e.space.Step(dt)
+	ecs.SyncPhysics(e.world)
+	e.removed += ecs.RemoveOutside(e.world, ecsBounds)
+	return nil
+}
+
+func (e *ECSDemo) Draw(screen *ebiten.Image) {
+	ebitenutil.DrawLine(screen, ecsGround[0].X, ecsGround[0].Y, ecsGround[1].X, ecsGround[1].Y, colornames.Burlywood)
+	ecs.Render(e.world, screen)
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Entities: %d, removed: %d.\nClick to drop a box, B to drop a ball.",
+		e.world.Len(), e.removed,
+	))
+}
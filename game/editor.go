@@ -0,0 +1,153 @@
+package game
+
+import (
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+// sandboxLevelFile is where the editor saves the level and loads it from, in
+// the working directory.
+const sandboxLevelFile = "level.json"
+
+// toggleEditor switches the editor mode, where the physics is paused:
+// dragged bodies are placed where they are dropped instead of being pulled
+// there, and right clicking a static line deletes it. Leaving it plays the
+// level from where the bodies were placed.
+func (s *Sandbox) toggleEditor() {
+	s.cancel()
+	s.editing = !s.editing
+	if s.editing {
+		for _, body := range s.bodies {
+			body.SetVelocityVector(cp.Vector{})
+			body.SetAngularVelocity(0)
+		}
+	}
+}
+
+// place moves a body to pos while the physics is paused.
+func (s *Sandbox) place(body *cp.Body, pos cp.Vector) {
+	body.SetPosition(pos)
+	body.SetVelocityVector(cp.Vector{})
+	body.SetAngularVelocity(0)
+	physics.Reindex(s.space, body)
+}
+
+// deleteStatic removes the static shape under the screen point p, if any.
+// It isn't recorded to be undone.
+func (s *Sandbox) deleteStatic(p cp.Vector) {
+	info := s.space.PointQueryNearest(s.cam.ToWorld(p), sandboxStrokeRadius, cp.SHAPE_FILTER_ALL)
+	if info.Shape != nil && info.Shape.Body() == s.space.StaticBody {
+		s.space.RemoveShape(info.Shape)
+		s.edited = true
+	}
+}
+
+// saveLevel writes the static lines and the bodies to the level file at
+// path, in the order they were added.
+func (s *Sandbox) saveLevel(path string) error {
+	ids := make([]int, 0, len(s.bodies))
+	for id := range s.bodies {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	bodies := make([]*cp.Body, 0, len(ids))
+	for _, id := range ids {
+		bodies = append(bodies, s.bodies[id])
+	}
+	if err := physics.WriteLevel(path, physics.NewLevel(s.space, bodies)); err != nil {
+		s.status = "Not saved: " + err.Error()
+		return err
+	}
+	s.edited = false
+	s.status = "Saved to " + path
+	return nil
+}
+
+// autosave saves the level, on quitting, if it was edited since it was
+// last saved or loaded: the edits would be lost otherwise.
+func (s *Sandbox) autosave() {
+	s.cancel()
+	if !s.edited {
+		return
+	}
+	s.saveLevel(sandboxLevelFile)
+	if s.edited {
+		logGame.Errorf("Sandbox: %s", s.status)
+		return
+	}
+	logGame.Infof("Sandbox: %s", s.status)
+}
+
+// loadLevel replaces the static lines and the bodies by those of the level
+// file at path. What was there can't be undone back anymore.
+func (s *Sandbox) loadLevel(path string) error {
+	level, err := physics.ReadLevel(path)
+	if err != nil {
+		s.status = "Not loaded: " + err.Error()
+		return err
+	}
+	s.setLevel(level, path)
+	return nil
+}
+
+// setLevel replaces the static lines and the bodies by those of the level,
+// read from the file at path.
+func (s *Sandbox) setLevel(level physics.Level, path string) {
+	s.replace(level.Build, path)
+}
+
+// replace replaces the static lines and the bodies, and their joints, by
+// those build adds to the space, read from the file at path.
+func (s *Sandbox) replace(build func(space *cp.Space) []*cp.Body, path string) {
+	s.cancel()
+	for _, body := range s.bodies {
+		s.remove(body)
+	}
+	var static []*cp.Shape
+	s.space.StaticBody.EachShape(func(shape *cp.Shape) {
+		static = append(static, shape)
+	})
+	for _, shape := range static {
+		s.space.RemoveShape(shape)
+	}
+	for _, body := range build(s.space) {
+		s.sprites.Add(body, sandboxSprite(body))
+		s.register(body)
+	}
+	s.selection = map[*cp.Body]bool{}
+	s.undos, s.redos = nil, nil
+	s.edited = false
+	s.status = "Loaded " + path
+}
+
+// sandboxSprite is the sprite of a loaded body, which the level doesn't
+// keep: a ball's for a round body, a box's otherwise.
+func sandboxSprite(body *cp.Body) *ebiten.Image {
+	sprite := sandboxBoxSprite
+	body.EachShape(func(shape *cp.Shape) {
+		if _, ok := shape.Class.(*cp.Circle); ok {
+			sprite = sandboxBallSprite
+		}
+	})
+	return sprite
+}
+
+func (s *Sandbox) updateEditor() {
+	if input.IsActionJustPressed(input.ActionEditor) {
+		s.toggleEditor()
+	}
+	if !input.IsActionPressed(input.ActionControl) {
+		return
+	}
+	switch {
+	case input.IsActionJustPressed(input.ActionSave):
+		s.saveLevel(sandboxLevelFile)
+	case input.IsActionJustPressed(input.ActionLoad):
+		s.loadLevel(sandboxLevelFile)
+	}
+}
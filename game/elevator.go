@@ -0,0 +1,136 @@
+package game
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	elevatorGravity   = 300
+	elevatorMaxSpeed  = 150
+	elevatorAccel     = 120
+	elevatorWidth     = 120
+	elevatorThickness = 10
+	elevatorShaftX    = 400
+	elevatorPushForce = 200
+)
+
+// elevatorFloors are the heights the elevator stops at, bottom first.
+var elevatorFloors = []float64{groundY, 400, 240, 100}
+
+// Elevator is a kinematic platform moving between floors. Its velocity ramps
+// up and down instead of jumping, so what rides on it is carried by friction
+// and contacts without being thrown around or bouncing.
+type Elevator struct {
+	baseScene
+
+	space    *cp.Space
+	platform *cp.Body
+	ball     *cp.Body
+	floor    int
+}
+
+func init() {
+	RegisterDemo("Elevator", "a kinematic platform between floors", func() Scene { return NewElevator() })
+}
+
+func NewElevator() *Elevator {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: elevatorGravity})
+	space.Iterations = 20
+
+	e := &Elevator{space: space}
+
+	// Floors on both sides of the shaft, and walls to keep things in.
+	left, right := elevatorShaftX-elevatorWidth/2.0-2, elevatorShaftX+elevatorWidth/2.0+2
+	segs := [][2]cp.Vector{
+		{{X: 0, Y: 0}, {X: 0, Y: ScreenHeight}},
+		{{X: ScreenWidth, Y: 0}, {X: ScreenWidth, Y: ScreenHeight}},
+	}
+	for _, y := range elevatorFloors {
+		segs = append(segs,
+			[2]cp.Vector{{X: 0, Y: y}, {X: left, Y: y}},
+			[2]cp.Vector{{X: right, Y: y}, {X: ScreenWidth, Y: y}},
+		)
+	}
+	for _, seg := range segs {
+		physics.AddStaticSegment(space, seg[0], seg[1], 0)
+	}
+	// The bottom of the shaft.
+	physics.AddStaticSegment(space, cp.Vector{X: left, Y: ScreenHeight}, cp.Vector{X: right, Y: ScreenHeight}, 0)
+
+	e.platform = space.AddBody(cp.NewKinematicBody())
+	e.platform.SetPosition(cp.Vector{X: elevatorShaftX, Y: elevatorFloors[0] + elevatorThickness/2})
+	platform := space.AddShape(cp.NewBox(e.platform, elevatorWidth, elevatorThickness, 0))
+	platform.SetFriction(1)
+	platform.UserData = colornames.Gold
+
+	// Passengers: a ball and a couple of boxes.
+	moment := cp.MomentForCircle(1, 0, 12, cp.Vector{})
+	e.ball = space.AddBody(cp.NewBody(1, moment))
+	e.ball.SetPosition(cp.Vector{X: elevatorShaftX - 30, Y: elevatorFloors[0] - 12})
+	ball := space.AddShape(cp.NewCircle(e.ball, 12, cp.Vector{}))
+	ball.SetFriction(0.9)
+	ball.UserData = colornames.Orange
+	physics.AddBox(space, cp.Vector{X: elevatorShaftX + 20, Y: elevatorFloors[0] - 15}, 30, 30)
+	physics.AddBox(space, cp.Vector{X: elevatorShaftX + 20, Y: elevatorFloors[0] - 40}, 20, 20)
+
+	return e
+}
+
+// target is the platform's center at the current floor.
+func (e *Elevator) target() float64 {
+	return elevatorFloors[e.floor] + elevatorThickness/2
+}
+
+// ramp returns the platform's next vertical velocity: accelerate towards the
+// current floor up to the maximum speed, and brake early enough to stop on it.
+func (e *Elevator) ramp(dt float64) float64 {
+	dist := e.target() - e.platform.Position().Y
+	v := e.platform.Velocity().Y
+	if math.Abs(dist) < math.Abs(v)*dt || math.Abs(dist) < 0.01 {
+		// Land exactly on the floor this step.
+		return dist / dt
+	}
+	want := math.Copysign(math.Min(elevatorMaxSpeed, math.Sqrt(2*elevatorAccel*math.Abs(dist))), dist)
+	return cp.LerpConst(v, want, elevatorAccel*dt)
+}
+
+func (e *Elevator) Update(dt float64) error {
+	if input.IsActionJustPressed(input.ActionUp) && e.floor < len(elevatorFloors)-1 {
+		e.floor++
+	}
+	if input.IsActionJustPressed(input.ActionDown) && e.floor > 0 {
+		e.floor--
+	}
+	e.platform.SetVelocity(0, e.ramp(dt))
+
+	// Roll the ball on and off the elevator.
+	if input.IsActionPressed(input.ActionLeft) {
+		e.ball.ApplyForceAtWorldPoint(cp.Vector{X: -elevatorPushForce}, e.ball.Position())
+	}
+	if input.IsActionPressed(input.ActionRight) {
+		e.ball.ApplyForceAtWorldPoint(cp.Vector{X: elevatorPushForce}, e.ball.Position())
+	}
+
+	e.space.Step(dt)
+	return nil
+}
+
+func (e *Elevator) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, e.space, colornames.Burlywood)
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Floor: %d/%d, elevator speed: %5.1f px/s.\nUp/Down: call the elevator, Left/Right: roll the ball.",
+		e.floor, len(elevatorFloors)-1, -e.platform.Velocity().Y,
+	))
+}
@@ -0,0 +1,89 @@
+package game
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+)
+
+// Entity is a game object: a body, the shapes attached to it and the sprite
+// drawn for it, added to and removed from the space together by an
+// entityManager.
+type Entity struct {
+	Body   *cp.Body
+	Shapes []*cp.Shape
+	Sprite *ebiten.Image
+	// Kind names the entities made alike: the manager pools those removed
+	// for the spawns of the kind to reuse, see entityPool. An entity without
+	// one isn't pooled.
+	Kind string
+
+	// OnSpawn and OnRemove, when set, are called once the entity was added to
+	// the space, and once it was removed from it.
+	OnSpawn  func(e *Entity)
+	OnRemove func(e *Entity)
+}
+
+// entityManager keeps the entities of a space, in the order they were
+// spawned.
+type entityManager struct {
+	space    *cp.Space
+	entities []*Entity
+	pool     entityPool
+}
+
+func newEntityManager(space *cp.Space) *entityManager {
+	return &entityManager{space: space}
+}
+
+// spawn adds the entity's body and shapes to the space. A static entity
+// uses the space's static body, which is already there.
+func (m *entityManager) spawn(e *Entity) *Entity {
+	if e.Body != m.space.StaticBody {
+		m.space.AddBody(e.Body)
+	}
+	for _, shape := range e.Shapes {
+		m.space.AddShape(shape)
+	}
+	m.entities = append(m.entities, e)
+	if e.OnSpawn != nil {
+		e.OnSpawn(e)
+	}
+	return e
+}
+
+// remove takes the entity's shapes and body out of the space, and pools it
+// if it has a kind: it mustn't be used after. The space must not be
+// stepping, use a post-step callback from a collision handler.
+func (m *entityManager) remove(e *Entity) {
+	for i, other := range m.entities {
+		if other == e {
+			m.entities = append(m.entities[:i], m.entities[i+1:]...)
+			break
+		}
+	}
+	for _, shape := range e.Shapes {
+		m.space.RemoveShape(shape)
+	}
+	if e.Body != m.space.StaticBody {
+		m.space.RemoveBody(e.Body)
+	}
+	if e.OnRemove != nil {
+		e.OnRemove(e)
+	}
+	m.pool.put(e)
+}
+
+// reuse returns an entity of kind removed before, at rest at pos and ready
+// to be spawned again with its hooks, or nil if none is left: the caller
+// makes one then.
+func (m *entityManager) reuse(kind string, pos cp.Vector) *Entity {
+	return m.pool.get(kind, pos)
+}
+
+// each calls f for every entity, in spawn order. f may remove the entity it
+// is given.
+func (m *entityManager) each(f func(e *Entity)) {
+	for _, e := range append([]*Entity(nil), m.entities...) {
+		f(e)
+	}
+}
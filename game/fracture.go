@@ -0,0 +1,188 @@
+package game
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	fractureGravity = 300
+	// fractureImpulse is the impulse that breaks a piece.
+	fractureImpulse = 1500
+	// fractureMinArea is the area under which pieces don't break any more.
+	fractureMinArea   = 150
+	fractureBallSpeed = 700
+	fractureBallMass  = 10
+	fractureRadius    = 15
+)
+
+const fractureBreakableType cp.CollisionType = 1
+
+var fractureCannon = cp.Vector{X: 60, Y: 450}
+
+// Fracture shatters boxes that get hit hard: a breakable shape that takes
+// too large an impulse is replaced, after the step, by smaller shards cut
+// along the impact, which keep the velocity of where they were on the body.
+type Fracture struct {
+	baseScene
+
+	space  *cp.Space
+	breaks int
+}
+
+func init() {
+	RegisterDemo("Fracture", "boxes that shatter into shards", func() Scene { return NewFracture() })
+}
+
+func NewFracture() *Fracture {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: fractureGravity})
+	space.Iterations = 20
+
+	f := &Fracture{space: space}
+
+	physics.AddStaticSegment(space, cp.Vector{Y: groundY}, cp.Vector{X: ScreenWidth, Y: groundY}, 0)
+
+	// Two columns of boxes and a slab on top.
+	const size = 60
+	for _, x := range []float64{450, 630} {
+		for row := 0; row < 3; row++ {
+			y := groundY - size - float64(row)*size
+			f.addPiece([]cp.Vector{{X: x, Y: y}, {X: x, Y: y + size}, {X: x + size, Y: y + size}, {X: x + size, Y: y}}, cp.Vector{}, 0)
+		}
+	}
+	top := float64(groundY - 3*size)
+	f.addPiece([]cp.Vector{{X: 430, Y: top - 30}, {X: 430, Y: top}, {X: 710, Y: top}, {X: 710, Y: top - 30}}, cp.Vector{}, 0)
+
+	handler := space.NewWildcardCollisionHandler(fractureBreakableType)
+	handler.PostSolveFunc = f.hit
+
+	return f
+}
+
+// addPiece adds a breakable polygon given in world coordinates, moving at v
+// and spinning at w.
+func (f *Fracture) addPiece(verts []cp.Vector, v cp.Vector, w float64) {
+	if cp.AreaForPoly(len(verts), verts, 0) < 0 {
+		// Chipmunk's area and moment need the other winding.
+		for i, j := 0, len(verts)-1; i < j; i, j = i+1, j-1 {
+			verts[i], verts[j] = verts[j], verts[i]
+		}
+	}
+	centroid := cp.CentroidForPoly(len(verts), verts)
+	mass := cp.AreaForPoly(len(verts), verts, 0) * physics.Density
+	body := f.space.AddBody(cp.NewBody(mass, cp.MomentForPoly(mass, len(verts), verts, centroid.Neg(), 0)))
+	body.SetPosition(centroid)
+	body.SetVelocityVector(v)
+	body.SetAngularVelocity(w)
+	shape := f.space.AddShape(cp.NewPolyShape(body, len(verts), verts, cp.NewTransformTranslate(centroid.Neg()), 0))
+	shape.SetFriction(0.8)
+	shape.SetCollisionType(fractureBreakableType)
+	shape.UserData = colornames.Burlywood
+}
+
+// hit breaks the piece when the impulse is too large. The wildcard handler
+// always gets the arbiter with the breakable shape first.
+func (f *Fracture) hit(arb *cp.Arbiter, space *cp.Space, _ interface{}) {
+	if arb.TotalImpulse().Length() < fractureImpulse {
+		return
+	}
+	shape, _ := arb.Shapes()
+	poly := shape.Class.(*cp.PolyShape)
+	if math.Abs(cp.AreaForPoly(poly.Count(), fractureVerts(poly), 0)) < fractureMinArea {
+		return
+	}
+	point, normal := arb.ContactPointSet().Points[0].PointA, arb.Normal()
+	space.AddPostStepCallback(func(space *cp.Space, key, _ interface{}) {
+		f.shatter(key.(*cp.Shape), point, normal)
+	}, shape, nil)
+}
+
+// fractureVerts returns the polygon's vertices in world coordinates.
+func fractureVerts(poly *cp.PolyShape) []cp.Vector {
+	verts := make([]cp.Vector, poly.Count())
+	for i := range verts {
+		verts[i] = poly.TransformVert(i)
+	}
+	return verts
+}
+
+// shatter replaces the shape by four shards: one cut through the impact
+// along the impact normal, then each half cut across through its middle.
+func (f *Fracture) shatter(shape *cp.Shape, point, normal cp.Vector) {
+	body := shape.Body()
+	verts := fractureVerts(shape.Class.(*cp.PolyShape))
+	f.space.RemoveShape(shape)
+	f.space.RemoveBody(body)
+	f.breaks++
+
+	side := normal.Perp()
+	for _, half := range [][]cp.Vector{clipPoly(verts, point, side), clipPoly(verts, point, side.Neg())} {
+		if len(half) < 3 {
+			continue
+		}
+		middle := cp.CentroidForPoly(len(half), half)
+		for _, shard := range [][]cp.Vector{clipPoly(half, middle, normal), clipPoly(half, middle, normal.Neg())} {
+			if len(shard) < 3 || math.Abs(cp.AreaForPoly(len(shard), shard, 0)) < 1 {
+				continue
+			}
+			centroid := cp.CentroidForPoly(len(shard), shard)
+			f.addPiece(shard, body.VelocityAtWorldPoint(centroid), body.AngularVelocity())
+		}
+	}
+}
+
+// clipPoly keeps the part of a convex polygon behind the line through p with
+// normal n, keeping the winding.
+func clipPoly(verts []cp.Vector, p, n cp.Vector) []cp.Vector {
+	var clipped []cp.Vector
+	for i, a := range verts {
+		b := verts[(i+1)%len(verts)]
+		da, db := a.Sub(p).Dot(n), b.Sub(p).Dot(n)
+		if da <= 0 {
+			clipped = append(clipped, a)
+		}
+		if da*db < 0 {
+			clipped = append(clipped, a.Lerp(b, da/(da-db)))
+		}
+	}
+	return clipped
+}
+
+func (f *Fracture) fire(target cp.Vector) {
+	moment := cp.MomentForCircle(fractureBallMass, 0, fractureRadius, cp.Vector{})
+	ball := f.space.AddBody(cp.NewBody(fractureBallMass, moment))
+	ball.SetPosition(fractureCannon)
+	ball.SetVelocityVector(target.Sub(fractureCannon).Normalize().Mult(fractureBallSpeed))
+	shape := f.space.AddShape(cp.NewCircle(ball, fractureRadius, cp.Vector{}))
+	shape.SetFriction(0.5)
+	shape.UserData = colornames.Dimgray
+}
+
+func (f *Fracture) Update(dt float64) error {
+	if input.Pointer.IsJustPressed() {
+		f.fire(input.Pointer.Position())
+	}
+
+	f.space.Step(dt)
+	return nil
+}
+
+func (f *Fracture) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, f.space, colornames.White)
+	render.DrawCircle(screen, fractureCannon, fractureRadius+4, colornames.Dimgray)
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Pieces broken: %d.\nClick to fire a cannonball, hard hits shatter the boxes.",
+		f.breaks,
+	))
+}
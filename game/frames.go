@@ -0,0 +1,62 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+)
+
+// frameDumper writes every frame drawn to a numbered PNG file, for a video
+// to be assembled offline from frames of any quality, however long each
+// took to write.
+type frameDumper struct {
+	dir string
+	// n is the frames written so far, err why they stopped being.
+	n   int
+	err error
+}
+
+// DumpFrames writes every frame drawn from then on to dir, created if need
+// be, as frame-000001.png and on. The game steps once a tick, whatever time
+// the tick took, for the frames to be those of a steady physicsStep of game
+// time apart, the same every run. A frame that can't be written quits the
+// game.
+func (g *Game) DumpFrames(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	g.frames = &frameDumper{dir: dir}
+	logRender.Infof("Dumping the frames to %s", dir)
+	return nil
+}
+
+// dump writes the frame drawn on screen.
+func (d *frameDumper) dump(screen *ebiten.Image) {
+	if d.err != nil {
+		return
+	}
+	d.n++
+	path := filepath.Join(d.dir, fmt.Sprintf("frame-%06d.png", d.n))
+	if err := writePNG(path, screen); err != nil {
+		d.err = err
+		logRender.Errorf("Frames to %s: %v", d.dir, err)
+	}
+}
+
+// close logs the frames written, before the game quits.
+func (d *frameDumper) close() {
+	if d.err == nil {
+		logRender.Infof("Dumped %d frames to %s", d.n, d.dir)
+	}
+}
+
+// settle waits for a scene stepped on another goroutine to have made the
+// steps of the tick, for the frame to show them.
+func (d *frameDumper) settle(scene Scene) {
+	if scene, ok := scene.(spaceOwner); ok {
+		scene.inSpace(func(*cp.Space) {})
+	}
+}
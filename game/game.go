@@ -0,0 +1,543 @@
+// Package game is the demo itself: the scenes, and the Game that switches
+// between them and runs the current one.
+package game
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	rtrace "runtime/trace"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/logging"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/replay"
+)
+
+// The loggers of the game's subsystems, see the logging package.
+var (
+	logGame    = logging.New("game")
+	logConfig  = logging.New("config")
+	logPhysics = logging.New("physics")
+	logRender  = logging.New("render")
+)
+
+const (
+	Title        = "Hello Chipmunk (World)"
+	ScreenWidth  = 800
+	ScreenHeight = 600
+
+	// groundY is where the scenes with a flat floor put it.
+	groundY = 560
+)
+
+// Scene is a single demo. Game creates it from the registry, calls Init once
+// before the first Update, forwards Update and Draw to it while it is the
+// current one, and Dispose when it leaves it, on a switch or a restart.
+type Scene interface {
+	Init()
+	// Update advances the scene by one fixed step of dt seconds. Game calls
+	// it as many times as the time since the last tick takes, none at all
+	// when the ticks are shorter than a step.
+	Update(dt float64) error
+	Draw(screen *ebiten.Image)
+	Dispose()
+}
+
+// baseScene is embedded by the scenes that build everything in their
+// constructor and hold nothing that needs freeing: its Init and Dispose do
+// nothing.
+type baseScene struct{}
+
+func (baseScene) Init()    {}
+func (baseScene) Dispose() {}
+
+type Game struct {
+	scene Scene
+	index int
+	pads  input.GamepadNotice
+	// state is what the game does with the scene, see state.go.
+	state    gameState
+	menu     mainMenu
+	settings settingsScreen
+	// settingsFrom is the state the settings were opened from.
+	settingsFrom gameState
+	// crashed is why the scene stopped, in the crashed state.
+	crashed crashReport
+	// remap is the keybindings screen, while it is open.
+	remap *input.RemapScreen
+	// console is the developer console, see console.go.
+	console devConsole
+	clock   fixedClock
+	// perf is the frame rate and timings overlay, and stats the physics
+	// statistics overlay of the scene's spaces.
+	perf   perfOverlay
+	stats  statsOverlay
+	spaces []*cp.Space
+	// tuning is the panel of sliders of the space's parameters, and
+	// timeScale how fast the game's time goes by for the scene, 1 for real
+	// time.
+	tuning    tuningPanel
+	timeScale float64
+	// plot graphs the energy and momentum of the scene's spaces, and spikes
+	// the time of its steps.
+	plot   plotOverlay
+	spikes spikeDetector
+	// logTail shows the last log messages.
+	logTail logTail
+	// heatmap shows where the scene's bodies hit.
+	heatmap heatmap
+	// broadphase compares the scene's steps under both broadphase indexes.
+	broadphase broadphaseComparison
+	// settle brings the scene to rest, see settle.go.
+	settle settleMode
+	// budget warns when the frames take longer than the frame budget, and
+	// quality merges the steps while the physics takes most of it.
+	budget  budgetWarning
+	quality adaptiveQuality
+	// inspector edits the body clicked in the scene.
+	inspector bodyInspector
+	// recorder records the session to recordPath, see Record, and
+	// trajectories the bodies' trajectories, see RecordTrajectories.
+	recorder     *replay.Writer
+	recordPath   string
+	trajectories *trajectoryRecorder
+	// inputs are those of the last steps, for a dump.
+	inputs inputHistory
+	// stream serves the world to dashboards, see Stream.
+	stream *worldStream
+	// screenshot is set for the frame being drawn to be saved, gif captures
+	// the frames of the next seconds, video streams them to ffmpeg while it
+	// records, and toast tells how they went.
+	screenshot bool
+	gif        gifCapture
+	video      *videoRecorder
+	toast      toast
+	// frames writes every frame to a file, see DumpFrames.
+	frames *frameDumper
+}
+
+// New returns the game, on the Hello Chipmunk scene.
+func New() *Game {
+	index, err := FindScene(HelloScene)
+	if err != nil {
+		panic(err)
+	}
+	return NewAt(index)
+}
+
+// NewAt returns the game, on the menu over the scene at index in the
+// registry.
+func NewAt(index int) *Game {
+	g := &Game{timeScale: 1, quality: adaptiveQuality{merge: 1}}
+	g.switchScene(index)
+	if g.state == statePlaying {
+		g.state = stateMenu
+	}
+	g.menu.cursor = index
+	unlockAudio()
+	return g
+}
+
+// FindScene returns the index of a scene given by its number, from 1, or by
+// its name, in any case.
+func FindScene(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 || n > len(scenes) {
+			return 0, fmt.Errorf("no scene %d, there are %d", n, len(scenes))
+		}
+		return n - 1, nil
+	}
+	for i, scene := range scenes {
+		if strings.EqualFold(scene.name, s) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no scene named %q", s)
+}
+
+// switchScene starts playing the scene at index, from any state. A scene
+// that panics while being disposed of or made crashes instead.
+func (g *Game) switchScene(index int) {
+	g.state = statePlaying
+	g.clock.reset()
+	if g.scene != nil {
+		g.safely(g.scene.Dispose)
+	}
+	render.DropLayers()
+	g.index = (index + len(scenes)) % len(scenes)
+	g.scene = brokenScene{}
+	madeSpaces = nil
+	g.safely(func() {
+		scene := scenes[g.index].new()
+		scene.Init()
+		g.scene = scene
+	})
+	g.spaces, madeSpaces = madeSpaces, nil
+	g.plot.reset()
+	g.spikes.reset()
+	g.heatmap.reset()
+	g.broadphase.started(g.index)
+	g.settle.cancel()
+	g.inspector.close()
+	logGame.Infof("Scene: %s", scenes[g.index].name)
+	ebiten.SetWindowTitle(Title + " - " + scenes[g.index].name)
+}
+
+func (g *Game) Update() error {
+	allocs.enter(allocUpdate)
+	start := time.Now()
+	defer func() { g.budget.updated(time.Since(start)) }()
+	if quitting() {
+		g.Shutdown()
+		return ErrQuit
+	}
+	if g.frames != nil && g.frames.err != nil {
+		g.Shutdown()
+		return g.frames.err
+	}
+	if g.suspendedTick() {
+		return nil
+	}
+	g.pads.Update()
+	input.Touch.Update()
+	input.Pointer.Update()
+	if configWatch.poll(time.Now()) {
+		ApplySettings(cfg)
+		if scene, ok := g.scene.(configurable); ok {
+			g.safely(func() { scene.applyConfig(cfg) })
+		}
+	}
+
+	g.gif.update(&g.toast)
+
+	if g.remap != nil {
+		if !g.remap.Update() {
+			g.remap = nil
+		}
+		g.clock.reset()
+		return nil
+	}
+	if g.console.open {
+		g.console.update(g)
+		g.clock.reset()
+		return nil
+	}
+	// A value typed in the inspector isn't the game's keys either.
+	if g.inspector.typing() {
+		g.safely(func() { g.inspector.update(g) })
+		g.clock.reset()
+		return nil
+	}
+	if input.IsActionJustPressed(input.ActionRemap) {
+		g.remap = input.NewRemapScreen()
+		return nil
+	}
+	if input.IsActionJustPressed(input.ActionConsole) {
+		g.console.open = true
+		return nil
+	}
+	if input.IsActionJustPressed(input.ActionPerf) {
+		g.perf.visible = !g.perf.visible
+	}
+	if input.IsActionJustPressed(input.ActionStats) {
+		g.stats.visible = !g.stats.visible
+	}
+	if input.IsActionJustPressed(input.ActionTune) {
+		g.tuning.visible = !g.tuning.visible
+	}
+	if input.IsActionJustPressed(input.ActionPlot) {
+		g.plot.visible = !g.plot.visible
+	}
+	if input.IsActionJustPressed(input.ActionSpikes) {
+		g.spikes.visible = !g.spikes.visible
+	}
+	if input.IsActionJustPressed(input.ActionHeatmap) {
+		g.heatmap.visible = !g.heatmap.visible
+	}
+	if input.IsActionJustPressed(input.ActionBroadphase) && (g.state == statePlaying || g.state == statePaused) {
+		g.toggleBroadphase()
+	}
+	if input.IsActionJustPressed(input.ActionSettle) && (g.state == statePlaying || g.state == statePaused) {
+		g.toggleSettle()
+	}
+	if input.IsActionJustPressed(input.ActionLog) {
+		g.logTail.visible = !g.logTail.visible
+	}
+	if input.IsActionJustPressed(input.ActionTrajectories) {
+		g.toggleTrajectories()
+	}
+	if input.IsActionJustPressed(input.ActionScreenshot) {
+		g.screenshot = true
+	}
+	if input.IsActionJustPressed(input.ActionGIF) {
+		g.gif.start(&g.toast)
+	}
+	if input.IsActionJustPressed(input.ActionVideo) {
+		g.toggleVideo()
+	}
+
+	if g.state == stateCrashed {
+		switch {
+		case input.IsActionJustPressed(input.ActionMenu):
+			// The menu is over a new one of the scene, not the broken one.
+			g.switchScene(g.index)
+			if g.state == statePlaying {
+				g.menu.cursor = g.index
+				g.setState(stateMenu)
+			}
+		case input.IsActionJustPressed(input.ActionRestart), input.IsGamepadButtonJustPressed(input.GamepadRestart):
+			g.switchScene(g.index)
+		}
+		return nil
+	}
+	if g.state == stateSettings {
+		if !g.settings.update() {
+			back := statePaused
+			if g.settingsFrom == stateMenu {
+				back = stateMenu
+			}
+			g.setState(back)
+		}
+		return nil
+	}
+	if input.IsActionJustPressed(input.ActionSettings) && g.state != stateGameOver {
+		g.settingsFrom = g.state
+		g.settings.open()
+		g.setState(stateSettings)
+		return nil
+	}
+	if g.state == stateMenu {
+		if index, ok := g.menu.update(); ok {
+			g.switchScene(index)
+		} else if input.IsActionJustPressed(input.ActionMenu) || input.IsGamepadButtonJustPressed(input.GamepadRestart) {
+			g.setState(statePlaying)
+		}
+		return nil
+	}
+
+	for i := 0; i < len(scenes) && i < 9; i++ {
+		if input.IsActionJustPressed(input.SceneAction(i)) {
+			g.switchScene(i)
+		}
+	}
+	switch {
+	case input.IsActionJustPressed(input.ActionNextScene), input.IsGamepadButtonJustPressed(input.GamepadNextScene):
+		g.switchScene(g.index + 1)
+	case input.IsActionJustPressed(input.ActionPrevScene), input.IsGamepadButtonJustPressed(input.GamepadPrevScene):
+		g.switchScene(g.index - 1)
+	case input.IsActionJustPressed(input.ActionRestart), input.IsGamepadButtonJustPressed(input.GamepadRestart):
+		g.switchScene(g.index)
+	case g.state == stateGameOver && input.IsActionJustPressed(input.ActionConfirm):
+		g.switchScene(g.index)
+	case input.IsActionJustPressed(input.ActionMenu):
+		g.menu.cursor = g.index
+		g.setState(stateMenu)
+	case input.IsActionJustPressed(input.ActionPause):
+		switch g.state {
+		case statePlaying:
+			g.setState(statePaused)
+		case statePaused:
+			g.setState(statePlaying)
+		}
+	case input.IsActionJustPressed(input.ActionDebugDraw):
+		render.ShowCollisionPoints = !render.ShowCollisionPoints
+	}
+	// A press on the tuning panel isn't the scene's, which doesn't step
+	// this tick not to see it: the next tick makes up for it.
+	var pressed, inspected bool
+	g.safely(func() {
+		pressed = g.tuning.update(g)
+		inspected = g.inspector.update(g)
+	})
+	if g.state != statePlaying || pressed || inspected {
+		return nil
+	}
+
+	g.safely(g.step)
+	g.safely(func() { g.settle.update(g) })
+	if scene, ok := g.scene.(inspectable); ok {
+		if body := scene.tappedBody(); body != nil {
+			g.inspector.open(body)
+		}
+	}
+	g.safely(func() { g.spikes.tickDone(frameBudget(), g.physicsStats) })
+	if g.frames != nil {
+		g.frames.settle(g.scene)
+	}
+	return nil
+}
+
+// step runs the scene for the time since the last tick. An error of the
+// scene stops it like a panic does, without the stack.
+func (g *Game) step() {
+	// It is *highly* recommended to use a fixed size time step. It doesn't
+	// depend on the ticks per second either: the time since the last tick
+	// is made of as many steps as it takes.
+	trace := logPhysics.Enabled(logging.LevelDebug)
+	g.applyTilt()
+	// Dumping frames, a tick is a step, however long it took.
+	var n int
+	if g.frames != nil {
+		n = g.clock.fixed(g.timeScale)
+	} else {
+		n = g.clock.steps(time.Now(), 1/float64(ebiten.MaxTPS()), g.timeScale)
+	}
+	// Under load, the fixed steps are merged, see adaptiveQuality.
+	adapt := g.canAdapt()
+	steps, physics := n, time.Duration(0)
+	for n > 0 {
+		m := 1
+		if adapt {
+			m = g.quality.chunk(n)
+		}
+		n -= m
+		dt := float64(m) * physicsStep
+		start := time.Now()
+		g.recordStep(m)
+		g.perf.step()
+		// The regions of an execution trace, see -trace.
+		region := rtrace.StartRegion(context.Background(), "physics step")
+		allocs.enter(allocPhysics)
+		err := g.scene.Update(dt)
+		allocs.enter(allocBookkeeping)
+		region.End()
+		if err != nil {
+			g.crash(err, "")
+			return
+		}
+		d := time.Since(start)
+		physics += d
+		g.stats.stepped(d)
+		g.budget.stepped(d)
+		g.broadphase.stepped(d)
+		g.spikes.stepped(g.stepTime(start))
+		// The trajectories and the stream keep the steps from merging, see
+		// canAdapt, to be sampled at every fixed step.
+		g.recordTrajectories()
+		g.heatmapStep()
+		g.streamStep()
+		if g.plot.visible {
+			g.plot.sample(g.physicsStats())
+		}
+		if trace {
+			logPhysics.Debugf("%s: step of %gs in %v, %d to go", scenes[g.index].name, dt, time.Since(start), n)
+		}
+		if scene, ok := g.scene.(ender); ok && scene.Over() {
+			g.setState(stateGameOver)
+			return
+		}
+	}
+	allocs.enter(allocUpdate)
+	g.quality.ticked(steps, physics, frameBudget(), adapt)
+}
+
+func (g *Game) Draw(screen *ebiten.Image) {
+	allocs.enter(allocScene)
+	defer allocs.frameDone()
+	defer rtrace.StartRegion(context.Background(), "draw").End()
+	// Dumping frames, they take as long as they take.
+	if g.frames == nil {
+		defer g.budget.frameDone(time.Now(), frameBudget())
+	}
+	// Background
+	screen.Fill(colornames.Black)
+	// Over everything, whatever the state.
+	defer g.drawOverlays(screen)
+
+	if g.state == stateCrashed {
+		g.crashed.draw(screen)
+		return
+	}
+	if !g.safely(func() { g.scene.Draw(screen) }) {
+		g.crashed.draw(screen)
+		return
+	}
+	allocs.enter(allocOverlays)
+	input.Touch.Draw(screen)
+	if g.remap != nil {
+		g.remap.Draw(screen)
+		return
+	}
+
+	if notice := g.pads.String(); notice != "" {
+		ebitenutil.DebugPrintAt(screen, notice, 0, ScreenHeight-32)
+	}
+	switch g.state {
+	case stateMenu:
+		g.menu.draw(screen, g.index)
+		return
+	case stateSettings:
+		g.settings.draw(screen)
+		return
+	case statePaused:
+		drawHUD(screen, hudPaused, ScreenWidth-48, 0)
+	case stateGameOver:
+		drawBanner(screen, "Game over", "Enter/Backspace: play again, Esc: menu")
+	}
+	if g.trajectories != nil {
+		ebitenutil.DebugPrintAt(screen, "Recording trajectories", ScreenWidth-48-150, 0)
+	}
+	g.settle.draw(screen)
+	g.quality.draw(screen)
+	var cam *render.Camera
+	if scene, ok := g.scene.(viewer); ok {
+		cam = scene.camera()
+	}
+	g.heatmap.draw(screen, cam)
+	g.tuning.draw(screen)
+	g.inspector.draw(screen)
+	g.plot.draw(screen)
+	g.spikes.draw(screen)
+	drawHUD(screen, hudHelp, 0, ScreenHeight-16)
+}
+
+// drawOverlays draws the overlays that are on, one under the other, and
+// saves the screenshot, the dumped frame and the GIF and video frames with
+// them, before the recording indicator, the budget warning and the toast.
+func (g *Game) drawOverlays(screen *ebiten.Image) {
+	y := g.perf.draw(screen, overlayY)
+	y = g.stats.draw(screen, y, g.physicsStats)
+	g.broadphase.draw(screen, y)
+	g.logTail.draw(screen)
+	if g.console.open {
+		g.console.draw(screen)
+	}
+	if g.screenshot {
+		g.screenshot = false
+		g.saveScreenshot(screen)
+	}
+	g.gif.capture(screen)
+	if g.frames != nil {
+		g.frames.dump(screen)
+	}
+	if g.video != nil {
+		g.video.capture(screen)
+		g.video.draw(screen)
+	}
+	g.budget.draw(screen)
+	g.toast.draw(screen)
+}
+
+// Layout keeps the screen the same size, scaled to fit the window, or in a
+// browser the canvas, which follows the page's size: ebiten gives its size
+// in the page's pixels whenever it changes.
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	canvasSize = [2]int{outsideWidth, outsideHeight}
+	return ScreenWidth, ScreenHeight
+}
+
+// canvasSize is what the screen was last scaled to, see Layout.
+var canvasSize [2]int
+
+// browser is set for the browser build, whose window is the page's: the
+// settings don't size it.
+const browser = runtime.GOOS == "js"
@@ -0,0 +1,154 @@
+package game
+
+import (
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// gifDuration is how long a capture lasts, and gifInterval the time
+	// between its frames, 20 per second.
+	gifDuration = 5 * time.Second
+	gifInterval = 50 * time.Millisecond
+	// gifScale is the size of the frames, as a fraction of the screen's.
+	gifScale = 0.5
+)
+
+// gifCapture captures the frames drawn for gifDuration, downscaled, and
+// encodes them to an animated GIF on another goroutine, for a behavior to be
+// shared in an issue or a chat.
+type gifCapture struct {
+	// small is what the screen is downscaled to, on the GPU, before it is
+	// read back.
+	small *ebiten.Image
+	// frames are those captured so far, at times, until end; capturing is
+	// false between captures.
+	capturing bool
+	frames    []*image.RGBA
+	times     []time.Time
+	end       time.Time
+	// done tells how the encodings went, for the toast, and encoding waits
+	// for them.
+	done     chan string
+	encoding sync.WaitGroup
+}
+
+// start starts capturing, unless a capture is under way.
+func (c *gifCapture) start(t *toast) {
+	if c.capturing {
+		return
+	}
+	if c.done == nil {
+		c.done = make(chan string, 1)
+		c.small = ebiten.NewImage(int(ScreenWidth*gifScale), int(ScreenHeight*gifScale))
+	}
+	c.capturing, c.frames, c.times = true, nil, nil
+	c.end = time.Now().Add(gifDuration)
+	t.show("Capturing a GIF of the next 5 s")
+}
+
+// update shows how the last encoding went, once it has.
+func (c *gifCapture) update(t *toast) {
+	select {
+	case message := <-c.done:
+		t.show(message)
+	default:
+	}
+}
+
+// capture keeps the frame drawn on screen, if it is time for one, and hands
+// the frames over to be encoded once the capture is over.
+func (c *gifCapture) capture(screen *ebiten.Image) {
+	if !c.capturing {
+		return
+	}
+	now := time.Now()
+	if now.After(c.end) {
+		c.stop()
+		return
+	}
+	if n := len(c.times); n > 0 && now.Sub(c.times[n-1]) < gifInterval {
+		return
+	}
+	c.small.Clear()
+	op := &ebiten.DrawImageOptions{Filter: ebiten.FilterLinear}
+	op.GeoM.Scale(gifScale, gifScale)
+	c.small.DrawImage(screen, op)
+	frame := image.NewRGBA(c.small.Bounds())
+	draw.Draw(frame, frame.Bounds(), c.small, image.Point{}, draw.Src)
+	c.frames = append(c.frames, frame)
+	c.times = append(c.times, now)
+}
+
+// stop ends the capture, and encodes its frames on another goroutine.
+func (c *gifCapture) stop() {
+	c.capturing = false
+	if len(c.frames) == 0 {
+		return
+	}
+	c.encoding.Add(1)
+	go c.encode("capture-"+time.Now().Format("20060102-150405")+".gif", c.frames, c.times)
+	c.frames, c.times = nil, nil
+}
+
+// finish stops the capture under way, if any, and waits for the frames
+// captured to be encoded, before the game quits.
+func (c *gifCapture) finish() {
+	if c.capturing {
+		c.stop()
+	}
+	c.encoding.Wait()
+}
+
+// encode writes the frames to path, each lasting until the next, on its own
+// goroutine. The frames are mapped to the Plan 9 palette without dithering,
+// which keeps the flat colors of the scenes flat.
+func (c *gifCapture) encode(path string, frames []*image.RGBA, times []time.Time) {
+	defer c.encoding.Done()
+	anim := &gif.GIF{}
+	for i, frame := range frames {
+		paletted := image.NewPaletted(frame.Bounds(), palette.Plan9)
+		draw.Draw(paletted, paletted.Bounds(), frame, image.Point{}, draw.Src)
+		delay := gifInterval
+		if i+1 < len(times) {
+			delay = times[i+1].Sub(times[i])
+		}
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, int(delay/(10*time.Millisecond)))
+	}
+	if err := writeGIF(path, anim); err != nil {
+		logRender.Errorf("GIF: %v", err)
+		c.report("GIF not saved: " + err.Error())
+		return
+	}
+	logRender.Infof("GIF of %d frames saved to %s", len(frames), path)
+	c.report("GIF saved to " + path)
+}
+
+// report hands message over to the toast, unless another one is waiting
+// for it already: the log has both.
+func (c *gifCapture) report(message string) {
+	select {
+	case c.done <- message:
+	default:
+	}
+}
+
+func writeGIF(path string, anim *gif.GIF) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, anim); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,140 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	glueGravity   = 300
+	glueBallSpeed = 600
+	glueBallMass  = 1
+	glueRadius    = 8
+	// glueImpulse is how hard a glue ball must hit to stick.
+	glueImpulse = 50
+)
+
+const glueBallType cp.CollisionType = 1
+
+var glueLauncher = cp.Vector{X: 60, Y: 450}
+
+// gluePair is the key of a glue joint, so each pair of bodies is glued once.
+type gluePair struct {
+	a, b *cp.Body
+}
+
+// Glue fires sticky balls: a ball that hits anything hard enough gets welded
+// to it by a pivot joint at the contact point, added after the step since the
+// space can't be changed while it runs.
+type Glue struct {
+	baseScene
+
+	space  *cp.Space
+	joints map[gluePair]*cp.Constraint
+	fired  int
+}
+
+func init() {
+	RegisterDemo("Glue", "sticky balls that weld to what they hit", func() Scene { return NewGlue() })
+}
+
+func NewGlue() *Glue {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: glueGravity})
+
+	g := &Glue{space: space, joints: map[gluePair]*cp.Constraint{}}
+
+	for _, seg := range [][2]cp.Vector{
+		{{X: 0, Y: groundY}, {X: ScreenWidth, Y: groundY}},
+		{{X: ScreenWidth, Y: 0}, {X: ScreenWidth, Y: groundY}},
+		{{X: 300, Y: 150}, {X: 500, Y: 150}},
+		{{X: 650, Y: 100}, {X: 650, Y: 300}},
+	} {
+		physics.AddStaticSegment(space, seg[0], seg[1], 4)
+	}
+	for i := 0; i < 4; i++ {
+		physics.AddBox(space, cp.Vector{X: 450, Y: groundY - 25 - float64(i)*50}, 50, 50)
+	}
+
+	handler := space.NewWildcardCollisionHandler(glueBallType)
+	handler.PostSolveFunc = g.stick
+
+	return g
+}
+
+// stick glues a ball to what it hit. The wildcard handler always gets the
+// arbiter with the glue ball first.
+func (g *Glue) stick(arb *cp.Arbiter, space *cp.Space, _ interface{}) {
+	if arb.TotalImpulse().Length() < glueImpulse {
+		return
+	}
+	a, b := arb.Bodies()
+	pair := gluePair{a, b}
+	if g.glued(a, b) {
+		return
+	}
+	point := arb.ContactPointSet().Points[0].PointA
+	space.AddPostStepCallback(func(space *cp.Space, key, _ interface{}) {
+		// Two glue balls hitting each other are called back twice.
+		if g.glued(a, b) {
+			return
+		}
+		g.joints[pair] = space.AddConstraint(cp.NewPivotJoint(a, b, point))
+	}, pair, nil)
+}
+
+func (g *Glue) glued(a, b *cp.Body) bool {
+	_, ab := g.joints[gluePair{a, b}]
+	_, ba := g.joints[gluePair{b, a}]
+	return ab || ba
+}
+
+// dissolve removes every glue joint.
+func (g *Glue) dissolve() {
+	for pair, joint := range g.joints {
+		g.space.RemoveConstraint(joint)
+		delete(g.joints, pair)
+	}
+}
+
+func (g *Glue) fire(target cp.Vector) {
+	moment := cp.MomentForCircle(glueBallMass, 0, glueRadius, cp.Vector{})
+	ball := g.space.AddBody(cp.NewBody(glueBallMass, moment))
+	ball.SetPosition(glueLauncher)
+	ball.SetVelocityVector(target.Sub(glueLauncher).Normalize().Mult(glueBallSpeed))
+	shape := g.space.AddShape(cp.NewCircle(ball, glueRadius, cp.Vector{}))
+	shape.SetFriction(0.7)
+	shape.SetCollisionType(glueBallType)
+	shape.UserData = colornames.Limegreen
+	g.fired++
+}
+
+func (g *Glue) Update(dt float64) error {
+	if input.Pointer.IsJustPressed() {
+		g.fire(input.Pointer.Position())
+	}
+	if input.IsActionJustPressed(input.ActionDissolve) {
+		g.dissolve()
+	}
+
+	g.space.Step(dt)
+	return nil
+}
+
+func (g *Glue) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, g.space, colornames.Burlywood)
+	render.DrawCircle(screen, glueLauncher, glueRadius+4, colornames.Limegreen)
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Glue balls: %d. Glue joints: %d.\nClick: fire a glue ball, D: dissolve the glue.",
+		g.fired, len(g.joints),
+	))
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+// heatmapCell is the side of the cells of the heatmap, in world units.
+const heatmapCell = 16
+
+// viewer is a scene drawn through a camera, which overlays in the world
+// are drawn through too.
+type viewer interface {
+	camera() *render.Camera
+}
+
+// heatmap counts the impacts of the scene, the contacts made at each step,
+// in a grid over the world, and draws it over the scene: where the bodies
+// hit the most in a level, the hot cells, rather than where they rest. It
+// counts while it is shown, from the start of the scene.
+type heatmap struct {
+	visible bool
+	// cells are the impacts by cell, most the most of a cell, total all of
+	// them.
+	cells map[[2]int]float64
+	most  float64
+	total float64
+}
+
+// reset forgets the impacts, of another scene.
+func (h *heatmap) reset() {
+	*h = heatmap{visible: h.visible}
+}
+
+// count adds the contacts made at the last step of space. A contact is
+// seen from both its bodies when they are both dynamic, and counts half
+// from each.
+func (h *heatmap) count(space *cp.Space) {
+	if h.cells == nil {
+		h.cells = map[[2]int]float64{}
+	}
+	space.EachBody(func(body *cp.Body) {
+		if body.GetType() != cp.BODY_DYNAMIC {
+			return
+		}
+		body.EachArbiter(func(arb *cp.Arbiter) {
+			if !arb.IsFirstContact() {
+				return
+			}
+			set := arb.ContactPointSet()
+			if set.Count == 0 {
+				return
+			}
+			weight := 1.0
+			if _, other := arb.Bodies(); other.GetType() == cp.BODY_DYNAMIC {
+				weight = 0.5
+			}
+			var p cp.Vector
+			for i := 0; i < set.Count; i++ {
+				p = p.Add(set.Points[i].PointA)
+			}
+			p = p.Mult(1 / float64(set.Count))
+			cell := [2]int{int(math.Floor(p.X / heatmapCell)), int(math.Floor(p.Y / heatmapCell))}
+			h.cells[cell] += weight
+			if h.cells[cell] > h.most {
+				h.most = h.cells[cell]
+			}
+			h.total += weight
+		})
+	})
+}
+
+// heatmapStep counts the impacts of the step just made, while the heatmap
+// is shown.
+func (g *Game) heatmapStep() {
+	if !g.heatmap.visible {
+		return
+	}
+	g.inSpace(g.heatmap.count)
+}
+
+// draw draws the cells, through cam if it isn't nil, from a translucent
+// blue for the fewest impacts to an opaque red for the most.
+func (h *heatmap) draw(screen *ebiten.Image, cam *render.Camera) {
+	if !h.visible {
+		return
+	}
+	zoom := 1.0
+	if cam != nil {
+		zoom = cam.Zoom
+	}
+	for cell, n := range h.cells {
+		p := cp.Vector{X: float64(cell[0] * heatmapCell), Y: float64(cell[1] * heatmapCell)}
+		if cam != nil {
+			p = cam.ToScreen(p)
+		}
+		side := heatmapCell * zoom
+		if p.X+side < 0 || p.Y+side < 0 || p.X > ScreenWidth || p.Y > ScreenHeight {
+			continue
+		}
+		ebitenutil.DrawRect(screen, p.X, p.Y, side, side, heatColor(n/h.most))
+	}
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Heatmap: %.0f impacts, at most %.0f in a cell", h.total, h.most), 8, ScreenHeight-48)
+}
+
+// heatColor is the color of a cell with a share t, 0 to 1, of the most
+// impacts.
+func heatColor(t float64) color.Color {
+	// A cell hit once stays visible next to one hit thousands of times.
+	t = math.Sqrt(t)
+	return color.NRGBA{
+		R: uint8(255 * t),
+		G: uint8(64 * (1 - t)),
+		B: uint8(255 * (1 - t)),
+		A: uint8(64 + 160*t),
+	}
+}
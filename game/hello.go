@@ -0,0 +1,241 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/config"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+// The Hello Chipmunk space itself is built in the physics package, see
+// hello.go there. The gravity, the ball, the ground and how long a round
+// lasts come from the config.
+
+const (
+	// ballForce is the force the keyboard pushes the ball with, and
+	// ballJump the impulse of a jump.
+	ballForce = 150
+	ballJump  = 80
+)
+
+const (
+	helloBallType cp.CollisionType = iota + 1
+	helloGoalType
+)
+
+// helloBallKind is the kind of the spawned balls, pooled once lost.
+const helloBallKind = "ball"
+
+var (
+	// goal is the region at the bottom of the ramp that scores a point for
+	// every ball rolling into it.
+	goal = cp.BB{L: 680, B: 470, R: 760, T: 590}
+)
+
+type HelloWorld struct {
+	baseScene
+
+	space    *cp.Space
+	entities *entityManager
+	// ball is the driven ball, the others are spawned with B.
+	ball     *Entity
+	sprite   *ebiten.Image
+	balls    int
+	lost     int
+	time     float64
+	score    int
+	grounded bool
+}
+
+func init() {
+	RegisterDemo("Hello Chipmunk", "a ball rolls down a slope into a goal, against the clock", func() Scene { return NewHelloWorld() })
+}
+
+func NewHelloWorld() *HelloWorld {
+	// The space and the ball are built by the physics package, which runs
+	// without a window too.
+	space := trackSpace(physics.NewHelloSpace(cfg.HelloParams))
+	space.Iterations = uint(cfg.Iterations)
+	ballBody, ballShape := physics.NewHelloBall(cfg.HelloParams, physics.HelloStart)
+	ballShape.SetCollisionType(helloBallType)
+
+	// The body and its shapes make an entity, with the image drawn for it.
+	// Spawning it adds them all to the space.
+	size := int(math.Ceil(cfg.BallRadius))
+	sprite := ebiten.NewImage(size, size)
+	sprite.Fill(color.White)
+	h := &HelloWorld{
+		space:    space,
+		entities: newEntityManager(space),
+		sprite:   sprite,
+	}
+	h.ball = h.entities.spawn(&Entity{Body: ballBody, Shapes: []*cp.Shape{ballShape}, Sprite: sprite})
+
+	// A sensor detects collisions but doesn't push anything away, and a
+	// collision handler is told when a ball starts touching it.
+	goalShape := space.AddShape(cp.NewBox2(space.StaticBody, goal, 0))
+	goalShape.SetSensor(true)
+	goalShape.SetCollisionType(helloGoalType)
+	handler := space.NewCollisionHandler(helloBallType, helloGoalType)
+	handler.BeginFunc = func(_ *cp.Arbiter, _ *cp.Space, _ interface{}) bool {
+		h.score++
+		return true
+	}
+
+	return h
+}
+
+// spawnBall drops one more ball above the ramp. The hooks keep count of the
+// balls in play and of those lost off the screen.
+// A ball lost before is reused, with the friction and elasticity of the
+// config as it is now.
+func (h *HelloWorld) spawnBall() {
+	pos := cp.Vector{X: ScreenWidth/2 + rand.Float64()*200 - 100, Y: ScreenHeight / 4}
+	if e := h.entities.reuse(helloBallKind, pos); e != nil {
+		for _, shape := range e.Shapes {
+			shape.SetFriction(cfg.Friction)
+			shape.SetElasticity(cfg.Elasticity)
+		}
+		h.entities.spawn(e)
+		return
+	}
+	body, shape := physics.NewHelloBall(cfg.HelloParams, pos)
+	shape.SetCollisionType(helloBallType)
+	h.entities.spawn(&Entity{
+		Body:    body,
+		Shapes:  []*cp.Shape{shape},
+		Sprite:  h.sprite,
+		Kind:    helloBallKind,
+		OnSpawn: func(*Entity) { h.balls++ },
+		OnRemove: func(*Entity) {
+			h.balls--
+			h.lost++
+		},
+	})
+}
+
+// applyConfig applies a reloaded config to the running space: its gravity,
+// damping and iterations, and the friction and elasticity of the ground and the balls.
+// The size and mass of the balls, and where the ground is, only change on a
+// restart.
+func (h *HelloWorld) applyConfig(c config.Config) {
+	h.space.SetGravity(c.Gravity)
+	h.space.SetDamping(c.Damping)
+	h.space.Iterations = uint(c.Iterations)
+	h.space.EachShape(func(shape *cp.Shape) {
+		switch {
+		case shape.Sensor():
+		case shape.Body() == h.space.StaticBody:
+			shape.SetElasticity(c.Elasticity)
+		default:
+			shape.SetFriction(c.Friction)
+			shape.SetElasticity(c.Elasticity)
+		}
+	})
+}
+
+// drive pushes the ball with the arrow keys or WASD. Forces last for the
+// next step only, so they are applied every update the key is held. A jump
+// is an impulse, an instant change of velocity, and only allowed when the
+// ball stands on something.
+func (h *HelloWorld) drive() {
+	var force cp.Vector
+	if input.IsActionPressed(input.ActionLeft) {
+		force.X -= ballForce
+	}
+	if input.IsActionPressed(input.ActionRight) {
+		force.X += ballForce
+	}
+	if input.IsActionPressed(input.ActionDown) {
+		force.Y += ballForce
+	}
+	// The stick pushes as far as it's tilted, but like the keys it can't
+	// lift the ball.
+	stick := input.GamepadStick()
+	force = force.Add(cp.Vector{X: stick.X, Y: math.Max(stick.Y, 0)}.Mult(ballForce))
+	if force != (cp.Vector{}) {
+		h.ball.Body.ApplyForceAtWorldPoint(force, h.ball.Body.Position())
+	}
+
+	// The arbiter normal points from the ball to what it touches: down means
+	// something is under the ball.
+	h.grounded = false
+	h.ball.Body.EachArbiter(func(arb *cp.Arbiter) {
+		if arb.Normal().Y > 0.5 {
+			h.grounded = true
+		}
+	})
+	jump := input.IsActionJustPressed(input.ActionJump) || input.IsGamepadButtonJustPressed(input.GamepadJump)
+	if jump && h.grounded {
+		h.ball.Body.ApplyImpulseAtWorldPoint(cp.Vector{Y: -ballJump}, h.ball.Body.Position())
+	}
+}
+
+func (h *HelloWorld) Update(timeStep float64) error {
+	if input.IsActionJustPressed(input.ActionSpawn) || input.IsGamepadButtonJustPressed(input.GamepadSpawn) {
+		h.spawnBall()
+	}
+	h.drive()
+
+	// Now that it's all set up, we simulate all the objects in the space by
+	// stepping forward through time in small increments called steps.
+	h.time += timeStep
+	h.space.Step(timeStep)
+
+	// Forget the balls that rolled off the screen, but the driven ball
+	// comes back to the top instead.
+	h.entities.each(func(e *Entity) {
+		if e.Body.Position().Y <= ScreenHeight {
+			return
+		}
+		if e != h.ball {
+			h.entities.remove(e)
+			return
+		}
+		e.Body.SetPosition(physics.HelloStart)
+		e.Body.SetVelocityVector(cp.Vector{})
+	})
+
+	return nil
+}
+
+func (h *HelloWorld) Draw(screen *ebiten.Image) {
+	// Goal
+	ebitenutil.DrawRect(screen, goal.L, goal.B, goal.R-goal.L, goal.T-goal.B, color.RGBA{G: 0x60, A: 0xff})
+
+	// Ground
+	ebitenutil.DrawLine(screen, cfg.Ground[0].X, cfg.Ground[0].Y, cfg.Ground[1].X, cfg.Ground[1].Y, cfg.GroundColor)
+
+	// Balls
+	op := &ebiten.DrawImageOptions{}
+	op.ColorM.ScaleWithColor(cfg.BallColor)
+	for _, e := range h.entities.entities {
+		op.GeoM.Reset()
+		op.GeoM.Translate(e.Body.Position().X, e.Body.Position().Y)
+		screen.DrawImage(e.Sprite, op)
+	}
+
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Score: %d. Balls: %d, lost: %d. B: spawn a ball, arrows/WASD: push the ball, Up/W/Space: jump.", h.score, h.balls, h.lost), 0, 16)
+	pos := h.ball.Body.Position()
+	vel := h.ball.Body.Velocity()
+	ebitenutil.DebugPrint(
+		screen,
+		fmt.Sprintf(
+			"Time is %5.2f of %g. ballBody is at (%5.2f, %5.2f). It's velocity is (%5.2f, %5.2f)",
+			h.time, cfg.SimulateMaxSeconds, pos.X, pos.Y, vel.X, vel.Y,
+		))
+}
+
+// Over ends the round once the ball has been followed for the configured
+// time, the game shows the score it ended on.
+func (h *HelloWorld) Over() bool {
+	return h.time >= cfg.SimulateMaxSeconds
+}
@@ -0,0 +1,50 @@
+package game
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"golang.org/x/image/font"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/assets"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+// hudSize is the size of the HUD's font, the menu title's, about that of
+// the debug font.
+const hudSize = 12
+
+var (
+	// hudFace is the face of the HUD, nil until it is first drawn, or if
+	// the font didn't load.
+	hudFace     font.Face
+	hudFaceDone bool
+	// hudHelp is the line of keys at the bottom of the screen.
+	hudHelp = render.TextBuffer("1-9/PgUp/PgDn: scene, Backspace: restart, P: pause, Esc: menu, F1/F2: keys/settings, F3-F7: contacts/perf/stats/tune/plot, `: console")
+	// hudPaused is the pause notice.
+	hudPaused = render.TextBuffer("Paused")
+	// hudColor and hudShadow are the colors of the text, made interfaces
+	// once: color.White made one at every call would be allocated.
+	hudColor, hudShadow color.Color = color.White, color.Black
+)
+
+// drawHUD draws text at (x, y), its top left corner, with a shadow as the
+// debug font has, in the HUD's face from glyphs rendered once, see
+// render.DrawText: the game's overlays, written into buffers they keep,
+// allocate nothing a frame. Without the font, it is in the debug font.
+func drawHUD(screen *ebiten.Image, text render.TextBuffer, x, y int) {
+	if !hudFaceDone {
+		hudFaceDone = true
+		var err error
+		if hudFace, err = assets.Font(menuTitleFont, hudSize); err != nil {
+			logRender.Warnf("HUD: %v, it is in the debug font", err)
+		}
+	}
+	if hudFace == nil {
+		ebitenutil.DebugPrintAt(screen, string(text), x, y)
+		return
+	}
+	render.DrawText(screen, text, hudFace, x+1, y+1, overlayLineHeight, hudShadow)
+	render.DrawText(screen, text, hudFace, x, y, overlayLineHeight, hudColor)
+}
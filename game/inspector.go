@@ -0,0 +1,326 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+const (
+	// inspectorWidth is the width of the panel, on the right of the screen
+	// under the overlays, and inspectorLabelWidth that of its labels.
+	inspectorWidth      = 240
+	inspectorLabelWidth = 110
+	inspectorX          = ScreenWidth - inspectorWidth - 8
+	inspectorY          = 152
+)
+
+// inspectable is a scene whose bodies can be clicked to be inspected.
+type inspectable interface {
+	// tappedBody returns the body clicked since it was last asked, if any.
+	tappedBody() *cp.Body
+}
+
+// inspectorField is a property of the inspected body that the panel shows
+// and sets. The properties of the shapes are those of the first one, and
+// setting one sets all of the body's.
+type inspectorField struct {
+	label string
+	get   func(body *cp.Body, shape *cp.Shape) string
+	set   func(body *cp.Body, text string) error
+}
+
+var inspectorFields = []inspectorField{
+	{
+		label: "Mass",
+		get:   func(body *cp.Body, _ *cp.Shape) string { return ftoa(body.Mass()) },
+		set: func(body *cp.Body, text string) error {
+			v, err := parsePositive(text)
+			if err == nil {
+				body.SetMass(v)
+			}
+			return err
+		},
+	},
+	{
+		// An infinite moment, inf, keeps the body from rotating.
+		label: "Moment",
+		get:   func(body *cp.Body, _ *cp.Shape) string { return ftoa(body.Moment()) },
+		set: func(body *cp.Body, text string) error {
+			v, err := parsePositive(text)
+			if err == nil {
+				body.SetMoment(v)
+			}
+			return err
+		},
+	},
+	{
+		label: "Velocity X",
+		get:   func(body *cp.Body, _ *cp.Shape) string { return ftoa(body.Velocity().X) },
+		set: func(body *cp.Body, text string) error {
+			v, err := parseReal(text)
+			if err == nil {
+				body.SetVelocity(v, body.Velocity().Y)
+			}
+			return err
+		},
+	},
+	{
+		label: "Velocity Y",
+		get:   func(body *cp.Body, _ *cp.Shape) string { return ftoa(body.Velocity().Y) },
+		set: func(body *cp.Body, text string) error {
+			v, err := parseReal(text)
+			if err == nil {
+				body.SetVelocity(body.Velocity().X, v)
+			}
+			return err
+		},
+	},
+	{
+		label: "Angular vel.",
+		get:   func(body *cp.Body, _ *cp.Shape) string { return ftoa(body.AngularVelocity()) },
+		set: func(body *cp.Body, text string) error {
+			v, err := parseReal(text)
+			if err == nil {
+				body.SetAngularVelocity(v)
+			}
+			return err
+		},
+	},
+	{
+		label: "Friction",
+		get:   func(_ *cp.Body, shape *cp.Shape) string { return ftoa(shape.Friction()) },
+		set: func(body *cp.Body, text string) error {
+			v, err := parseNonNegative(text)
+			if err == nil {
+				body.EachShape(func(shape *cp.Shape) { shape.SetFriction(v) })
+			}
+			return err
+		},
+	},
+	{
+		label: "Elasticity",
+		get:   func(_ *cp.Body, shape *cp.Shape) string { return ftoa(shape.Elasticity()) },
+		set: func(body *cp.Body, text string) error {
+			v, err := parseNonNegative(text)
+			if err == nil {
+				body.EachShape(func(shape *cp.Shape) { shape.SetElasticity(v) })
+			}
+			return err
+		},
+	},
+	{
+		label: "Collision type",
+		get:   func(_ *cp.Body, shape *cp.Shape) string { return fmt.Sprint(physics.CollisionType(shape)) },
+		set: func(body *cp.Body, text string) error {
+			v, err := strconv.ParseUint(text, 0, 64)
+			if err == nil {
+				body.EachShape(func(shape *cp.Shape) { shape.SetCollisionType(cp.CollisionType(v)) })
+			}
+			return err
+		},
+	},
+	inspectorFilterField("Filter group", false, func(f *cp.ShapeFilter) *uint { return &f.Group }),
+	inspectorFilterField("Categories", true, func(f *cp.ShapeFilter) *uint { return &f.Categories }),
+	inspectorFilterField("Mask", true, func(f *cp.ShapeFilter) *uint { return &f.Mask }),
+}
+
+// inspectorFilterField is the field of a part of the shapes' filter, in
+// hexadecimal for the bit masks.
+func inspectorFilterField(label string, hex bool, part func(f *cp.ShapeFilter) *uint) inspectorField {
+	return inspectorField{
+		label: label,
+		get: func(_ *cp.Body, shape *cp.Shape) string {
+			filter := shape.Filter
+			if hex {
+				return fmt.Sprintf("%#x", *part(&filter))
+			}
+			return fmt.Sprint(*part(&filter))
+		},
+		set: func(body *cp.Body, text string) error {
+			v, err := strconv.ParseUint(text, 0, 32)
+			if err != nil {
+				return err
+			}
+			body.EachShape(func(shape *cp.Shape) {
+				filter := shape.Filter
+				*part(&filter) = uint(v)
+				shape.SetFilter(filter)
+			})
+			return nil
+		},
+	}
+}
+
+func parseReal(text string) (float64, error) {
+	v, err := strconv.ParseFloat(text, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("not a number: %s", text)
+	}
+	return v, nil
+}
+
+func parseNonNegative(text string) (float64, error) {
+	v, err := parseReal(text)
+	if err == nil && v < 0 {
+		err = errors.New("must be 0 or more")
+	}
+	return v, err
+}
+
+// parsePositive parses a mass or a moment, which may be infinite.
+func parsePositive(text string) (float64, error) {
+	v, err := strconv.ParseFloat(text, 64)
+	if err != nil || !(v > 0) {
+		return 0, errors.New("must be a positive number, or inf")
+	}
+	return v, nil
+}
+
+// bodyInspector is the panel of the body clicked in the scene: it lists its
+// properties, and clicking one edits it, typed in and set with Enter. It is
+// closed while body is nil.
+type bodyInspector struct {
+	body *cp.Body
+	// editing is the field being typed in, text what was typed, -1 for
+	// none; message is why the last value wasn't set.
+	editing int
+	text    []rune
+	message string
+}
+
+func (p *bodyInspector) open(body *cp.Body) {
+	*p = bodyInspector{body: body, editing: -1}
+}
+
+func (p *bodyInspector) close() {
+	*p = bodyInspector{}
+}
+
+// typing reports whether a value is being typed, which takes the keyboard
+// from the game.
+func (p *bodyInspector) typing() bool {
+	return p.body != nil && p.editing >= 0
+}
+
+// row returns the row of the panel under the screen point q: -1 for the
+// title, a field's index, or false off the panel.
+func (p *bodyInspector) row(q cp.Vector) (int, bool) {
+	if q.X < inspectorX || q.X >= inspectorX+inspectorWidth || q.Y < inspectorY {
+		return 0, false
+	}
+	i := int(q.Y-inspectorY)/overlayLineHeight - 1
+	return i, i <= len(inspectorFields)
+}
+
+// update edits the fields, and closes the panel once its body is gone from
+// the scene, or has no shape left to show. It reports whether the mouse was
+// just pressed on the panel, which the scene shouldn't see.
+func (p *bodyInspector) update(g *Game) (pressed bool) {
+	if p.body == nil {
+		return false
+	}
+	gone := true
+	g.inSpace(func(space *cp.Space) { gone = !space.ContainsBody(p.body) })
+	if gone || p.shape() == nil {
+		p.close()
+		return false
+	}
+	if p.editing >= 0 {
+		p.edit()
+	}
+	if !input.Pointer.IsJustPressed() {
+		return false
+	}
+	q := input.Pointer.Position()
+	i, on := p.row(q)
+	p.editing, p.text = -1, nil
+	switch {
+	case !on:
+		return false
+	case i == -1 && q.X >= inspectorX+inspectorWidth-24:
+		p.close()
+	case i >= 0 && i < len(inspectorFields):
+		p.editing, p.message = i, ""
+		p.text = []rune(inspectorFields[i].get(p.body, p.shape()))
+	}
+	return true
+}
+
+// edit edits the text of the field being edited, sets it on Enter, and
+// gives up on Esc.
+func (p *bodyInspector) edit() {
+	for _, r := range ebiten.AppendInputChars(nil) {
+		if r > ' ' {
+			p.text = append(p.text, r)
+		}
+	}
+	switch {
+	case consoleRepeated(ebiten.KeyBackspace) && len(p.text) > 0:
+		p.text = p.text[:len(p.text)-1]
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		p.editing, p.text = -1, nil
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		f := inspectorFields[p.editing]
+		if err := f.set(p.body, string(p.text)); err != nil {
+			p.message = f.label + ": " + err.Error()
+			return
+		}
+		p.body.Activate()
+		p.editing, p.text, p.message = -1, nil, ""
+	}
+}
+
+// shape is the body's first shape, whose properties the panel shows.
+func (p *bodyInspector) shape() *cp.Shape {
+	var first *cp.Shape
+	p.body.EachShape(func(shape *cp.Shape) {
+		if first == nil {
+			first = shape
+		}
+	})
+	return first
+}
+
+func (p *bodyInspector) draw(screen *ebiten.Image) {
+	if p.body == nil {
+		return
+	}
+	rows := len(inspectorFields) + 2
+	ebitenutil.DrawRect(screen, inspectorX-4, inspectorY-4, inspectorWidth+8, float64(rows*overlayLineHeight+8), color.RGBA{A: 0xc0})
+	pos := p.body.Position()
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Body at %.0f, %.0f", pos.X, pos.Y), inspectorX, inspectorY)
+	ebitenutil.DebugPrintAt(screen, "[x]", inspectorX+inspectorWidth-20, inspectorY)
+	shape := p.shape()
+	y := inspectorY
+	for i, f := range inspectorFields {
+		y += overlayLineHeight
+		ebitenutil.DebugPrintAt(screen, f.label, inspectorX, y)
+		value := f.get(p.body, shape)
+		if i == p.editing {
+			ebitenutil.DrawRect(screen, inspectorX+inspectorLabelWidth-2, float64(y), inspectorWidth-inspectorLabelWidth, overlayLineHeight, colornames.Darkslategray)
+			value = string(p.text) + "_"
+		}
+		ebitenutil.DebugPrintAt(screen, value, inspectorX+inspectorLabelWidth, y)
+	}
+	y += overlayLineHeight
+	hint := "Click a value to edit it."
+	switch {
+	case p.message != "":
+		hint = p.message
+	case p.editing >= 0:
+		hint = "Enter: set, Esc: cancel."
+	}
+	ebitenutil.DebugPrintAt(screen, hint, inspectorX, y)
+}
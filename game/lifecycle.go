@@ -0,0 +1,90 @@
+package game
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/jakecoffman/cp"
+)
+
+// tiltTolerance is how far the gravity of a space is turned at least, as a
+// part of its strength, before the tilt turns it: a phone held still in the
+// hand jitters, which would wake the sleeping bodies every tick.
+const tiltTolerance = 0.02
+
+// The app's lifecycle and the phone's tilt, told by the app on another
+// goroutine than the game's, see the mobile package.
+var (
+	// suspended is 1 while the app is in the background, resumed 1 once it
+	// comes back until the next tick.
+	suspended, resumed int32
+
+	tiltMu sync.Mutex
+	// tilt is where the phone's gravity is on the screen, none if zero.
+	tilt cp.Vector
+	// tiltChanged tells that the tilt was turned off, for the gravity to be
+	// turned down once.
+	tiltChanged bool
+)
+
+// Suspend pauses the game while the app is in the background: its ticks,
+// if any, step nothing.
+func Suspend() {
+	atomic.StoreInt32(&suspended, 1)
+}
+
+// Resume goes on with the game once the app is back, from where it was:
+// the time spent in the background isn't caught up.
+func Resume() {
+	atomic.StoreInt32(&suspended, 0)
+	atomic.StoreInt32(&resumed, 1)
+}
+
+// SetTilt turns the gravity of the scenes toward where the phone's is on
+// the screen, x to the right and y down, keeping its strength: only the
+// direction counts. Zero turns it back down, as without tilt.
+func SetTilt(x, y float64) {
+	tiltMu.Lock()
+	defer tiltMu.Unlock()
+	v := cp.Vector{X: x, Y: y}
+	tiltChanged = tiltChanged || v != tilt
+	tilt = v
+}
+
+// suspendedTick reports whether the app is in the background, the tick
+// skipped, and forgets the time it was there once it is back.
+func (g *Game) suspendedTick() bool {
+	if atomic.LoadInt32(&suspended) == 1 {
+		g.clock.reset()
+		return true
+	}
+	if atomic.SwapInt32(&resumed, 0) == 1 {
+		g.clock.reset()
+	}
+	return false
+}
+
+// applyTilt turns the gravity of the scene's spaces toward the tilt, waking
+// their bodies to fall the new way. The spaces without gravity stay so.
+func (g *Game) applyTilt() {
+	tiltMu.Lock()
+	dir, changed := tilt, tiltChanged
+	tiltChanged = false
+	tiltMu.Unlock()
+	if dir == (cp.Vector{}) {
+		if !changed {
+			return
+		}
+		dir = cp.Vector{Y: 1}
+	}
+	dir = dir.Normalize()
+	g.eachSpace(func(space *cp.Space) {
+		old := space.Gravity()
+		gravity := dir.Mult(old.Length())
+		if gravity.Near(old, tiltTolerance*old.Length()) {
+			return
+		}
+		space.SetGravity(gravity)
+		space.EachBody(func(body *cp.Body) { body.Activate() })
+	})
+}
@@ -0,0 +1,46 @@
+package game
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/logging"
+)
+
+const (
+	// logTailLines is how many of the last log messages the overlay shows,
+	// over the bottom of the screen, and logTailColumns how many characters
+	// of each fit across it.
+	logTailLines   = 12
+	logTailColumns = ScreenWidth/6 - 1
+	logTailY       = ScreenHeight - 40 - logTailLines*overlayLineHeight
+)
+
+// logTail shows the last messages of the log over the scene, those the
+// levels let through, for them to be read in fullscreen and in a browser,
+// with no terminal at hand.
+type logTail struct {
+	visible bool
+}
+
+func (t *logTail) draw(screen *ebiten.Image) {
+	if !t.visible {
+		return
+	}
+	ebitenutil.DrawRect(screen, 0, logTailY-4, ScreenWidth, logTailLines*overlayLineHeight+8, color.RGBA{A: 0xa0})
+	lines := logging.Tail(logTailLines)
+	if len(lines) == 0 {
+		ebitenutil.DebugPrintAt(screen, "Nothing logged yet, -log debug logs more.", 4, logTailY)
+		return
+	}
+	y := logTailY + (logTailLines-len(lines))*overlayLineHeight
+	for _, line := range lines {
+		if len(line) > logTailColumns {
+			line = line[:logTailColumns]
+		}
+		ebitenutil.DebugPrintAt(screen, line, 4, y)
+		y += overlayLineHeight
+	}
+}
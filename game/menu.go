@@ -0,0 +1,102 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/font"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/assets"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+)
+
+const (
+	// menuLineHeight is the height of a line of the debug font, and
+	// menuHeader the lines above the list.
+	menuLineHeight = 16
+	menuHeader     = 3
+	// menuTitleFont and menuTitleSize are the font of the title, which
+	// takes the first two lines of the header.
+	menuTitleFont = "fonts/Go-Regular.ttf"
+	menuTitleSize = 24
+)
+
+// mainMenu lists the demos of the registry with their descriptions: the
+// arrows or the mouse move the highlight, Enter or a click launches the
+// highlighted demo.
+type mainMenu struct {
+	cursor int
+	// mouse is where the mouse was, the highlight follows it only when it
+	// moves, not to fight the arrows.
+	mouse cp.Vector
+	// title is the face of the title, nil until the menu is first drawn,
+	// or if the font didn't load.
+	title     font.Face
+	titleDone bool
+}
+
+// row returns the demo listed at the screen point p, if any.
+func (m *mainMenu) row(p cp.Vector) (int, bool) {
+	i := int(p.Y)/menuLineHeight - menuHeader
+	return i, p.Y >= 0 && i >= 0 && i < len(scenes)
+}
+
+// update moves the highlight, and returns the demo to launch, if any.
+func (m *mainMenu) update() (int, bool) {
+	switch {
+	case input.IsActionJustPressed(input.ActionUp):
+		m.cursor = (m.cursor - 1 + len(scenes)) % len(scenes)
+	case input.IsActionJustPressed(input.ActionDown):
+		m.cursor = (m.cursor + 1) % len(scenes)
+	}
+	mouse := input.Pointer.Position()
+	if mouse != m.mouse {
+		m.mouse = mouse
+		if i, ok := m.row(mouse); ok {
+			m.cursor = i
+		}
+	}
+
+	if input.Pointer.IsJustPressed() {
+		return m.row(mouse)
+	}
+	launch := input.IsActionJustPressed(input.ActionConfirm) ||
+		input.IsGamepadButtonJustPressed(input.GamepadJump)
+	return m.cursor, launch
+}
+
+// draw draws the list over the scene, which shows through. current is the
+// scene behind it, which Esc goes back to.
+func (m *mainMenu) draw(screen *ebiten.Image, current int) {
+	ebitenutil.DrawRect(screen, 0, 0, ScreenWidth, ScreenHeight, color.RGBA{A: 0xe0})
+
+	if !m.titleDone {
+		m.titleDone = true
+		var err error
+		if m.title, err = assets.Font(menuTitleFont, menuTitleSize); err != nil {
+			logRender.Warnf("Menu: %v, the title is in the debug font", err)
+		}
+	}
+
+	help := "Up/Down or the mouse: choose a demo, Enter or click: play it, Esc: back to " + scenes[current].name + ", F2: settings.\n"
+	var b strings.Builder
+	if m.title != nil {
+		text.Draw(screen, Title, m.title, 4, menuTitleSize, color.White)
+		b.WriteString("\n\n" + help)
+	} else {
+		b.WriteString(Title + "\n" + help + "\n")
+	}
+	for i, scene := range scenes {
+		marker := " "
+		if i == m.cursor {
+			marker = ">"
+		}
+		fmt.Fprintf(&b, "%s %2d. %-18s %s\n", marker, i+1, scene.name, scene.description)
+	}
+	ebitenutil.DebugPrint(screen, b.String())
+}
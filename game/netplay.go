@@ -0,0 +1,285 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/netsync"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/stream"
+)
+
+// NetScene is the scene -host and -join start on.
+const NetScene = "Networked"
+
+const (
+	// netSnapshotSteps is how many steps apart the host sends snapshots,
+	// 20 a second: the clients interpolate between them.
+	netSnapshotSteps = 3
+	// netDelaySteps is how far behind the last snapshot a client plays,
+	// two snapshots' worth, for one to be late without the bodies stopping.
+	netDelaySteps = 2 * netSnapshotSteps
+	// netMaxBodies caps the bodies spawned, the oldest removed first.
+	netMaxBodies = 150
+	// netStack is how many boxes the room starts with, stacked.
+	netStack    = 5
+	netBoxSize  = 30
+	netBallSize = 15
+	netGravity  = 600
+)
+
+// netColors are the colors of the bodies spawned by the clients, by their
+// number; the host's have the space's.
+var netColors = []color.Color{colornames.Orange, colornames.Limegreen, colornames.Hotpink, colornames.Gold, colornames.Turquoise}
+
+// netLink is the game's end of the network: a host serving its clients, or
+// a client of a host.
+type netLink struct {
+	server *stream.Server
+	conn   *stream.Conn
+	// addr is where it serves, or the host's URL.
+	addr string
+}
+
+// netplay is the link of -host or -join, nil without either.
+var netplay *netLink
+
+// Host serves the Networked scene over WebSocket at addr's /ws, until the
+// game shuts down: the clients joining it see its bodies, and spawn and
+// drag them as the host does. Web pages of origins may join too, see
+// stream.Listen. It is called before the game is made.
+func Host(addr string, origins []string) error {
+	server, err := stream.Listen(addr, origins...)
+	if err != nil {
+		return err
+	}
+	netplay = &netLink{server: server, addr: server.Addr().String()}
+	logGame.Infof("Hosting on ws://%s/ws", netplay.addr)
+	return nil
+}
+
+// Join connects to a host's Networked scene at url, e.g.
+// "ws://localhost:8091/ws", which the Networked scene shows from then on.
+// It is called before the game is made.
+func Join(url string) error {
+	conn, err := stream.Dial(url)
+	if err != nil {
+		return err
+	}
+	netplay = &netLink{conn: conn, addr: url}
+	logGame.Infof("Joined %s", url)
+	return nil
+}
+
+// stopNet closes the link, if the game has one.
+func stopNet() {
+	if netplay == nil {
+		return
+	}
+	var err error
+	if netplay.server != nil {
+		err = netplay.server.Close()
+	} else {
+		err = netplay.conn.Close()
+	}
+	if err != nil {
+		logGame.Errorf("Network: %v", err)
+	}
+	netplay = nil
+}
+
+// Networked is a room to spawn and drag bodies in, shared over the network:
+// the host steps the space, and sends snapshots of it to the clients, which
+// play them back, interpolated, and send their pointer to the host, which
+// spawns and drags bodies for them as for its own. Without -host nor
+// -join, it is the host of nobody.
+type Networked struct {
+	baseScene
+
+	space *cp.Space
+	// players are the host's, 0, and the clients' by their number.
+	players *netsync.Players
+	// spawned are the bodies spawned, the oldest first.
+	spawned []*cp.Body
+	encoder netsync.Encoder
+	steps   int
+
+	// mirror plays the host's space back on a client, nil on the host.
+	mirror *netsync.Mirror
+	// last is where the client's pointer last was sent.
+	last cp.Vector
+}
+
+func init() {
+	RegisterDemo(NetScene, "spawn and drag bodies with others over the network, see -host and -join", func() Scene { return NewNetworked() })
+}
+
+func NewNetworked() *Networked {
+	if netplay != nil && netplay.conn != nil {
+		return &Networked{mirror: netsync.NewMirror(netDelaySteps)}
+	}
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: netGravity})
+	physics.AddWalls(space, cp.BB{R: ScreenWidth, T: groundY})
+	for i := 0; i < netStack; i++ {
+		physics.AddBox(space, cp.Vector{X: 400, Y: groundY - netBoxSize/2 - float64(i)*netBoxSize}, netBoxSize, netBoxSize)
+	}
+	// A snapshot has room for every body, the static one of the full
+	// snapshots included: the newest spawned, visited last, aren't cut.
+	n := &Networked{
+		space:   space,
+		players: netsync.NewPlayers(space),
+		encoder: netsync.Encoder{MaxBodies: 1 + netStack + netMaxBodies},
+	}
+	n.players.Spawn = n.spawn
+	return n
+}
+
+// Init forgets what the clients sent while the host was on other scenes,
+// but for those that left, whose players leave.
+func (n *Networked) Init() {
+	if netplay == nil || netplay.server == nil {
+		return
+	}
+	for {
+		msg, ok := netplay.server.Receive()
+		if !ok {
+			return
+		}
+		if msg.Gone {
+			n.players.Leave(msg.Client)
+		}
+	}
+}
+
+func (n *Networked) Update(dt float64) error {
+	if n.mirror != nil {
+		n.updateClient(dt)
+		return nil
+	}
+	p := input.Pointer.Position()
+	in := netsync.Input{Kind: netsync.Move, X: p.X, Y: p.Y}
+	switch {
+	case input.Pointer.IsJustPressed():
+		in.Kind = netsync.Press
+	case input.Pointer.IsJustReleased():
+		in.Kind = netsync.Release
+	}
+	n.players.Apply(0, in)
+	hosting := netplay != nil && netplay.server != nil
+	if hosting {
+		if err := n.players.Receive(netplay.server); err != nil {
+			logGame.Warnf("Network: %v", err)
+		}
+	}
+	n.players.Step(dt)
+	n.space.Step(dt)
+	n.steps++
+	if hosting && n.steps%netSnapshotSteps == 0 {
+		return n.encoder.Publish(netplay.server, n.space, n.steps)
+	}
+	return nil
+}
+
+// spawn spawns a box or a ball in turn at p, for player, in its color.
+func (n *Networked) spawn(player int, p cp.Vector) {
+	var body *cp.Body
+	var shape *cp.Shape
+	if len(n.spawned)%2 == 0 {
+		body, shape = physics.AddBox(n.space, p, netBoxSize, netBoxSize)
+	} else {
+		body, shape = physics.AddBall(n.space, p, netBallSize)
+	}
+	if player > 0 {
+		shape.UserData = netColors[(player-1)%len(netColors)]
+	}
+	n.spawned = append(n.spawned, body)
+	if len(n.spawned) > netMaxBodies {
+		n.remove(n.spawned[0])
+		n.spawned = n.spawned[1:]
+	}
+}
+
+// remove removes a spawned body, its single shape, and the joint of the
+// mouse dragging it if any.
+func (n *Networked) remove(body *cp.Body) {
+	var constraints []*cp.Constraint
+	body.EachConstraint(func(c *cp.Constraint) { constraints = append(constraints, c) })
+	for _, c := range constraints {
+		n.space.RemoveConstraint(c)
+	}
+	var shape *cp.Shape
+	body.EachShape(func(s *cp.Shape) { shape = s })
+	n.space.RemoveShape(shape)
+	n.space.RemoveBody(body)
+}
+
+// updateClient plays the host's snapshots received, and sends it the
+// pointer.
+func (n *Networked) updateClient(dt float64) {
+	conn := netplay.conn
+	if err := n.mirror.Receive(conn); err != nil {
+		logGame.Warnf("Network: %v", err)
+	}
+	n.mirror.Advance(dt / physicsStep)
+
+	p := input.Pointer.Position()
+	var in netsync.Input
+	switch {
+	case input.Pointer.IsJustPressed():
+		in.Kind = netsync.Press
+	case input.Pointer.IsJustReleased():
+		in.Kind = netsync.Release
+	case input.Pointer.IsPressed() && p != n.last:
+		in.Kind = netsync.Move
+	default:
+		return
+	}
+	n.last, in.X, in.Y = p, p.X, p.Y
+	data, err := json.Marshal(in)
+	if err != nil {
+		logGame.Errorf("Network: %v", err)
+		return
+	}
+	if err := conn.Send(data); err != nil && !conn.Closed() {
+		logGame.Warnf("Network: %v", err)
+	}
+}
+
+// inSpace calls f with the space shown: the mirror's on a client.
+func (n *Networked) inSpace(f func(space *cp.Space)) {
+	if n.mirror != nil {
+		f(n.mirror.Space)
+		return
+	}
+	f(n.space)
+}
+
+func (n *Networked) Draw(screen *ebiten.Image) {
+	if n.mirror != nil {
+		render.DrawSpace(screen, n.mirror.Space, colornames.Lightsteelblue)
+		status := "Waiting for the host's snapshots"
+		if step, ok := n.mirror.Step(); ok {
+			status = fmt.Sprintf("Host step %.0f, %d snapshots kept", step, n.mirror.Snapshots())
+		}
+		if netplay.conn.Closed() {
+			status = "The host is gone"
+		}
+		ebitenutil.DebugPrint(screen, fmt.Sprintf("Joined %s. %s.\nClick: spawn a body, drag: move one.", netplay.addr, status))
+		return
+	}
+	render.DrawSpace(screen, n.space, colornames.Lightsteelblue)
+	hosting := "Playing alone, see -host"
+	if netplay != nil && netplay.server != nil {
+		hosting = fmt.Sprintf("Hosting on ws://%s/ws, %d clients", netplay.addr, netplay.server.Clients())
+	}
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("%s. Bodies: %d, players: %d.\nClick: spawn a body, drag: move one.", hosting, len(n.spawned), n.players.Len()))
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	// pendulumNudge is how much the second pendulum's start angle differs.
+	pendulumNudge = 1e-6
+
+	phaseSize = 220
+)
+
+var (
+	pendulumAnchor = cp.Vector{X: 280, Y: 220}
+	phaseOrigin    = cp.Vector{X: ScreenWidth - phaseSize - 20, Y: 20}
+)
+
+// DoublePendulumScene runs two pendulums that only differ by pendulumNudge
+// and plots both in the (theta1, theta2) phase plane to show how quickly
+// they part ways.
+type DoublePendulumScene struct {
+	baseScene
+
+	pendulums [2]*physics.DoublePendulum
+	colors    [2]color.Color
+	phase     *ebiten.Image
+	time      float64
+}
+
+func init() {
+	RegisterDemo("Double pendulum", "chaos: two pendulums starting 1e-6 rad apart", func() Scene { return NewDoublePendulumScene() })
+}
+
+func NewDoublePendulumScene() *DoublePendulumScene {
+	phase := ebiten.NewImage(phaseSize, phaseSize)
+	phase.Fill(color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xff})
+
+	return &DoublePendulumScene{
+		pendulums: [2]*physics.DoublePendulum{
+			physics.NewDoublePendulum(newSpace(), pendulumAnchor, math.Pi/2, math.Pi),
+			physics.NewDoublePendulum(newSpace(), pendulumAnchor, math.Pi/2+pendulumNudge, math.Pi),
+		},
+		colors: [2]color.Color{colornames.Orange, colornames.Deepskyblue},
+		phase:  phase,
+	}
+}
+
+// Dispose frees the phase plot's image right away rather than leaving it to
+// the garbage collector, it's redrawn from scratch on a restart anyway.
+func (s *DoublePendulumScene) Dispose() {
+	s.phase.Dispose()
+}
+
+func (s *DoublePendulumScene) Update(dt float64) error {
+	s.time += dt
+	for i, p := range s.pendulums {
+		p.Step(dt)
+		theta1, theta2 := p.Angles()
+		x := (theta1/math.Pi + 1) / 2 * phaseSize
+		y := (1 - theta2/math.Pi) / 2 * phaseSize
+		ebitenutil.DrawRect(s.phase, x, y, 1, 1, s.colors[i])
+	}
+	return nil
+}
+
+func (s *DoublePendulumScene) Draw(screen *ebiten.Image) {
+	for i, p := range s.pendulums {
+		upper, lower := p.Upper.Position(), p.Lower.Position()
+		ebitenutil.DrawLine(screen, pendulumAnchor.X, pendulumAnchor.Y, upper.X, upper.Y, s.colors[i])
+		ebitenutil.DrawLine(screen, upper.X, upper.Y, lower.X, lower.Y, s.colors[i])
+		render.DrawSpace(screen, p.Space, s.colors[i])
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(phaseOrigin.X, phaseOrigin.Y)
+	screen.DrawImage(s.phase, op)
+	ebitenutil.DebugPrintAt(screen, "theta1 -> / theta2 ^", int(phaseOrigin.X), int(phaseOrigin.Y)+phaseSize+4)
+
+	a1, a2 := s.pendulums[0].Angles()
+	b1, b2 := s.pendulums[1].Angles()
+	ebitenutil.DebugPrint(
+		screen,
+		fmt.Sprintf(
+			"Time is %5.2f. Start angles differ by %g rad.\nAngle difference is now (%8.5f, %8.5f)",
+			s.time, pendulumNudge, angleDiff(a1, b1), angleDiff(a2, b2),
+		))
+}
+
+// angleDiff returns a-b wrapped to [-Pi, Pi].
+func angleDiff(a, b float64) float64 {
+	return math.Remainder(a-b, 2*math.Pi)
+}
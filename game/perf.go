@@ -0,0 +1,144 @@
+package game
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	// perfWindow is how long the overlays' worst and averages are over.
+	perfWindow = time.Second
+	// overlayX is where the overlays are, down the right of the screen
+	// under the pause notice, overlayLineHeight the height of their lines.
+	overlayX          = ScreenWidth - 200
+	overlayY          = 16
+	overlayLineHeight = 16
+)
+
+// perfOverlay shows how smoothly the game runs: the frames and ticks per
+// second ebiten measures, the physics steps made for the frame, and the
+// time between frames, with the worst of the last second for the hitches an
+// average hides. It also shows the memory of the last second, for the
+// allocations of the loop, and the collections they cost, to show as soon
+// as they grow, and the quads of the debug drawer's batch against the room
+// in its buffers.
+type perfOverlay struct {
+	visible bool
+	// steps are the physics steps since the last frame.
+	steps int
+	// lastFrame is when the last frame was drawn.
+	lastFrame time.Time
+	// frame and frameSteps are the last frame's time and steps, slowest and
+	// mostSteps the worst of the window started at windowStart.
+	frame, slowest        time.Duration
+	frameSteps, mostSteps int
+	windowStart           time.Time
+	// shown are the worst of the last full window, which the overlay shows.
+	shownSlowest time.Duration
+	shownSteps   int
+	// frames are the frames of the window, mem the memory statistics at its
+	// start, without Mallocs if they weren't read then.
+	frames   int
+	mem      runtime.MemStats
+	memShown memSample
+	// batch is what the debug drawer's batch drew in the last frame.
+	batch render.BatchStats
+	// text is the overlay's, written anew every frame.
+	text render.TextBuffer
+}
+
+// memSample is the memory of a window: the heap in use at its end, the
+// allocations a frame, and the collections and their pauses over it.
+type memSample struct {
+	heap           uint64
+	allocsPerFrame float64
+	gcs            uint32
+	pause          time.Duration
+	// ok is false until a window was sampled from start to end.
+	ok bool
+}
+
+// step counts a physics step of the frame being made.
+func (p *perfOverlay) step() {
+	p.steps++
+}
+
+// frameDone measures the frame drawn at now, since the one before.
+func (p *perfOverlay) frameDone(now time.Time) {
+	if !p.lastFrame.IsZero() {
+		p.frame = now.Sub(p.lastFrame)
+	}
+	p.lastFrame = now
+	p.frameSteps, p.steps = p.steps, 0
+	p.frames++
+	p.batch = render.TakeBatchStats()
+	if p.frame > p.slowest {
+		p.slowest = p.frame
+	}
+	if p.frameSteps > p.mostSteps {
+		p.mostSteps = p.frameSteps
+	}
+	if now.Sub(p.windowStart) >= perfWindow {
+		p.shownSlowest, p.shownSteps = p.slowest, p.mostSteps
+		p.slowest, p.mostSteps = 0, 0
+		p.windowStart = now
+		if p.visible {
+			p.sampleMemory()
+		} else {
+			p.mem.Mallocs = 0
+		}
+		p.frames = 0
+	}
+}
+
+// sampleMemory reads the memory statistics at the end of a window, only
+// once a window as reading them stops the world, and sets the sample shown
+// against those of the window before.
+func (p *perfOverlay) sampleMemory() {
+	before := p.mem
+	runtime.ReadMemStats(&p.mem)
+	if before.Mallocs == 0 || p.frames == 0 {
+		p.memShown = memSample{heap: p.mem.HeapInuse}
+		return
+	}
+	p.memShown = memSample{
+		heap:           p.mem.HeapInuse,
+		allocsPerFrame: float64(p.mem.Mallocs-before.Mallocs) / float64(p.frames),
+		gcs:            p.mem.NumGC - before.NumGC,
+		pause:          time.Duration(p.mem.PauseTotalNs - before.PauseTotalNs),
+		ok:             true,
+	}
+}
+
+// draw draws the overlay at y, under the other overlays, and returns the y
+// under it.
+func (p *perfOverlay) draw(screen *ebiten.Image, y int) int {
+	p.frameDone(time.Now())
+	if !p.visible {
+		return y
+	}
+	t := p.text.Reset().
+		Text("FPS ").Float(ebiten.CurrentFPS(), 1).Text("  TPS ").Float(ebiten.CurrentTPS(), 1).
+		Text("\nSteps ").Int(p.frameSteps).Text(", at most ").Int(p.shownSteps).
+		Text("\nFrame ").Float(milliseconds(p.frame), 1).Text(" ms, at most ").Float(milliseconds(p.shownSlowest), 1)
+	m := p.memShown
+	t.Text("\nHeap ").Float(float64(m.heap)/(1<<20), 1).Text(" MB")
+	if m.ok {
+		t.Text(", ").Float(m.allocsPerFrame, 0).Text(" allocs/frame\nGC ").Int(int(m.gcs)).Text("/s, pauses ").Float(milliseconds(m.pause), 2).Text(" ms")
+	} else {
+		t.Text("\nGC measuring...")
+	}
+	b := p.batch
+	t.Text("\nBatch ").Int(b.Quads).Text(" quads, ").Int(b.Calls).Text(" calls").
+		Text("\nBuffers ").Int(b.Capacity).Text(" quads, grew ").Int(b.Grows)
+	drawHUD(screen, p.text, overlayX, y)
+	return y + 8*overlayLineHeight
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
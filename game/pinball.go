@@ -0,0 +1,229 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	pinballGravity     = 400
+	pinballBallRadius  = 8
+	pinballBallMass    = 1
+	pinballBalls       = 3
+	pinballFlipperMass = 5
+	pinballFlipperLen  = 60
+	pinballFlipperRest = 0.5
+	pinballFlipperRate = 20
+	pinballBumperKick  = 300
+	pinballBumperScore = 100
+	pinballPlungerPull = 50000
+
+	// pinballFrame is the group of the table, flippers and plunger, so that
+	// the moving parts never catch on the walls they sit against.
+	pinballFrame = 3
+)
+
+const (
+	pinballBallType cp.CollisionType = iota + 1
+	pinballBumperType
+)
+
+var (
+	pinballLeftPivot  = cp.Vector{X: 305, Y: 510}
+	pinballRightPivot = cp.Vector{X: 455, Y: 510}
+	pinballBallStart  = cp.Vector{X: 580, Y: 540}
+	pinballBumpers    = []cp.Vector{{X: 300, Y: 180}, {X: 460, Y: 180}, {X: 380, Y: 270}}
+)
+
+// flipper is a paddle on a pivot, held within its range by a rotary limit
+// joint and driven up or down by a motor.
+type flipper struct {
+	body  *cp.Body
+	motor *cp.SimpleMotor
+	key   input.Action
+	// up is the motor rate that swings the flipper up. It changes sign
+	// between the left and the right flipper.
+	up float64
+}
+
+// Pinball is a minimal table: two flippers, a spring plunger in the launch
+// lane and bumpers that kick the ball away on contact.
+type Pinball struct {
+	baseScene
+
+	space    *cp.Space
+	ball     *cp.Body
+	plunger  *cp.Body
+	flippers [2]*flipper
+	hits     map[*cp.Shape]int
+
+	ticks int
+	score int
+	balls int
+}
+
+func init() {
+	RegisterDemo("Pinball", "flippers, a plunger and bumpers", func() Scene { return NewPinball() })
+}
+
+func NewPinball() *Pinball {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: pinballGravity})
+	frame := cp.NewShapeFilter(pinballFrame, cp.ALL_CATEGORIES, cp.ALL_CATEGORIES)
+
+	// The table outline, the launch lane wall and the inlanes.
+	for _, seg := range [][2]cp.Vector{
+		{{X: 200, Y: 600}, {X: 200, Y: 100}},
+		{{X: 200, Y: 100}, {X: 260, Y: 40}},
+		{{X: 260, Y: 40}, {X: 540, Y: 40}},
+		{{X: 540, Y: 40}, {X: 600, Y: 100}},
+		{{X: 600, Y: 100}, {X: 600, Y: 600}},
+		{{X: 560, Y: 150}, {X: 560, Y: 600}},
+		{{X: 200, Y: 450}, {X: 300, Y: 505}},
+		{{X: 560, Y: 450}, {X: 460, Y: 505}},
+	} {
+		wall := space.AddShape(cp.NewSegment(space.StaticBody, seg[0], seg[1], 2))
+		wall.SetElasticity(0.5)
+		wall.SetFriction(0.3)
+		wall.SetFilter(frame)
+	}
+
+	p := &Pinball{
+		space: space,
+		hits:  map[*cp.Shape]int{},
+		balls: pinballBalls,
+	}
+
+	for _, pos := range pinballBumpers {
+		bumper := space.AddShape(cp.NewCircle(space.StaticBody, 20, pos))
+		bumper.SetElasticity(0.8)
+		bumper.SetCollisionType(pinballBumperType)
+	}
+	handler := space.NewCollisionHandler(pinballBallType, pinballBumperType)
+	handler.BeginFunc = p.bump
+
+	p.flippers[0] = addFlipper(space, pinballLeftPivot, 1, input.ActionFlipperLeft)
+	p.flippers[1] = addFlipper(space, pinballRightPivot, -1, input.ActionFlipperRight)
+	p.plunger = addPlunger(space)
+
+	moment := cp.MomentForCircle(pinballBallMass, 0, pinballBallRadius, cp.Vector{})
+	p.ball = space.AddBody(cp.NewBody(pinballBallMass, moment))
+	ballShape := space.AddShape(cp.NewCircle(p.ball, pinballBallRadius, cp.Vector{}))
+	ballShape.SetElasticity(0.5)
+	ballShape.SetFriction(0.3)
+	ballShape.SetCollisionType(pinballBallType)
+	p.serve()
+
+	return p
+}
+
+// addFlipper adds a flipper pointing along dir (1 for right, -1 for left)
+// from its pivot.
+func addFlipper(space *cp.Space, pivot cp.Vector, dir float64, key input.Action) *flipper {
+	verts := []cp.Vector{
+		{X: 0, Y: -8}, {X: dir * pinballFlipperLen, Y: -4},
+		{X: dir * pinballFlipperLen, Y: 4}, {X: 0, Y: 8},
+	}
+	moment := cp.MomentForPoly(pinballFlipperMass, len(verts), verts, cp.Vector{}, 0)
+	body := space.AddBody(cp.NewBody(pinballFlipperMass, moment))
+	body.SetPosition(pivot)
+	body.SetAngle(dir * pinballFlipperRest)
+	shape := space.AddShape(cp.NewPolyShape(body, len(verts), verts, cp.NewTransformIdentity(), 0))
+	shape.SetElasticity(0.2)
+	shape.SetFriction(0.8)
+	shape.SetFilter(cp.NewShapeFilter(pinballFrame, cp.ALL_CATEGORIES, cp.ALL_CATEGORIES))
+
+	space.AddConstraint(cp.NewPivotJoint(space.StaticBody, body, pivot))
+	space.AddConstraint(cp.NewRotaryLimitJoint(space.StaticBody, body, -pinballFlipperRest, pinballFlipperRest))
+	// cp's motor drives the angular velocity of B relative to A to -rate.
+	motor := space.AddConstraint(cp.NewSimpleMotor(space.StaticBody, body, 0))
+	motor.SetMaxForce(3e6)
+
+	return &flipper{
+		body:  body,
+		motor: motor.Class.(*cp.SimpleMotor),
+		key:   key,
+		up:    dir * pinballFlipperRate,
+	}
+}
+
+// addPlunger adds a block that slides along a groove in the launch lane and
+// is held in place by a spring.
+func addPlunger(space *cp.Space) *cp.Body {
+	body := space.AddBody(cp.NewBody(2, cp.INFINITY))
+	body.SetPosition(cp.Vector{X: 580, Y: 560})
+	shape := space.AddShape(cp.NewBox(body, 36, 10, 0))
+	shape.SetFilter(cp.NewShapeFilter(pinballFrame, cp.ALL_CATEGORIES, cp.ALL_CATEGORIES))
+
+	space.AddConstraint(cp.NewGrooveJoint(space.StaticBody, body, cp.Vector{X: 580, Y: 520}, cp.Vector{X: 580, Y: 595}, cp.Vector{}))
+	space.AddConstraint(cp.NewDampedSpring(space.StaticBody, body, cp.Vector{X: 580, Y: 480}, cp.Vector{}, 80, 1500, 10))
+	return body
+}
+
+// bump kicks the ball away from the bumper it just touched.
+func (p *Pinball) bump(arb *cp.Arbiter, space *cp.Space, _ interface{}) bool {
+	ball, bumper := arb.Shapes()
+	body := ball.Body()
+	body.ApplyImpulseAtWorldPoint(arb.Normal().Neg().Mult(pinballBumperKick*pinballBallMass), body.Position())
+	p.hits[bumper] = p.ticks
+	p.score += pinballBumperScore
+	return true
+}
+
+// serve puts the ball back on the plunger.
+func (p *Pinball) serve() {
+	p.ball.SetPosition(pinballBallStart)
+	p.ball.SetVelocityVector(cp.Vector{})
+	p.ball.SetAngularVelocity(0)
+}
+
+func (p *Pinball) Update(dt float64) error {
+	for _, f := range p.flippers {
+		if input.IsActionPressed(f.key) {
+			f.motor.Rate = f.up
+		} else {
+			f.motor.Rate = -f.up
+		}
+		f.body.Activate()
+	}
+	if input.IsActionPressed(input.ActionLaunch) {
+		p.plunger.ApplyForceAtLocalPoint(cp.Vector{Y: pinballPlungerPull}, cp.Vector{})
+		p.plunger.Activate()
+	}
+
+	p.ticks++
+	p.space.Step(dt)
+
+	if p.balls > 0 && p.ball.Position().Y > ScreenHeight+pinballBallRadius {
+		p.balls--
+		if p.balls > 0 {
+			p.serve()
+		}
+	}
+	return nil
+}
+
+func (p *Pinball) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, p.space, colornames.White)
+
+	// Bumpers glow for a few ticks after a hit.
+	for shape, tick := range p.hits {
+		if p.ticks-tick < 10 {
+			circle := shape.Class.(*cp.Circle)
+			render.DrawCircle(screen, shape.BB().Center(), circle.Radius()+3, colornames.Yellow)
+		}
+	}
+
+	msg := fmt.Sprintf("Score: %d. Balls: %d.\nZ/M: flippers, hold and release Space: plunger.", p.score, p.balls)
+	if p.balls == 0 {
+		msg += "\nGame over, Backspace to play again."
+	}
+	ebitenutil.DebugPrint(screen, msg)
+}
@@ -0,0 +1,178 @@
+package game
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+// Adapted from Chipmunk's Player demo. The y axis points down on screen, so
+// "up" is negative y throughout.
+const (
+	playerGravity      = 1000
+	playerVelocity     = 250
+	playerGroundAccel  = playerVelocity / 0.1
+	playerAirAccel     = playerVelocity / 0.25
+	playerJumpHeight   = 60
+	playerJumpBoost    = 40
+	playerFallVelocity = 600
+	playerMaxSlope     = 45 * math.Pi / 180
+	playerHalfWidth    = 10
+	playerHalfHeight   = 18
+	playerCornerRadius = 4
+	playerSpawnX       = 80
+	playerSpawnY       = 400
+)
+
+// Platformer is the canonical Chipmunk player controller: a body that never
+// rotates, walks by moving the surface velocity of its feet and only counts
+// as grounded on slopes gentle enough to stand on.
+type Platformer struct {
+	baseScene
+
+	space  *cp.Space
+	body   *cp.Body
+	shape  *cp.Shape
+	ground cp.Vector
+
+	grounded       bool
+	lastJump       bool
+	remainingBoost float64
+}
+
+func init() {
+	RegisterDemo("Platformer", "Chipmunk's player controller", func() Scene { return NewPlatformer() })
+}
+
+func NewPlatformer() *Platformer {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: playerGravity})
+
+	p := &Platformer{space: space}
+
+	// A floor, a gentle 30 degrees slope you can walk up, a 60 degrees slope
+	// you slide down from, and a few platforms.
+	for _, seg := range [][2]cp.Vector{
+		{{X: 0, Y: groundY}, {X: ScreenWidth, Y: groundY}},
+		{{X: 0, Y: 0}, {X: 0, Y: groundY}},
+		{{X: ScreenWidth, Y: 0}, {X: ScreenWidth, Y: groundY}},
+		{{X: 200, Y: groundY}, {X: 400, Y: groundY - 200*math.Tan(math.Pi/6)}},
+		{{X: 800, Y: 300}, {X: 650, Y: 300 + 150*math.Tan(math.Pi/3)}},
+	} {
+		physics.AddStaticSegment(space, seg[0], seg[1], 0)
+	}
+	for _, bb := range []cp.BB{
+		{L: 460, B: 380, R: 560, T: 390},
+		{L: 560, B: 300, R: 620, T: 310},
+		{L: 300, B: 240, R: 440, T: 250},
+	} {
+		shape := space.AddShape(cp.NewBox2(space.StaticBody, bb, 0))
+		shape.SetFriction(1)
+	}
+
+	// An infinite moment of inertia keeps the player upright.
+	p.body = space.AddBody(cp.NewBody(1, cp.INFINITY))
+	p.body.SetPosition(cp.Vector{X: playerSpawnX, Y: playerSpawnY})
+	p.body.SetVelocityUpdateFunc(p.updateVelocity)
+	p.shape = space.AddShape(cp.NewBox2(p.body, cp.BB{
+		L: -playerHalfWidth, B: -playerHalfHeight, R: playerHalfWidth, T: playerHalfHeight,
+	}, playerCornerRadius))
+	p.shape.SetElasticity(0)
+	p.shape.SetFriction(0)
+	p.shape.UserData = colornames.Orange
+
+	return p
+}
+
+func playerInput() (x float64, jump bool) {
+	if input.IsActionPressed(input.ActionLeft) {
+		x--
+	}
+	if input.IsActionPressed(input.ActionRight) {
+		x++
+	}
+	jump = input.IsActionPressed(input.ActionJump)
+	return x, jump
+}
+
+// updateVelocity replaces the body's velocity integration. It finds the
+// most upright contact normal from the last step to decide if the player
+// stands on something, then steers with friction when grounded and with a
+// capped acceleration in the air.
+func (p *Platformer) updateVelocity(body *cp.Body, gravity cp.Vector, damping, dt float64) {
+	x, jump := playerInput()
+
+	// The arbiter normal points from the player to what it touches.
+	p.ground = cp.Vector{}
+	body.EachArbiter(func(arb *cp.Arbiter) {
+		n := arb.Normal().Neg()
+		if n.Y < p.ground.Y {
+			p.ground = n
+		}
+	})
+	p.grounded = p.ground.Y < -math.Cos(playerMaxSlope)
+	if p.ground.Y > 0 {
+		// Bumped a ceiling.
+		p.remainingBoost = 0
+	}
+
+	// Holding jump cancels gravity for a short while for higher jumps.
+	if jump && p.remainingBoost > 0 {
+		gravity = cp.Vector{}
+	}
+	body.UpdateVelocity(gravity, damping, dt)
+
+	// The feet move opposite to the player: friction does the walking.
+	target := playerVelocity * x
+	p.shape.SetSurfaceV(cp.Vector{X: -target})
+	if p.grounded {
+		p.shape.SetFriction(playerGroundAccel / playerGravity)
+	} else {
+		p.shape.SetFriction(0)
+		v := body.Velocity()
+		body.SetVelocity(cp.LerpConst(v.X, target, playerAirAccel*dt), v.Y)
+	}
+
+	v := body.Velocity()
+	body.SetVelocity(v.X, math.Min(v.Y, playerFallVelocity))
+}
+
+func (p *Platformer) Update(dt float64) error {
+	_, jump := playerInput()
+	if jump && !p.lastJump && p.grounded {
+		jumpV := math.Sqrt(2 * playerJumpHeight * playerGravity)
+		p.body.SetVelocityVector(p.body.Velocity().Add(cp.Vector{Y: -jumpV}))
+		p.remainingBoost = playerJumpBoost / jumpV
+	}
+
+	p.space.Step(dt)
+	p.remainingBoost -= dt
+	p.lastJump = jump
+
+	if p.body.Position().Y > ScreenHeight {
+		p.body.SetPosition(cp.Vector{X: playerSpawnX, Y: playerSpawnY})
+		p.body.SetVelocityVector(cp.Vector{})
+	}
+	return nil
+}
+
+func (p *Platformer) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, p.space, colornames.White)
+
+	slope := 0.0
+	if p.ground.Y < 0 {
+		slope = math.Acos(-p.ground.Y) * 180 / math.Pi
+	}
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Grounded: %v. Slope under feet: %4.1f deg (max %2.0f).\nLeft/Right or A/D: walk, Up/W/Space: jump (hold for higher).",
+		p.grounded, slope, playerMaxSlope*180/math.Pi,
+	))
+}
@@ -0,0 +1,110 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+const (
+	// plotSamples is how many steps the plot goes back, 5 s at 60 steps
+	// per second.
+	plotSamples = 300
+	// plotWidth and plotHeight are the size of the graph, in the bottom
+	// left corner of the screen under its legend.
+	plotWidth  = 360
+	plotHeight = 100
+	plotX      = 8
+	plotY      = ScreenHeight - 40 - plotHeight
+)
+
+// plotCurves are the colors of the curves, in the order of plotSample.
+var plotCurves = [...]color.Color{colornames.White, colornames.Orange, colornames.Skyblue, colornames.Lime}
+
+// plotSample is what the plot keeps of a step: the energy, kinetic and
+// potential, and the momentum's magnitude.
+type plotSample [4]float64
+
+// plotOverlay graphs the energy and the momentum of the scene's spaces over
+// the last steps, to see damping, elasticity and the solver's drift as
+// curves. The energies share a scale, for one to be seen turning into the
+// other; the momentum has its own.
+type plotOverlay struct {
+	visible bool
+	// samples is a ring of the last n samples, next the one to write.
+	samples [plotSamples]plotSample
+	next, n int
+}
+
+// reset forgets the samples, of another scene.
+func (p *plotOverlay) reset() {
+	p.next, p.n = 0, 0
+}
+
+// sample keeps the stats of a step.
+func (p *plotOverlay) sample(s physics.Stats) {
+	p.samples[p.next] = plotSample{s.Energy(), s.Kinetic, s.Potential, s.Momentum.Length()}
+	p.next = (p.next + 1) % plotSamples
+	if p.n < plotSamples {
+		p.n++
+	}
+}
+
+// at returns the i-th sample kept, from the oldest.
+func (p *plotOverlay) at(i int) plotSample {
+	return p.samples[(p.next-p.n+i+plotSamples)%plotSamples]
+}
+
+func (p *plotOverlay) draw(screen *ebiten.Image) {
+	if !p.visible {
+		return
+	}
+	ebitenutil.DrawRect(screen, plotX-4, plotY-52, plotWidth+8, plotHeight+56, color.RGBA{A: 0xc0})
+	if p.n == 0 {
+		ebitenutil.DebugPrintAt(screen, "No steps to plot yet.", plotX, plotY-48)
+		return
+	}
+	last := p.at(p.n - 1)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf(
+		"Energy %.4g, kinetic %.4g\nPotential %.4g, momentum %.4g\nWhite, orange, blue, green; the last %.0f s",
+		last[0], last[1], last[2], last[3], float64(plotSamples)*physicsStep,
+	), plotX, plotY-48)
+
+	// The energies, the first three, share their range, the momentum has
+	// its own from 0.
+	low, high := math.Inf(1), math.Inf(-1)
+	top := 0.0
+	for i := 0; i < p.n; i++ {
+		s := p.at(i)
+		for _, e := range s[:3] {
+			low, high = math.Min(low, e), math.Max(high, e)
+		}
+		top = math.Max(top, s[3])
+	}
+	y := func(curve int, v float64) float64 {
+		min, max := low, high
+		if curve == 3 {
+			min, max = 0, top
+		}
+		if max-min < 1e-9 {
+			return plotY + plotHeight/2
+		}
+		return plotY + plotHeight - (v-min)/(max-min)*plotHeight
+	}
+	dx := float64(plotWidth) / plotSamples
+	for curve, clr := range plotCurves {
+		prev := p.at(0)
+		for i := 1; i < p.n; i++ {
+			s := p.at(i)
+			x := plotX + float64(i)*dx
+			ebitenutil.DrawLine(screen, x-dx, y(curve, prev[curve]), x, y(curve, s[curve]), clr)
+			prev = s
+		}
+	}
+}
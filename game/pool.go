@@ -0,0 +1,56 @@
+package game
+
+import "github.com/jakecoffman/cp"
+
+// entityPoolMax caps the entities a pool keeps of a kind: past it, the
+// removed ones are left to the collector.
+const entityPoolMax = 256
+
+// entityPool keeps removed entities by kind, for the next spawns of the
+// kind to reuse their body, shapes and sprite rather than allocate new
+// ones: a scene spawning and losing bodies all the time, balls or shots,
+// then allocates no more than the most it had at once.
+type entityPool struct {
+	free map[string][]*Entity
+	// made and reused count the entities the pool couldn't and could give.
+	made, reused int
+}
+
+// put keeps e, out of its space, for its kind. An entity without a kind
+// isn't kept.
+func (p *entityPool) put(e *Entity) {
+	if e.Kind == "" || len(p.free[e.Kind]) >= entityPoolMax {
+		return
+	}
+	if p.free == nil {
+		p.free = map[string][]*Entity{}
+	}
+	p.free[e.Kind] = append(p.free[e.Kind], e)
+}
+
+// get returns an entity of kind kept before, its body at rest at pos, or
+// nil if there is none: the caller makes one then.
+func (p *entityPool) get(kind string, pos cp.Vector) *Entity {
+	free := p.free[kind]
+	if len(free) == 0 {
+		p.made++
+		return nil
+	}
+	e := free[len(free)-1]
+	free[len(free)-1] = nil
+	p.free[kind] = free[:len(free)-1]
+	p.reused++
+	resetBody(e.Body, pos)
+	return e
+}
+
+// resetBody puts a body out of any space back at rest at pos, unturned,
+// as a new one would be, its mass and shapes kept.
+func resetBody(body *cp.Body, pos cp.Vector) {
+	body.SetAngle(0)
+	body.SetPosition(pos)
+	body.SetVelocity(0, 0)
+	body.SetAngularVelocity(0)
+	body.SetForce(cp.Vector{})
+	body.SetTorque(0)
+}
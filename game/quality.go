@@ -0,0 +1,107 @@
+package game
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	// qualityMaxMerge is the most fixed steps made as one, a step of 1/30 s
+	// at most, which the scenes' bodies don't tunnel through the walls at.
+	qualityMaxMerge = 2
+	// qualityDegrade is the time a fixed step's worth of physics may take,
+	// as a fraction of the frame budget, before the steps are merged, and
+	// qualityRestore the time under which they are made one by one again:
+	// merged, a step's worth takes about half as long, the gap between them
+	// keeps the quality from flipping back and forth.
+	qualityDegrade = 0.8
+	qualityRestore = 0.3
+	// qualityTicks is how many ticks in a row over or under the thresholds
+	// change the quality.
+	qualityTicks = 30
+)
+
+// adaptiveQuality merges the fixed steps of a tick into fewer, longer ones
+// while the physics takes most of the frame budget, and makes them one by
+// one again once the load drops: a scene grown too heavy steps less
+// precisely rather than falling behind. The steps of a tick are merged, not
+// those of several ticks, for the scene to update, and see the input, every
+// tick it steps.
+type adaptiveQuality struct {
+	// merge is how many fixed steps are made as one, 1 at full quality,
+	// where the game starts.
+	merge int
+	// over and under are the ticks in a row over and under the thresholds.
+	over, under int
+	// text is the indicator, written when the quality changes.
+	text render.TextBuffer
+}
+
+// canAdapt reports whether the steps may be merged: on in the config, and
+// not while the session, the trajectories or the frames are recorded, or
+// the world streamed, which count the steps as fixed ones.
+func (g *Game) canAdapt() bool {
+	return cfg.AdaptiveSteps && g.recorder == nil && g.trajectories == nil && g.frames == nil && g.stream == nil
+}
+
+// chunk returns how many of the n fixed steps left the next step is made
+// of.
+func (q *adaptiveQuality) chunk(n int) int {
+	if q.merge > n {
+		return n
+	}
+	return q.merge
+}
+
+// ticked weighs the physics of a tick, which took d to make steps fixed
+// steps' worth, against budget, and changes the quality if it has been
+// over or under the thresholds long enough.
+func (q *adaptiveQuality) ticked(steps int, d, budget time.Duration, adapt bool) {
+	if !adapt {
+		q.set(1)
+		return
+	}
+	if steps == 0 {
+		return
+	}
+	perStep := float64(d) / float64(steps)
+	switch {
+	case perStep > qualityDegrade*float64(budget):
+		q.over++
+		q.under = 0
+	case perStep < qualityRestore*float64(budget):
+		q.under++
+		q.over = 0
+	default:
+		q.over, q.under = 0, 0
+	}
+	switch {
+	case q.over >= qualityTicks && q.merge < qualityMaxMerge:
+		q.set(q.merge + 1)
+	case q.under >= qualityTicks && q.merge > 1:
+		q.set(q.merge - 1)
+	default:
+		return
+	}
+	logPhysics.Infof("Physics takes %.1f ms a fixed step, stepping by 1/%d s", perStep/float64(time.Millisecond), int(1/physicsStep)/q.merge)
+}
+
+// set merges the steps n by n, and starts counting the ticks over.
+func (q *adaptiveQuality) set(n int) {
+	if n == q.merge {
+		return
+	}
+	q.merge, q.over, q.under = n, 0, 0
+	q.text.Reset().Text("Reduced quality: steps of 1/").Int(int(1/physicsStep) / n).Text(" s")
+}
+
+// draw shows the quality at the top right corner while it is reduced.
+func (q *adaptiveQuality) draw(screen *ebiten.Image) {
+	if q.merge <= 1 {
+		return
+	}
+	drawHUD(screen, q.text, ScreenWidth-48-150, 2*overlayLineHeight)
+}
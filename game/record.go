@@ -0,0 +1,110 @@
+package game
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"math/rand"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/replay"
+)
+
+// recordedButtons are the mouse buttons of replay.Frame.Buttons, a bit each.
+var recordedButtons = []ebiten.MouseButton{ebiten.MouseButtonLeft, ebiten.MouseButtonRight, ebiten.MouseButtonMiddle}
+
+// Record starts recording the session to the replay file at path: the input
+// of every step from then on, the first 64 actions held, the cursor and the
+// mouse buttons, and the scene stepped. math/rand is seeded anew for the
+// seed to be in the header. The file is complete once the game shuts down.
+func (g *Game) Record(path string) error {
+	seed := time.Now().UnixNano()
+	rand.Seed(seed)
+	h := replay.Header{
+		Seed:       seed,
+		ConfigHash: configHash(),
+		Step:       physicsStep,
+		Scenes:     SceneNames(),
+	}
+	for _, a := range input.Actions() {
+		h.Actions = append(h.Actions, string(a))
+	}
+	if len(h.Actions) > 64 {
+		h.Actions = h.Actions[:64]
+	}
+	w, err := replay.Create(path, h)
+	if err != nil {
+		return err
+	}
+	g.recorder, g.recordPath = w, path
+	logGame.Infof("Recording to %s", path)
+	return nil
+}
+
+// configHash is the FNV-1a hash of the config in JSON, to tell whether a
+// session ran with the config at hand.
+func configHash() uint64 {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write(data)
+	return h.Sum64()
+}
+
+// recordStep records the input of the step about to be made, made of
+// steps fixed steps, once for each of them: a dump and the session being
+// recorded, if any, have a frame per fixed step, as replay.Header.Step
+// says. A session that can't be written any more stops being recorded,
+// the game goes on.
+func (g *Game) recordStep(steps int) {
+	f := g.inputFrame()
+	for i := 0; i < steps; i++ {
+		g.inputs.add(f)
+	}
+	if g.recorder == nil {
+		return
+	}
+	for i := 0; i < steps; i++ {
+		if err := g.recorder.WriteFrame(f); err != nil {
+			logGame.Errorf("Recording to %s: %v", g.recordPath, err)
+			g.stopRecording()
+			return
+		}
+	}
+}
+
+// inputFrame is the input of the step about to be made.
+func (g *Game) inputFrame() replay.Frame {
+	var f replay.Frame
+	for i, a := range input.Actions() {
+		if i < 64 && input.IsActionPressed(a) {
+			f.Actions |= 1 << uint(i)
+		}
+	}
+	x, y := ebiten.CursorPosition()
+	f.Cursor = [2]int32{int32(x), int32(y)}
+	for i, button := range recordedButtons {
+		if ebiten.IsMouseButtonPressed(button) {
+			f.Buttons |= 1 << uint(i)
+		}
+	}
+	f.Scene = uint32(g.index)
+	return f
+}
+
+// stopRecording ends the session being recorded, if any.
+func (g *Game) stopRecording() {
+	if g.recorder == nil {
+		return
+	}
+	if err := g.recorder.Close(); err != nil {
+		logGame.Errorf("Recording to %s: %v", g.recordPath, err)
+	} else {
+		logGame.Infof("Recorded to %s", g.recordPath)
+	}
+	g.recorder = nil
+}
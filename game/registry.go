@@ -0,0 +1,52 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// HelloScene is the scene the game starts on unless told otherwise.
+const HelloScene = "Hello Chipmunk"
+
+// sceneEntry is a demo of the registry: its name, what it shows, and how to
+// make a new one.
+type sceneEntry struct {
+	name        string
+	description string
+	new         func() Scene
+}
+
+// scenes is the registry of the demos, sorted by name in any case, which is
+// the order of the number keys. Game only knows scenes through it.
+var scenes []sceneEntry
+
+// RegisterDemo adds a demo to the registry, under the name shown in the
+// window title and accepted by -scene, with the short description of the
+// menu. Each demo registers itself from an init function of its own file,
+// adding one is adding a file. It panics if the name is taken, like
+// registering a driver twice would.
+func RegisterDemo(name, description string, factory func() Scene) {
+	if factory == nil {
+		panic("game: RegisterDemo of " + name + " without a factory")
+	}
+	i := sort.Search(len(scenes), func(i int) bool {
+		return strings.ToLower(scenes[i].name) >= strings.ToLower(name)
+	})
+	if i < len(scenes) && strings.EqualFold(scenes[i].name, name) {
+		panic(fmt.Sprintf("game: RegisterDemo called twice for %q", name))
+	}
+	scenes = append(scenes, sceneEntry{})
+	copy(scenes[i+1:], scenes[i:])
+	scenes[i] = sceneEntry{name: name, description: description, new: factory}
+}
+
+// SceneNames returns the names of the registered demos, in the order of
+// their numbers.
+func SceneNames() []string {
+	names := make([]string, len(scenes))
+	for i, scene := range scenes {
+		names[i] = scene.name
+	}
+	return names
+}
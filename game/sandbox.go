@@ -0,0 +1,668 @@
+package game
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	sandboxGravity = 300
+	// sandboxTapTicks and sandboxTapSlop tell a tap from a drag: a short
+	// press that barely moved.
+	sandboxTapTicks = 15
+	sandboxTapSlop  = 10
+	// sandboxDoubleTicks is the longest time between the presses of a
+	// double click.
+	sandboxDoubleTicks = 20
+	sandboxBoxSize     = 30
+	sandboxBallSize    = 15
+	// sandboxStrokeStep is the distance the pointer moves before a stroke
+	// gets a new point, and sandboxStrokeTolerance how far the simplified
+	// stroke may stray from the drawn one, in screen pixels.
+	sandboxStrokeStep      = 3
+	sandboxStrokeTolerance = 4
+	sandboxStrokeRadius    = 2
+	// sandboxHoverTicks is how long the cursor rests on a shape before its
+	// tooltip shows.
+	sandboxHoverTicks = 30
+)
+
+// sandboxPasteOffset is how far each paste is moved from the previous one, so
+// that pastes don't pile up on the originals.
+var sandboxPasteOffset = cp.Vector{X: 20, Y: -20}
+
+var (
+	sandboxBoxSprite  = render.NewBoxSprite(sandboxBoxSize, sandboxBoxSize, colornames.Saddlebrown)
+	sandboxBallSprite = render.NewBallSprite(sandboxBallSize, colornames.Darkorange)
+)
+
+// Sandbox is a playground to throw bodies around with the mouse or with
+// fingers: tap to spawn a body, drag one to move it, pinch or scroll to zoom.
+type Sandbox struct {
+	baseScene
+
+	space   *cp.Space
+	cam     *render.Camera
+	sprites *render.SpriteRegistry
+
+	// mouse drags the grabbed body around, target is where the pointer is
+	// in the world.
+	mouse  *physics.MouseJoint
+	target cp.Vector
+
+	// The press being made, to tell taps from drags. pressTicks starts at
+	// sandboxDoubleTicks, for the first press not to make a double click.
+	pressed    bool
+	pressPos   cp.Vector
+	pressTicks int
+
+	// touches are the last positions of the fingers on the screen, since a
+	// lifted finger has none.
+	touches  map[ebiten.TouchID]cp.Vector
+	pinch    float64
+	pinchMid cp.Vector
+
+	// drawing is the mode where dragging draws static segments along
+	// stroke, in world coordinates.
+	drawing bool
+	stroke  []cp.Vector
+
+	// selection are the bodies picked by dragging a rectangle from an empty
+	// spot, selecting is set while that rectangle is being dragged to
+	// selectTo, on the screen. Dragging a selected body moves them all,
+	// keeping their offsets to the pointer.
+	selection map[*cp.Body]bool
+	selecting bool
+	selectTo  cp.Vector
+	offsets   map[*cp.Body]cp.Vector
+
+	// clipboard holds the copied bodies, each with its sprite, pasted pastes
+	// times since.
+	clipboard []sandboxCopy
+	pastes    int
+
+	// ids identify the bodies for the edits in undos and redos, see
+	// sandboxEdit. moving are the states of the grabbed bodies when they were
+	// grabbed.
+	ids    map[*cp.Body]int
+	bodies map[int]*cp.Body
+	nextID int
+	undos  []sandboxEdit
+	redos  []sandboxEdit
+	moving map[int]sandboxCopy
+	// edited is whether the level was edited since it was last saved or
+	// loaded, for quitting to save it.
+	edited bool
+
+	// hover is the shape under the cursor, which has rested at hoverPos for
+	// hoverTicks.
+	hover      *cp.Shape
+	hoverPos   cp.Vector
+	hoverTicks int
+	// tapped is the body last clicked without being dragged, for the
+	// game's inspector.
+	tapped *cp.Body
+
+	// editing is the editor mode, see toggleEditor. status tells how the
+	// last save or load of the level went.
+	editing bool
+	status  string
+
+	spawned int
+	deleted int
+	// despawned are the bodies removed over the cap, see despawn.
+	despawned int
+	lines     int
+}
+
+func init() {
+	RegisterDemo("Sandbox", "spawn, drag, draw, undo and edit levels", func() Scene { return NewSandbox() })
+}
+
+func NewSandbox() *Sandbox {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: sandboxGravity})
+	space.Iterations = 20
+
+	s := &Sandbox{
+		space:      space,
+		cam:        render.NewCamera(ScreenWidth, ScreenHeight),
+		sprites:    render.NewSpriteRegistry(),
+		mouse:      physics.NewMouseJoint(space),
+		pressTicks: sandboxDoubleTicks,
+		touches:    map[ebiten.TouchID]cp.Vector{},
+		selection:  map[*cp.Body]bool{},
+		ids:        map[*cp.Body]int{},
+		bodies:     map[int]*cp.Body{},
+	}
+
+	// The ground goes well beyond the screen for when the camera zooms out.
+	physics.AddStaticSegment(space, cp.Vector{X: -ScreenWidth, Y: groundY}, cp.Vector{X: 2 * ScreenWidth, Y: groundY}, 0)
+
+	// A pyramid to knock over.
+	for row := 0; row < 5; row++ {
+		for i := 0; i < 5-row; i++ {
+			pos := cp.Vector{
+				X: 400 + (float64(i)-float64(4-row)/2)*sandboxBoxSize,
+				Y: groundY - sandboxBoxSize/2 - float64(row)*sandboxBoxSize,
+			}
+			s.addBox(pos)
+		}
+	}
+
+	return s
+}
+
+// press starts a tap or a drag at the screen point p. Pressing a body grabs
+// it with a pivot joint to the pointer, with a limited force so that it can't
+// push through the ground. Pressing a selected body grabs the whole selection.
+// Pressing a body again right after, at the same spot, clones it instead.
+func (s *Sandbox) press(p cp.Vector) {
+	double := s.pressTicks < sandboxDoubleTicks && p.Distance(s.pressPos) < sandboxTapSlop
+	s.pressed, s.pressPos, s.pressTicks = true, p, 0
+
+	world := s.cam.ToWorld(p)
+	if s.drawing {
+		s.stroke = []cp.Vector{world}
+		return
+	}
+	s.target = world
+	body := s.bodyAt(p)
+	if body == nil {
+		return
+	}
+	if double {
+		// The press is used up, its release must not spawn a body too.
+		s.clone(body)
+		s.pressed = false
+		return
+	}
+	if s.selection[body] {
+		s.offsets = map[*cp.Body]cp.Vector{}
+		var bodies []*cp.Body
+		for b := range s.selection {
+			s.offsets[b] = b.Position().Sub(world)
+			bodies = append(bodies, b)
+		}
+		s.moving = s.states(bodies...)
+		return
+	}
+	s.selection = map[*cp.Body]bool{}
+	s.moving = s.states(body)
+	if s.editing {
+		// Without steps a joint wouldn't pull the body, it's placed.
+		s.offsets = map[*cp.Body]cp.Vector{body: body.Position().Sub(world)}
+		return
+	}
+	s.mouse.Grab(body, world)
+}
+
+func (s *Sandbox) move(p cp.Vector) {
+	if !s.pressed {
+		return
+	}
+	world := s.cam.ToWorld(p)
+	if s.drawing {
+		if len(s.stroke) > 0 && s.stroke[len(s.stroke)-1].Distance(world)*s.cam.Zoom >= sandboxStrokeStep {
+			s.stroke = append(s.stroke, world)
+		}
+		return
+	}
+	s.target = world
+	s.mouse.MoveTo(world)
+	if s.mouse.Grabbed() == nil && s.offsets == nil && p.Distance(s.pressPos) >= sandboxTapSlop {
+		s.selecting = true
+	}
+	s.selectTo = p
+}
+
+// release ends the press: drops the grabbed bodies, selects those in the
+// rectangle, or spawns one on a tap, or inspects the body tapped. In drawing mode, it turns the stroke
+// into segments.
+func (s *Sandbox) release(p cp.Vector) {
+	if s.pressed && s.drawing {
+		s.move(p)
+		s.addStroke()
+		s.cancel()
+		return
+	}
+	if s.pressed && s.selecting {
+		s.selectIn(s.pressPos, p)
+		s.cancel()
+		return
+	}
+	tap := s.pressed && s.pressTicks < sandboxTapTicks && p.Distance(s.pressPos) < sandboxTapSlop
+	if tap && s.mouse.Grabbed() == nil && s.offsets == nil {
+		s.selection = map[*cp.Body]bool{}
+		s.spawn(s.cam.ToWorld(p))
+	} else if tap {
+		s.tapped = s.bodyAt(p)
+	}
+	s.cancel()
+}
+
+// tappedBody returns the body clicked since the last call, to inspect it.
+func (s *Sandbox) tappedBody() *cp.Body {
+	body := s.tapped
+	s.tapped = nil
+	return body
+}
+
+// camera is the camera the sandbox is drawn through, for the heatmap.
+func (s *Sandbox) camera() *render.Camera {
+	return s.cam
+}
+
+// selectIn selects the dynamic bodies with a shape entirely within the
+// rectangle between the screen points a and b.
+func (s *Sandbox) selectIn(a, b cp.Vector) {
+	a, b = s.cam.ToWorld(a), s.cam.ToWorld(b)
+	rect := cp.BB{L: math.Min(a.X, b.X), B: math.Min(a.Y, b.Y), R: math.Max(a.X, b.X), T: math.Max(a.Y, b.Y)}
+	s.selection = map[*cp.Body]bool{}
+	s.space.BBQuery(rect, cp.SHAPE_FILTER_ALL, func(shape *cp.Shape, _ interface{}) {
+		if shape.Body().GetType() == cp.BODY_DYNAMIC && rect.Contains(shape.BB()) {
+			s.selection[shape.Body()] = true
+		}
+	}, nil)
+}
+
+// dragSelection moves the dragged selection with the pointer, through the
+// velocities to leave the collisions to the space. In the editor, where
+// nothing collides, they are placed right away.
+func (s *Sandbox) dragSelection(dt float64) {
+	for body, offset := range s.offsets {
+		goal := s.target.Add(offset)
+		if s.editing {
+			s.place(body, goal)
+			continue
+		}
+		body.SetVelocityVector(goal.Sub(body.Position()).Mult(1 / dt))
+		body.SetAngularVelocity(0)
+	}
+}
+
+// cancel forgets the press without acting on it.
+func (s *Sandbox) cancel() {
+	s.mouse.Release()
+	s.pressed = false
+	s.stroke = nil
+	s.selecting = false
+	s.offsets = nil
+	if s.moving != nil {
+		s.recordMove()
+	}
+}
+
+// addStroke adds the stroke as static segments. A stroke has a point every
+// few pixels, most of them on straight parts that don't need them, so it is
+// simplified first.
+func (s *Sandbox) addStroke() {
+	points := physics.DouglasPeucker(s.stroke, sandboxStrokeTolerance/s.cam.Zoom)
+	for i := 1; i < len(points); i++ {
+		segment := s.space.AddShape(cp.NewSegment(s.space.StaticBody, points[i-1], points[i], sandboxStrokeRadius))
+		segment.SetFriction(1)
+		segment.SetElasticity(0.3)
+	}
+	if len(points) > 1 {
+		s.edited = true
+		s.lines++
+	}
+}
+
+// spawn adds a box or a ball, in turn.
+func (s *Sandbox) spawn(pos cp.Vector) {
+	s.spawned++
+	var body *cp.Body
+	if s.spawned%2 == 1 {
+		body = s.addBox(pos)
+	} else {
+		body = s.addBall(pos)
+	}
+	s.record(sandboxEdit{after: s.states(body)})
+}
+
+func (s *Sandbox) addBox(pos cp.Vector) *cp.Body {
+	body, _ := physics.AddBox(s.space, pos, sandboxBoxSize, sandboxBoxSize)
+	s.sprites.Add(body, sandboxBoxSprite)
+	s.register(body)
+	return body
+}
+
+func (s *Sandbox) addBall(pos cp.Vector) *cp.Body {
+	body, shape := physics.AddBall(s.space, pos, sandboxBallSize)
+	shape.UserData = colornames.Orange
+	s.sprites.Add(body, sandboxBallSprite)
+	s.register(body)
+	return body
+}
+
+// consoleCommand spawns bodies with their sprites at the cursor through the
+// camera, and undoably, and saves and loads the level like the editor does.
+func (s *Sandbox) consoleCommand(name string, args []string) (string, bool, error) {
+	switch name {
+	case "spawn":
+		kind, size, err := consoleShape(args)
+		if err != nil {
+			return "", true, err
+		}
+		pos := s.cam.ToWorld(input.CursorPosition())
+		var body *cp.Body
+		if kind == "circle" {
+			body, _ = physics.AddBall(s.space, pos, size)
+			s.sprites.Add(body, render.NewBallSprite(size, colornames.Darkorange))
+		} else {
+			body, _ = physics.AddBox(s.space, pos, size, size)
+			s.sprites.Add(body, render.NewBoxSprite(size, size, colornames.Saddlebrown))
+		}
+		s.register(body)
+		s.record(sandboxEdit{after: s.states(body)})
+		return fmt.Sprintf("Spawned a %s at %.0f, %.0f", kind, pos.X, pos.Y), true, nil
+	case "save", "load":
+		path, err := consoleLevelPath(args)
+		if err != nil {
+			return "", true, err
+		}
+		if name == "save" {
+			err = s.saveLevel(path)
+		} else {
+			err = s.loadLevel(path)
+		}
+		return s.status, true, err
+	}
+	return "", false, nil
+}
+
+// bodyAt returns the dynamic body under the screen point p, if any.
+func (s *Sandbox) bodyAt(p cp.Vector) *cp.Body {
+	info := s.space.PointQueryNearest(s.cam.ToWorld(p), 0, cp.SHAPE_FILTER_ALL)
+	if info.Shape == nil || info.Shape.Body().GetType() != cp.BODY_DYNAMIC {
+		return nil
+	}
+	return info.Shape.Body()
+}
+
+// delete removes a body with its shapes and constraints from the space and
+// its sprite from the registry, recording it to be undone. It's done in a
+// post-step callback, which runs once the space is done stepping, so it is
+// safe even from within the step, e.g. in a collision handler. Keying it by
+// body removes it once even when asked twice. The editor doesn't step the
+// space, which would keep the callback waiting, so there it's done at once.
+func (s *Sandbox) delete(body *cp.Body) {
+	s.record(sandboxEdit{before: s.states(body)})
+	if s.editing {
+		s.remove(body)
+		s.deleted++
+		return
+	}
+	s.space.AddPostStepCallback(func(space *cp.Space, key, _ interface{}) {
+		s.remove(key.(*cp.Body))
+		s.deleted++
+	}, body, nil)
+}
+
+// remove removes a body right away, which is only safe outside of the step.
+func (s *Sandbox) remove(body *cp.Body) {
+	if s.mouse.Grabbed() == body {
+		s.mouse.Release()
+	}
+	body.EachConstraint(func(constraint *cp.Constraint) {
+		s.space.RemoveConstraint(constraint)
+	})
+	delete(s.selection, body)
+	delete(s.offsets, body)
+	delete(s.bodies, s.ids[body])
+	delete(s.ids, body)
+	// Removing a shape changes the body's list of shapes, collect them
+	// first.
+	var shapes []*cp.Shape
+	body.EachShape(func(shape *cp.Shape) {
+		shapes = append(shapes, shape)
+	})
+	for _, shape := range shapes {
+		s.space.RemoveShape(shape)
+	}
+	s.space.RemoveBody(body)
+	s.sprites.Remove(body)
+}
+
+// updateTouches turns the fingers into presses: one finger taps and drags
+// like the mouse, two fingers pinch to zoom and pan.
+func (s *Sandbox) updateTouches() {
+	for _, id := range inpututil.AppendJustPressedTouchIDs(nil) {
+		if input.Touch.OnButton(input.TouchPosition(id)) {
+			// The touch buttons get their own fingers.
+			continue
+		}
+		s.touches[id] = input.TouchPosition(id)
+		switch len(s.touches) {
+		case 1:
+			s.press(s.touches[id])
+		case 2:
+			// A second finger turns the gesture into a pinch.
+			s.cancel()
+			s.pinch = 0
+		}
+	}
+	for id, last := range s.touches {
+		if inpututil.IsTouchJustReleased(id) {
+			if len(s.touches) == 1 {
+				s.release(last)
+			}
+			delete(s.touches, id)
+			s.pinch = 0
+			continue
+		}
+		s.touches[id] = input.TouchPosition(id)
+	}
+
+	var fingers []cp.Vector
+	for _, p := range s.touches {
+		fingers = append(fingers, p)
+	}
+	switch len(fingers) {
+	case 1:
+		s.move(fingers[0])
+	case 2:
+		s.pinchZoom(fingers[0], fingers[1])
+	}
+}
+
+// pinchZoom zooms by how much the fingers spread since the last update,
+// around the point between them, and pans with that point.
+func (s *Sandbox) pinchZoom(a, b cp.Vector) {
+	dist, mid := a.Distance(b), a.Lerp(b, 0.5)
+	if s.pinch > 0 && dist > 0 {
+		s.cam.ZoomAt(mid, dist/s.pinch)
+		s.cam.Pan(s.pinchMid.Sub(mid))
+	}
+	s.pinch, s.pinchMid = dist, mid
+}
+
+// clone adds a copy of the body, with the same shapes, materials and
+// velocity, right of it.
+func (s *Sandbox) clone(body *cp.Body) {
+	var bb *cp.BB
+	body.EachShape(func(shape *cp.Shape) {
+		shapeBB := shape.BB()
+		if bb != nil {
+			shapeBB = bb.Merge(shapeBB)
+		}
+		bb = &shapeBB
+	})
+	c := s.states(body)[s.ids[body]]
+	c.state.Position = c.state.Position.Add(cp.Vector{X: bb.R - bb.L + 2})
+	clone := c.state.Restore(s.space)
+	if c.sprite != nil {
+		s.sprites.Add(clone, c.sprite)
+	}
+	s.register(clone)
+	s.record(sandboxEdit{after: s.states(clone)})
+}
+
+// sandboxCopy is a copied body.
+type sandboxCopy struct {
+	state  physics.BodyState
+	sprite *ebiten.Image
+}
+
+// copySelection copies the selected bodies to the clipboard, as they are
+// now: moving or deleting them afterwards doesn't change what is pasted.
+func (s *Sandbox) copySelection() {
+	s.clipboard, s.pastes = nil, 0
+	for body := range s.selection {
+		s.clipboard = append(s.clipboard, sandboxCopy{state: physics.SaveBody(body), sprite: s.sprites.Image(body)})
+	}
+}
+
+// paste adds the clipboard's bodies a little further than the last paste,
+// and selects them.
+func (s *Sandbox) paste() {
+	if len(s.clipboard) == 0 {
+		return
+	}
+	s.pastes++
+	s.selection = map[*cp.Body]bool{}
+	for _, c := range s.clipboard {
+		state := c.state
+		state.Position = state.Position.Add(sandboxPasteOffset.Mult(float64(s.pastes)))
+		body := state.Restore(s.space)
+		if c.sprite != nil {
+			s.sprites.Add(body, c.sprite)
+		}
+		s.register(body)
+		s.selection[body] = true
+	}
+	var bodies []*cp.Body
+	for body := range s.selection {
+		bodies = append(bodies, body)
+	}
+	s.record(sandboxEdit{after: s.states(bodies...)})
+}
+
+func (s *Sandbox) updateClipboard() {
+	if !input.IsActionPressed(input.ActionControl) {
+		return
+	}
+	switch {
+	case input.IsActionJustPressed(input.ActionCopy):
+		s.copySelection()
+	case input.IsActionJustPressed(input.ActionPaste):
+		s.paste()
+	}
+}
+
+// updateHover looks for the shape under the cursor, on every update since
+// bodies move under a resting cursor too.
+func (s *Sandbox) updateHover() {
+	p := input.CursorPosition()
+	if p != s.hoverPos {
+		s.hoverPos, s.hoverTicks = p, 0
+	} else {
+		s.hoverTicks++
+	}
+	s.hover = s.space.PointQueryNearest(s.cam.ToWorld(p), 0, cp.SHAPE_FILTER_ALL).Shape
+}
+
+// tooltip describes the hovered shape and its body.
+func (s *Sandbox) tooltip() string {
+	body := s.hover.Body()
+	v := body.Velocity()
+	return fmt.Sprintf(
+		"Mass: %.2f, moment: %.1f\nVelocity: (%.1f, %.1f)\nFriction: %.2f, elasticity: %.2f\nCollision type: %d",
+		body.Mass(), body.Moment(), v.X, v.Y, s.hover.Friction(), s.hover.Elasticity(), physics.CollisionType(s.hover),
+	)
+}
+
+func (s *Sandbox) updateMouse() {
+	s.updateHover()
+	switch {
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft):
+		s.press(input.CursorPosition())
+	case inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft):
+		s.release(input.CursorPosition())
+	case ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft):
+		s.move(input.CursorPosition())
+	}
+
+	if input.IsActionJustPressed(input.ActionDraw) {
+		s.cancel()
+		s.drawing = !s.drawing
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		if body := s.bodyAt(input.CursorPosition()); body != nil {
+			s.delete(body)
+		} else if s.editing {
+			s.deleteStatic(input.CursorPosition())
+		}
+	}
+}
+
+func (s *Sandbox) Update(dt float64) error {
+	s.pressTicks++
+	if len(s.touches) > 0 || len(inpututil.AppendJustPressedTouchIDs(nil)) > 0 {
+		s.updateTouches()
+	} else {
+		s.updateMouse()
+		s.cam.ZoomWheel(input.CursorPosition(), input.Wheel())
+	}
+
+	s.mouse.Step(dt)
+
+	s.updateClipboard()
+	s.updateHistory()
+	s.updateEditor()
+	s.dragSelection(dt)
+
+	if !s.editing {
+		s.despawn()
+		s.space.Step(dt)
+	}
+	return nil
+}
+
+func (s *Sandbox) Draw(screen *ebiten.Image) {
+	s.sprites.Draw(screen, s.cam)
+	render.DrawSpaceFrom(screen, s.space, colornames.Burlywood, s.cam)
+	for body := range s.selection {
+		body.EachShape(func(shape *cp.Shape) {
+			bb := shape.BB()
+			render.DrawRectOutline(screen, s.cam.ToScreen(cp.Vector{X: bb.L, Y: bb.B}), s.cam.ToScreen(cp.Vector{X: bb.R, Y: bb.T}), colornames.Yellow)
+		})
+	}
+	if s.selecting {
+		render.DrawRectOutline(screen, s.pressPos, s.selectTo, colornames.Yellow)
+	}
+	for i := 1; i < len(s.stroke); i++ {
+		a, b := s.cam.ToScreen(s.stroke[i-1]), s.cam.ToScreen(s.stroke[i])
+		ebitenutil.DrawLine(screen, a.X, a.Y, b.X, b.Y, colornames.Yellow)
+	}
+
+	if s.hover != nil && s.hoverTicks >= sandboxHoverTicks && !s.pressed && len(s.touches) == 0 {
+		render.DrawTooltip(screen, s.hoverPos, s.tooltip())
+	}
+
+	help := "Tap or click: spawn a body, drag: move one or select several, right click: delete one, pinch or wheel: zoom, D: draw.\nCtrl+C/Ctrl+V: copy/paste the selection, Ctrl+Z/Ctrl+Y: undo/redo, double click: clone a body.\nRest the cursor on a shape to inspect it, tap it to edit it. E: editor."
+	if s.editing {
+		help = "Editor, the physics is paused. Click: place a body, drag: move bodies, right click: delete a body or a line, D: draw.\nCtrl+S/Ctrl+L: save/load " + sandboxLevelFile + ", E: play."
+	}
+	if s.drawing {
+		help = "Drag to draw a line, D: stop drawing."
+	}
+	if s.status != "" {
+		help += "\n" + s.status
+	}
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Zoom: %3.0f%%. Bodies spawned: %d, deleted: %d, despawned: %d. Lines drawn: %d.\n%s",
+		s.cam.Zoom*100, s.spawned, s.deleted, s.despawned, s.lines, help,
+	))
+}
@@ -0,0 +1,39 @@
+package game
+
+import (
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// saveScreenshot saves the screen as drawn so far to a PNG file named after
+// the time, in the working directory, and tells how it went with a toast.
+func (g *Game) saveScreenshot(screen *ebiten.Image) {
+	path := "screenshot-" + time.Now().Format("20060102-150405.000") + ".png"
+	if err := writePNG(path, screen); err != nil {
+		logRender.Errorf("Screenshot: %v", err)
+		g.toast.show("Screenshot not saved: " + err.Error())
+		return
+	}
+	logRender.Infof("Screenshot saved to %s", path)
+	g.toast.show("Screenshot saved to " + path)
+}
+
+// writePNG reads the image back from the GPU and writes it to path.
+func writePNG(path string, img *ebiten.Image) error {
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, rgba); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,70 @@
+package game
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/assets"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/script"
+)
+
+// scriptFile is the Lua script the Script scene runs, among the assets.
+// Restarting the scene reloads it.
+const scriptFile = "scripts/demo.lua"
+
+// Scripted is a scene authored in Lua: the script builds the space, reacts
+// to its collisions and updates it, the scene only steps and draws it.
+type Scripted struct {
+	baseScene
+
+	space  *cp.Space
+	script *script.Script
+	// err is why the script doesn't run, shown instead of the scene.
+	err error
+}
+
+func init() {
+	RegisterDemo("Script", "a scene written in Lua", func() Scene { return NewScripted() })
+}
+
+func NewScripted() *Scripted {
+	s := &Scripted{space: newSpace()}
+	src, err := assets.Text(scriptFile)
+	if err == nil {
+		s.script, err = script.Load(s.space, scriptFile, src)
+	}
+	s.err = err
+	return s
+}
+
+// Dispose closes the script's Lua state.
+func (s *Scripted) Dispose() {
+	if s.script != nil {
+		s.script.Close()
+	}
+}
+
+func (s *Scripted) Update(dt float64) error {
+	if s.err != nil {
+		return nil
+	}
+	// A broken script stops the scene, not the game.
+	if s.err = s.script.Update(dt); s.err != nil {
+		return nil
+	}
+	s.space.Step(dt)
+	return nil
+}
+
+func (s *Scripted) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, s.space, colornames.Burlywood)
+
+	if s.err != nil {
+		ebitenutil.DebugPrint(screen, "Script error:\n"+s.err.Error())
+		return
+	}
+	ebitenutil.DebugPrint(screen, s.script.Status()+"\nEdit "+scriptFile+" and press Backspace to reload it.")
+}
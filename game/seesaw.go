@@ -0,0 +1,167 @@
+package game
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	seesawGravity      = 300
+	seesawPlankLength  = 500
+	seesawPlankMass    = 5
+	seesawMaxTilt      = 0.4
+	seesawFulcrumSpeed = 60
+	seesawLightMass    = 1
+	seesawHeavyMass    = 3
+	seesawBallRadius   = 12
+)
+
+var seesawPivot = cp.Vector{X: 400, Y: 450}
+
+// Seesaw is a plank on a pivot joint. Balls dropped on it push it around and
+// the HUD shows the torque each side applies around the fulcrum, which the
+// user can slide along the plank.
+type Seesaw struct {
+	baseScene
+
+	space *cp.Space
+	plank *cp.Body
+	pivot *cp.PivotJoint
+	// fulcrum is where the pivot sits along the plank, from its center.
+	fulcrum float64
+
+	left, right float64
+}
+
+func init() {
+	RegisterDemo("Seesaw", "a plank balancing on a movable fulcrum", func() Scene { return NewSeesaw() })
+}
+
+func NewSeesaw() *Seesaw {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: seesawGravity})
+	space.Iterations = 20
+
+	physics.AddStaticSegment(space, cp.Vector{Y: groundY}, cp.Vector{X: ScreenWidth, Y: groundY}, 0)
+
+	// The plank rests on the fulcrum, they share a group to not collide.
+	frame := cp.NewShapeFilter(5, cp.ALL_CATEGORIES, cp.ALL_CATEGORIES)
+	fulcrum := space.AddShape(cp.NewPolyShape(space.StaticBody, 3, []cp.Vector{
+		{X: seesawPivot.X - 40, Y: groundY}, seesawPivot, {X: seesawPivot.X + 40, Y: groundY},
+	}, cp.NewTransformIdentity(), 0))
+	fulcrum.SetFilter(frame)
+	fulcrum.UserData = colornames.Sienna
+
+	moment := cp.MomentForBox(seesawPlankMass, seesawPlankLength, 10)
+	plank := space.AddBody(cp.NewBody(seesawPlankMass, moment))
+	plank.SetPosition(seesawPivot)
+	shape := space.AddShape(cp.NewBox(plank, seesawPlankLength, 10, 0))
+	shape.SetFriction(1)
+	shape.SetFilter(frame)
+	shape.UserData = colornames.Burlywood
+	// Lips at both ends keep the balls from rolling off.
+	for _, x := range []float64{-seesawPlankLength / 2, seesawPlankLength/2 - 6} {
+		lip := space.AddShape(cp.NewBox2(plank, cp.BB{L: x, B: -25, R: x + 6, T: -5}, 0))
+		lip.SetFriction(1)
+		lip.SetFilter(frame)
+		lip.UserData = colornames.Burlywood
+	}
+
+	pivot := space.AddConstraint(cp.NewPivotJoint(space.StaticBody, plank, seesawPivot))
+	space.AddConstraint(cp.NewRotaryLimitJoint(space.StaticBody, plank, -seesawMaxTilt, seesawMaxTilt))
+
+	return &Seesaw{
+		space: space,
+		plank: plank,
+		pivot: pivot.Class.(*cp.PivotJoint),
+	}
+}
+
+func (s *Seesaw) dropBall(pos cp.Vector, mass float64) {
+	moment := cp.MomentForCircle(mass, 0, seesawBallRadius, cp.Vector{})
+	body := s.space.AddBody(cp.NewBody(mass, moment))
+	body.SetPosition(pos)
+	shape := s.space.AddShape(cp.NewCircle(body, seesawBallRadius, cp.Vector{}))
+	shape.SetFriction(0.9)
+	if mass > seesawLightMass {
+		shape.UserData = colornames.Orange
+	}
+}
+
+func (s *Seesaw) Update(dt float64) error {
+	if input.Pointer.IsJustPressed() {
+		mass := float64(seesawLightMass)
+		if input.IsActionPressed(input.ActionHeavy) {
+			mass = seesawHeavyMass
+		}
+		s.dropBall(input.Pointer.Position(), mass)
+	}
+
+	// Moving the fulcrum moves the plank's anchor; the pivot stays in place
+	// so the plank slides over it.
+	move := 0.0
+	if input.IsActionPressed(input.ActionLeft) {
+		move--
+	}
+	if input.IsActionPressed(input.ActionRight) {
+		move++
+	}
+	if move != 0 {
+		limit := seesawPlankLength/2 - 20.0
+		s.fulcrum = cp.Clamp(s.fulcrum+move*seesawFulcrumSpeed*dt, -limit, limit)
+		s.pivot.AnchorB = cp.Vector{X: s.fulcrum}
+		s.plank.Activate()
+	}
+
+	s.space.Step(dt)
+	s.measureTorques(dt)
+	return nil
+}
+
+// measureTorques sums the torque of every contact on the plank around the
+// pivot, split by the side of the pivot it pushes on.
+func (s *Seesaw) measureTorques(dt float64) {
+	s.left, s.right = 0, 0
+	s.plank.EachArbiter(func(arb *cp.Arbiter) {
+		force := arb.TotalImpulse().Mult(1 / dt)
+		set := arb.ContactPointSet()
+		for i := 0; i < set.Count; i++ {
+			r := set.Points[i].PointA.Sub(seesawPivot)
+			// Split the force evenly between the contact points.
+			torque := r.Cross(force) / float64(set.Count)
+			if r.Dot(s.plank.Rotation()) < 0 {
+				s.left -= torque
+			} else {
+				s.right += torque
+			}
+		}
+	})
+}
+
+func (s *Seesaw) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, s.space, colornames.White)
+
+	// A balance bar: it leans towards the heavier side.
+	const barWidth = 200
+	net := s.right - s.left
+	total := math.Abs(s.left) + math.Abs(s.right)
+	center := float64(ScreenWidth) / 2
+	ebitenutil.DrawRect(screen, center-barWidth/2, 50, barWidth, 4, colornames.Dimgray)
+	if total > 0 {
+		ebitenutil.DrawRect(screen, center+net/total*barWidth/2-2, 44, 4, 16, colornames.Yellow)
+	}
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Torque around the fulcrum: left %8.0f, right %8.0f.\nClick: drop a ball (Shift: heavy), Left/Right: move the fulcrum.",
+		s.left, s.right,
+	))
+}
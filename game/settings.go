@@ -0,0 +1,196 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/config"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+// settingsResolutions are the window sizes the settings screen goes through,
+// at the 4:3 of the screen.
+var settingsResolutions = [][2]int{{640, 480}, {800, 600}, {1024, 768}, {1280, 960}, {1600, 1200}}
+
+const (
+	// settingsMaxIterations bounds the iterations, which cost as much time
+	// each as the first.
+	settingsMaxIterations = 100
+)
+
+// setting is a line of the settings screen: its name, its value as shown,
+// and how Left/Right, -1/+1, change it. Enter changes it by +1.
+type setting struct {
+	name   string
+	value  func(c *config.Config) string
+	change func(c *config.Config, delta int)
+}
+
+var settings = []setting{
+	{
+		name: "Resolution",
+		value: func(c *config.Config) string {
+			if browser {
+				return fmt.Sprintf("%dx%d, the page's", canvasSize[0], canvasSize[1])
+			}
+			return fmt.Sprintf("%dx%d", c.WindowWidth, c.WindowHeight)
+		},
+		change: func(c *config.Config, delta int) {
+			if browser {
+				return
+			}
+			// A size that isn't in the list goes to the first or last.
+			i := -1
+			for j, r := range settingsResolutions {
+				if r == [2]int{c.WindowWidth, c.WindowHeight} {
+					i = j
+				}
+			}
+			if i < 0 && delta < 0 {
+				i = len(settingsResolutions)
+			}
+			i = (i + delta + len(settingsResolutions)) % len(settingsResolutions)
+			c.WindowWidth, c.WindowHeight = settingsResolutions[i][0], settingsResolutions[i][1]
+		},
+	},
+	{
+		name:   "Fullscreen",
+		value:  func(c *config.Config) string { return onOff(c.Fullscreen) },
+		change: func(c *config.Config, _ int) { c.Fullscreen = !c.Fullscreen },
+	},
+	{
+		name:   "Vertical sync",
+		value:  func(c *config.Config) string { return onOff(c.VSync) },
+		change: func(c *config.Config, _ int) { c.VSync = !c.VSync },
+	},
+	{
+		name:   "Contact points",
+		value:  func(c *config.Config) string { return onOff(c.ShowContacts) },
+		change: func(c *config.Config, _ int) { c.ShowContacts = !c.ShowContacts },
+	},
+	{
+		name:  "Physics iterations",
+		value: func(c *config.Config) string { return fmt.Sprint(c.Iterations) },
+		change: func(c *config.Config, delta int) {
+			c.Iterations = clampInt(c.Iterations+delta, 1, settingsMaxIterations)
+		},
+	},
+	{
+		name:   "Adaptive steps",
+		value:  func(c *config.Config) string { return onOff(c.AdaptiveSteps) },
+		change: func(c *config.Config, _ int) { c.AdaptiveSteps = !c.AdaptiveSteps },
+	},
+	{
+		name:   "Redraw regions",
+		value:  func(c *config.Config) string { return onOff(c.RedrawRegions) },
+		change: func(c *config.Config, _ int) { c.RedrawRegions = !c.RedrawRegions },
+	},
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+func clampInt(v, low, high int) int {
+	if v < low {
+		return low
+	}
+	if v > high {
+		return high
+	}
+	return v
+}
+
+// ApplySettings applies the display settings of the config, the window, the
+// debug overlays and the regions redrawn, at once. The iterations apply to
+// the scenes made from then on.
+func ApplySettings(c config.Config) {
+	ebiten.SetWindowSize(c.WindowWidth, c.WindowHeight)
+	ebiten.SetFullscreen(c.Fullscreen)
+	ebiten.SetVsyncEnabled(c.VSync)
+	render.ShowCollisionPoints = c.ShowContacts
+	render.RedrawRegions = c.RedrawRegions
+}
+
+// settingsScreen changes the settings of the config in use, applied as they
+// change and saved to the config file on closing.
+type settingsScreen struct {
+	cursor int
+}
+
+// open starts with the highlight on top, and the contact points as F3
+// last left them.
+func (s *settingsScreen) open() {
+	s.cursor = 0
+	cfg.ShowContacts = render.ShowCollisionPoints
+}
+
+// update changes the highlighted setting, and reports whether the screen is
+// still open.
+func (s *settingsScreen) update() bool {
+	delta := 0
+	switch {
+	case input.IsActionJustPressed(input.ActionMenu), input.IsActionJustPressed(input.ActionSettings):
+		s.save()
+		return false
+	case input.IsActionJustPressed(input.ActionUp):
+		s.cursor = (s.cursor - 1 + len(settings)) % len(settings)
+	case input.IsActionJustPressed(input.ActionDown):
+		s.cursor = (s.cursor + 1) % len(settings)
+	case input.IsActionJustPressed(input.ActionLeft):
+		delta = -1
+	case input.IsActionJustPressed(input.ActionRight), input.IsActionJustPressed(input.ActionConfirm):
+		delta = 1
+	}
+	if delta != 0 {
+		settings[s.cursor].change(&cfg, delta)
+		ApplySettings(cfg)
+	}
+	return true
+}
+
+// save writes the settings to the config file, over what it has: the other
+// values of the config in use may come from the command line, they aren't
+// saved. A broken file isn't overwritten.
+func (s *settingsScreen) save() {
+	c, err := config.Load(config.File)
+	if err != nil {
+		logConfig.Errorf("Settings: %v, not saved", err)
+		return
+	}
+	c.WindowWidth, c.WindowHeight = cfg.WindowWidth, cfg.WindowHeight
+	c.Fullscreen, c.VSync = cfg.Fullscreen, cfg.VSync
+	c.ShowContacts, c.Iterations, c.AdaptiveSteps = cfg.ShowContacts, cfg.Iterations, cfg.AdaptiveSteps
+	c.RedrawRegions = cfg.RedrawRegions
+	if err := config.Save(config.File, c); err != nil {
+		logConfig.Errorf("Settings: %v, not saved", err)
+		return
+	}
+	// The file changed, but the config in use is already up to date.
+	configWatch.modTime = configModTime()
+	logConfig.Infof("Settings: saved to %s", config.File)
+}
+
+func (s *settingsScreen) draw(screen *ebiten.Image) {
+	ebitenutil.DrawRect(screen, 0, 0, ScreenWidth, ScreenHeight, color.RGBA{A: 0xe0})
+
+	var b strings.Builder
+	b.WriteString("Settings. Up/Down: choose a setting, Left/Right/Enter: change it, Esc: save and close.\n")
+	b.WriteString("Physics iterations apply to the scenes started from now on.\n\n")
+	for i, st := range settings {
+		marker := " "
+		if i == s.cursor {
+			marker = ">"
+		}
+		fmt.Fprintf(&b, "%s %-20s %s\n", marker, st.name, st.value(&cfg))
+	}
+	ebitenutil.DebugPrint(screen, b.String())
+}
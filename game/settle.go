@@ -0,0 +1,150 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	// settleDamping is the damping of the spaces while they settle, the
+	// fraction of their velocity the bodies keep after a second, and
+	// settleSleepTime how long a body idles before it sleeps.
+	settleDamping   = 0.2
+	settleSleepTime = 0.2
+	// settleSpeed is the speed under which an awake body is at rest, in
+	// units a second.
+	settleSpeed = 2
+	// settleMinTicks is how long the spaces settle at least, long enough
+	// for the idle bodies to fall asleep.
+	settleMinTicks = 30
+	// settleTicks is how long a scene is given to come to rest, 20 s at 60
+	// TPS: a scene a player or a motor keeps moving never does.
+	settleTicks = 1200
+	// settleKeptSleepTime is the sleep threshold a space that never slept
+	// is left with once bodies sleep in it: cp only wakes the bodies touched
+	// while sleeping is on, with it back off a sleeping pile would be as
+	// hard as a wall.
+	settleKeptSleepTime = 1
+)
+
+// settledSpace is a space settling and the parameters it is given back.
+type settledSpace struct {
+	space     *cp.Space
+	damping   float64
+	sleepTime float64
+}
+
+// settleMode damps the scene's spaces and has their bodies sleep sooner
+// until it comes to rest, then gives them their parameters back: a pile of
+// thousands of bodies sleeps in a few seconds rather than jittering for
+// long, and a sleeping body costs a step nothing.
+type settleMode struct {
+	// spaces are those settling, none while the mode is off.
+	spaces []settledSpace
+	// ticks is how long they've been settling, awake the bodies that
+	// weren't at rest at the last tick, and asleep those sleeping.
+	ticks  int
+	awake  int
+	asleep int
+	// text is the mode's line, written anew every frame.
+	text render.TextBuffer
+}
+
+// on reports whether the scene is settling.
+func (s *settleMode) on() bool {
+	return len(s.spaces) > 0
+}
+
+// toggleSettle starts settling the scene, or gives its spaces their
+// parameters back if it is settling.
+func (g *Game) toggleSettle() {
+	if g.settle.on() {
+		g.settle.restore()
+		g.toast.show("Settle mode off")
+		return
+	}
+	g.eachSpace(func(space *cp.Space) {
+		g.settle.spaces = append(g.settle.spaces, settledSpace{space, space.Damping(), space.SleepTimeThreshold})
+		if space.Damping() > settleDamping {
+			space.SetDamping(settleDamping)
+		}
+		if space.SleepTimeThreshold > settleSleepTime {
+			space.SleepTimeThreshold = settleSleepTime
+		}
+	})
+	if !g.settle.on() {
+		g.toast.show("The scene has no space to settle")
+		return
+	}
+	g.settle.ticks = 0
+	g.toast.show("Settling the scene")
+}
+
+// update counts the bodies not at rest after a tick, and ends the mode once
+// there are none, or the scene had its time.
+func (s *settleMode) update(g *Game) {
+	if !s.on() {
+		return
+	}
+	s.ticks++
+	s.awake, s.asleep = 0, 0
+	g.eachSpace(func(space *cp.Space) {
+		space.EachBody(func(body *cp.Body) {
+			switch {
+			case body.IsSleeping():
+				s.asleep++
+			case body.GetType() == cp.BODY_DYNAMIC && body.Velocity().Length() > settleSpeed:
+				s.awake++
+			}
+		})
+	})
+	switch {
+	case s.awake == 0 && s.ticks >= settleMinTicks:
+		g.toast.show(fmt.Sprintf("Settled in %.1f s, %d asleep", float64(s.ticks)/float64(ebiten.MaxTPS()), s.asleep))
+		s.restore()
+	case s.ticks >= settleTicks:
+		g.toast.show("Not settled in time, settle mode off")
+		s.restore()
+	}
+}
+
+// restore gives the spaces their parameters back, but for a space that
+// never slept and has sleeping bodies now, which keeps sleeping on.
+func (s *settleMode) restore() {
+	for _, saved := range s.spaces {
+		saved.space.SetDamping(saved.damping)
+		sleepTime := saved.sleepTime
+		if sleepTime == cp.INFINITY && hasSleeping(saved.space) {
+			sleepTime = settleKeptSleepTime
+		}
+		saved.space.SleepTimeThreshold = sleepTime
+	}
+	s.spaces = s.spaces[:0]
+}
+
+// hasSleeping reports whether any body of space sleeps.
+func hasSleeping(space *cp.Space) bool {
+	sleeping := false
+	space.EachBody(func(body *cp.Body) {
+		sleeping = sleeping || body.IsSleeping()
+	})
+	return sleeping
+}
+
+// cancel forgets the spaces of a scene being made anew.
+func (s *settleMode) cancel() {
+	s.spaces = s.spaces[:0]
+}
+
+// draw shows how many bodies are still moving, at the top right corner.
+func (s *settleMode) draw(screen *ebiten.Image) {
+	if !s.on() {
+		return
+	}
+	s.text.Reset().Text("Settling: ").Int(s.awake).Text(" moving (R)")
+	drawHUD(screen, s.text, ScreenWidth-48-150, overlayLineHeight)
+}
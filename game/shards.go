@@ -0,0 +1,213 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	// shardsBins are the bins side by side across the screen, shardsBodies
+	// the bodies in each, shardsSize their size.
+	shardsBins   = 4
+	shardsBodies = 1000
+	shardsSize   = 6
+	// shardsDrain is where the funnels at the bottom of the bins open, and
+	// shardsGap how wide.
+	shardsDrain = 520
+	shardsGap   = 24
+)
+
+// shardsStrips is how many spaces the Shards scene's world is split into,
+// set by the console's shards command.
+var shardsStrips = shardsBins
+
+// shardsColors tell the spaces apart, a body changing color as it is
+// handed off to another.
+var shardsColors = []color.Color{colornames.Orange, colornames.Steelblue, colornames.Yellowgreen, colornames.Orchid}
+
+// Shards fills bins walled off from each other with thousands of bodies,
+// each bin draining into the next, in a world split into spaces across x,
+// see physics.Shards: they step on goroutines of their own, side by side,
+// and a body draining into another space's bin is handed off to it.
+type Shards struct {
+	baseScene
+	shards *physics.Shards
+	// stepTime is how long the last step of all the spaces took.
+	stepTime time.Duration
+	rand     *rand.Rand
+}
+
+func init() {
+	RegisterDemo("Shards", "thousands of bodies in spaces stepped side by side", func() Scene { return NewShards() })
+}
+
+func NewShards() *Shards {
+	s := &Shards{
+		shards: physics.NewShards(shardsStrips, 0, ScreenWidth, shardsSize, newSpace),
+		rand:   rand.New(rand.NewSource(1)),
+	}
+	// Every space has all the walls, the bodies of any strip stay in their
+	// bin.
+	binWidth := float64(ScreenWidth / shardsBins)
+	for _, space := range s.shards.Spaces {
+		space.SetGravity(cp.Vector{Y: avalancheGravity})
+		for i := 0; i <= shardsBins; i++ {
+			x := float64(i) * binWidth
+			physics.AddStaticSegment(space, cp.Vector{X: x, Y: -500}, cp.Vector{X: x, Y: shardsDrain - 70}, 0)
+		}
+		for i := 0; i < shardsBins; i++ {
+			left, middle := float64(i)*binWidth, (float64(i)+0.5)*binWidth
+			physics.AddStaticSegment(space, cp.Vector{X: left, Y: shardsDrain - 70}, cp.Vector{X: middle - shardsGap/2, Y: shardsDrain}, 0)
+			physics.AddStaticSegment(space, cp.Vector{X: left + binWidth, Y: shardsDrain - 70}, cp.Vector{X: middle + shardsGap/2, Y: shardsDrain}, 0)
+		}
+	}
+	// The bodies start in a grid over each bin, the rows above the screen
+	// falling in.
+	columns := int(binWidth/(shardsSize+3)) - 1
+	for bin := 0; bin < shardsBins; bin++ {
+		for i := 0; i < shardsBodies; i++ {
+			pos := cp.Vector{
+				X: float64(bin)*binWidth + float64(i%columns+1)*(shardsSize+3),
+				Y: shardsDrain - 80 - float64(i/columns)*(shardsSize+3),
+			}
+			s.shards.Add(s.newBody(pos))
+		}
+	}
+	return s
+}
+
+// newBody returns a small box or ball at pos.
+func (s *Shards) newBody(pos cp.Vector) (*cp.Body, *cp.Shape) {
+	if s.rand.Intn(2) == 0 {
+		return shardsBody("box", shardsSize, pos)
+	}
+	return shardsBody("circle", shardsSize, pos)
+}
+
+// shardsBody returns a box or a circle, as kind says, of size at pos.
+func shardsBody(kind string, size float64, pos cp.Vector) (*cp.Body, *cp.Shape) {
+	var body *cp.Body
+	var shape *cp.Shape
+	if kind == "box" {
+		body = cp.NewBody(1, cp.MomentForBox(1, size, size))
+		shape = cp.NewBox(body, size, size, 0)
+	} else {
+		body = cp.NewBody(1, cp.MomentForCircle(1, 0, size/2, cp.Vector{}))
+		shape = cp.NewCircle(body, size/2, cp.Vector{})
+	}
+	body.SetPosition(pos)
+	shape.SetFriction(0.5)
+	return body, shape
+}
+
+// inSpace calls f on every space.
+func (s *Shards) inSpace(f func(space *cp.Space)) {
+	for _, space := range s.shards.Spaces {
+		f(space)
+	}
+}
+
+// consoleCommand runs the commands made for one space across all of them:
+// spawn adds the body to the space of the cursor's strip, save and export
+// write the bodies of every space, with the walls they all have.
+func (s *Shards) consoleCommand(name string, args []string) (string, bool, error) {
+	switch name {
+	case "spawn":
+		kind, size, err := consoleShape(args)
+		if err != nil {
+			return "", true, err
+		}
+		pos := input.CursorPosition()
+		s.shards.Add(shardsBody(kind, size, pos))
+		return fmt.Sprintf("Spawned a %s at %.0f, %.0f in space %d", kind, pos.X, pos.Y, s.shards.Shard(pos)+1), true, nil
+	case "save", "export":
+		path, err := consoleLevelPath(args)
+		if err != nil {
+			return "", true, err
+		}
+		var bodies []*cp.Body
+		for _, space := range s.shards.Spaces {
+			if name == "save" {
+				bodies = append(bodies, levelBodies(space)...)
+			} else {
+				bodies = append(bodies, exportBodies(space)...)
+			}
+		}
+		if name == "save" {
+			out, err := writeLevel(path, physics.NewLevel(s.shards.Spaces[0], bodies))
+			return out, true, err
+		}
+		out, err := writeScene(path, physics.ExportScene(s.shards.Spaces[0], bodies))
+		return out, true, err
+	}
+	return "", false, nil
+}
+
+func (s *Shards) Update(dt float64) error {
+	start := time.Now()
+	s.shards.Step(dt)
+	s.stepTime = time.Since(start)
+	s.recycle()
+	return nil
+}
+
+// recycle drops the bodies that drained out of a bin into the top of the
+// next one, the last draining into the first: the next step hands them off
+// to its space.
+func (s *Shards) recycle() {
+	binWidth := float64(ScreenWidth / shardsBins)
+	s.inSpace(func(space *cp.Space) {
+		space.EachBody(func(body *cp.Body) {
+			p := body.Position()
+			if p.Y < ScreenHeight+shardsSize {
+				return
+			}
+			next := (int(p.X/binWidth) + 1) % shardsBins
+			body.SetPosition(cp.Vector{X: (float64(next) + 0.2 + 0.6*s.rand.Float64()) * binWidth, Y: -shardsSize})
+			body.SetVelocity(0, 0)
+		})
+	})
+}
+
+func (s *Shards) Draw(screen *ebiten.Image) {
+	text := fmt.Sprintf("%d spaces stepped in %.2f ms, each in", len(s.shards.Spaces), milliseconds(s.stepTime))
+	for i, space := range s.shards.Spaces {
+		render.DrawSpace(screen, space, shardsColors[i%len(shardsColors)])
+		text += fmt.Sprintf(" %.2f", milliseconds(s.shards.StepTimes[i]))
+	}
+	text += fmt.Sprintf("\n%d bodies handed off. Console: shards 1|2|4", s.shards.HandedOff)
+	ebitenutil.DebugPrint(screen, text)
+}
+
+// consoleShards splits the Shards scene's world into another number of
+// spaces, and restarts it if it is on.
+func consoleShards(g *Game, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("usage: " + consoleCommands["shards"].usage)
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || (n != 1 && n != 2 && n != 4) {
+		return "", errors.New("usage: " + consoleCommands["shards"].usage)
+	}
+	shardsStrips = n
+	if _, ok := g.scene.(*Shards); ok {
+		g.switchScene(g.index)
+	}
+	if n == 1 {
+		return "The Shards scene is one space", nil
+	}
+	return fmt.Sprintf("The Shards scene is split into %d spaces", n), nil
+}
@@ -0,0 +1,49 @@
+package game
+
+import (
+	"errors"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+)
+
+// ErrQuit is what Update returns once the game has shut down, on closing
+// the window or on Ctrl+Q, for RunGame to return: it isn't a failure.
+var ErrQuit = errors.New("quit")
+
+// autosaver is a scene with work to save on quitting, e.g. an edited level.
+type autosaver interface {
+	autosave()
+}
+
+// quitting reports whether the player asked to quit. Closing the window
+// only asks when the closing is handled, see ebiten.SetWindowClosingHandled.
+func quitting() bool {
+	return ebiten.IsWindowBeingClosed() ||
+		input.IsActionPressed(input.ActionControl) && input.IsActionJustPressed(input.ActionQuit)
+}
+
+// Shutdown saves what quitting would lose, the scene's work, the settings
+// being changed, the session and the trajectories being recorded and the
+// GIF being captured, and disposes of the scene.
+// A crashed scene is saved too, its work may well be fine.
+func (g *Game) Shutdown() {
+	if g.state == stateSettings {
+		g.settings.save()
+	}
+	if scene, ok := g.scene.(autosaver); ok {
+		g.safely(scene.autosave)
+	}
+	g.safely(g.scene.Dispose)
+	g.stopRecording()
+	g.StopTrajectories()
+	g.gif.finish()
+	g.stopVideo()
+	g.stopStream()
+	stopNet()
+	if g.frames != nil {
+		g.frames.close()
+	}
+	logGame.Infof("Shut down")
+}
@@ -0,0 +1,147 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	slingshotGravity     = 300
+	slingshotMaxStretch  = 120
+	slingshotGrabRadius  = 40
+	slingshotPower       = 6
+	slingshotShotRadius  = 10
+	slingshotShotMass    = 2
+	slingshotArcSteps    = 20
+	slingshotArcInterval = 0.1
+	// slingshotShotKind is the kind of the shots, pooled once off the
+	// screen.
+	slingshotShotKind = "shot"
+)
+
+var (
+	slingshotAnchor = cp.Vector{X: 150, Y: 420}
+	slingshotForks  = [2]cp.Vector{{X: 135, Y: 415}, {X: 165, Y: 415}}
+)
+
+// Slingshot is an Angry-Birds style launcher: drag back from the anchor and
+// release to fire a projectile at a structure of blocks.
+type Slingshot struct {
+	baseScene
+
+	space *cp.Space
+	// shots are the projectiles fired, removed once off the screen.
+	shots    *entityManager
+	dragging bool
+	pull     cp.Vector
+	fired    int
+}
+
+func init() {
+	RegisterDemo("Slingshot", "drag back to shoot at a block structure", func() Scene { return NewSlingshot() })
+}
+
+func NewSlingshot() *Slingshot {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: slingshotGravity})
+	space.SleepTimeThreshold = 0.5
+
+	physics.AddStaticSegment(space, cp.Vector{Y: groundY}, cp.Vector{X: ScreenWidth, Y: groundY}, 0)
+
+	// The slingshot's post, only for show.
+	post := space.AddShape(cp.NewSegment(space.StaticBody, cp.Vector{X: slingshotAnchor.X, Y: groundY}, slingshotAnchor, 3))
+	post.SetFilter(cp.SHAPE_FILTER_NONE)
+
+	// Two towers of blocks bridged by a plank, topped with a smaller tower.
+	for _, x := range []float64{560, 680} {
+		for i := 0; i < 4; i++ {
+			physics.AddBox(space, cp.Vector{X: x, Y: groundY - 20 - float64(i)*40}, 20, 40)
+		}
+	}
+	physics.AddBox(space, cp.Vector{X: 620, Y: groundY - 165}, 160, 10)
+	for i := 0; i < 3; i++ {
+		physics.AddBox(space, cp.Vector{X: 620, Y: groundY - 185 - float64(i)*30}, 30, 30)
+	}
+
+	return &Slingshot{space: space, shots: newEntityManager(space)}
+}
+
+// launchVelocity is the velocity given to a projectile released at pull.
+func launchVelocity(pull cp.Vector) cp.Vector {
+	return slingshotAnchor.Sub(pull).Mult(slingshotPower)
+}
+
+func (s *Slingshot) Update(dt float64) error {
+	switch {
+	case input.Pointer.IsJustPressed():
+		s.dragging = input.Pointer.Position().Distance(slingshotAnchor) < slingshotGrabRadius
+		if s.dragging {
+			input.Pointer.Capture()
+		}
+	case s.dragging && input.Pointer.IsJustReleased():
+		s.dragging = false
+		s.fire()
+	}
+	if s.dragging {
+		s.pull = slingshotAnchor.Add(input.Pointer.Position().Sub(slingshotAnchor).Clamp(slingshotMaxStretch))
+	}
+
+	s.space.Step(dt)
+	s.shots.each(func(e *Entity) {
+		if p := e.Body.Position(); p.X > ScreenWidth+slingshotShotRadius || p.X < -slingshotShotRadius || p.Y > ScreenHeight+slingshotShotRadius {
+			s.shots.remove(e)
+		}
+	})
+	return nil
+}
+
+// fire shoots a projectile from the pull, one lost off the screen before if
+// any is left.
+func (s *Slingshot) fire() {
+	e := s.shots.reuse(slingshotShotKind, s.pull)
+	if e == nil {
+		moment := cp.MomentForCircle(slingshotShotMass, 0, slingshotShotRadius, cp.Vector{})
+		body := cp.NewBody(slingshotShotMass, moment)
+		body.SetPosition(s.pull)
+		shape := cp.NewCircle(body, slingshotShotRadius, cp.Vector{})
+		shape.SetFriction(0.8)
+		e = &Entity{Body: body, Shapes: []*cp.Shape{shape}, Kind: slingshotShotKind}
+	}
+	s.shots.spawn(e)
+	e.Body.ApplyImpulseAtLocalPoint(launchVelocity(s.pull).Mult(slingshotShotMass), cp.Vector{})
+	s.fired++
+}
+
+func (s *Slingshot) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, s.space, colornames.White)
+
+	if s.dragging {
+		for _, fork := range slingshotForks {
+			ebitenutil.DrawLine(screen, fork.X, fork.Y, s.pull.X, s.pull.Y, colornames.Sienna)
+		}
+
+		// Predicted arc, ignoring collisions and damping.
+		vel := launchVelocity(s.pull)
+		gravity := s.space.Gravity()
+		for i := 1; i <= slingshotArcSteps; i++ {
+			t := float64(i) * slingshotArcInterval
+			p := s.pull.Add(vel.Mult(t)).Add(gravity.Mult(t * t / 2))
+			ebitenutil.DrawRect(screen, p.X-1, p.Y-1, 2, 2, colornames.Yellow)
+		}
+		ebitenutil.DrawRect(screen, s.pull.X-2, s.pull.Y-2, 4, 4, colornames.Yellow)
+	} else {
+		for _, fork := range slingshotForks {
+			ebitenutil.DrawLine(screen, fork.X, fork.Y, slingshotAnchor.X, slingshotAnchor.Y, colornames.Sienna)
+		}
+	}
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("Shots: %d. Drag back from the slingshot and release to fire.", s.fired))
+}
@@ -0,0 +1,169 @@
+package game
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	shipMass         = 1
+	shipLength       = 25
+	shipThrust       = 150
+	shipSideThrust   = 15
+	shipFuel         = 100
+	shipMainBurn     = 10
+	shipSideBurn     = 2
+	shipOrbit        = 180
+	planetRadius     = 60
+	planetGravity    = 1.8e6
+	shipFlameLength  = 12
+	shipSideFlameLen = 5
+)
+
+var planetCenter = cp.Vector{X: ScreenWidth / 2, Y: ScreenHeight / 2}
+
+// Spaceship flies a ship with a main engine at its tail and side thrusters at
+// its nose, around a planet whose gravity pulls towards its center like in
+// Chipmunk's Planet demo. With gravity off it's a frictionless asteroids
+// field.
+type Spaceship struct {
+	baseScene
+
+	space   *cp.Space
+	ship    *cp.Body
+	gravity bool
+	fuel    float64
+
+	thrust, left, right bool
+}
+
+func init() {
+	RegisterDemo("Spaceship", "orbit a planet with off-center thrusters", func() Scene { return NewSpaceship() })
+}
+
+func NewSpaceship() *Spaceship {
+	space := newSpace()
+
+	s := &Spaceship{space: space, gravity: true, fuel: shipFuel}
+
+	planet := space.AddShape(cp.NewCircle(space.StaticBody, planetRadius, planetCenter))
+	planet.SetFriction(1)
+	planet.UserData = colornames.Steelblue
+
+	// The ship points along its local x axis.
+	verts := []cp.Vector{
+		{X: shipLength * 0.6}, {X: -shipLength * 0.4, Y: -shipLength * 0.35}, {X: -shipLength * 0.4, Y: shipLength * 0.35},
+	}
+	moment := cp.MomentForPoly(shipMass, len(verts), verts, cp.Vector{}, 0)
+	s.ship = space.AddBody(cp.NewBody(shipMass, moment))
+	s.ship.SetVelocityUpdateFunc(s.updateVelocity)
+	shape := space.AddShape(cp.NewPolyShape(s.ship, len(verts), verts, cp.NewTransformIdentity(), 0))
+	shape.SetFriction(0.7)
+	shape.UserData = colornames.Lightgray
+
+	// Start on a circular orbit: gravity is exactly the centripetal force.
+	s.ship.SetPosition(planetCenter.Add(cp.Vector{Y: -shipOrbit}))
+	s.ship.SetVelocity(math.Sqrt(planetGravity/shipOrbit), 0)
+
+	return s
+}
+
+// updateVelocity replaces the space's uniform gravity by the planet's, which
+// points to its center and falls off with the square of the distance.
+func (s *Spaceship) updateVelocity(body *cp.Body, _ cp.Vector, damping, dt float64) {
+	var g cp.Vector
+	if s.gravity {
+		p := body.Position().Sub(planetCenter)
+		d := p.Length()
+		g = p.Mult(-planetGravity / (d * d * d))
+	}
+	body.UpdateVelocity(g, damping, dt)
+}
+
+func (s *Spaceship) Update(dt float64) error {
+	if input.IsActionJustPressed(input.ActionGravity) {
+		s.gravity = !s.gravity
+	}
+
+	s.thrust = input.IsActionPressed(input.ActionUp) && s.fuel > 0
+	s.left = input.IsActionPressed(input.ActionLeft) && s.fuel > 0
+	s.right = input.IsActionPressed(input.ActionRight) && s.fuel > 0
+
+	// Forces are given in the ship's frame and applied where the engines are,
+	// so the side thrusters turn the ship instead of pushing it.
+	nose, tail := cp.Vector{X: shipLength * 0.6}, cp.Vector{X: -shipLength * 0.4}
+	if s.thrust {
+		s.ship.ApplyForceAtLocalPoint(cp.Vector{X: shipThrust}, tail)
+		s.fuel -= shipMainBurn * dt
+	}
+	for _, side := range []struct {
+		on  bool
+		dir float64
+	}{{s.left, -1}, {s.right, 1}} {
+		if !side.on {
+			continue
+		}
+		// The thrusters fire sideways at the nose and the opposite way at the
+		// tail: a pure torque.
+		push := cp.Vector{Y: side.dir * shipSideThrust}
+		s.ship.ApplyForceAtLocalPoint(push, nose)
+		s.ship.ApplyForceAtLocalPoint(push.Neg(), tail)
+		s.fuel -= shipSideBurn * dt
+	}
+	s.fuel = math.Max(s.fuel, 0)
+
+	s.space.Step(dt)
+	s.wrap()
+	return nil
+}
+
+// wrap brings the ship back on the other side when it leaves the screen.
+func (s *Spaceship) wrap() {
+	p := s.ship.Position()
+	p.X = math.Mod(p.X+ScreenWidth, ScreenWidth)
+	p.Y = math.Mod(p.Y+ScreenHeight, ScreenHeight)
+	if p != s.ship.Position() {
+		s.ship.SetPosition(p)
+	}
+}
+
+func (s *Spaceship) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, s.space, colornames.White)
+
+	flame := func(at, dir cp.Vector, length float64) {
+		end := at.Add(dir.Mult(length))
+		ebitenutil.DrawLine(screen, at.X, at.Y, end.X, end.Y, colornames.Orange)
+	}
+	rot := s.ship.Rotation()
+	nose := s.ship.LocalToWorld(cp.Vector{X: shipLength * 0.6})
+	tail := s.ship.LocalToWorld(cp.Vector{X: -shipLength * 0.4})
+	if s.thrust {
+		flame(tail, rot.Neg(), shipFlameLength)
+	}
+	if s.left {
+		flame(nose, rot.Perp(), shipSideFlameLen)
+		flame(tail, rot.ReversePerp(), shipSideFlameLen)
+	}
+	if s.right {
+		flame(nose, rot.ReversePerp(), shipSideFlameLen)
+		flame(tail, rot.Perp(), shipSideFlameLen)
+	}
+
+	gravity := "on"
+	if !s.gravity {
+		gravity = "off"
+	}
+	altitude := s.ship.Position().Distance(planetCenter) - planetRadius
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Fuel: %5.1f%%. Speed: %5.1f px/s. Altitude: %5.1f px. Gravity: %s.\nUp: thrust, Left/Right: turn, G: toggle gravity.",
+		s.fuel*100/shipFuel, s.ship.Velocity().Length(), altitude, gravity,
+	))
+}
@@ -0,0 +1,170 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+const (
+	// spikeBuckets is the number of buckets of the histogram: under 1/8
+	// ms, then doubling, up to 8 ms and more.
+	spikeBuckets  = 8
+	spikeSmallest = time.Millisecond / 8
+	// spikeWorst is how many of the worst ticks the panel lists.
+	spikeWorst = 5
+	// spikesX and spikesY are the top left corner of the panel, on the left
+	// of the screen above the plot, spikesBarWidth its longest bar.
+	spikesX        = 8
+	spikesY        = 96
+	spikesBarWidth = 160
+)
+
+// stepTimer is a scene whose space isn't stepped in its Update, e.g. on
+// another goroutine, which times its steps itself.
+type stepTimer interface {
+	stepTime() time.Duration
+}
+
+// spike is a tick whose steps took longer than the frame budget, with what
+// the scene was made of then.
+type spike struct {
+	tick   int
+	steps  int
+	total  time.Duration
+	bodies int
+}
+
+// spikeDetector keeps a histogram of the wall time of the scene's steps,
+// and the ticks whose steps took longer than a tick, the frame budget, and
+// so made the game hitch: the worst of them, with their bodies, tell what
+// the scene was doing when it hitched.
+type spikeDetector struct {
+	visible bool
+	// buckets count the steps by duration, steps all of them.
+	buckets [spikeBuckets]int
+	steps   int
+	// tick is the ticks so far, tickSteps and tickTotal the steps of the
+	// current one and their time.
+	tick      int
+	tickSteps int
+	tickTotal time.Duration
+	// spikes is the number of ticks over budget, worst the longest of them,
+	// the longest first.
+	spikes int
+	worst  []spike
+}
+
+// reset forgets the steps, of another scene.
+func (s *spikeDetector) reset() {
+	*s = spikeDetector{visible: s.visible}
+}
+
+// stepped counts a step that took d.
+func (s *spikeDetector) stepped(d time.Duration) {
+	i := 0
+	for limit := spikeSmallest; d >= limit && i < spikeBuckets-1; limit *= 2 {
+		i++
+	}
+	s.buckets[i]++
+	s.steps++
+	s.tickSteps++
+	s.tickTotal += d
+}
+
+// tickDone ends the tick, and keeps it if its steps took longer than
+// budget: stats are called then only, for its bodies.
+func (s *spikeDetector) tickDone(budget time.Duration, stats func() physics.Stats) {
+	s.tick++
+	steps, total := s.tickSteps, s.tickTotal
+	s.tickSteps, s.tickTotal = 0, 0
+	if total <= budget {
+		return
+	}
+	s.spikes++
+	sp := spike{tick: s.tick, steps: steps, total: total, bodies: stats().Bodies}
+	logPhysics.Debugf("Spike at tick %d: %d steps in %v, %d bodies", sp.tick, sp.steps, sp.total, sp.bodies)
+	if len(s.worst) == spikeWorst && total <= s.worst[spikeWorst-1].total {
+		return
+	}
+	s.worst = append(s.worst, sp)
+	sort.SliceStable(s.worst, func(i, j int) bool { return s.worst[i].total > s.worst[j].total })
+	if len(s.worst) > spikeWorst {
+		s.worst = s.worst[:spikeWorst]
+	}
+}
+
+// frameBudget is the time of a tick, which the steps of a tick must fit in,
+// a step's, 1/60 s, when the ticks follow the frames.
+func frameBudget() time.Duration {
+	if tps := ebiten.MaxTPS(); tps > 0 {
+		return time.Second / time.Duration(tps)
+	}
+	return time.Second / 60
+}
+
+func (s *spikeDetector) draw(screen *ebiten.Image) {
+	if !s.visible {
+		return
+	}
+	height := 56 + spikeBuckets*overlayLineHeight + (spikeWorst+1)*overlayLineHeight
+	ebitenutil.DrawRect(screen, spikesX-4, spikesY-4, spikesBarWidth+160, float64(height), color.RGBA{A: 0xc0})
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%d steps, %d ticks over %.1f ms", s.steps, s.spikes, milliseconds(frameBudget())), spikesX, spikesY)
+
+	most := 1
+	for _, n := range s.buckets {
+		if n > most {
+			most = n
+		}
+	}
+	y := spikesY + 2*overlayLineHeight
+	limit := spikeSmallest
+	for i, n := range s.buckets {
+		label := fmt.Sprintf("< %5.3g ms", milliseconds(limit))
+		if i == spikeBuckets-1 {
+			label = fmt.Sprintf(">=%5.3g ms", milliseconds(limit/2))
+		}
+		ebitenutil.DebugPrintAt(screen, label, spikesX, y)
+		ebitenutil.DrawRect(screen, spikesX+72, float64(y+4), float64(n)/float64(most)*spikesBarWidth, 8, colornames.Skyblue)
+		ebitenutil.DebugPrintAt(screen, fmt.Sprint(n), spikesX+80+spikesBarWidth, y)
+		limit *= 2
+		y += overlayLineHeight
+	}
+
+	y += overlayLineHeight
+	if len(s.worst) == 0 {
+		ebitenutil.DebugPrintAt(screen, "No tick over budget.", spikesX, y)
+		return
+	}
+	ebitenutil.DebugPrintAt(screen, "Worst ticks:", spikesX, y)
+	for _, sp := range s.worst {
+		y += overlayLineHeight
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf(
+			"tick %d: %.2f ms, %d steps, %d bodies", sp.tick, milliseconds(sp.total), sp.steps, sp.bodies,
+		), spikesX, y)
+	}
+}
+
+// stepTime returns the wall time of the step started at start, or the
+// scene's own of its last step if it times them.
+func (g *Game) stepTime(start time.Time) time.Duration {
+	if scene, ok := g.scene.(stepTimer); ok {
+		return scene.stepTime()
+	}
+	return time.Since(start)
+}
+
+// stepTime is the time of the runner's last step, the scene's Update only
+// asking for it.
+func (a *Avalanche) stepTime() time.Duration {
+	var d time.Duration
+	a.runner.View(func(s *physics.Snapshot) { d = s.StepTime })
+	return d
+}
@@ -0,0 +1,92 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// gameState is what the game is doing, which decides what Update and Draw
+// do with the scene.
+type gameState int
+
+const (
+	// stateMenu shows the main menu over the scene, which doesn't run.
+	stateMenu gameState = iota
+	// statePlaying runs the scene.
+	statePlaying
+	// statePaused draws the scene without running it.
+	statePaused
+	// stateGameOver draws the scene that ended without running it.
+	stateGameOver
+	// stateSettings shows the settings screen over the scene, which doesn't
+	// run.
+	stateSettings
+	// stateCrashed shows why the scene stopped, a panic or an error, instead
+	// of the scene, see crash.go.
+	stateCrashed
+)
+
+var stateNames = [...]string{
+	stateMenu:     "menu",
+	statePlaying:  "playing",
+	statePaused:   "paused",
+	stateGameOver: "game over",
+	stateSettings: "settings",
+	stateCrashed:  "crashed",
+}
+
+func (s gameState) String() string {
+	return stateNames[s]
+}
+
+// transitions are the states each state can go to. Switching or restarting
+// the scene starts playing it from any state, see switchScene, and a crash
+// of the scene stops it from any state, see crash.
+var transitions = map[gameState][]gameState{
+	stateMenu:     {statePlaying, stateSettings},
+	statePlaying:  {statePaused, stateGameOver, stateMenu, stateSettings},
+	statePaused:   {statePlaying, stateMenu, stateSettings},
+	stateGameOver: {statePlaying, stateMenu},
+	// The settings go back to the menu they were opened from, or pause the
+	// scene they were opened over.
+	stateSettings: {stateMenu, statePaused},
+	stateCrashed:  {stateMenu},
+}
+
+// ender is a scene that ends, e.g. a round against the clock: the game is
+// over once Over is true, until the scene is restarted.
+type ender interface {
+	Over() bool
+}
+
+// setState goes to the state to, which must be one the current state can
+// go to. The clock starts over on leaving a state where the scene doesn't
+// run, for the time spent there not to be caught up with.
+func (g *Game) setState(to gameState) {
+	allowed := false
+	for _, s := range transitions[g.state] {
+		allowed = allowed || s == to
+	}
+	if !allowed {
+		panic(fmt.Sprintf("game: no transition from %v to %v", g.state, to))
+	}
+	logGame.Debugf("State: %v to %v", g.state, to)
+	g.state = to
+	g.clock.reset()
+}
+
+// drawBanner draws lines of text centered on a dark band across the middle
+// of the screen.
+func drawBanner(screen *ebiten.Image, lines ...string) {
+	const lineHeight, charWidth = 16, 6
+	height := float64((len(lines) + 2) * lineHeight)
+	top := (ScreenHeight - height) / 2
+	ebitenutil.DrawRect(screen, 0, top, ScreenWidth, height, color.RGBA{A: 0xc0})
+	for i, line := range lines {
+		x := (ScreenWidth - len(line)*charWidth) / 2
+		ebitenutil.DebugPrintAt(screen, line, x, int(top)+(i+1)*lineHeight)
+	}
+}
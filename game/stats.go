@@ -0,0 +1,94 @@
+package game
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+// madeSpaces are the spaces made by newSpace since the scene being made
+// started, which are the scene's.
+var madeSpaces []*cp.Space
+
+// trackSpace keeps a space made by a scene, for the statistics overlay to
+// count what it's made of.
+func trackSpace(space *cp.Space) *cp.Space {
+	useBroadphase(space)
+	madeSpaces = append(madeSpaces, space)
+	return space
+}
+
+// statser is a scene that counts its spaces itself, e.g. one whose space
+// is stepped on another goroutine, which the game mustn't walk.
+type statser interface {
+	physicsStats() physics.Stats
+}
+
+// statsOverlay shows what the scene's spaces are made of, and the wall
+// time of the scene's steps, Update with Step in it, averaged and the
+// longest over the last second.
+type statsOverlay struct {
+	visible bool
+	// total, longest and steps are those of the window started at
+	// windowStart, shownAverage and shownLongest those of the last full one.
+	total, longest             time.Duration
+	steps                      int
+	windowStart                time.Time
+	shownAverage, shownLongest time.Duration
+	// text is the overlay's, written anew every frame.
+	text render.TextBuffer
+}
+
+// stepped measures a step of the scene that took d.
+func (s *statsOverlay) stepped(d time.Duration) {
+	s.total += d
+	s.steps++
+	if d > s.longest {
+		s.longest = d
+	}
+}
+
+// roll starts a new window at now once the current one is over.
+func (s *statsOverlay) roll(now time.Time) {
+	if now.Sub(s.windowStart) < perfWindow {
+		return
+	}
+	s.shownAverage, s.shownLongest = 0, s.longest
+	if s.steps > 0 {
+		s.shownAverage = s.total / time.Duration(s.steps)
+	}
+	s.total, s.longest, s.steps = 0, 0, 0
+	s.windowStart = now
+}
+
+// physicsStats returns the statistics of the scene's spaces.
+func (g *Game) physicsStats() physics.Stats {
+	if scene, ok := g.scene.(statser); ok {
+		return scene.physicsStats()
+	}
+	var stats physics.Stats
+	for _, space := range g.spaces {
+		stats = stats.Add(physics.SpaceStats(space))
+	}
+	return stats
+}
+
+// draw draws the overlay at y with the stats of the scene, and returns the
+// y under it.
+func (s *statsOverlay) draw(screen *ebiten.Image, y int, stats func() physics.Stats) int {
+	s.roll(time.Now())
+	if !s.visible {
+		return y
+	}
+	st := stats()
+	s.text.Reset().
+		Text("Bodies ").Int(st.Bodies).Text("  Shapes ").Int(st.Shapes).
+		Text("\nConstraints ").Int(st.Constraints).Text("  Arbiters ").Int(st.Arbiters).
+		Text("\nStep ").Float(milliseconds(s.shownAverage), 2).Text(" ms, at most ").Float(milliseconds(s.shownLongest), 2)
+	drawHUD(screen, s.text, overlayX, y)
+	return y + 4*overlayLineHeight
+}
@@ -0,0 +1,135 @@
+package game
+
+import (
+	"encoding/json"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/stream"
+)
+
+// streamMaxBodies caps the bodies of a message, for the avalanche's not to
+// weigh megabytes a second.
+const streamMaxBodies = 500
+
+// streamMessage is the state of the world sent at every step: the steps
+// since the game started, the scene, the transforms of its dynamic bodies
+// and the stats of its spaces.
+type streamMessage struct {
+	Step   int            `json:"step"`
+	Time   float64        `json:"time"`
+	Scene  string         `json:"scene"`
+	Bodies []streamedBody `json:"bodies"`
+	Stats  streamedStats  `json:"stats"`
+}
+
+// streamedBody is a body's transform and velocity, id its number from 1 in
+// the scene, in the order they were first sent.
+type streamedBody struct {
+	ID              int     `json:"id"`
+	X               float64 `json:"x"`
+	Y               float64 `json:"y"`
+	Angle           float64 `json:"angle"`
+	VX              float64 `json:"vx"`
+	VY              float64 `json:"vy"`
+	AngularVelocity float64 `json:"angular_velocity"`
+}
+
+// streamedStats are the physics.Stats of the scene, the momentum as its
+// magnitude.
+type streamedStats struct {
+	Bodies      int     `json:"bodies"`
+	Shapes      int     `json:"shapes"`
+	Constraints int     `json:"constraints"`
+	Arbiters    int     `json:"arbiters"`
+	Kinetic     float64 `json:"kinetic"`
+	Potential   float64 `json:"potential"`
+	Momentum    float64 `json:"momentum"`
+}
+
+// worldStream publishes the state of the world to the server's clients.
+type worldStream struct {
+	server *stream.Server
+	// ids are the numbers of the bodies of scene, a scene index.
+	scene int
+	ids   map[*cp.Body]int
+}
+
+// Stream serves the state of the world at every step, as JSON over
+// WebSocket at addr's /ws, and a dashboard showing it at its root, until
+// the game shuts down, to web pages of origins too, see stream.Listen. The
+// messages are only made while a client is connected.
+func (g *Game) Stream(addr string, origins []string) error {
+	server, err := stream.Listen(addr, origins...)
+	if err != nil {
+		return err
+	}
+	g.stream = &worldStream{server: server, scene: -1}
+	logGame.Infof("Streaming the world on http://%s/", server.Addr())
+	return nil
+}
+
+// stopStream stops serving, if the game was.
+func (g *Game) stopStream() {
+	if g.stream == nil {
+		return
+	}
+	if err := g.stream.server.Close(); err != nil {
+		logGame.Errorf("Stream: %v", err)
+	}
+	g.stream = nil
+}
+
+// streamStep publishes the step just made.
+func (g *Game) streamStep() {
+	w := g.stream
+	if w == nil || w.server.Clients() == 0 {
+		return
+	}
+	if w.scene != g.index {
+		w.scene, w.ids = g.index, map[*cp.Body]int{}
+	}
+	msg := streamMessage{
+		Step:   g.inputs.steps,
+		Time:   float64(g.inputs.steps) * physicsStep,
+		Scene:  scenes[g.index].name,
+		Bodies: []streamedBody{},
+	}
+	g.inSpace(func(space *cp.Space) {
+		space.EachBody(func(body *cp.Body) {
+			if body.GetType() != cp.BODY_DYNAMIC || len(msg.Bodies) == streamMaxBodies {
+				return
+			}
+			id, ok := w.ids[body]
+			if !ok {
+				id = len(w.ids) + 1
+				w.ids[body] = id
+			}
+			pos, vel := body.Position(), body.Velocity()
+			msg.Bodies = append(msg.Bodies, streamedBody{
+				ID: id, X: pos.X, Y: pos.Y, Angle: body.Angle(),
+				VX: vel.X, VY: vel.Y, AngularVelocity: body.AngularVelocity(),
+			})
+		})
+	})
+	msg.Stats = streamStats(g.physicsStats())
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logGame.Errorf("Stream: %v", err)
+		return
+	}
+	w.server.Publish(data)
+}
+
+func streamStats(s physics.Stats) streamedStats {
+	return streamedStats{
+		Bodies:      s.Bodies,
+		Shapes:      s.Shapes,
+		Constraints: s.Constraints,
+		Arbiters:    s.Arbiters,
+		Kinetic:     s.Kinetic,
+		Potential:   s.Potential,
+		Momentum:    s.Momentum.Length(),
+	}
+}
@@ -0,0 +1,122 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/assets"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/svg"
+)
+
+const (
+	// svgLevelFile is the drawing of the scene, among the assets.
+	svgLevelFile    = "maps/level.svg"
+	svgLevelGravity = 300
+	svgLevelBall    = 8
+	// svgLevelRadius is the thickness of the drawn lines, for small balls
+	// not to slip between the segments of a sharp corner.
+	svgLevelRadius = 1
+	// svgMinTolerance and svgMaxTolerance bound the flattening tolerance,
+	// which Up and Down double and halve.
+	svgMinTolerance = 0.125
+	svgMaxTolerance = 32
+)
+
+// SVGLevel is a level drawn in an SVG editor such as Inkscape: its paths,
+// curves included, become chains of static segments.
+type SVGLevel struct {
+	baseScene
+
+	space     *cp.Space
+	tolerance float64
+	// segments is how many static segments the drawing made.
+	segments int
+	// err is why the drawing didn't load, shown instead of the scene.
+	err error
+}
+
+func init() {
+	RegisterDemo("SVG level", "a level drawn in Inkscape", func() Scene { return NewSVGLevel() })
+}
+
+func NewSVGLevel() *SVGLevel {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: svgLevelGravity})
+	s := &SVGLevel{space: space, tolerance: svg.DefaultTolerance}
+	s.load()
+	return s
+}
+
+// load replaces the static segments by those of the drawing, flattened
+// with the current tolerance.
+func (s *SVGLevel) load() {
+	var static []*cp.Shape
+	s.space.StaticBody.EachShape(func(shape *cp.Shape) {
+		static = append(static, shape)
+	})
+	for _, shape := range static {
+		s.space.RemoveShape(shape)
+	}
+	lines, err := svg.Load(assets.FS, svgLevelFile, s.tolerance)
+	s.err = err
+	s.segments = len(svg.AddSegments(s.space, lines, svgLevelRadius))
+}
+
+func (s *SVGLevel) addBall(pos cp.Vector) {
+	_, shape := physics.AddBall(s.space, pos, svgLevelBall)
+	shape.SetFriction(0.5)
+	shape.SetElasticity(0.3)
+	shape.UserData = colornames.Lightskyblue
+}
+
+func (s *SVGLevel) Update(dt float64) error {
+	if input.Pointer.IsJustPressed() {
+		s.addBall(input.Pointer.Position())
+	}
+	switch {
+	case input.IsActionJustPressed(input.ActionUp) && s.tolerance < svgMaxTolerance:
+		s.tolerance *= 2
+		s.load()
+	case input.IsActionJustPressed(input.ActionDown) && s.tolerance > svgMinTolerance:
+		s.tolerance /= 2
+		s.load()
+	}
+
+	s.space.Step(dt)
+
+	// Balls that fell off the level are gone.
+	var fallen []*cp.Body
+	s.space.EachBody(func(body *cp.Body) {
+		if body.Position().Y > ScreenHeight+svgLevelBall {
+			fallen = append(fallen, body)
+		}
+	})
+	for _, body := range fallen {
+		// A ball has a single shape.
+		var shape *cp.Shape
+		body.EachShape(func(s *cp.Shape) { shape = s })
+		s.space.RemoveShape(shape)
+		s.space.RemoveBody(body)
+	}
+	return nil
+}
+
+func (s *SVGLevel) Draw(screen *ebiten.Image) {
+	if s.err != nil {
+		ebitenutil.DebugPrint(screen, "SVG error:\n"+s.err.Error())
+		return
+	}
+	render.DrawSpace(screen, s.space, colornames.Burlywood)
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"%s: %d segments, flattened within %g px.\nUp/Down to change the tolerance, click to drop a ball.",
+		svgLevelFile, s.segments, s.tolerance,
+	))
+}
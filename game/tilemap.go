@@ -0,0 +1,103 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/assets"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/tiled"
+)
+
+const (
+	// tileMapFile is the Tiled map of the scene, among the assets.
+	tileMapFile    = "maps/demo.tmx"
+	tileMapGravity = 300
+	tileMapBall    = 10
+)
+
+// TileMap loads a Tiled map: its tile layers are drawn behind the space, and
+// its collision geometry, the object layers and the tiles' collision
+// shapes, becomes the space's static shapes.
+type TileMap struct {
+	baseScene
+
+	space *cp.Space
+	tiles *render.TileMap
+	// shapes is how many static shapes the map made.
+	shapes int
+	// err is why the map didn't load, shown instead of the scene.
+	err error
+}
+
+func init() {
+	RegisterDemo("Tiled map", "collision geometry from a Tiled map", func() Scene { return NewTileMap() })
+}
+
+func NewTileMap() *TileMap {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: tileMapGravity})
+	t := &TileMap{space: space}
+
+	m, err := tiled.Load(assets.FS, tileMapFile)
+	if err == nil {
+		t.tiles, err = render.NewTileMap(m, assets.Image)
+	}
+	if err == nil {
+		var shapes []*cp.Shape
+		shapes, err = m.AddCollision(space, cp.Vector{})
+		t.shapes = len(shapes)
+	}
+	t.err = err
+	return t
+}
+
+func (t *TileMap) addBall(pos cp.Vector) {
+	_, shape := physics.AddBall(t.space, pos, tileMapBall)
+	shape.SetElasticity(0.5)
+	shape.UserData = colornames.Orange
+}
+
+func (t *TileMap) Update(dt float64) error {
+	if input.Pointer.IsJustPressed() {
+		t.addBall(input.Pointer.Position())
+	}
+
+	t.space.Step(dt)
+
+	// Balls that fell off the map are gone.
+	var fallen []*cp.Body
+	t.space.EachBody(func(body *cp.Body) {
+		if body.Position().Y > ScreenHeight+tileMapBall {
+			fallen = append(fallen, body)
+		}
+	})
+	for _, body := range fallen {
+		// A ball has a single shape.
+		var shape *cp.Shape
+		body.EachShape(func(s *cp.Shape) { shape = s })
+		t.space.RemoveShape(shape)
+		t.space.RemoveBody(body)
+	}
+	return nil
+}
+
+func (t *TileMap) Draw(screen *ebiten.Image) {
+	if t.err != nil {
+		ebitenutil.DebugPrint(screen, "Map error:\n"+t.err.Error())
+		return
+	}
+	t.tiles.Draw(screen, cp.Vector{})
+	render.DrawSpace(screen, t.space, colornames.Burlywood)
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"%s: %d static shapes.\nClick to drop a ball.",
+		tileMapFile, t.shapes,
+	))
+}
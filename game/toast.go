@@ -0,0 +1,35 @@
+package game
+
+import (
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// toastDuration is how long a toast stays on screen.
+const toastDuration = 3 * time.Second
+
+// toast is a short message over the bottom of the screen that goes away on
+// its own, to confirm what a key did, e.g. saving a screenshot.
+type toast struct {
+	message string
+	until   time.Time
+}
+
+// show shows message, in place of the one shown if any.
+func (t *toast) show(message string) {
+	t.message, t.until = message, time.Now().Add(toastDuration)
+}
+
+func (t *toast) draw(screen *ebiten.Image) {
+	if t.message == "" || time.Now().After(t.until) {
+		return
+	}
+	// The debug font is 6 pixels wide.
+	w := float64(len(t.message)*6 + 16)
+	x, y := (ScreenWidth-w)/2, float64(ScreenHeight-80)
+	ebitenutil.DrawRect(screen, x, y, w, 24, color.RGBA{A: 0xc0})
+	ebitenutil.DebugPrintAt(screen, t.message, int(x)+8, int(y)+4)
+}
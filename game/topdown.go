@@ -0,0 +1,159 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	topdownSpeed       = 150
+	topdownDriveForce  = 20000
+	topdownTurnSpeed   = 3
+	topdownTurnForce   = 500000
+	topdownCrateSize   = 30
+	topdownCrateMass   = 1
+	topdownCrateGrip   = 1000
+	topdownCrateTwist  = 5000
+	topdownCharSize    = 24
+	topdownCharMass    = 10
+	topdownWallPadding = 20
+)
+
+// TopDown moves a character without gravity, like Chipmunk's Tank demo. The
+// character is tied to a kinematic control body by constraints whose bias is
+// zero: they never pull it back into place, they only match velocities with
+// a limited force. Steering the control body's velocity drives the character
+// and a still control body brakes it, which is how ground friction is faked
+// when there is no ground.
+type TopDown struct {
+	baseScene
+
+	space     *cp.Space
+	control   *cp.Body
+	character *cp.Body
+}
+
+func init() {
+	RegisterDemo("Top-down movement", "velocity-only constraints as friction", func() Scene { return NewTopDown() })
+}
+
+func NewTopDown() *TopDown {
+	space := newSpace()
+
+	bounds := cp.BB{
+		L: topdownWallPadding, B: topdownWallPadding,
+		R: ScreenWidth - topdownWallPadding, T: groundY,
+	}
+	for _, seg := range [][2]cp.Vector{
+		{{X: bounds.L, Y: bounds.B}, {X: bounds.R, Y: bounds.B}},
+		{{X: bounds.R, Y: bounds.B}, {X: bounds.R, Y: bounds.T}},
+		{{X: bounds.R, Y: bounds.T}, {X: bounds.L, Y: bounds.T}},
+		{{X: bounds.L, Y: bounds.T}, {X: bounds.L, Y: bounds.B}},
+	} {
+		physics.AddStaticSegment(space, seg[0], seg[1], 0)
+	}
+
+	// Crates get the same treatment, tied to the static body so they stop
+	// sliding once the character stops pushing.
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 4; col++ {
+			pos := cp.Vector{X: 450 + float64(col)*50, Y: 200 + float64(row)*80}
+			crate := space.AddBody(cp.NewBody(topdownCrateMass, cp.MomentForBox(topdownCrateMass, topdownCrateSize, topdownCrateSize)))
+			crate.SetPosition(pos)
+			shape := space.AddShape(cp.NewBox(crate, topdownCrateSize, topdownCrateSize, 0))
+			shape.SetFriction(0.7)
+			shape.UserData = colornames.Burlywood
+			addTopDownFriction(space, space.StaticBody, crate, topdownCrateGrip, topdownCrateTwist)
+		}
+	}
+
+	control := space.AddBody(cp.NewKinematicBody())
+	character := space.AddBody(cp.NewBody(topdownCharMass, cp.MomentForBox(topdownCharMass, topdownCharSize, topdownCharSize)))
+	character.SetPosition(cp.Vector{X: 200, Y: 300})
+	shape := space.AddShape(cp.NewBox(character, topdownCharSize, topdownCharSize, 0))
+	shape.SetFriction(0.7)
+	shape.UserData = colornames.Orange
+	_, gear := addTopDownFriction(space, control, character, topdownDriveForce, topdownTurnForce)
+	// Let the gear correct the angle, slowly, so the character turns to face
+	// where it goes.
+	gear.SetMaxBias(topdownTurnSpeed)
+
+	return &TopDown{
+		space:     space,
+		control:   control,
+		character: character,
+	}
+}
+
+// addTopDownFriction ties body to control with a pivot joint for linear
+// friction and a gear joint for angular friction. With a zero max bias they
+// only remove relative velocity, up to the given forces.
+func addTopDownFriction(space *cp.Space, control, body *cp.Body, force, torque float64) (pivot, gear *cp.Constraint) {
+	pivot = space.AddConstraint(cp.NewPivotJoint2(control, body, cp.Vector{}, cp.Vector{}))
+	pivot.SetMaxBias(0)
+	pivot.SetMaxForce(force)
+
+	gear = space.AddConstraint(cp.NewGearJoint(control, body, 0, 1))
+	gear.SetErrorBias(0)
+	gear.SetMaxBias(0)
+	gear.SetMaxForce(torque)
+	return pivot, gear
+}
+
+func topDownInput() cp.Vector {
+	var dir cp.Vector
+	if input.IsActionPressed(input.ActionLeft) {
+		dir.X--
+	}
+	if input.IsActionPressed(input.ActionRight) {
+		dir.X++
+	}
+	if input.IsActionPressed(input.ActionUp) {
+		dir.Y--
+	}
+	if input.IsActionPressed(input.ActionDown) {
+		dir.Y++
+	}
+	if dir.LengthSq() > 0 {
+		dir = dir.Normalize()
+	}
+	return dir
+}
+
+func (t *TopDown) Update(dt float64) error {
+	dir := topDownInput()
+
+	// The target velocity is the control body's velocity.
+	t.control.SetVelocityVector(dir.Mult(topdownSpeed))
+
+	// Turn the short way round towards the direction of travel.
+	if dir.LengthSq() > 0 {
+		turn := t.character.Rotation().Unrotate(dir).ToAngle()
+		t.control.SetAngle(t.character.Angle() - turn)
+	}
+
+	t.space.Step(dt)
+	return nil
+}
+
+func (t *TopDown) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, t.space, colornames.White)
+
+	// Mark the character's front.
+	pos := t.character.Position()
+	front := t.character.LocalToWorld(cp.Vector{X: topdownCharSize / 2})
+	ebitenutil.DrawLine(screen, pos.X, pos.Y, front.X, front.Y, colornames.Orange)
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Speed: %5.1f px/s (target %d). WASD/arrows: move and push the crates.",
+		t.character.Velocity().Length(), topdownSpeed,
+	))
+}
@@ -0,0 +1,155 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/assets"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/trace"
+)
+
+const (
+	// tracedTerrainFile and tracedRockFile are the artwork of the scene,
+	// among the assets.
+	tracedTerrainFile = "maps/terrain.png"
+	tracedRockFile    = "maps/rock.png"
+	tracedGravity     = 300
+	// tracedThreshold is the alpha from which a pixel is solid, and
+	// tracedTolerance how far in pixels the outlines may stray from the
+	// traced ones.
+	tracedThreshold = 128
+	tracedTolerance = 1
+	// tracedDensity is the mass of a rock per square pixel.
+	tracedDensity = 0.01
+)
+
+// Traced collides along the artwork rather than along boxes: the outlines of
+// the terrain and of the rocks are traced from the alpha of their images.
+// The terrain keeps its exact outline, cave included, as static segments;
+// a rock, a dynamic body, gets the convex hull of its own.
+type Traced struct {
+	baseScene
+
+	space   *cp.Space
+	sprites *render.SpriteRegistry
+	cam     *render.Camera
+	terrain *ebiten.Image
+	// terrainAt is where the terrain's top left corner is.
+	terrainAt cp.Vector
+	rock      *ebiten.Image
+	// rockHull is the rock's outline around its center.
+	rockHull []cp.Vector
+	// points is how many points the outlines have.
+	points int
+	err    error
+}
+
+func init() {
+	RegisterDemo("Traced", "outlines traced from the alpha of images", func() Scene { return NewTraced() })
+}
+
+func NewTraced() *Traced {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: tracedGravity})
+	t := &Traced{
+		space:   space,
+		sprites: render.NewSpriteRegistry(),
+		cam:     render.NewCamera(ScreenWidth, ScreenHeight),
+	}
+
+	img, err := assets.Decoded(tracedTerrainFile)
+	if err == nil {
+		t.terrain, err = assets.Image(tracedTerrainFile)
+	}
+	if err != nil {
+		t.err = err
+		return t
+	}
+	terrain := trace.Outline(img, tracedThreshold, tracedTolerance)
+	t.terrainAt = cp.Vector{Y: float64(ScreenHeight - img.Bounds().Dy())}
+	trace.AddSegments(space, space.StaticBody, terrain, t.terrainAt, 0)
+
+	img, err = assets.Decoded(tracedRockFile)
+	if err == nil {
+		t.rock, err = assets.Image(tracedRockFile)
+	}
+	if err != nil {
+		t.err = err
+		return t
+	}
+	rock := trace.Outline(img, tracedThreshold, tracedTolerance)
+	size := img.Bounds().Size()
+	t.rockHull = trace.Hull(rock, cp.Vector{X: -float64(size.X) / 2, Y: -float64(size.Y) / 2})
+
+	for _, c := range append(terrain, rock...) {
+		t.points += len(c) - 1
+	}
+	return t
+}
+
+func (t *Traced) addRock(pos cp.Vector) {
+	count := len(t.rockHull)
+	mass := tracedDensity * cp.AreaForPoly(count, t.rockHull, 0)
+	body := t.space.AddBody(cp.NewBody(mass, cp.MomentForPoly(mass, count, t.rockHull, cp.Vector{}, 0)))
+	body.SetPosition(pos)
+	shape := t.space.AddShape(cp.NewPolyShape(body, count, t.rockHull, cp.NewTransformIdentity(), 0))
+	shape.SetFriction(0.8)
+	shape.SetElasticity(0.2)
+	t.sprites.Add(body, t.rock)
+}
+
+func (t *Traced) Update(dt float64) error {
+	if t.err != nil {
+		return nil
+	}
+	if input.Pointer.IsJustPressed() || input.IsActionJustPressed(input.ActionSpawn) {
+		t.addRock(input.Pointer.Position())
+	}
+
+	t.space.Step(dt)
+
+	// Rocks that rolled off the screen are gone.
+	var gone []*cp.Body
+	t.space.EachBody(func(body *cp.Body) {
+		if p := body.Position(); p.Y > ScreenHeight+50 || p.X < -50 || p.X > ScreenWidth+50 {
+			gone = append(gone, body)
+		}
+	})
+	for _, body := range gone {
+		// A rock has a single shape.
+		var shape *cp.Shape
+		body.EachShape(func(s *cp.Shape) { shape = s })
+		t.space.RemoveShape(shape)
+		t.space.RemoveBody(body)
+		t.sprites.Remove(body)
+	}
+	return nil
+}
+
+func (t *Traced) camera() *render.Camera {
+	return t.cam
+}
+
+func (t *Traced) Draw(screen *ebiten.Image) {
+	if t.err != nil {
+		ebitenutil.DebugPrint(screen, "Artwork error:\n"+t.err.Error())
+		return
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(t.terrainAt.X, t.terrainAt.Y)
+	screen.DrawImage(t.terrain, op)
+	t.sprites.Draw(screen, t.cam)
+	// The outlines over the artwork, to see how well they match.
+	render.DrawSpace(screen, t.space, colornames.Yellow)
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Outlines traced from %s and %s: %d points.\nClick or press B to drop a rock.",
+		tracedTerrainFile, tracedRockFile, t.points,
+	))
+}
@@ -0,0 +1,153 @@
+package game
+
+import (
+	"encoding/csv"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jakecoffman/cp"
+)
+
+// trajectoryMaxBodies caps the bodies recorded at a step when none are
+// selected, for the avalanche not to write thousands of rows a step.
+const trajectoryMaxBodies = 64
+
+// trajectoryHeader are the columns of a trajectories file: a row per body
+// per step, after the step, its number and time since the recording
+// started, body the body's number from 1 in the order they were first
+// recorded.
+var trajectoryHeader = []string{"step", "time", "scene", "body", "x", "y", "vx", "vy", "angle", "angular_velocity"}
+
+// selector is a scene whose bodies can be selected, whose trajectories are
+// recorded rather than all of them.
+type selector interface {
+	selectedBodies() []*cp.Body
+}
+
+// trajectoryRecorder writes the trajectories of the scene's bodies to a CSV
+// file at every step, for tools other than the game to analyze or plot.
+type trajectoryRecorder struct {
+	file *os.File
+	w    *csv.Writer
+	path string
+	// step is the steps recorded, ids the numbers of the bodies.
+	step int
+	ids  map[*cp.Body]int
+}
+
+// RecordTrajectories starts recording to the CSV file at path the position,
+// velocity and angle of the selected bodies at every step from then on, of
+// the first dynamic ones if none are selected. The recording goes on across
+// scenes, until StopTrajectories or the game shuts down.
+func (g *Game) RecordTrajectories(path string) error {
+	g.StopTrajectories()
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	if err := w.Write(trajectoryHeader); err != nil {
+		f.Close()
+		return err
+	}
+	g.trajectories = &trajectoryRecorder{file: f, w: w, path: path, ids: map[*cp.Body]int{}}
+	logGame.Infof("Recording trajectories to %s", path)
+	return nil
+}
+
+// StopTrajectories ends the trajectories being recorded, if any.
+func (g *Game) StopTrajectories() {
+	t := g.trajectories
+	if t == nil {
+		return
+	}
+	g.trajectories = nil
+	t.w.Flush()
+	err := t.w.Error()
+	if cerr := t.file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		logGame.Errorf("Trajectories to %s: %v", t.path, err)
+		return
+	}
+	logGame.Infof("Recorded %d steps of trajectories to %s", t.step, t.path)
+}
+
+// toggleTrajectories starts recording to a new file named after the time,
+// in the working directory, or stops recording.
+func (g *Game) toggleTrajectories() {
+	if g.trajectories != nil {
+		g.StopTrajectories()
+		return
+	}
+	path := "trajectories-" + time.Now().Format("20060102-150405") + ".csv"
+	if err := g.RecordTrajectories(path); err != nil {
+		logGame.Errorf("Trajectories: %v", err)
+	}
+}
+
+// recordTrajectories records the step just made. A file that can't be
+// written any more stops being recorded, the game goes on.
+func (g *Game) recordTrajectories() {
+	t := g.trajectories
+	if t == nil {
+		return
+	}
+	var selected []*cp.Body
+	if scene, ok := g.scene.(selector); ok {
+		selected = scene.selectedBodies()
+	}
+	t.step++
+	err := g.inSpace(func(space *cp.Space) {
+		bodies := selected
+		if len(bodies) == 0 {
+			space.EachBody(func(body *cp.Body) {
+				if body.GetType() == cp.BODY_DYNAMIC && len(bodies) < trajectoryMaxBodies {
+					bodies = append(bodies, body)
+				}
+			})
+		}
+		for _, body := range bodies {
+			t.write(g, body)
+		}
+	})
+	if err == nil {
+		err = t.w.Error()
+	}
+	if err != nil {
+		logGame.Errorf("Trajectories to %s: %v", t.path, err)
+		g.StopTrajectories()
+	}
+}
+
+// write writes the row of a body.
+func (t *trajectoryRecorder) write(g *Game, body *cp.Body) {
+	id, ok := t.ids[body]
+	if !ok {
+		id = len(t.ids) + 1
+		t.ids[body] = id
+	}
+	pos, vel := body.Position(), body.Velocity()
+	t.w.Write([]string{
+		strconv.Itoa(t.step), ftoa(float64(t.step) * physicsStep), scenes[g.index].name, strconv.Itoa(id),
+		ftoa(pos.X), ftoa(pos.Y), ftoa(vel.X), ftoa(vel.Y), ftoa(body.Angle()), ftoa(body.AngularVelocity()),
+	})
+}
+
+func ftoa(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// selectedBodies are the bodies of the selection, in the order they were
+// added.
+func (s *Sandbox) selectedBodies() []*cp.Body {
+	bodies := make([]*cp.Body, 0, len(s.selection))
+	for body := range s.selection {
+		bodies = append(bodies, body)
+	}
+	sort.Slice(bodies, func(i, j int) bool { return s.ids[bodies[i]] < s.ids[bodies[j]] })
+	return bodies
+}
@@ -0,0 +1,222 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+)
+
+const (
+	// tuningRowHeight is the height of a slider's row, and tuningLabelWidth
+	// and tuningTrackWidth the widths of its name and of its track, in
+	// pixels. The value is printed right of the track.
+	tuningRowHeight  = 20
+	tuningLabelWidth = 84
+	tuningTrackWidth = 140
+	tuningWidth      = tuningLabelWidth + tuningTrackWidth + 64
+	// tuningGrip is how far above and below its track a slider can be
+	// grabbed.
+	tuningGrip = 6
+)
+
+// tuningSlider is a parameter of the scene's space, or of the game, that the
+// tuning panel has a slider for and the console's set command sets: name is
+// its name for set, label the slider's.
+type tuningSlider struct {
+	name, label string
+	min, max    float64
+	// whole is set for the parameters that only take whole numbers.
+	whole bool
+	get   func(g *Game, space *cp.Space) float64
+	set   func(g *Game, space *cp.Space, v float64)
+}
+
+var tuningSliders = []tuningSlider{
+	{
+		name: "gravity_x", label: "Gravity X", min: -1000, max: 1000,
+		get: func(_ *Game, space *cp.Space) float64 { return space.Gravity().X },
+		set: func(_ *Game, space *cp.Space, v float64) {
+			space.SetGravity(cp.Vector{X: v, Y: space.Gravity().Y})
+		},
+	},
+	{
+		name: "gravity_y", label: "Gravity Y", min: -1000, max: 1000,
+		get: func(_ *Game, space *cp.Space) float64 { return space.Gravity().Y },
+		set: func(_ *Game, space *cp.Space, v float64) {
+			space.SetGravity(cp.Vector{X: space.Gravity().X, Y: v})
+		},
+	},
+	{
+		name: "friction", label: "Friction", min: 0, max: 2,
+		get: func(_ *Game, space *cp.Space) float64 { return shapeParam(space, (*cp.Shape).Friction) },
+		set: func(_ *Game, space *cp.Space, v float64) {
+			space.EachShape(func(shape *cp.Shape) { shape.SetFriction(v) })
+		},
+	},
+	{
+		name: "elasticity", label: "Elasticity", min: 0, max: 1.5,
+		get: func(_ *Game, space *cp.Space) float64 { return shapeParam(space, (*cp.Shape).Elasticity) },
+		set: func(_ *Game, space *cp.Space, v float64) {
+			space.EachShape(func(shape *cp.Shape) { shape.SetElasticity(v) })
+		},
+	},
+	{
+		name: "damping", label: "Damping", min: 0, max: 1,
+		get: func(_ *Game, space *cp.Space) float64 { return space.Damping() },
+		set: func(_ *Game, space *cp.Space, v float64) { space.SetDamping(v) },
+	},
+	{
+		name: "iterations", label: "Iterations", min: 1, max: settingsMaxIterations, whole: true,
+		get: func(_ *Game, space *cp.Space) float64 { return float64(space.Iterations) },
+		set: func(_ *Game, space *cp.Space, v float64) { space.Iterations = uint(v) },
+	},
+	{
+		name: "time_scale", label: "Time scale", min: 0, max: 3,
+		get: func(g *Game, _ *cp.Space) float64 { return g.timeScale },
+		set: func(g *Game, _ *cp.Space, v float64) { g.timeScale = v },
+	},
+}
+
+// shapeParam returns a parameter of the first dynamic shape of the space,
+// of the first shape if none is dynamic: the shapes may differ, setting it
+// sets them all.
+func shapeParam(space *cp.Space, param func(shape *cp.Shape) float64) float64 {
+	var first, dynamic *cp.Shape
+	space.EachShape(func(shape *cp.Shape) {
+		if first == nil {
+			first = shape
+		}
+		if dynamic == nil && shape.Body().GetType() == cp.BODY_DYNAMIC {
+			dynamic = shape
+		}
+	})
+	switch {
+	case dynamic != nil:
+		return param(dynamic)
+	case first != nil:
+		return param(first)
+	}
+	return 0
+}
+
+// findSlider returns the slider named name, for the console.
+func findSlider(name string) (tuningSlider, bool) {
+	for _, s := range tuningSliders {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return tuningSlider{}, false
+}
+
+// tuningPanel has a slider for each of tuningSliders, bound to the scene's
+// space: it shows their values as they are, and dragging one sets it, live.
+type tuningPanel struct {
+	visible bool
+	// dragging is whether the slider at drag is being dragged.
+	dragging bool
+	drag     int
+	// values are those of the sliders at the last update, nil if the scene
+	// has no space.
+	values []float64
+}
+
+// origin is the top left corner of the panel, in the bottom right corner of
+// the screen above the help line.
+func (p *tuningPanel) origin() (x, y float64) {
+	return ScreenWidth - tuningWidth - 8, ScreenHeight - 24 - float64(len(tuningSliders))*tuningRowHeight
+}
+
+// track returns the left end of the track of slider i, at its middle.
+func (p *tuningPanel) track(i int) (x, y float64) {
+	x, y = p.origin()
+	return x + tuningLabelWidth, y + float64(i)*tuningRowHeight + tuningRowHeight/2
+}
+
+// sliderAt returns the slider whose track is under the screen point q, if
+// any.
+func (p *tuningPanel) sliderAt(q cp.Vector) (int, bool) {
+	for i := range tuningSliders {
+		x, y := p.track(i)
+		if q.X >= x-tuningGrip && q.X <= x+tuningTrackWidth+tuningGrip && math.Abs(q.Y-y) <= tuningGrip {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// update drags the sliders and reads their values. It reports whether the
+// mouse was just pressed on the panel, which the scene shouldn't see.
+func (p *tuningPanel) update(g *Game) (pressed bool) {
+	if !p.visible {
+		p.dragging = false
+		return false
+	}
+	cursor := input.Pointer.Position()
+	if input.Pointer.IsJustPressed() {
+		x, y := p.origin()
+		pressed = cursor.X >= x && cursor.Y >= y && cursor.Y < y+float64(len(tuningSliders))*tuningRowHeight
+		p.drag, p.dragging = p.sliderAt(cursor)
+	}
+	if !input.Pointer.IsPressed() {
+		p.dragging = false
+	}
+	err := g.inSpace(func(space *cp.Space) {
+		if p.dragging {
+			s := tuningSliders[p.drag]
+			x, _ := p.track(p.drag)
+			v := s.min + (cursor.X-x)/tuningTrackWidth*(s.max-s.min)
+			v = math.Max(s.min, math.Min(s.max, v))
+			if s.whole {
+				v = math.Round(v)
+			}
+			s.set(g, space, v)
+		}
+		p.values = p.values[:0]
+		for _, s := range tuningSliders {
+			p.values = append(p.values, s.get(g, space))
+		}
+	})
+	if err != nil {
+		p.values, p.dragging = nil, false
+	}
+	return pressed
+}
+
+func (p *tuningPanel) draw(screen *ebiten.Image) {
+	if !p.visible {
+		return
+	}
+	x, y := p.origin()
+	ebitenutil.DrawRect(screen, x-4, y-4, tuningWidth+8, float64(len(tuningSliders))*tuningRowHeight+8, color.RGBA{A: 0xc0})
+	if p.values == nil {
+		ebitenutil.DebugPrintAt(screen, "The scene has no space to tune.", int(x), int(y))
+		return
+	}
+	for i, s := range tuningSliders {
+		tx, ty := p.track(i)
+		ebitenutil.DebugPrintAt(screen, s.label, int(x), int(ty)-8)
+		ebitenutil.DrawRect(screen, tx, ty-1, tuningTrackWidth, 2, colornames.Gray)
+		v := p.values[i]
+		// Values set out of range, e.g. from the console, pin the knob to
+		// an end.
+		knob := tx + math.Max(0, math.Min(1, (v-s.min)/(s.max-s.min)))*tuningTrackWidth
+		clr := colornames.White
+		if p.dragging && p.drag == i {
+			clr = colornames.Yellow
+		}
+		ebitenutil.DrawRect(screen, knob-3, ty-6, 6, 12, clr)
+		value := fmt.Sprintf("%.2f", v)
+		if s.whole {
+			value = fmt.Sprint(int(v))
+		}
+		ebitenutil.DebugPrintAt(screen, value, int(tx)+tuningTrackWidth+10, int(ty)-8)
+	}
+}
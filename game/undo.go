@@ -0,0 +1,130 @@
+package game
+
+import (
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+// sandboxUndoLimit is how many edits can be undone.
+const sandboxUndoLimit = 100
+
+// sandboxEdit is a change made to the sandbox's bodies, as their states
+// before and after it, by id. A body missing from before was added by the
+// edit, one missing from after was deleted.
+type sandboxEdit struct {
+	before, after map[int]sandboxCopy
+}
+
+// register gives the body an id. Undoing a delete brings back a new body,
+// the edits refer to bodies by ids so they still find it.
+func (s *Sandbox) register(body *cp.Body) {
+	s.nextID++
+	s.ids[body], s.bodies[s.nextID] = s.nextID, body
+}
+
+// states returns the current states of the bodies, by id.
+func (s *Sandbox) states(bodies ...*cp.Body) map[int]sandboxCopy {
+	states := map[int]sandboxCopy{}
+	for _, body := range bodies {
+		states[s.ids[body]] = sandboxCopy{state: physics.SaveBody(body), sprite: s.sprites.Image(body)}
+	}
+	return states
+}
+
+// record adds an edit to undo, forgetting the undone ones.
+func (s *Sandbox) record(edit sandboxEdit) {
+	s.edited = true
+	s.undos = append(s.undos, edit)
+	if len(s.undos) > sandboxUndoLimit {
+		s.undos = s.undos[1:]
+	}
+	s.redos = nil
+}
+
+// recordMove records the move of the bodies grabbed at the press, if they
+// moved.
+func (s *Sandbox) recordMove() {
+	edit := sandboxEdit{before: s.moving, after: map[int]sandboxCopy{}}
+	moved := false
+	for id, before := range s.moving {
+		body, ok := s.bodies[id]
+		if !ok {
+			continue
+		}
+		edit.after[id] = s.states(body)[id]
+		moved = moved || body.Position().Distance(before.state.Position) >= sandboxTapSlop
+	}
+	s.moving = nil
+	if moved {
+		s.record(edit)
+	}
+}
+
+func (s *Sandbox) undo() {
+	s.cancel()
+	if len(s.undos) == 0 {
+		return
+	}
+	edit := s.undos[len(s.undos)-1]
+	s.undos = s.undos[:len(s.undos)-1]
+	s.edited = true
+	s.setStates(edit, edit.before)
+	s.redos = append(s.redos, edit)
+}
+
+func (s *Sandbox) redo() {
+	s.cancel()
+	if len(s.redos) == 0 {
+		return
+	}
+	edit := s.redos[len(s.redos)-1]
+	s.redos = s.redos[:len(s.redos)-1]
+	s.edited = true
+	s.setStates(edit, edit.after)
+	s.undos = append(s.undos, edit)
+}
+
+// setStates puts the bodies of the edit in the given states: it removes
+// those without one, brings back those that were removed, and moves the
+// others.
+func (s *Sandbox) setStates(edit sandboxEdit, states map[int]sandboxCopy) {
+	ids := map[int]bool{}
+	for id := range edit.before {
+		ids[id] = true
+	}
+	for id := range edit.after {
+		ids[id] = true
+	}
+	for id := range ids {
+		c, ok := states[id]
+		body, exists := s.bodies[id]
+		switch {
+		case !ok && exists:
+			s.remove(body)
+		case ok && exists:
+			c.state.Apply(body)
+			// In the editor the space doesn't step to catch up with the move.
+			physics.Reindex(s.space, body)
+		case ok && !exists:
+			body = c.state.Restore(s.space)
+			if c.sprite != nil {
+				s.sprites.Add(body, c.sprite)
+			}
+			s.ids[body], s.bodies[id] = id, body
+		}
+	}
+}
+
+func (s *Sandbox) updateHistory() {
+	if !input.IsActionPressed(input.ActionControl) {
+		return
+	}
+	switch {
+	case input.IsActionJustPressed(input.ActionUndo):
+		s.undo()
+	case input.IsActionJustPressed(input.ActionRedo):
+		s.redo()
+	}
+}
@@ -0,0 +1,165 @@
+package game
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"golang.org/x/image/colornames"
+)
+
+const (
+	// videoFPS is the frame rate of the videos, whatever the game's.
+	videoFPS = 30
+	// videoQueue is how many frames may wait for ffmpeg: a slower ffmpeg
+	// drops frames rather than slow the game down.
+	videoQueue = 30
+)
+
+// videoCodecs are the ffmpeg output options of each format of the config.
+var videoCodecs = map[string][]string{
+	"mp4":  {"-c:v", "libx264", "-pix_fmt", "yuv420p", "-preset", "fast"},
+	"webm": {"-c:v", "libvpx-vp9", "-b:v", "0", "-crf", "32"},
+}
+
+// videoRecorder streams the frames drawn, as raw RGBA, to an ffmpeg process
+// encoding them to a video, if ffmpeg is on the PATH. Its frames are written
+// to ffmpeg on a goroutine of their own.
+type videoRecorder struct {
+	path   string
+	cmd    *exec.Cmd
+	frames chan *image.RGBA
+	// done is closed once the writer is done with ffmpeg's input, err is
+	// why it stopped early, if it did.
+	done chan struct{}
+	err  error
+	// last is when the last frame was captured, dropped how many frames
+	// ffmpeg was too slow for.
+	last    time.Time
+	dropped int
+}
+
+// toggleVideo starts recording a video named after the time, in the working
+// directory, or stops the one being recorded.
+func (g *Game) toggleVideo() {
+	if g.video != nil {
+		g.stopVideo()
+		return
+	}
+	format := cfg.VideoFormat
+	path := "video-" + time.Now().Format("20060102-150405") + "." + format
+	v, err := startVideo(path, format)
+	if err != nil {
+		logRender.Errorf("Video: %v", err)
+		g.toast.show("Video not recorded: " + err.Error())
+		return
+	}
+	g.video = v
+	logRender.Infof("Recording a video to %s", path)
+	g.toast.show("Recording a video to " + path)
+}
+
+// startVideo starts ffmpeg encoding to path.
+func startVideo(path, format string) (*videoRecorder, error) {
+	codec, ok := videoCodecs[format]
+	if !ok {
+		return nil, fmt.Errorf("no video format %q, mp4 or webm", format)
+	}
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg not found: %w", err)
+	}
+	args := []string{
+		"-loglevel", "error", "-y",
+		"-f", "rawvideo", "-pixel_format", "rgba",
+		"-video_size", fmt.Sprintf("%dx%d", ScreenWidth, ScreenHeight),
+		"-framerate", fmt.Sprint(videoFPS), "-i", "-",
+	}
+	cmd := exec.Command(ffmpeg, append(append(args, codec...), path)...)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	v := &videoRecorder{
+		path:   path,
+		cmd:    cmd,
+		frames: make(chan *image.RGBA, videoQueue),
+		done:   make(chan struct{}),
+	}
+	go v.write(in)
+	return v, nil
+}
+
+// write writes the frames to ffmpeg's input until they end, and closes it.
+func (v *videoRecorder) write(in io.WriteCloser) {
+	defer close(v.done)
+	defer in.Close()
+	for frame := range v.frames {
+		if v.err != nil {
+			continue
+		}
+		if _, err := in.Write(frame.Pix); err != nil {
+			v.err = err
+		}
+	}
+}
+
+// capture queues the frame drawn on screen for ffmpeg, at the video's frame
+// rate.
+func (v *videoRecorder) capture(screen *ebiten.Image) {
+	now := time.Now()
+	if !v.last.IsZero() && now.Sub(v.last) < time.Second/videoFPS {
+		return
+	}
+	v.last = now
+	frame := image.NewRGBA(screen.Bounds())
+	draw.Draw(frame, frame.Bounds(), screen, image.Point{}, draw.Src)
+	select {
+	case v.frames <- frame:
+	default:
+		v.dropped++
+	}
+}
+
+// stopVideo ends the video being recorded, if any, and waits for ffmpeg to
+// finish it.
+func (g *Game) stopVideo() {
+	v := g.video
+	if v == nil {
+		return
+	}
+	g.video = nil
+	close(v.frames)
+	<-v.done
+	err := v.cmd.Wait()
+	if v.err != nil {
+		err = v.err
+	}
+	if err != nil {
+		logRender.Errorf("Video to %s: %v", v.path, err)
+		g.toast.show("Video not saved: " + err.Error())
+		return
+	}
+	if v.dropped > 0 {
+		logRender.Warnf("Video to %s: dropped %d frames, ffmpeg was too slow", v.path, v.dropped)
+	}
+	logRender.Infof("Video saved to %s", v.path)
+	g.toast.show("Video saved to " + v.path)
+}
+
+// draw draws the recording indicator, after the frame was captured.
+func (v *videoRecorder) draw(screen *ebiten.Image) {
+	// Left of the trajectories' indicator, the dot blinking once a second.
+	if time.Now().UnixNano()/int64(time.Second/2)%2 == 0 {
+		ebitenutil.DrawRect(screen, ScreenWidth-240, 3, 10, 10, colornames.Red)
+	}
+	ebitenutil.DebugPrintAt(screen, "REC", ScreenWidth-226, 0)
+}
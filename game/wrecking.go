@@ -0,0 +1,159 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/render"
+)
+
+const (
+	wreckingGravity    = 300
+	wreckingArmSpeed   = 200
+	wreckingArmMinX    = 100
+	wreckingArmMaxX    = 450
+	wreckingArmMinY    = 40
+	wreckingArmMaxY    = 320
+	wreckingLinks      = 8
+	wreckingLinkLength = 20
+	wreckingLinkRadius = 4
+	wreckingLinkMass   = 1
+	wreckingBallRadius = 30
+	wreckingBallMass   = 60
+	wreckingBoxSize    = 40
+	wreckingTowerRows  = 8
+)
+
+// WreckingBall swings a heavy ball on a chain from a crane arm into a tower
+// of boxes. The arm is a kinematic body: the user sets its velocity and the
+// chain follows through the joints.
+type WreckingBall struct {
+	baseScene
+
+	space *cp.Space
+	arm   *cp.Body
+	ball  *cp.Body
+	boxes []*cp.Body
+	start []cp.Vector
+}
+
+func init() {
+	RegisterDemo("Wrecking ball", "a crane swinging a ball on a chain", func() Scene { return NewWreckingBall() })
+}
+
+func NewWreckingBall() *WreckingBall {
+	space := newSpace()
+	space.SetGravity(cp.Vector{Y: wreckingGravity})
+	space.Iterations = 20
+
+	w := &WreckingBall{space: space}
+
+	physics.AddStaticSegment(space, cp.Vector{Y: groundY}, cp.Vector{X: ScreenWidth, Y: groundY}, 0)
+
+	// The chain's links overlap where they are joined, they share a group
+	// with the arm to not collide with each other.
+	chain := cp.NewShapeFilter(6, cp.ALL_CATEGORIES, cp.ALL_CATEGORIES)
+
+	w.arm = space.AddBody(cp.NewKinematicBody())
+	w.arm.SetPosition(cp.Vector{X: 200, Y: 150})
+	arm := space.AddShape(cp.NewBox2(w.arm, cp.BB{L: -200, B: -6, R: 0, T: 6}, 0))
+	arm.SetFilter(chain)
+	arm.UserData = colornames.Gold
+
+	prev, anchor := w.arm, cp.Vector{}
+	pos := w.arm.Position()
+	for i := 0; i < wreckingLinks; i++ {
+		pos = pos.Add(cp.Vector{Y: wreckingLinkLength})
+		moment := cp.MomentForCircle(wreckingLinkMass, 0, wreckingLinkRadius, cp.Vector{})
+		link := space.AddBody(cp.NewBody(wreckingLinkMass, moment))
+		link.SetPosition(pos)
+		shape := space.AddShape(cp.NewCircle(link, wreckingLinkRadius, cp.Vector{}))
+		shape.SetFilter(chain)
+		shape.UserData = colornames.Gray
+		// A slide joint is a rope: it keeps the links at most a link length
+		// apart but lets them get closer.
+		space.AddConstraint(cp.NewSlideJoint(prev, link, anchor, cp.Vector{}, 0, wreckingLinkLength))
+		prev, anchor = link, cp.Vector{}
+	}
+
+	pos = pos.Add(cp.Vector{Y: wreckingBallRadius})
+	moment := cp.MomentForCircle(wreckingBallMass, 0, wreckingBallRadius, cp.Vector{})
+	w.ball = space.AddBody(cp.NewBody(wreckingBallMass, moment))
+	w.ball.SetPosition(pos)
+	shape := space.AddShape(cp.NewCircle(w.ball, wreckingBallRadius, cp.Vector{}))
+	shape.SetFriction(0.5)
+	shape.SetFilter(chain)
+	shape.UserData = colornames.Dimgray
+	space.AddConstraint(cp.NewSlideJoint(prev, w.ball, cp.Vector{}, cp.Vector{Y: -wreckingBallRadius}, 0, wreckingLinkLength))
+
+	// A tower two boxes wide, in the reach of the ball.
+	for row := 0; row < wreckingTowerRows; row++ {
+		for col := 0; col < 2; col++ {
+			pos := cp.Vector{
+				X: 580 + float64(col)*wreckingBoxSize,
+				Y: groundY - wreckingBoxSize/2 - float64(row)*wreckingBoxSize,
+			}
+			box, _ := physics.AddBox(space, pos, wreckingBoxSize, wreckingBoxSize)
+			w.boxes = append(w.boxes, box)
+			w.start = append(w.start, pos)
+		}
+	}
+
+	return w
+}
+
+// fallen counts the boxes that were knocked away from where they started.
+func (w *WreckingBall) fallen() int {
+	n := 0
+	for i, box := range w.boxes {
+		if box.Position().Distance(w.start[i]) > wreckingBoxSize {
+			n++
+		}
+	}
+	return n
+}
+
+func (w *WreckingBall) Update(dt float64) error {
+	var v cp.Vector
+	if input.IsActionPressed(input.ActionLeft) {
+		v.X -= wreckingArmSpeed
+	}
+	if input.IsActionPressed(input.ActionRight) {
+		v.X += wreckingArmSpeed
+	}
+	if input.IsActionPressed(input.ActionUp) {
+		v.Y -= wreckingArmSpeed
+	}
+	if input.IsActionPressed(input.ActionDown) {
+		v.Y += wreckingArmSpeed
+	}
+
+	// Stop the arm at its limits, the velocity is what moves it so it can't
+	// simply be clamped after the step.
+	next := w.arm.Position().Add(v.Mult(dt))
+	if next.X < wreckingArmMinX || next.X > wreckingArmMaxX {
+		v.X = 0
+	}
+	if next.Y < wreckingArmMinY || next.Y > wreckingArmMaxY {
+		v.Y = 0
+	}
+	w.arm.SetVelocityVector(v)
+
+	w.space.Step(dt)
+	return nil
+}
+
+func (w *WreckingBall) Draw(screen *ebiten.Image) {
+	render.DrawSpace(screen, w.space, colornames.Burlywood)
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(
+		"Ball speed: %5.1f px/s. Boxes knocked down: %d/%d.\nLeft/Right: move the crane, Up/Down: raise and lower it.",
+		w.ball.Velocity().Length(), w.fallen(), len(w.boxes),
+	))
+}
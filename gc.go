@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"runtime/debug"
+	"strconv"
+)
+
+// The garbage collector flags, shared by the game and the headless build:
+// GOGC and GOMEMLIMIT from the command line, to tune the memory of a big
+// scene without setting the environment.
+var (
+	gcPercent = flag.String("gogc", "", "garbage collection target percentage, as GOGC, or off; the environment's by default")
+	memLimit  = flag.Int("memlimit", 0, "soft memory limit in MiB, as GOMEMLIMIT, which the collector works harder to stay under; the environment's by default")
+)
+
+// setupGC applies the garbage collector flags, once parsed.
+func setupGC() {
+	if *gcPercent != "" {
+		percent := -1
+		if *gcPercent != "off" {
+			var err error
+			if percent, err = strconv.Atoi(*gcPercent); err != nil || percent < 0 {
+				logMain.Fatalf("-gogc: %q is neither a percentage nor off", *gcPercent)
+			}
+		}
+		debug.SetGCPercent(percent)
+		logMain.Infof("Garbage collection target: %s", *gcPercent)
+	}
+	if *memLimit != 0 {
+		if *memLimit < 0 {
+			logMain.Fatalf("-memlimit: the limit can't be negative")
+		}
+		if err := setMemoryLimit(int64(*memLimit) << 20); err != nil {
+			logMain.Fatalf("-memlimit: %v", err)
+		}
+		logMain.Infof("Memory limit: %d MiB", *memLimit)
+	}
+}
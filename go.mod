@@ -5,6 +5,8 @@ go 1.18
 require (
 	github.com/hajimehoshi/ebiten/v2 v2.3.4
 	github.com/jakecoffman/cp v1.1.0
+	github.com/yohamta/donburi v1.4.4
+	github.com/yuin/gopher-lua v1.1.1
 	golang.org/x/image v0.0.0-20220321031419-a8550c1d254a
 )
 
@@ -16,4 +18,5 @@ require (
 	golang.org/x/mobile v0.0.0-20220518205345-8578da9835fd // indirect
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c // indirect
 	golang.org/x/sys v0.0.0-20220408201424-a24fb2fb8a0f // indirect
+	golang.org/x/text v0.3.7 // indirect
 )
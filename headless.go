@@ -0,0 +1,117 @@
+//go:build headless
+
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/config"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/logging"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+var logPhysics = logging.New("physics")
+
+// Built with the headless tag, the binary steps the Hello Chipmunk space
+// without ebiten, and so without a window or a display, and prints where
+// the ball is every simulated second:
+//
+//	go run -tags headless . -seconds 10 -out ball.csv
+//
+// With -serve, it steps it, or a level, in real time instead, for the game
+// to render from another machine with -join, see serve.go.
+
+func main() {
+	c, err := config.Load(config.File)
+	if err != nil {
+		logMain.Warnf("Config: %v, using the defaults", err)
+	}
+	seconds := flag.Float64("seconds", c.SimulateMaxSeconds, "simulated seconds")
+	tps := flag.Int("tps", 60, "steps per simulated second")
+	flag.Float64Var(&c.Gravity.Y, "gravity", c.Gravity.Y, "downward gravity")
+	out := flag.String("out", "", "CSV file to export the ball's state at every step to")
+	hash := flag.Bool("hash", false, "print a hash of the space's state every simulated second, to compare runs")
+	serveAddr := flag.String("serve", "", "address to serve the simulation on over WebSocket in real time, for the game's -join to render, e.g. :8091")
+	levelFile := flag.String("level", "", "level file to simulate instead of Hello Chipmunk, e.g. one the sandbox saved")
+	flag.Parse()
+	setupLogging()
+	setupGC()
+	startPprof()
+	defer logging.Close()
+	startTrace()
+	defer stopTracing()
+	if *tps <= 0 {
+		logMain.Fatalf("The steps per second must be positive")
+	}
+	if *levelFile != "" && *serveAddr == "" {
+		logMain.Fatalf("-level is only simulated with -serve, the others follow the Hello Chipmunk ball")
+	}
+
+	var w *csv.Writer
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			logMain.Fatalf("%v", err)
+		}
+		defer f.Close()
+		w = csv.NewWriter(f)
+		w.Write([]string{"time", "x", "y", "vx", "vy"})
+	}
+
+	space := physics.NewHelloSpace(c.HelloParams)
+	body, shape := physics.NewHelloBall(c.HelloParams, physics.HelloStart)
+	space.AddBody(body)
+	space.AddShape(shape)
+	if *levelFile != "" {
+		level, err := physics.ReadLevel(*levelFile)
+		if err != nil {
+			logMain.Fatalf("-level: %v", err)
+		}
+		space = cp.NewSpace()
+		level.Build(space)
+	}
+	if *serveAddr != "" {
+		if err := serve(*serveAddr, space, *tps, allowedOrigins()); err != nil {
+			logMain.Fatalf("-serve: %v", err)
+		}
+		return
+	}
+
+	// It is *highly* recommended to use a fixed size time step.
+	timeStep := 1.0 / float64(*tps)
+	steps := int(*seconds * float64(*tps))
+	for i := 0; i <= steps; i++ {
+		time := float64(i) * timeStep
+		pos, vel := body.Position(), body.Velocity()
+		if i%*tps == 0 || i == steps {
+			fmt.Printf(
+				"Time is %5.2f. ballBody is at (%5.2f, %5.2f). It's velocity is (%5.2f, %5.2f)\n",
+				time, pos.X, pos.Y, vel.X, vel.Y,
+			)
+			if *hash {
+				fmt.Printf("Hash is %016x\n", physics.Hash(space))
+			}
+		}
+		if w != nil {
+			w.Write([]string{ftoa(time), ftoa(pos.X), ftoa(pos.Y), ftoa(vel.X), ftoa(vel.Y)})
+		}
+		space.Step(timeStep)
+		logPhysics.Debugf("Step %d: ball at %v, velocity %v", i, body.Position(), body.Velocity())
+	}
+	if w != nil {
+		w.Flush()
+		if err := w.Error(); err != nil {
+			logMain.Fatalf("%v", err)
+		}
+	}
+}
+
+func ftoa(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,284 @@
+// Package input turns the keyboard, gamepads and touch screens into actions,
+// which the scenes ask about rather than keys, so that they can be remapped.
+package input
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/logging"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/storage"
+)
+
+var logInput = logging.New("input")
+
+// Action is something the player does. Scenes ask whether an action is
+// pressed rather than a key, and the keybindings tell which keys do what.
+type Action string
+
+const (
+	ActionLeft         Action = "left"
+	ActionRight        Action = "right"
+	ActionUp           Action = "up"
+	ActionDown         Action = "down"
+	ActionJump         Action = "jump"
+	ActionLaunch       Action = "launch"
+	ActionSpawn        Action = "spawn"
+	ActionHeavy        Action = "heavy"
+	ActionGravity      Action = "gravity"
+	ActionDissolve     Action = "dissolve"
+	ActionDraw         Action = "draw"
+	ActionFlipperLeft  Action = "flipper_left"
+	ActionFlipperRight Action = "flipper_right"
+	ActionNextScene    Action = "next_scene"
+	ActionPrevScene    Action = "prev_scene"
+	ActionRestart      Action = "restart"
+	ActionPause        Action = "pause"
+	ActionDebugDraw    Action = "debug_draw"
+	ActionPerf         Action = "perf"
+	ActionStats        Action = "stats"
+	ActionConsole      Action = "console"
+	ActionTune         Action = "tune"
+	ActionPlot         Action = "plot"
+	ActionTrajectories Action = "trajectories"
+	ActionScreenshot   Action = "screenshot"
+	ActionGIF          Action = "gif"
+	ActionVideo        Action = "video"
+	ActionSpikes       Action = "spikes"
+	ActionLog          Action = "log"
+	ActionHeatmap      Action = "heatmap"
+	ActionBroadphase   Action = "broadphase"
+	ActionSettle       Action = "settle"
+	// ActionControl is a modifier, for the Ctrl+ shortcuts.
+	ActionControl  Action = "control"
+	ActionCopy     Action = "copy"
+	ActionPaste    Action = "paste"
+	ActionUndo     Action = "undo"
+	ActionRedo     Action = "redo"
+	ActionRemap    Action = "remap"
+	ActionEditor   Action = "editor"
+	ActionSave     Action = "save"
+	ActionLoad     Action = "load"
+	ActionConfirm  Action = "confirm"
+	ActionMenu     Action = "menu"
+	ActionSettings Action = "settings"
+	ActionQuit     Action = "quit"
+)
+
+// Actions lists the actions in the order of the remapping screen, the scene
+// actions last.
+func Actions() []Action {
+	list := []Action{
+		ActionLeft, ActionRight, ActionUp, ActionDown, ActionJump, ActionLaunch,
+		ActionSpawn, ActionHeavy, ActionGravity, ActionDissolve, ActionDraw,
+		ActionFlipperLeft, ActionFlipperRight, ActionNextScene, ActionPrevScene,
+		ActionRestart, ActionPause, ActionDebugDraw, ActionPerf, ActionStats, ActionConsole, ActionTune, ActionPlot,
+		ActionTrajectories, ActionScreenshot, ActionGIF, ActionVideo, ActionSpikes, ActionLog, ActionHeatmap,
+		ActionBroadphase, ActionSettle,
+		ActionControl, ActionCopy, ActionPaste, ActionUndo, ActionRedo, ActionRemap, ActionEditor,
+		ActionSave, ActionLoad, ActionConfirm, ActionMenu,
+		ActionSettings, ActionQuit,
+	}
+	for i := 0; i < 9; i++ {
+		list = append(list, SceneAction(i))
+	}
+	return list
+}
+
+// SceneAction is the action that jumps straight to the i-th scene, for the
+// first nine.
+func SceneAction(i int) Action {
+	return Action(fmt.Sprintf("scene%d", i+1))
+}
+
+// KeybindingsFile is where the keybindings are read from.
+const KeybindingsFile = "keybindings.json"
+
+// keyBindings maps each action to the keys that trigger it.
+type keyBindings map[Action][]ebiten.Key
+
+func defaultBindings() keyBindings {
+	b := keyBindings{
+		ActionLeft:         {ebiten.KeyArrowLeft, ebiten.KeyA},
+		ActionRight:        {ebiten.KeyArrowRight, ebiten.KeyD},
+		ActionUp:           {ebiten.KeyArrowUp, ebiten.KeyW},
+		ActionDown:         {ebiten.KeyArrowDown, ebiten.KeyS},
+		ActionJump:         {ebiten.KeyArrowUp, ebiten.KeyW, ebiten.KeySpace},
+		ActionLaunch:       {ebiten.KeySpace},
+		ActionSpawn:        {ebiten.KeyB},
+		ActionHeavy:        {ebiten.KeyShift},
+		ActionGravity:      {ebiten.KeyG},
+		ActionDissolve:     {ebiten.KeyD},
+		ActionDraw:         {ebiten.KeyD},
+		ActionFlipperLeft:  {ebiten.KeyZ},
+		ActionFlipperRight: {ebiten.KeyM},
+		ActionNextScene:    {ebiten.KeyPageDown},
+		ActionPrevScene:    {ebiten.KeyPageUp},
+		ActionRestart:      {ebiten.KeyBackspace},
+		ActionPause:        {ebiten.KeyP},
+		ActionDebugDraw:    {ebiten.KeyF3},
+		ActionPerf:         {ebiten.KeyF4},
+		ActionStats:        {ebiten.KeyF5},
+		ActionConsole:      {ebiten.KeyBackquote},
+		ActionTune:         {ebiten.KeyF6},
+		ActionPlot:         {ebiten.KeyF7},
+		ActionTrajectories: {ebiten.KeyF8},
+		ActionScreenshot:   {ebiten.KeyF12},
+		ActionGIF:          {ebiten.KeyF9},
+		ActionVideo:        {ebiten.KeyF10},
+		ActionSpikes:       {ebiten.KeyF11},
+		ActionLog:          {ebiten.KeyTab},
+		ActionHeatmap:      {ebiten.KeyH},
+		ActionBroadphase:   {ebiten.KeyI},
+		ActionSettle:       {ebiten.KeyR},
+		ActionControl:      {ebiten.KeyControl},
+		ActionCopy:         {ebiten.KeyC},
+		ActionPaste:        {ebiten.KeyV},
+		ActionUndo:         {ebiten.KeyZ},
+		ActionRedo:         {ebiten.KeyY},
+		ActionRemap:        {ebiten.KeyF1},
+		ActionEditor:       {ebiten.KeyE},
+		ActionSave:         {ebiten.KeyS},
+		ActionLoad:         {ebiten.KeyL},
+		ActionConfirm:      {ebiten.KeyEnter},
+		ActionMenu:         {ebiten.KeyEscape},
+		ActionSettings:     {ebiten.KeyF2},
+		ActionQuit:         {ebiten.KeyQ},
+	}
+	for i := 0; i < 9; i++ {
+		b[SceneAction(i)] = []ebiten.Key{ebiten.KeyDigit1 + ebiten.Key(i)}
+	}
+	return b
+}
+
+// bindings are the keybindings in use.
+var bindings = defaultBindings()
+
+// keyNames maps the lowercase names of the keys, as ebiten prints them, back
+// to the keys.
+var keyNames = func() map[string]ebiten.Key {
+	names := map[string]ebiten.Key{}
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		names[strings.ToLower(k.String())] = k
+	}
+	return names
+}()
+
+// MarshalJSON writes the bindings with the keys by name, e.g.
+// {"jump": ["ArrowUp", "Space"]}.
+func (b keyBindings) MarshalJSON() ([]byte, error) {
+	named := map[Action][]string{}
+	for a, keys := range b {
+		named[a] = []string{}
+		for _, k := range keys {
+			named[a] = append(named[a], k.String())
+		}
+	}
+	return json.Marshal(named)
+}
+
+// UnmarshalJSON reads bindings written by MarshalJSON. It only replaces the
+// actions present, so a file can rebind a few actions and keep the defaults
+// for the others.
+func (b keyBindings) UnmarshalJSON(data []byte) error {
+	var named map[Action][]string
+	if err := json.Unmarshal(data, &named); err != nil {
+		return err
+	}
+	for a, names := range named {
+		keys := []ebiten.Key{}
+		for _, name := range names {
+			k, ok := keyNames[strings.ToLower(name)]
+			if !ok {
+				return fmt.Errorf("action %s: unknown key %q", a, name)
+			}
+			keys = append(keys, k)
+		}
+		b[a] = keys
+	}
+	return nil
+}
+
+// loadBindings returns the default bindings overridden by those of the file
+// at path, if there is one.
+func loadBindings(path string) (keyBindings, error) {
+	b := defaultBindings()
+	data, err := storage.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return b, err
+	}
+	if err := json.Unmarshal(data, &b); err != nil {
+		return defaultBindings(), fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+// saveBindings writes the bindings to the file at path.
+func saveBindings(path string, b keyBindings) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(path, append(data, '\n'))
+}
+
+// LoadKeybindings loads the keybindings file, falling back to the defaults
+// when it is broken.
+func LoadKeybindings() {
+	b, err := loadBindings(KeybindingsFile)
+	if err != nil {
+		logInput.Warnf("Keybindings: %v, using the defaults", err)
+	}
+	bindings = b
+}
+
+// IsActionPressed reports whether any key bound to the action is held, or
+// one of its touch buttons.
+func IsActionPressed(a Action) bool {
+	if pressed, _ := Touch.state(a); pressed {
+		return true
+	}
+	for _, k := range bindings[a] {
+		if ebiten.IsKeyPressed(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActionJustPressed reports whether any key bound to the action was just
+// pressed, or one of its touch buttons.
+func IsActionJustPressed(a Action) bool {
+	if pressed, was := Touch.state(a); pressed && !was {
+		return true
+	}
+	for _, k := range bindings[a] {
+		if inpututil.IsKeyJustPressed(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActionJustReleased reports whether any key bound to the action was just
+// released, or one of its touch buttons.
+func IsActionJustReleased(a Action) bool {
+	if pressed, was := Touch.state(a); !pressed && was {
+		return true
+	}
+	for _, k := range bindings[a] {
+		if inpututil.IsKeyJustReleased(k) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package input
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/jakecoffman/cp"
+)
+
+const (
+	// gamepadDeadZone is the stick deflection under which it counts as
+	// centered, worn sticks never quite come back to zero.
+	gamepadDeadZone = 0.2
+	// gamepadNoticeTicks is how long a plug or unplug notice stays on screen.
+	gamepadNoticeTicks = 180
+)
+
+// Gamepad buttons, in the standard layout: the face buttons are named by
+// their position so they mean the same on every brand of pad.
+const (
+	GamepadJump      = ebiten.StandardGamepadButtonRightBottom
+	GamepadSpawn     = ebiten.StandardGamepadButtonRightLeft
+	GamepadRestart   = ebiten.StandardGamepadButtonCenterRight
+	GamepadPrevScene = ebiten.StandardGamepadButtonFrontTopLeft
+	GamepadNextScene = ebiten.StandardGamepadButtonFrontTopRight
+)
+
+// gamepadIDs returns the connected gamepads that ebiten knows how to map to
+// the standard layout. It's asked every frame, so pads can come and go.
+func gamepadIDs() []ebiten.GamepadID {
+	var ids []ebiten.GamepadID
+	for _, id := range ebiten.AppendGamepadIDs(nil) {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// GamepadStick returns the left stick of the connected gamepads, with y
+// pointing down like the screen. The first one pushed wins.
+func GamepadStick() cp.Vector {
+	for _, id := range gamepadIDs() {
+		stick := cp.Vector{
+			X: ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal),
+			Y: ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical),
+		}
+		if stick.Length() > gamepadDeadZone {
+			return stick.Clamp(1)
+		}
+	}
+	return cp.Vector{}
+}
+
+// IsGamepadButtonJustPressed reports whether button was just pressed on any
+// connected gamepad.
+func IsGamepadButtonJustPressed(button ebiten.StandardGamepadButton) bool {
+	for _, id := range gamepadIDs() {
+		if inpututil.IsStandardGamepadButtonJustPressed(id, button) {
+			return true
+		}
+	}
+	return false
+}
+
+// GamepadNotice tells when gamepads are plugged in or out.
+type GamepadNotice struct {
+	ids     []ebiten.GamepadID
+	message string
+	ticks   int
+}
+
+func (n *GamepadNotice) Update() {
+	for _, id := range inpututil.AppendJustConnectedGamepadIDs(nil) {
+		n.show(fmt.Sprintf("Gamepad connected: %s", ebiten.GamepadName(id)))
+		if !ebiten.IsStandardGamepadLayoutAvailable(id) {
+			n.show(fmt.Sprintf("Gamepad %s has no standard layout, it is ignored", ebiten.GamepadName(id)))
+		}
+		n.ids = append(n.ids, id)
+	}
+	ids := n.ids[:0]
+	for _, id := range n.ids {
+		if inpututil.IsGamepadJustDisconnected(id) {
+			n.show("Gamepad disconnected")
+			continue
+		}
+		ids = append(ids, id)
+	}
+	n.ids = ids
+
+	if n.ticks > 0 {
+		n.ticks--
+	}
+}
+
+func (n *GamepadNotice) show(message string) {
+	logInput.Infof("%s", message)
+	n.message = message
+	n.ticks = gamepadNoticeTicks
+}
+
+// String is the notice to display, empty when there is none.
+func (n *GamepadNotice) String() string {
+	if n.ticks == 0 {
+		return ""
+	}
+	return n.message
+}
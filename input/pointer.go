@@ -0,0 +1,113 @@
+package input
+
+import (
+	"runtime"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/jakecoffman/cp"
+)
+
+// CursorPosition returns the mouse cursor position on the screen.
+func CursorPosition() cp.Vector {
+	x, y := ebiten.CursorPosition()
+	return cp.Vector{X: float64(x), Y: float64(y)}
+}
+
+// TouchPosition returns the position of a finger on the screen.
+func TouchPosition(id ebiten.TouchID) cp.Vector {
+	x, y := ebiten.TouchPosition(id)
+	return cp.Vector{X: float64(x), Y: float64(y)}
+}
+
+// Wheel returns how many notches the mouse wheel turned vertically since the
+// last update.
+func Wheel() float64 {
+	_, dy := ebiten.Wheel()
+	return dy
+}
+
+// PointerState is what the scenes click and drag with: the mouse's left
+// button, or on a touch screen the finger that touched it first off the
+// touch buttons, a tap being a click, for the scenes to play in a phone's
+// browser as with a mouse.
+type PointerState struct {
+	// touch is the finger, while touching.
+	touch    ebiten.TouchID
+	touching bool
+	pos      cp.Vector
+	// pressed, justPressed and justReleased are the button's or the
+	// finger's at the last update.
+	pressed, justPressed, justReleased bool
+	// captured is set while the cursor is captured, see Capture.
+	captured bool
+	ids      []ebiten.TouchID
+}
+
+// Pointer is the pointer, updated every tick after the touch buttons.
+var Pointer = &PointerState{}
+
+// Update reads the finger, or the mouse if none is down.
+func (p *PointerState) Update() {
+	p.justPressed, p.justReleased = false, false
+	if p.touching {
+		if inpututil.IsTouchJustReleased(p.touch) {
+			p.touching, p.pressed, p.justReleased = false, false, true
+			return
+		}
+		p.pos = TouchPosition(p.touch)
+		return
+	}
+	p.ids = inpututil.AppendJustPressedTouchIDs(p.ids[:0])
+	for _, id := range p.ids {
+		if pos := TouchPosition(id); !Touch.OnButton(pos) {
+			p.touch, p.touching, p.pos = id, true, pos
+			p.pressed, p.justPressed = true, true
+			return
+		}
+	}
+	p.pos = CursorPosition()
+	p.pressed = ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
+	p.justPressed = inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft)
+	p.justReleased = inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft)
+	if p.captured && !p.pressed {
+		ebiten.SetCursorMode(ebiten.CursorModeVisible)
+		p.captured = false
+	}
+}
+
+// Position returns where the pointer is on the screen, or was last for a
+// finger lifted.
+func (p *PointerState) Position() cp.Vector {
+	return p.pos
+}
+
+// IsPressed reports whether the button is held, or the finger down.
+func (p *PointerState) IsPressed() bool {
+	return p.pressed
+}
+
+// IsJustPressed reports whether the button was just pressed, or the screen
+// just touched.
+func (p *PointerState) IsJustPressed() bool {
+	return p.justPressed
+}
+
+// IsJustReleased reports whether the button was just released, or the
+// finger just lifted.
+func (p *PointerState) IsJustReleased() bool {
+	return p.justReleased
+}
+
+// Capture captures the mouse cursor for the drag under way, in a browser:
+// one leaving the canvas would lose the release there, and the cursor is
+// locked to it, hidden and moved by the mouse's movements, until the
+// button is released. A finger, or a window on a desktop, which follows the
+// drag out of it, needs none.
+func (p *PointerState) Capture() {
+	if runtime.GOOS != "js" || p.touching || !p.pressed || p.captured {
+		return
+	}
+	ebiten.SetCursorMode(ebiten.CursorModeCaptured)
+	p.captured = true
+}
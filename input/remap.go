@@ -0,0 +1,119 @@
+package input
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// remapLineHeight is the height of a line of the debug font.
+const remapLineHeight = 16
+
+// RemapScreen is the settings screen to rebind the actions: the arrows move
+// the highlight, Enter waits for the key to bind to the highlighted action.
+// Its own keys are fixed, so that a bad binding can always be undone.
+type RemapScreen struct {
+	actions   []Action
+	cursor    int
+	listening bool
+	// status tells how the last save went.
+	status string
+}
+
+func NewRemapScreen() *RemapScreen {
+	return &RemapScreen{actions: Actions()}
+}
+
+// justPressedKey returns a key just pressed, if any.
+func justPressedKey() (ebiten.Key, bool) {
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if inpututil.IsKeyJustPressed(k) {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// Update returns false once the screen is closed.
+func (r *RemapScreen) Update() bool {
+	if r.listening {
+		key, ok := justPressedKey()
+		switch {
+		case !ok:
+		case key == ebiten.KeyEscape:
+			r.listening = false
+		default:
+			r.bind(key)
+			r.listening = false
+		}
+		return true
+	}
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		return false
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		r.cursor = (r.cursor + len(r.actions) - 1) % len(r.actions)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		r.cursor = (r.cursor + 1) % len(r.actions)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		r.listening = true
+	}
+	return true
+}
+
+// bind binds the highlighted action to key alone, and saves the bindings.
+func (r *RemapScreen) bind(key ebiten.Key) {
+	bindings[r.actions[r.cursor]] = []ebiten.Key{key}
+	if err := saveBindings(KeybindingsFile, bindings); err != nil {
+		r.status = "Not saved: " + err.Error()
+		return
+	}
+	r.status = "Saved to " + KeybindingsFile
+}
+
+func (r *RemapScreen) Draw(screen *ebiten.Image) {
+	w, h := screen.Size()
+	ebitenutil.DrawRect(screen, 0, 0, float64(w), float64(h), color.RGBA{A: 0xe0})
+
+	var b strings.Builder
+	b.WriteString("Keybindings. Up/Down: choose an action, Enter: rebind it, Esc: close.\n")
+	if r.listening {
+		b.WriteString("Press the key for " + string(r.actions[r.cursor]) + ", Esc: cancel.\n")
+	} else {
+		b.WriteString(r.status + "\n")
+	}
+	// The list scrolls to keep the highlight on screen, below the two header
+	// lines.
+	rows := h/remapLineHeight - 2
+	first := 0
+	if len(r.actions) > rows {
+		first = clamp(r.cursor-rows/2, 0, len(r.actions)-rows)
+	}
+	for i := first; i < len(r.actions) && i < first+rows; i++ {
+		a := r.actions[i]
+		marker := " "
+		if i == r.cursor {
+			marker = ">"
+		}
+		var names []string
+		for _, k := range bindings[a] {
+			names = append(names, k.String())
+		}
+		fmt.Fprintf(&b, "%s %-14s %s\n", marker, a, strings.Join(names, ", "))
+	}
+	ebitenutil.DebugPrint(screen, b.String())
+}
+
+func clamp(v, low, high int) int {
+	if v < low {
+		return low
+	}
+	if v > high {
+		return high
+	}
+	return v
+}
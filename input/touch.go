@@ -0,0 +1,120 @@
+package input
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+)
+
+// touchButtonSize is the side of a touch button, big enough for a thumb.
+const touchButtonSize = 48
+
+// touchButton is an on-screen button. Holding it down is like holding a key
+// bound to its actions; a button does what the scene makes of any of them.
+type touchButton struct {
+	label   string
+	bb      cp.BB
+	actions []Action
+	// pressed and was are whether a finger is on it in this update and in the
+	// previous one.
+	pressed, was bool
+}
+
+// TouchControls are the on-screen buttons. They feed the same actions as the
+// keys, so scenes don't have to know about them.
+type TouchControls struct {
+	buttons []*touchButton
+	// visible is set by the first touch, there is no point in covering the
+	// screen with buttons nobody can tap.
+	visible bool
+}
+
+// touchButtonAt returns a button centered on (x, y).
+func touchButtonAt(x, y float64, label string, actions ...Action) *touchButton {
+	const half = touchButtonSize / 2
+	return &touchButton{
+		label:   label,
+		bb:      cp.BB{L: x - half, B: y - half, R: x + half, T: y + half},
+		actions: actions,
+	}
+}
+
+// Touch is the on-screen pad in use, laid out for an 800x600 screen: a d-pad at the bottom left, the A and B
+// buttons at the bottom right and the game buttons at the top right.
+var Touch = &TouchControls{
+	buttons: []*touchButton{
+		touchButtonAt(40, 480, "<", ActionLeft, ActionFlipperLeft),
+		touchButtonAt(140, 480, ">", ActionRight, ActionFlipperRight),
+		touchButtonAt(90, 430, "^", ActionUp),
+		touchButtonAt(90, 530, "v", ActionDown),
+		touchButtonAt(750, 480, "A", ActionJump, ActionLaunch, ActionConfirm),
+		touchButtonAt(690, 510, "B", ActionSpawn, ActionHeavy, ActionGravity, ActionDissolve, ActionDraw),
+		touchButtonAt(600, 50, "<<", ActionPrevScene),
+		touchButtonAt(655, 50, ">>", ActionNextScene),
+		touchButtonAt(710, 50, "R", ActionRestart),
+		touchButtonAt(765, 50, "||", ActionPause),
+	},
+}
+
+func (t *TouchControls) Update() {
+	ids := ebiten.AppendTouchIDs(nil)
+	if len(ids) > 0 {
+		t.visible = true
+	}
+	for _, b := range t.buttons {
+		b.was, b.pressed = b.pressed, false
+		for _, id := range ids {
+			if b.bb.ContainsVect(TouchPosition(id)) {
+				b.pressed = true
+			}
+		}
+	}
+}
+
+// OnButton reports whether p is on a button, if the buttons are shown.
+func (t *TouchControls) OnButton(p cp.Vector) bool {
+	if !t.visible {
+		return false
+	}
+	for _, b := range t.buttons {
+		if b.bb.ContainsVect(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// state reports whether a button of the action is pressed now and whether
+// it was in the previous update.
+func (t *TouchControls) state(a Action) (pressed, was bool) {
+	if !t.visible {
+		return false, false
+	}
+	for _, b := range t.buttons {
+		for _, ba := range b.actions {
+			if ba == a {
+				pressed = pressed || b.pressed
+				was = was || b.was
+			}
+		}
+	}
+	return pressed, was
+}
+
+func (t *TouchControls) Draw(screen *ebiten.Image) {
+	if !t.visible {
+		return
+	}
+	for _, b := range t.buttons {
+		clr := color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0x60}
+		if b.pressed {
+			clr = color.RGBA{R: 0xc0, G: 0xc0, B: 0xc0, A: 0xa0}
+		}
+		ebitenutil.DrawRect(screen, b.bb.L, b.bb.B, b.bb.R-b.bb.L, b.bb.T-b.bb.B, clr)
+		// The debug font is 6x16.
+		center := b.bb.Center()
+		ebitenutil.DebugPrintAt(screen, b.label, int(center.X)-3*len(b.label), int(center.Y)-8)
+	}
+}
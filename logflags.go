@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/logging"
+)
+
+// The logging flags, shared by the game and the headless build.
+var (
+	logSpec = flag.String("log", "info", "log levels: a default level, then tag=level pairs, e.g. warn,physics=debug; the levels are debug, info, warn and error")
+	logFile = flag.String("logfile", "", "file to append the log to, as well as the standard error")
+)
+
+var logMain = logging.New("main")
+
+// setupLogging applies the logging flags, once parsed.
+func setupLogging() {
+	if err := logging.Configure(*logSpec); err != nil {
+		logMain.Fatalf("-log: %v", err)
+	}
+	if *logFile != "" {
+		if err := logging.OpenFile(*logFile); err != nil {
+			logMain.Fatalf("-logfile: %v", err)
+		}
+	}
+}
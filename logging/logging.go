@@ -0,0 +1,216 @@
+// Package logging is the game's leveled logger: each subsystem logs under a
+// tag of its own, e.g. physics, render or input, at a level, debug, info,
+// warn or error, and only the messages at or above the level set for their
+// tag are written. It doesn't depend on ebiten.
+//
+// The levels are set from a spec, as the -log flag gives it: a default
+// level, then tag=level pairs for the tags that differ, e.g.
+// "warn,physics=debug" to trace the physics and only hear from the rest
+// when something's wrong.
+//
+// The last messages written are also kept, for the game to show them over
+// the screen, where there is no terminal to read them in, see Tail.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is how much a message matters.
+type Level int
+
+const (
+	// LevelDebug is for tracing, e.g. every step of the physics.
+	LevelDebug Level = iota
+	// LevelInfo is for what's worth knowing happened, e.g. a reload.
+	LevelInfo
+	// LevelWarn is for what went wrong and was worked around, e.g. a broken
+	// file replaced by the defaults.
+	LevelWarn
+	// LevelError is for what went wrong and wasn't.
+	LevelError
+)
+
+var levelNames = [...]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	if l < 0 || int(l) >= len(levelNames) {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel returns the level of a name, in any case.
+func ParseLevel(s string) (Level, error) {
+	for l, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(l), nil
+		}
+	}
+	return 0, fmt.Errorf("no log level %q, it's one of %s", s, strings.Join(levelNames[:], ", "))
+}
+
+var (
+	mu sync.Mutex
+	// out writes the messages, with the date and time.
+	out = log.New(os.Stderr, "", log.LstdFlags)
+	// level is the level of the tags not in levels.
+	level  = LevelInfo
+	levels = map[string]Level{}
+	// file is the log file, if any, closed by Close.
+	file *os.File
+	// tail are the last messages written, tail[written%TailSize] the next
+	// one, for the game to show them.
+	tail    [TailSize]string
+	written int
+)
+
+// TailSize is how many of the last messages Tail can return.
+const TailSize = 100
+
+// Configure sets the levels from a spec, see the package doc. The levels
+// not in it are left as they are.
+func Configure(spec string) error {
+	def, tags := level, map[string]Level{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		tag, name, ok := strings.Cut(part, "=")
+		if !ok {
+			l, err := ParseLevel(part)
+			if err != nil {
+				return err
+			}
+			def = l
+			continue
+		}
+		l, err := ParseLevel(strings.TrimSpace(name))
+		if err != nil {
+			return fmt.Errorf("%s: %w", tag, err)
+		}
+		tags[strings.ToLower(strings.TrimSpace(tag))] = l
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	level = def
+	for tag, l := range tags {
+		levels[tag] = l
+	}
+	return nil
+}
+
+// OpenFile writes the messages to the file at path, appended, as well as to
+// the standard error, until Close.
+func OpenFile(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if file != nil {
+		file.Close()
+	}
+	file = f
+	out.SetOutput(io.MultiWriter(os.Stderr, f))
+	return nil
+}
+
+// Close closes the log file, if any, going back to the standard error.
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if file == nil {
+		return nil
+	}
+	err := file.Close()
+	file = nil
+	out.SetOutput(os.Stderr)
+	return err
+}
+
+// Logger logs the messages of a subsystem, under its tag.
+type Logger struct {
+	tag string
+}
+
+// New returns the logger of the subsystem tag, a short lower case name.
+func New(tag string) *Logger {
+	return &Logger{tag: strings.ToLower(tag)}
+}
+
+// Enabled reports whether the messages at level l are written, to skip
+// working out those that aren't.
+func (lg *Logger) Enabled(l Level) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	threshold, ok := levels[lg.tag]
+	if !ok {
+		threshold = level
+	}
+	return l >= threshold
+}
+
+func (lg *Logger) logf(l Level, format string, v ...interface{}) {
+	if !lg.Enabled(l) {
+		return
+	}
+	msg := fmt.Sprintf(format, v...)
+	mu.Lock()
+	defer mu.Unlock()
+	write(l, lg.tag, msg)
+}
+
+// write writes a message, mu held, and keeps it for Tail.
+func write(l Level, tag, msg string) {
+	line := fmt.Sprintf("%-5s [%s] %s", strings.ToUpper(l.String()), tag, msg)
+	out.Print(line)
+	tail[written%TailSize] = time.Now().Format("15:04:05 ") + line
+	written++
+}
+
+// Tail returns the last n messages written, at most TailSize of them, the
+// oldest first, with the time but not the date.
+func Tail(n int) []string {
+	mu.Lock()
+	defer mu.Unlock()
+	if n > written {
+		n = written
+	}
+	if n > TailSize {
+		n = TailSize
+	}
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = tail[(written-n+i)%TailSize]
+	}
+	return lines
+}
+
+func (lg *Logger) Debugf(format string, v ...interface{}) { lg.logf(LevelDebug, format, v...) }
+func (lg *Logger) Infof(format string, v ...interface{})  { lg.logf(LevelInfo, format, v...) }
+func (lg *Logger) Warnf(format string, v ...interface{})  { lg.logf(LevelWarn, format, v...) }
+func (lg *Logger) Errorf(format string, v ...interface{}) { lg.logf(LevelError, format, v...) }
+
+// Fatalf logs an error whatever the levels, closes the log file and exits.
+func (lg *Logger) Fatalf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	mu.Lock()
+	write(LevelError, lg.tag, msg)
+	mu.Unlock()
+	Close()
+	os.Exit(1)
+}
@@ -1,135 +1,148 @@
+//go:build !headless
+
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"image/color"
+	"os"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
-	"github.com/jakecoffman/cp"
-	"golang.org/x/image/colornames"
-
-	"log"
-)
-
-// See the original at https://chipmunk-physics.net/release/ChipmunkLatest-Docs/#Intro-HelloChipmunk
-// Values are changed due to screen size.
-
-const (
-	title              = "Hello Chipmunk (World)"
-	simulateMaxSeconds = 6
-	screenWidth        = 800
-	screenHeight       = 600
-)
 
-var (
-	ball = ebiten.NewImage(5, 5)
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/config"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/game"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/input"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/logging"
 )
 
-func init() {
-	ball.Fill(color.White)
-}
-
 func main() {
-	log.Println(title)
-	ebiten.SetWindowSize(screenWidth, screenHeight)
-	ebiten.SetWindowTitle(title)
-	if err := ebiten.RunGame(NewGame()); err != nil {
-		log.Fatal(err)
+	// The flags override the config file, which gives their defaults.
+	config := game.LoadConfig()
+	flag.IntVar(&config.WindowWidth, "width", config.WindowWidth, "window width")
+	flag.IntVar(&config.WindowHeight, "height", config.WindowHeight, "window height")
+	flag.Float64Var(&config.Gravity.Y, "gravity", config.Gravity.Y, "downward gravity of Hello Chipmunk")
+	tps := flag.Int("tps", ebiten.DefaultTPS, "ticks per second")
+	flag.BoolVar(&config.VSync, "vsync", config.VSync, "wait for the display's vertical sync")
+	flag.BoolVar(&config.Fullscreen, "fullscreen", config.Fullscreen, "start in fullscreen")
+	scene := flag.String("scene", game.HelloScene, "scene to start on, by number or name")
+	list := flag.Bool("list", false, "list the scenes and exit")
+	record := flag.String("record", "", "file to record the session to, e.g. for a bug report")
+	trajectories := flag.String("trajectories", "", "CSV file to record the trajectories of the bodies to at every step")
+	streamAddr := flag.String("stream", "", "address to stream the world on over WebSocket, with a dashboard, e.g. :8090")
+	dumpFrames := flag.String("dumpframes", "", "directory to write every frame to as a numbered PNG, a step of game time apart")
+	host := flag.String("host", "", "address to host the Networked scene on over WebSocket, e.g. :8091")
+	join := flag.String("join", "", "URL of a host to join the Networked scene of, e.g. ws://localhost:8091/ws")
+	allocReport := flag.Bool("allocreport", false, "count the allocations of every frame by subsystem, and print a summary after the run")
+	flag.Parse()
+	setupLogging()
+	setupGC()
+	startPprof()
+	defer logging.Close()
+	startTrace()
+	defer stopTracing()
+
+	if *list {
+		for i, name := range game.SceneNames() {
+			fmt.Printf("%2d. %s\n", i+1, name)
+		}
+		return
 	}
-}
 
-type Game struct {
-	space    *cp.Space
-	ballBody *cp.Body
-	time     float64
-}
-
-func NewGame() *Game {
-	// Create an empty space.
-	gravity := cp.Vector{Y: 100}
-	space := cp.NewSpace()
-	space.SetGravity(gravity)
-
-	// Add a static line segment shape for the ground.
-	// We'll make it slightly tilted so the ball will roll off.
-	// We attach it to a static body to tell Chipmunk it shouldn't be movable.
-	ground := cp.NewSegment(
-		space.StaticBody,
-		cp.Vector{},
-		cp.Vector{X: screenWidth, Y: screenHeight},
-		0,
-	)
-	ground.SetFriction(1)
-	space.AddShape(ground)
-
-	// Now let's make a ball that falls onto the line and rolls off.
-	// First we need to make a cpBody to hold the physical properties of the object.
-	// These include the mass, position, velocity, angle, etc. of the object.
-	// Then we attach collision shapes to the cpBody to give it a size and shape.
-
-	var radius float64 = 5
-	var mass float64 = 1
-
-	// The moment of inertia is like mass for rotation
-	// Use the cp.MomentFor*() functions to help you approximate it.
-	moment := cp.MomentForCircle(mass, 0, radius, cp.Vector{})
-
-	// The Space.Add*() functions return the thing that you are adding.
-	// It's convenient to create and add an object in one line.
-	ballBody := space.AddBody(cp.NewBody(mass, moment))
-	ballBody.SetPosition(cp.Vector{X: screenWidth / 2, Y: screenHeight / 4})
-
-	// Now we create the collision shape for the ball.
-	// You can create multiple collision shapes that point to the same body.
-	// They will all be attached to the body and move around to follow it.
-	ballShape := space.AddShape(cp.NewCircle(ballBody, radius, cp.Vector{}))
-	ballShape.SetFriction(0.7)
-
-	return &Game{
-		space:    space,
-		ballBody: ballBody,
+	logMain.Infof("%s", game.Title)
+	input.LoadKeybindings()
+	if config.WindowWidth <= 0 || config.WindowHeight <= 0 || *tps <= 0 {
+		logMain.Fatalf("The window size and the ticks per second must be positive")
 	}
-}
-
-func (g *Game) Update() error {
-	// Now that it's all set up, we simulate all the objects in the space by
-	// stepping forward through time in small increments called steps.
-	// It is *highly* recommended to use a fixed size time step.
-	timeStep := 1.0 / float64(ebiten.MaxTPS())
-	g.time += timeStep
-	if g.time < simulateMaxSeconds {
-		g.space.Step(timeStep)
+	if *host != "" && *join != "" {
+		logMain.Fatalf("-host and -join don't go together: a game hosts or joins")
 	}
-
-	return nil
-}
-
-func (g *Game) Draw(screen *ebiten.Image) {
-	// Background
-	screen.Fill(colornames.Black)
-
-	// Ground
-	ebitenutil.DrawLine(screen, 0, 0, screenWidth, screenHeight, color.White)
-
-	// Ball
-	op := &ebiten.DrawImageOptions{}
-	op.ColorM.Scale(200.0/255.0, 200.0/255.0, 200.0/255.0, 1)
-	op.GeoM.Translate(g.ballBody.Position().X, g.ballBody.Position().Y)
-	screen.DrawImage(ball, op)
-
-	if g.time < simulateMaxSeconds {
-		pos := g.ballBody.Position()
-		vel := g.ballBody.Velocity()
-		ebitenutil.DebugPrint(
-			screen,
-			fmt.Sprintf(
-				"Time is %5.2f. ballBody is at (%5.2f, %5.2f). It's velocity is (%5.2f, %5.2f)",
-				g.time, pos.X, pos.Y, vel.X, vel.Y,
-			))
+	if *host != "" || *join != "" {
+		sceneSet := false
+		flag.Visit(func(f *flag.Flag) { sceneSet = sceneSet || f.Name == "scene" })
+		if !sceneSet {
+			*scene = game.NetScene
+		}
+	}
+	index, err := game.FindScene(*scene)
+	if err != nil {
+		logMain.Fatalf("%v", err)
+	}
+	if *host != "" {
+		if err := game.Host(*host, allowedOrigins()); err != nil {
+			logMain.Fatalf("-host: %v", err)
+		}
+	}
+	if *join != "" {
+		if err := game.Join(*join); err != nil {
+			logMain.Fatalf("-join: %v", err)
+		}
+	}
+	if *dumpFrames != "" {
+		// As fast as the frames can be written, a step and a frame a tick.
+		config.VSync = false
+		*tps = ebiten.SyncWithFPS
+	}
+	game.SetConfig(config)
+	game.SetConfigOverrides(flagOverrides(config, *dumpFrames != ""))
+
+	game.ApplySettings(config)
+	ebiten.SetWindowTitle(game.Title)
+	ebiten.SetMaxTPS(*tps)
+	// The game saves its work before the window closes, see game.Shutdown.
+	ebiten.SetWindowClosingHandled(true)
+	g := game.NewAt(index)
+	if *record != "" {
+		if err := g.Record(*record); err != nil {
+			logMain.Fatalf("-record: %v", err)
+		}
+	}
+	if *trajectories != "" {
+		if err := g.RecordTrajectories(*trajectories); err != nil {
+			logMain.Fatalf("-trajectories: %v", err)
+		}
+	}
+	if *streamAddr != "" {
+		if err := g.Stream(*streamAddr, allowedOrigins()); err != nil {
+			logMain.Fatalf("-stream: %v", err)
+		}
+	}
+	if *dumpFrames != "" {
+		if err := g.DumpFrames(*dumpFrames); err != nil {
+			logMain.Fatalf("-dumpframes: %v", err)
+		}
+	}
+	if *allocReport {
+		game.ReportAllocations()
+	}
+	err = ebiten.RunGame(g)
+	game.PrintAllocations(os.Stdout)
+	if err != nil && !errors.Is(err, game.ErrQuit) {
+		logMain.Fatalf("%v", err)
 	}
 }
 
-func (g *Game) Layout(_, _ int) (int, int) {
-	return screenWidth, screenHeight
+// flagOverrides returns what sets the values of the flags given, those of
+// set, in the config reloaded from the file, and turns the vertical sync
+// off again when dumping frames.
+func flagOverrides(set config.Config, dumpFrames bool) func(c *config.Config) {
+	return func(c *config.Config) {
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "width":
+				c.WindowWidth = set.WindowWidth
+			case "height":
+				c.WindowHeight = set.WindowHeight
+			case "gravity":
+				c.Gravity.Y = set.Gravity.Y
+			case "vsync":
+				c.VSync = set.VSync
+			case "fullscreen":
+				c.Fullscreen = set.Fullscreen
+			}
+		})
+		if dumpFrames {
+			c.VSync = false
+		}
+	}
 }
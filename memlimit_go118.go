@@ -0,0 +1,10 @@
+//go:build !go1.19
+
+package main
+
+import "errors"
+
+// setMemoryLimit fails: the soft memory limit came with Go 1.19.
+func setMemoryLimit(int64) error {
+	return errors.New("a memory limit needs a build with Go 1.19 or later")
+}
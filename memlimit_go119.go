@@ -0,0 +1,11 @@
+//go:build go1.19
+
+package main
+
+import "runtime/debug"
+
+// setMemoryLimit sets the runtime's soft memory limit to bytes.
+func setMemoryLimit(bytes int64) error {
+	debug.SetMemoryLimit(bytes)
+	return nil
+}
@@ -0,0 +1,44 @@
+// Package mobile is the game as an Android library or an iOS framework,
+// bound by ebitenmobile: the app shows the view ebitenmobile generates, and
+// tells the game where to keep its settings, the phone's tilt, and when it
+// goes to the background, with the functions here, which gomobile binds
+// too. It only runs on a phone.
+package mobile
+
+import (
+	ebitenmobile "github.com/hajimehoshi/ebiten/v2/mobile"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/game"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/storage"
+)
+
+func init() {
+	ebitenmobile.SetGame(game.New())
+}
+
+// SetDataDir keeps the settings in dir, the app's files directory, and
+// loads them from it. The app calls it before showing the view: the
+// working directory of an app isn't its own.
+func SetDataDir(dir string) {
+	storage.SetDir(dir)
+	game.ApplySettings(game.LoadConfig())
+}
+
+// SetTilt turns the gravity toward where the phone's is on the screen, x to
+// the right and y down, e.g. from the accelerometer: only the direction
+// counts, and zero turns it back down.
+func SetTilt(x, y float64) {
+	game.SetTilt(x, y)
+}
+
+// Suspend pauses the physics while the app is in the background, along
+// with the view's own suspending.
+func Suspend() {
+	game.Suspend()
+}
+
+// Resume goes on with the physics from where it was paused, the time in
+// the background skipped.
+func Resume() {
+	game.Resume()
+}
@@ -0,0 +1,266 @@
+package netsync
+
+import (
+	"encoding/json"
+	"image/color"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/stream"
+)
+
+const (
+	// mirrorSnapshots is how many snapshots a mirror keeps at most, the
+	// last ones: the playback only needs those around it.
+	mirrorSnapshots = 32
+	// resyncSteps is how far the playback may drift from where it should be
+	// before it jumps there, and how far back a snapshot's step may go
+	// before it is taken for a host that started over.
+	resyncSteps = 30
+	// catchUp is the part of its drift the playback makes up for every
+	// step it plays, for a host a bit faster or slower than the client.
+	catchUp = 0.02
+)
+
+// sleepingColor is the color of the shapes of a body asleep on the host, as
+// they are drawn there.
+var sleepingColor = color.Gray{Y: 102}
+
+// Mirror plays back the snapshots of a host on a client: its Space has the
+// host's bodies as kinematic bodies, put where they are at a step Delay
+// steps behind the last snapshot, between the two snapshots around it. It
+// is never stepped, only drawn.
+type Mirror struct {
+	Space *cp.Space
+	// Delay is how many steps behind the last snapshot the playback is,
+	// for a snapshot after it to interpolate towards: a few snapshots'
+	// worth, more for a network that jitters more.
+	Delay float64
+
+	snapshots []Snapshot
+	// shapes are the shapes of the bodies, by number, as last sent.
+	shapes  map[int][]Shape
+	bodies  map[int]*mirrorBody
+	play    float64
+	playing bool
+	// from and shown are kept from one Advance to the next.
+	from  map[int]Body
+	shown map[int]bool
+}
+
+// mirrorBody is a body of the mirror's space, its shapes and their colors
+// on the host when it isn't asleep.
+type mirrorBody struct {
+	body     *cp.Body
+	shapes   []*cp.Shape
+	colors   []interface{}
+	sleeping bool
+}
+
+// NewMirror returns a mirror without snapshots, playing delay steps behind
+// the last one.
+func NewMirror(delay float64) *Mirror {
+	return &Mirror{
+		Space:  cp.NewSpace(),
+		Delay:  delay,
+		shapes: map[int][]Shape{},
+		bodies: map[int]*mirrorBody{},
+		from:   map[int]Body{},
+		shown:  map[int]bool{},
+	}
+}
+
+// Add keeps a snapshot received, and the shapes of its bodies, changed
+// shapes rebuilding their body. A snapshot older than the last is dropped,
+// unless it is far older: then the host started over, and so does the
+// playback.
+func (m *Mirror) Add(s Snapshot) {
+	if n := len(m.snapshots); n > 0 {
+		last := m.snapshots[n-1].Step
+		if s.Step < last-resyncSteps {
+			m.snapshots, m.playing = m.snapshots[:0], false
+		} else if s.Step <= last {
+			return
+		}
+	}
+	for _, b := range s.Bodies {
+		if b.Shapes == nil || reflect.DeepEqual(b.Shapes, m.shapes[b.ID]) {
+			continue
+		}
+		m.shapes[b.ID] = b.Shapes
+		if b.ID == 0 {
+			m.buildStatics(b.Shapes)
+		} else if mb := m.bodies[b.ID]; mb != nil {
+			m.Space.RemoveBody(mb.body)
+			for _, shape := range mb.shapes {
+				m.Space.RemoveShape(shape)
+			}
+			delete(m.bodies, b.ID)
+		}
+	}
+	if len(m.snapshots) == mirrorSnapshots {
+		m.snapshots = append(m.snapshots[:0], m.snapshots[1:]...)
+	}
+	m.snapshots = append(m.snapshots, s)
+}
+
+// Receive adds the snapshots conn received since the last call. A message
+// that isn't a snapshot is skipped, the first one's error returned.
+func (m *Mirror) Receive(conn *stream.Conn) error {
+	var err error
+	for {
+		data, ok := conn.Receive()
+		if !ok {
+			return err
+		}
+		var s Snapshot
+		if e := json.Unmarshal(data, &s); e != nil {
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+		m.Add(s)
+	}
+}
+
+// Step returns the host's step played, false before the first snapshot.
+func (m *Mirror) Step() (float64, bool) {
+	return m.play, m.playing
+}
+
+// Snapshots returns how many snapshots are kept, those around the step
+// played and after it.
+func (m *Mirror) Snapshots() int {
+	return len(m.snapshots)
+}
+
+// Advance plays steps more, catching up a bit with where the playback
+// should be, and puts the bodies of the space where they are at the step
+// played.
+func (m *Mirror) Advance(steps float64) {
+	n := len(m.snapshots)
+	if n == 0 {
+		return
+	}
+	target := float64(m.snapshots[n-1].Step) - m.Delay
+	if !m.playing || math.Abs(target-m.play) > resyncSteps {
+		m.play, m.playing = target, true
+	} else {
+		m.play += steps + (target-m.play)*catchUp
+	}
+	// The first snapshot after the step played, and the one before.
+	i := sort.Search(n, func(i int) bool { return float64(m.snapshots[i].Step) > m.play })
+	var a, b Snapshot
+	t := 0.0
+	switch i {
+	case 0:
+		a, b = m.snapshots[0], m.snapshots[0]
+	case n:
+		a, b = m.snapshots[n-1], m.snapshots[n-1]
+	default:
+		a, b = m.snapshots[i-1], m.snapshots[i]
+		t = (m.play - float64(a.Step)) / float64(b.Step-a.Step)
+	}
+	if i > 1 {
+		m.snapshots = append(m.snapshots[:0], m.snapshots[i-1:]...)
+	}
+	m.place(a, b, t)
+}
+
+// place puts the bodies of b where they are a part t of the way from a to
+// it, and removes those not in b.
+func (m *Mirror) place(a, b Snapshot, t float64) {
+	for id := range m.from {
+		delete(m.from, id)
+	}
+	for _, body := range a.Bodies {
+		m.from[body.ID] = body
+	}
+	for id := range m.shown {
+		delete(m.shown, id)
+	}
+	for _, to := range b.Bodies {
+		if to.ID == 0 {
+			continue
+		}
+		mb := m.body(to.ID)
+		if mb == nil {
+			continue
+		}
+		pos, angle := to.Position, to.Angle
+		if from, ok := m.from[to.ID]; ok {
+			pos = from.Position.Lerp(to.Position, t)
+			angle = from.Angle + (to.Angle-from.Angle)*t
+		}
+		mb.body.SetPosition(pos)
+		mb.body.SetAngle(angle)
+		if to.Sleeping != mb.sleeping {
+			mb.sleeping = to.Sleeping
+			for i, shape := range mb.shapes {
+				shape.UserData = mb.colors[i]
+				if mb.sleeping {
+					shape.UserData = sleepingColor
+				}
+			}
+		}
+		physics.Reindex(m.Space, mb.body)
+		m.shown[to.ID] = true
+	}
+	for id, mb := range m.bodies {
+		if !m.shown[id] {
+			m.Space.RemoveBody(mb.body)
+			for _, shape := range mb.shapes {
+				m.Space.RemoveShape(shape)
+			}
+			delete(m.bodies, id)
+		}
+	}
+}
+
+// body returns the body numbered id, built from its shapes if it isn't
+// yet, nil if they weren't sent yet.
+func (m *Mirror) body(id int) *mirrorBody {
+	if mb := m.bodies[id]; mb != nil {
+		return mb
+	}
+	shapes, ok := m.shapes[id]
+	if !ok {
+		return nil
+	}
+	mb := &mirrorBody{body: restore(m.Space, cp.BODY_KINEMATIC, shapes)}
+	mb.body.EachShape(func(shape *cp.Shape) {
+		mb.shapes = append(mb.shapes, shape)
+		mb.colors = append(mb.colors, shape.UserData)
+	})
+	m.bodies[id] = mb
+	return mb
+}
+
+// buildStatics replaces the shapes of the static body.
+func (m *Mirror) buildStatics(shapes []Shape) {
+	var old []*cp.Shape
+	m.Space.StaticBody.EachShape(func(shape *cp.Shape) { old = append(old, shape) })
+	for _, shape := range old {
+		m.Space.RemoveShape(shape)
+	}
+	restore(m.Space, cp.BODY_STATIC, shapes)
+}
+
+// restore adds a body of type bodyType with shapes to space, the static
+// body if static, their colors as their user data.
+func restore(space *cp.Space, bodyType int, shapes []Shape) *cp.Body {
+	state := physics.BodyState{BodyType: bodyType}
+	for _, s := range shapes {
+		g := s.Geometry
+		if s.Color != nil {
+			g.UserData = color.RGBA{R: s.Color[0], G: s.Color[1], B: s.Color[2], A: s.Color[3]}
+		}
+		state.Shapes = append(state.Shapes, g)
+	}
+	return state.Restore(space)
+}
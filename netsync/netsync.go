@@ -0,0 +1,149 @@
+// Package netsync syncs a cp space over the network, a host stepping it and
+// its clients showing it: an Encoder makes snapshots of the host's space a
+// few times a second, and a Mirror plays them back on a client, in a space
+// of its own, interpolating between them. The clients send the host their
+// pointer as Inputs, which its Players act on as on its own pointer. The
+// messages are JSON, carried by the stream package's WebSocket. It doesn't
+// depend on ebiten.
+package netsync
+
+import (
+	"encoding/json"
+	"image/color"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/stream"
+)
+
+// FullEvery is how many snapshots apart the full ones are, with the shapes
+// of every body: a client that joined since gets them within it.
+const FullEvery = 30
+
+// Snapshot is the host's space at a step, as the host sends it.
+type Snapshot struct {
+	// Step is the host's step, counted by the host from any start.
+	Step int `json:"step"`
+	// Full tells that every body has its shapes, and the static body's
+	// shapes are the body 0: otherwise a body only has them the first time
+	// it is sent.
+	Full   bool   `json:"full,omitempty"`
+	Bodies []Body `json:"bodies"`
+}
+
+// Body is a body of a snapshot, numbered from 1 in the order it was first
+// sent, where it is.
+type Body struct {
+	ID       int       `json:"id"`
+	Position cp.Vector `json:"position"`
+	Angle    float64   `json:"angle"`
+	Sleeping bool      `json:"sleeping,omitempty"`
+	Shapes   []Shape   `json:"shapes,omitempty"`
+}
+
+// Shape is a shape of a body, its geometry as a level saves it, and its
+// color if its user data is one.
+type Shape struct {
+	Geometry physics.ShapeState `json:"geometry"`
+	Color    *[4]uint8          `json:"color,omitempty"`
+}
+
+// The kinds of Input.
+const (
+	Press   = "press"
+	Move    = "move"
+	Release = "release"
+)
+
+// Input is what a client's pointer does, where, in the space's coordinates.
+type Input struct {
+	Kind string  `json:"kind"`
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+}
+
+// Encoder makes the snapshots of a host's space, numbering its bodies.
+type Encoder struct {
+	// MaxBodies caps the bodies of a snapshot, none if 0.
+	MaxBodies int
+
+	ids map[*cp.Body]int
+	// seen is the snapshot each body was last in, for those gone to be
+	// forgotten.
+	seen      map[*cp.Body]int
+	lastID    int
+	snapshots int
+}
+
+// Encode returns the snapshot of space at step: its dynamic and kinematic
+// bodies with shapes, where they are, with the shapes of those new to the
+// snapshots, or of all of them and the static body's in a full one.
+func (e *Encoder) Encode(space *cp.Space, step int) Snapshot {
+	if e.ids == nil {
+		e.ids, e.seen = map[*cp.Body]int{}, map[*cp.Body]int{}
+	}
+	e.snapshots++
+	s := Snapshot{Step: step, Full: e.snapshots%FullEvery == 1, Bodies: []Body{}}
+	if s.Full {
+		s.Bodies = append(s.Bodies, Body{Shapes: shapesOf(space.StaticBody)})
+	}
+	space.EachBody(func(body *cp.Body) {
+		if body.GetType() == cp.BODY_STATIC || e.MaxBodies > 0 && len(s.Bodies) >= e.MaxBodies {
+			return
+		}
+		shapes := 0
+		body.EachShape(func(*cp.Shape) { shapes++ })
+		if shapes == 0 {
+			return
+		}
+		id, known := e.ids[body]
+		if !known {
+			e.lastID++
+			id = e.lastID
+			e.ids[body] = id
+		}
+		e.seen[body] = e.snapshots
+		b := Body{ID: id, Position: body.Position(), Angle: body.Angle(), Sleeping: body.IsSleeping()}
+		if s.Full || !known {
+			b.Shapes = shapesOf(body)
+		}
+		s.Bodies = append(s.Bodies, b)
+	})
+	for body, n := range e.seen {
+		if n != e.snapshots {
+			delete(e.seen, body)
+			delete(e.ids, body)
+		}
+	}
+	return s
+}
+
+// Publish sends the snapshot of space at step to the clients of server, if
+// it has any.
+func (e *Encoder) Publish(server *stream.Server, space *cp.Space, step int) error {
+	if server.Clients() == 0 {
+		return nil
+	}
+	data, err := json.Marshal(e.Encode(space, step))
+	if err != nil {
+		return err
+	}
+	server.Publish(data)
+	return nil
+}
+
+// shapesOf returns the shapes of body, with their colors.
+func shapesOf(body *cp.Body) []Shape {
+	var shapes []Shape
+	for _, state := range physics.SaveBody(body).Shapes {
+		shape := Shape{Geometry: state}
+		if clr, ok := state.UserData.(color.Color); ok {
+			c := color.RGBAModel.Convert(clr).(color.RGBA)
+			shape.Color = &[4]uint8{c.R, c.G, c.B, c.A}
+		}
+		shape.Geometry.UserData = nil
+		shapes = append(shapes, shape)
+	}
+	return shapes
+}
@@ -0,0 +1,106 @@
+package netsync
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/stream"
+)
+
+// Players are the pointers of a host's players, its own and its clients',
+// each dragging the bodies of its space with a mouse joint of its own.
+type Players struct {
+	// Spawn is called for a press on no dynamic body, with the player and
+	// where; nil spawns nothing.
+	Spawn func(player int, p cp.Vector)
+
+	space *cp.Space
+	mice  map[int]*physics.MouseJoint
+}
+
+// NewPlayers returns the players of space, none yet: a player comes with
+// its first press.
+func NewPlayers(space *cp.Space) *Players {
+	return &Players{space: space, mice: map[int]*physics.MouseJoint{}}
+}
+
+// Apply does what the pointer of player did: a press grabs the dynamic body
+// under it, or spawns one, a move drags what it grabbed, a release lets go.
+// A player comes with its first press; the moves and releases of a player
+// without one, e.g. one that left, and the inputs of unknown kinds, are
+// ignored.
+func (ps *Players) Apply(player int, in Input) {
+	mouse := ps.mice[player]
+	if mouse == nil {
+		if in.Kind != Press {
+			return
+		}
+		mouse = physics.NewMouseJoint(ps.space)
+		ps.mice[player] = mouse
+	}
+	p := cp.Vector{X: in.X, Y: in.Y}
+	switch in.Kind {
+	case Press:
+		info := ps.space.PointQueryNearest(p, 0, cp.SHAPE_FILTER_ALL)
+		if info.Shape != nil && info.Shape.Body().GetType() == cp.BODY_DYNAMIC {
+			mouse.Grab(info.Shape.Body(), p)
+		} else if ps.Spawn != nil {
+			ps.Spawn(player, p)
+		}
+	case Move:
+		mouse.MoveTo(p)
+	case Release:
+		mouse.Release()
+	}
+}
+
+// Leave lets go of what player dragged, and forgets it.
+func (ps *Players) Leave(player int) {
+	mouse := ps.mice[player]
+	if mouse == nil {
+		return
+	}
+	mouse.Release()
+	ps.space.RemoveBody(mouse.Body)
+	delete(ps.mice, player)
+}
+
+// Receive applies the inputs the clients of server sent since the last
+// call, each client the player of its number, and their leaving. A message
+// that isn't an input is skipped, the first one's error returned.
+func (ps *Players) Receive(server *stream.Server) error {
+	var err error
+	for {
+		msg, ok := server.Receive()
+		if !ok {
+			return err
+		}
+		if msg.Gone {
+			ps.Leave(msg.Client)
+			continue
+		}
+		var in Input
+		if e := json.Unmarshal(msg.Data, &in); e != nil {
+			if err == nil {
+				err = fmt.Errorf("client %d: %w", msg.Client, e)
+			}
+			continue
+		}
+		ps.Apply(msg.Client, in)
+	}
+}
+
+// Step moves the mouse joints towards their pointers, before a step of dt.
+func (ps *Players) Step(dt float64) {
+	for _, mouse := range ps.mice {
+		mouse.Step(dt)
+	}
+}
+
+// Len returns how many players there are.
+func (ps *Players) Len() int {
+	return len(ps.mice)
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"flag"
+	"strings"
+)
+
+// originsFlag is shared by the game and the headless build, whose servers
+// refuse the web pages of other origins but for those.
+var originsFlag = flag.String("origins", "", "comma separated origins of other web pages allowed to connect over WebSocket to -stream, -host or -serve, e.g. http://localhost:8000, * for any")
+
+// allowedOrigins returns the origins of -origins.
+func allowedOrigins() []string {
+	var origins []string
+	for _, o := range strings.Split(*originsFlag, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			origins = append(origins, o)
+		}
+	}
+	return origins
+}
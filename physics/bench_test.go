@@ -0,0 +1,60 @@
+package physics_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+// benchSizes are the numbers of bodies the benchmarks run with, from a
+// handful to a crowd.
+var benchSizes = []int{10, 1000, 10000}
+
+// pileSpace returns a space with n boxes falling in rows into a bin, stepped
+// a second for them to be piled, touching one another, as in a busy scene.
+func pileSpace(n int) *cp.Space {
+	const size, gap = 8, 2
+	columns := int(math.Ceil(math.Sqrt(float64(n))))
+	width := float64(columns * (size + gap))
+
+	space := cp.NewSpace()
+	space.SetGravity(cp.Vector{Y: 300})
+	bottom := float64(columns * (size + gap))
+	for _, wall := range [][2]cp.Vector{
+		{{X: 0, Y: 0}, {X: 0, Y: bottom}},
+		{{X: 0, Y: bottom}, {X: width, Y: bottom}},
+		{{X: width, Y: bottom}, {X: width, Y: 0}},
+	} {
+		space.AddShape(cp.NewSegment(space.StaticBody, wall[0], wall[1], 1)).SetFriction(1)
+	}
+	for i := 0; i < n; i++ {
+		pos := cp.Vector{
+			X: float64(i%columns*(size+gap)) + size/2 + gap/2,
+			Y: bottom - float64(i/columns*(size+gap)) - size/2 - gap,
+		}
+		physics.AddBox(space, pos, size, size)
+	}
+	for i := 0; i < 60; i++ {
+		space.Step(1.0 / 60)
+	}
+	return space
+}
+
+// BenchmarkStep measures a step of the game, a 60th of a second, of a pile
+// of bodies.
+func BenchmarkStep(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("bodies=%d", n), func(b *testing.B) {
+			space := pileSpace(n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				space.Step(1.0 / 60)
+			}
+		})
+	}
+}
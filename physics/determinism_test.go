@@ -0,0 +1,116 @@
+package physics_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+const (
+	// determinismSteps is how long each run lasts, 20 seconds of the game,
+	// and determinismEvery how often its state is hashed, every second.
+	determinismSteps = 1200
+	determinismEvery = 60
+)
+
+// seededSpace returns a space with boxes and balls of random sizes dropped
+// at random on a V shaped ground, from the seed: they collide, stack and
+// slide, and any difference between two runs grows.
+func seededSpace(seed int64) *cp.Space {
+	r := rand.New(rand.NewSource(seed))
+	space := cp.NewSpace()
+	space.SetGravity(cp.Vector{Y: 300})
+	space.AddShape(cp.NewSegment(space.StaticBody, cp.Vector{X: 0, Y: 300}, cp.Vector{X: 400, Y: 560}, 2)).SetFriction(0.6)
+	space.AddShape(cp.NewSegment(space.StaticBody, cp.Vector{X: 400, Y: 560}, cp.Vector{X: 800, Y: 300}, 2)).SetFriction(0.6)
+	for i := 0; i < 100; i++ {
+		pos := cp.Vector{X: 100 + 600*r.Float64(), Y: 300 * r.Float64()}
+		size := 6 + 14*r.Float64()
+		if r.Intn(2) == 0 {
+			physics.AddBox(space, pos, size, size*(0.5+r.Float64()))
+			continue
+		}
+		body := space.AddBody(cp.NewBody(1, cp.MomentForCircle(1, 0, size/2, cp.Vector{})))
+		body.SetPosition(pos)
+		shape := space.AddShape(cp.NewCircle(body, size/2, cp.Vector{}))
+		shape.SetFriction(0.5)
+		shape.SetElasticity(0.4)
+	}
+	return space
+}
+
+// hashRun steps the seeded space and returns its hashes, every
+// determinismEvery steps.
+func hashRun(seed int64) []uint64 {
+	space := seededSpace(seed)
+	var hashes []uint64
+	for i := 1; i <= determinismSteps; i++ {
+		space.Step(1.0 / 60)
+		if i%determinismEvery == 0 {
+			hashes = append(hashes, physics.Hash(space))
+		}
+	}
+	return hashes
+}
+
+// TestDeterminism runs the same seeded scene twice and fails at the first
+// second the two runs differ: on a given platform and build, cp must step
+// the same space the same way, or replays and lockstep networking can't
+// work. Across platforms, see the README.
+func TestDeterminism(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3} {
+		a, b := hashRun(seed), hashRun(seed)
+		for i := range a {
+			if a[i] != b[i] {
+				t.Errorf("seed %d: the runs diverge by step %d, %016x != %016x", seed, (i+1)*determinismEvery, a[i], b[i])
+				break
+			}
+		}
+	}
+}
+
+// TestDeterminismSeeds makes sure the test above tests something: other
+// seeds make other scenes, whose hashes differ.
+func TestDeterminismSeeds(t *testing.T) {
+	if a, b := hashRun(1), hashRun(2); a[len(a)-1] == b[len(b)-1] {
+		t.Errorf("seeds 1 and 2 end in the same state, %016x", a[len(a)-1])
+	}
+}
+
+// pendulumRun steps a double pendulum from theta1 and theta2 for
+// determinismSteps, and returns its angles every determinismEvery steps.
+func pendulumRun(theta1, theta2 float64) [][2]float64 {
+	p := physics.NewDoublePendulum(cp.NewSpace(), cp.Vector{X: 280, Y: 220}, theta1, theta2)
+	var angles [][2]float64
+	for i := 1; i <= determinismSteps; i++ {
+		p.Step(1.0 / 60)
+		if i%determinismEvery == 0 {
+			a1, a2 := p.Angles()
+			angles = append(angles, [2]float64{a1, a2})
+		}
+	}
+	return angles
+}
+
+// TestPendulumDeterminism steps the same double pendulum twice: chaotic,
+// it turns the least difference between the runs into another motion, yet
+// the angles must be the same to the bit.
+func TestPendulumDeterminism(t *testing.T) {
+	a, b := pendulumRun(math.Pi/2, math.Pi), pendulumRun(math.Pi/2, math.Pi)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("the runs diverge by step %d, %v != %v", (i+1)*determinismEvery, a[i], b[i])
+		}
+		for _, angle := range a[i] {
+			if angle <= -math.Pi || angle > math.Pi {
+				t.Fatalf("at step %d, an angle of %g is out of (-Pi, Pi]", (i+1)*determinismEvery, angle)
+			}
+		}
+	}
+	if c := pendulumRun(math.Pi/2+1e-6, math.Pi); c[len(c)-1] == a[len(a)-1] {
+		t.Errorf("a pendulum started 1e-6 rad apart ends in the same state, %v", a[len(a)-1])
+	}
+}
@@ -0,0 +1,32 @@
+package physics
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+
+	"github.com/jakecoffman/cp"
+)
+
+// Hash returns a hash of the state of the space's bodies, bit for bit: their
+// positions, angles and velocities, in the order of the space. Two runs
+// stepped alike are in the same state only if their hashes are equal, which
+// tells whether the physics is deterministic.
+func Hash(space *cp.Space) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	write := func(f float64) {
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(f))
+		h.Write(buf[:])
+	}
+	space.EachBody(func(body *cp.Body) {
+		p, v := body.Position(), body.Velocity()
+		write(p.X)
+		write(p.Y)
+		write(body.Angle())
+		write(v.X)
+		write(v.Y)
+		write(body.AngularVelocity())
+	})
+	return h.Sum64()
+}
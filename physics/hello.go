@@ -0,0 +1,65 @@
+package physics
+
+import "github.com/jakecoffman/cp"
+
+// See the original at https://chipmunk-physics.net/release/ChipmunkLatest-Docs/#Intro-HelloChipmunk
+// Values are changed due to screen size.
+
+// HelloParams are the parameters of the Hello Chipmunk space.
+type HelloParams struct {
+	Gravity cp.Vector `json:"gravity"`
+	// Damping is the fraction of velocity the bodies keep after a second.
+	Damping    float64      `json:"damping"`
+	BallRadius float64      `json:"ball_radius"`
+	BallMass   float64      `json:"ball_mass"`
+	Friction   float64      `json:"friction"`
+	Elasticity float64      `json:"elasticity"`
+	Ground     [2]cp.Vector `json:"ground"`
+}
+
+// HelloStart is where the ball starts, a quarter down the middle of the
+// screen.
+var HelloStart = cp.Vector{X: 400, Y: 150}
+
+// NewHelloSpace returns the Hello Chipmunk space, with its ground but without
+// a ball, see NewHelloBall.
+func NewHelloSpace(p HelloParams) *cp.Space {
+	// Create an empty space.
+	space := cp.NewSpace()
+	space.SetGravity(p.Gravity)
+	space.SetDamping(p.Damping)
+
+	// Add a static line segment shape for the ground.
+	// We'll make it slightly tilted so the ball will roll off.
+	// We attach it to a static body to tell Chipmunk it shouldn't be movable.
+	ground := cp.NewSegment(space.StaticBody, p.Ground[0], p.Ground[1], 0)
+	ground.SetFriction(1)
+	ground.SetElasticity(p.Elasticity)
+	space.AddShape(ground)
+
+	return space
+}
+
+// NewHelloBall returns a ball at pos, to be added to the space with its
+// shape.
+func NewHelloBall(p HelloParams, pos cp.Vector) (*cp.Body, *cp.Shape) {
+	// Now let's make a ball that falls onto the line and rolls off.
+	// First we need to make a cpBody to hold the physical properties of the object.
+	// These include the mass, position, velocity, angle, etc. of the object.
+	// Then we attach collision shapes to the cpBody to give it a size and shape.
+
+	// The moment of inertia is like mass for rotation
+	// Use the cp.MomentFor*() functions to help you approximate it.
+	moment := cp.MomentForCircle(p.BallMass, 0, p.BallRadius, cp.Vector{})
+
+	body := cp.NewBody(p.BallMass, moment)
+	body.SetPosition(pos)
+
+	// Now we create the collision shape for the ball.
+	// You can create multiple collision shapes that point to the same body.
+	// They will all be attached to the body and move around to follow it.
+	shape := cp.NewCircle(body, p.BallRadius, cp.Vector{})
+	shape.SetFriction(p.Friction)
+	shape.SetElasticity(p.Elasticity)
+	return body, shape
+}
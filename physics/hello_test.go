@@ -0,0 +1,117 @@
+package physics_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/config"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+// helloStep is the step of the game, a 60th of a second.
+const helloStep = 1.0 / 60
+
+// helloRun builds the Hello Chipmunk space, as the game does but without
+// ebiten, and steps it for seconds, calling check after each step. It is
+// the template of the physics regression tests: set a scene up, step it,
+// and assert what must hold along the way or at the end.
+func helloRun(t *testing.T, p physics.HelloParams, seconds float64, check func(step int, body *cp.Body)) *cp.Body {
+	t.Helper()
+	space := physics.NewHelloSpace(p)
+	body, shape := physics.NewHelloBall(p, physics.HelloStart)
+	space.AddBody(body)
+	space.AddShape(shape)
+	for i := 0; i < int(seconds/helloStep); i++ {
+		space.Step(helloStep)
+		if check != nil {
+			check(i, body)
+		}
+	}
+	return body
+}
+
+// groundDistance returns how far the ball's center is from the ground's
+// line, positive above it.
+func groundDistance(p physics.HelloParams, pos cp.Vector) float64 {
+	a, b := p.Ground[0], p.Ground[1]
+	// The ground goes left to right, and y goes down: turning it
+	// clockwise on screen points up from it.
+	n := b.Sub(a).ReversePerp().Normalize()
+	return pos.Sub(a).Dot(n)
+}
+
+// energy is the ball's kinetic energy, moving and spinning, plus its
+// potential energy in the gravity.
+func energy(p physics.HelloParams, body *cp.Body) float64 {
+	v, w := body.Velocity(), body.AngularVelocity()
+	kinetic := 0.5*body.Mass()*v.LengthSq() + 0.5*body.Moment()*w*w
+	return kinetic - body.Mass()*p.Gravity.Dot(body.Position())
+}
+
+func TestHelloBallLandsOnTheGround(t *testing.T) {
+	p := config.Default().HelloParams
+	landed := -1
+	body := helloRun(t, p, 3, func(step int, body *cp.Body) {
+		d := groundDistance(p, body.Position())
+		// The ball may sink into the ground for the step it lands on, it
+		// moves a few pixels per step by then, not through it.
+		if d < p.BallRadius/2 {
+			t.Fatalf("step %d: the ball is %g from the ground, through it", step, d)
+		}
+		if landed < 0 && d < p.BallRadius+0.5 {
+			landed = step
+		}
+	})
+	if landed < 0 {
+		t.Fatalf("the ball never landed, it is at %v", body.Position())
+	}
+	// Falling the 150 pixels from HelloStart to the ground takes a bit
+	// less than 2 seconds in the gravity of 100.
+	if landed > 2/helloStep {
+		t.Errorf("the ball landed at step %d, later than 2 seconds", landed)
+	}
+	if d := groundDistance(p, body.Position()); math.Abs(d-p.BallRadius) > 0.5 {
+		t.Errorf("the ball ended %g from the ground, not resting on it", d)
+	}
+}
+
+func TestHelloBallRollsDownhill(t *testing.T) {
+	p := config.Default().HelloParams
+	body := helloRun(t, p, 3, nil)
+	downhill := p.Ground[1].Sub(p.Ground[0]).Normalize()
+	if p.Gravity.Dot(downhill) < 0 {
+		downhill = downhill.Neg()
+	}
+	if moved := body.Position().Sub(physics.HelloStart).Dot(downhill); moved <= 0 {
+		t.Errorf("the ball moved %g downhill, it should roll down", moved)
+	}
+	if speed := body.Velocity().Dot(downhill); speed <= 0 {
+		t.Errorf("the ball's speed downhill is %g, it should be rolling down", speed)
+	}
+	// Rolling, not sliding: it spins with its speed.
+	if w, v := body.AngularVelocity()*p.BallRadius, body.Velocity().Length(); math.Abs(math.Abs(w)-v) > 0.05*v {
+		t.Errorf("the ball spins at %g for a speed of %g, it isn't rolling", w, v)
+	}
+}
+
+func TestHelloEnergyDecreases(t *testing.T) {
+	p := config.Default().HelloParams
+	start := 0.0
+	previous := math.Inf(1)
+	helloRun(t, p, 3, func(step int, body *cp.Body) {
+		e := energy(p, body)
+		if step == 0 {
+			start = e
+		}
+		// The solver may add a little energy, not any that matters.
+		if e > previous+1e-3*math.Abs(start) {
+			t.Fatalf("step %d: the energy rose from %g to %g", step, previous, e)
+		}
+		previous = e
+	})
+	if previous >= start {
+		t.Errorf("the energy went from %g to %g, the landing should lose some", start, previous)
+	}
+}
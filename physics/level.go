@@ -0,0 +1,215 @@
+package physics
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/jakecoffman/cp"
+)
+
+// LevelVersion is the version of the level files written now. A level file
+// made by an earlier build is migrated to it when read, see levelMigrations;
+// one made by a later build isn't read.
+//
+// Version 1, the files without a version, had the static shapes as the
+// first body of the bodies. Version 2 has them apart, as static.
+const LevelVersion = 2
+
+// Level is a space saved to a file: its gravity, the shapes of its static
+// body, and the other bodies.
+type Level struct {
+	Version int          `json:"version"`
+	Gravity cp.Vector    `json:"gravity"`
+	Static  []ShapeState `json:"static"`
+	Bodies  []BodyState  `json:"bodies"`
+}
+
+// levelMigrations turn a level of version i+1, decoded as generic JSON with
+// its numbers as json.Number, into one of version i+2. A change of Level, BodyState or ShapeState that old
+// files can't be read as bumps LevelVersion and adds a migration here.
+var levelMigrations = []func(level map[string]interface{}) error{
+	// 1 to 2: the first body is the static one, its shapes become static.
+	func(level map[string]interface{}) error {
+		bodies, _ := level["bodies"].([]interface{})
+		if len(bodies) == 0 {
+			return errors.New("no static body")
+		}
+		static, ok := bodies[0].(map[string]interface{})
+		if !ok || static["body_type"] != json.Number(strconv.Itoa(cp.BODY_STATIC)) {
+			return errors.New("the first body isn't the static one")
+		}
+		level["static"] = static["shapes"]
+		level["bodies"] = bodies[1:]
+		return nil
+	},
+}
+
+// NewLevel saves the shapes of the space's static body and the given bodies,
+// which are the ones worth saving: the space may hold others, e.g. to drag
+// bodies around.
+func NewLevel(space *cp.Space, bodies []*cp.Body) Level {
+	level := Level{
+		Version: LevelVersion,
+		Gravity: space.Gravity(),
+		Static:  SaveBody(space.StaticBody).Shapes,
+	}
+	for _, body := range bodies {
+		level.Bodies = append(level.Bodies, SaveBody(body))
+	}
+	return level
+}
+
+// BrokenBody is a body FiniteLevel left out, its numbers as text, e.g.
+// "NaN" or "+Inf".
+type BrokenBody struct {
+	// Index is the body's among those given to FiniteLevel.
+	Index           int       `json:"index"`
+	BodyType        int       `json:"body_type"`
+	Mass            string    `json:"mass"`
+	Moment          string    `json:"moment"`
+	Position        [2]string `json:"position"`
+	Angle           string    `json:"angle"`
+	Velocity        [2]string `json:"velocity"`
+	AngularVelocity string    `json:"angular_velocity"`
+	Shapes          int       `json:"shapes"`
+}
+
+// FiniteLevel is NewLevel for a space that may have blown up: the bodies
+// whose state has a number JSON has none for, a NaN or an infinite
+// position, angle or velocity, are left out of the level and described
+// apart. An infinite mass or moment, a body's kept from rotating, is saved
+// as cp.INFINITY, which cp takes for one.
+func FiniteLevel(space *cp.Space, bodies []*cp.Body) (Level, []BrokenBody) {
+	level := NewLevel(space, nil)
+	var broken []BrokenBody
+	for i, body := range bodies {
+		state := SaveBody(body)
+		if math.IsInf(state.Mass, 1) {
+			state.Mass = cp.INFINITY
+		}
+		if math.IsInf(state.Moment, 1) {
+			state.Moment = cp.INFINITY
+		}
+		numbers := []float64{state.Mass, state.Moment, state.Position.X, state.Position.Y, state.Angle,
+			state.Velocity.X, state.Velocity.Y, state.AngularVelocity}
+		finite := true
+		for _, f := range numbers {
+			finite = finite && !math.IsNaN(f) && !math.IsInf(f, 0)
+		}
+		if finite {
+			level.Bodies = append(level.Bodies, state)
+			continue
+		}
+		text := func(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) }
+		broken = append(broken, BrokenBody{
+			Index:           i,
+			BodyType:        state.BodyType,
+			Mass:            text(state.Mass),
+			Moment:          text(state.Moment),
+			Position:        [2]string{text(state.Position.X), text(state.Position.Y)},
+			Angle:           text(state.Angle),
+			Velocity:        [2]string{text(state.Velocity.X), text(state.Velocity.Y)},
+			AngularVelocity: text(state.AngularVelocity),
+			Shapes:          len(state.Shapes),
+		})
+	}
+	return level, broken
+}
+
+// Build adds the level to the space and returns the bodies it added, without
+// the static one.
+func (l Level) Build(space *cp.Space) []*cp.Body {
+	space.SetGravity(l.Gravity)
+	BodyState{BodyType: cp.BODY_STATIC, Shapes: l.Static}.Restore(space)
+	var bodies []*cp.Body
+	for _, state := range l.Bodies {
+		if body := state.Restore(space); body != space.StaticBody {
+			bodies = append(bodies, body)
+		}
+	}
+	return bodies
+}
+
+// DecodeLevel decodes a level in JSON, of any version up to LevelVersion,
+// migrating it to LevelVersion.
+func DecodeLevel(data []byte) (Level, error) {
+	var l Level
+	if err := json.Unmarshal(data, &l); err != nil {
+		return l, err
+	}
+	version := l.Version
+	if version == 0 {
+		version = 1
+	}
+	switch {
+	case version == LevelVersion:
+		return l, nil
+	case version > LevelVersion:
+		return l, fmt.Errorf("level version %d is newer than this build's, %d", version, LevelVersion)
+	}
+
+	// The numbers are kept as they are written, a float64 would round the
+	// filters' masks.
+	var generic map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return l, err
+	}
+	for v := version; v < LevelVersion; v++ {
+		if err := levelMigrations[v-1](generic); err != nil {
+			return l, fmt.Errorf("migrating level version %d to %d: %w", v, v+1, err)
+		}
+	}
+	generic["version"] = LevelVersion
+	migrated, err := json.Marshal(generic)
+	if err != nil {
+		return l, err
+	}
+	l = Level{}
+	err = json.Unmarshal(migrated, &l)
+	return l, err
+}
+
+// ReadLevel reads the level file at path, migrating it from the version it
+// was saved in.
+func ReadLevel(path string) (Level, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Level{}, err
+	}
+	l, err := DecodeLevel(data)
+	if err != nil {
+		return l, fmt.Errorf("%s: %w", path, err)
+	}
+	return l, nil
+}
+
+// WriteLevel writes the level to the file at path, at LevelVersion.
+func WriteLevel(path string, l Level) error {
+	l.Version = LevelVersion
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Reindex updates where the spatial index has the body's shapes, after the
+// body was moved outside of a step: the space only does it while stepping,
+// and cp doesn't export it.
+func Reindex(space *cp.Space, body *cp.Body) {
+	var shapes []*cp.Shape
+	body.EachShape(func(shape *cp.Shape) {
+		shapes = append(shapes, shape)
+	})
+	for _, shape := range shapes {
+		space.RemoveShape(shape)
+		space.AddShape(shape)
+	}
+}
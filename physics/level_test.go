@@ -0,0 +1,112 @@
+package physics_test
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+// TestReadLevelV1 reads a level saved by a build before the versions, with
+// the static shapes as the first body: the files of the sandbox made then
+// must keep loading.
+func TestReadLevelV1(t *testing.T) {
+	level, err := physics.ReadLevel(filepath.Join("testdata", "level_v1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level.Version != physics.LevelVersion {
+		t.Errorf("the level is at version %d, not migrated to %d", level.Version, physics.LevelVersion)
+	}
+	if len(level.Static) != 2 || len(level.Bodies) != 2 {
+		t.Fatalf("the level has %d static shapes and %d bodies, want 2 and 2", len(level.Static), len(level.Bodies))
+	}
+
+	space := cp.NewSpace()
+	bodies := level.Build(space)
+	if len(bodies) != 2 || space.Gravity() != (cp.Vector{Y: 300}) {
+		t.Fatalf("built %d bodies in a gravity of %v", len(bodies), space.Gravity())
+	}
+	if ball := bodies[1]; ball.Position() != (cp.Vector{X: 200, Y: 100}) || ball.Velocity() != (cp.Vector{X: 10}) {
+		t.Errorf("the ball is at %v going %v", ball.Position(), ball.Velocity())
+	}
+	static := 0
+	space.StaticBody.EachShape(func(*cp.Shape) { static++ })
+	if static != 2 {
+		t.Errorf("the static body has %d shapes, want 2", static)
+	}
+}
+
+func TestLevelRoundTrip(t *testing.T) {
+	want, err := physics.ReadLevel(filepath.Join("testdata", "level_v1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "level.json")
+	if err := physics.WriteLevel(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := physics.ReadLevel(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("the level read back differs:\n%+v\nwritten:\n%+v", got, want)
+	}
+}
+
+func TestDecodeLevelErrors(t *testing.T) {
+	for _, tt := range []struct{ name, data, err string }{
+		{"newer", `{"version": 99, "bodies": []}`, "newer"},
+		{"v1 without bodies", `{"bodies": []}`, "no static body"},
+		{"v1 without static body", `{"bodies": [{"body_type": 0, "shapes": []}]}`, "isn't the static one"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := physics.DecodeLevel([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want one about %q", err, tt.err)
+			}
+		})
+	}
+	if _, err := physics.ReadLevel(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("reading a missing file: %v", err)
+	}
+}
+
+// TestFiniteLevel dumps a space blown up: the body with a NaN position is
+// left out and described, the one kept from rotating is kept, and the level
+// is written as JSON, which has no number for either.
+func TestFiniteLevel(t *testing.T) {
+	space := cp.NewSpace()
+	physics.AddWalls(space, cp.BB{R: 800, T: 600})
+	wheel, _ := physics.AddBall(space, cp.Vector{X: 100, Y: 100}, 10)
+	wheel.SetMoment(math.Inf(1))
+	blown, _ := physics.AddBox(space, cp.Vector{X: 200, Y: 100}, 20, 20)
+	blown.SetPosition(cp.Vector{X: math.NaN(), Y: 100})
+	blown.SetVelocityVector(cp.Vector{X: math.Inf(-1)})
+
+	level, broken := physics.FiniteLevel(space, []*cp.Body{wheel, blown})
+	if _, err := json.Marshal(level); err != nil {
+		t.Fatal(err)
+	}
+	if len(level.Bodies) != 1 || level.Bodies[0].Moment != cp.INFINITY {
+		t.Fatalf("the level has %d bodies, want the wheel with a moment of cp.INFINITY: %+v", len(level.Bodies), level.Bodies)
+	}
+	if len(broken) != 1 {
+		t.Fatalf("%d broken bodies, want 1", len(broken))
+	}
+	b := broken[0]
+	if b.Index != 1 || b.Position[0] != "NaN" || b.Velocity[0] != "-Inf" || b.Shapes != 1 {
+		t.Errorf("the blown up body is described as %+v", b)
+	}
+	if _, err := json.Marshal(broken); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,75 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/jakecoffman/cp"
+)
+
+const (
+	// MouseForce is the most force a mouse joint pulls with, for a grabbed
+	// body not to push through the ground.
+	MouseForce = 50000
+	// mouseEase is the fraction of the way to the pointer the mouse body
+	// goes in a step.
+	mouseEase = 0.25
+)
+
+// MouseJoint drags bodies around with the pointer: a kinematic body follows
+// the pointer, and the grabbed body is pinned to it by a pivot joint of
+// limited force, the way Chipmunk's demos do it.
+type MouseJoint struct {
+	// Body is the kinematic body following the pointer.
+	Body  *cp.Body
+	space *cp.Space
+	joint *cp.Constraint
+	// grabbed is the body the joint pins to the mouse.
+	grabbed *cp.Body
+	// target is where the pointer is, which Body eases towards.
+	target cp.Vector
+}
+
+// NewMouseJoint adds the mouse body to the space, grabbing nothing.
+func NewMouseJoint(space *cp.Space) *MouseJoint {
+	return &MouseJoint{Body: space.AddBody(cp.NewKinematicBody()), space: space}
+}
+
+// Grab pins body to the mouse at point, in world coordinates, moving the
+// mouse there. It releases the body grabbed before.
+func (m *MouseJoint) Grab(body *cp.Body, point cp.Vector) {
+	m.Release()
+	m.Body.SetPosition(point)
+	m.target = point
+	m.joint = m.space.AddConstraint(cp.NewPivotJoint2(m.Body, body, cp.Vector{}, body.WorldToLocal(point)))
+	m.joint.SetMaxForce(MouseForce)
+	m.joint.SetErrorBias(math.Pow(1-0.15, 60))
+	m.grabbed = body
+}
+
+// Grabbed returns the body grabbed, nil if none.
+func (m *MouseJoint) Grabbed() *cp.Body {
+	return m.grabbed
+}
+
+// Release lets go of the body grabbed, if any. The joint may have gone with
+// its body already.
+func (m *MouseJoint) Release() {
+	if m.joint != nil && m.space.ContainsConstraint(m.joint) {
+		m.space.RemoveConstraint(m.joint)
+	}
+	m.joint, m.grabbed = nil, nil
+}
+
+// MoveTo sets where the pointer is, in world coordinates, without moving
+// the mouse there yet: see Step.
+func (m *MouseJoint) MoveTo(point cp.Vector) {
+	m.target = point
+}
+
+// Step eases the mouse body towards the pointer before a step of dt, with
+// the matching velocity so the joint doesn't see it teleport.
+func (m *MouseJoint) Step(dt float64) {
+	pos := m.Body.Position().Lerp(m.target, mouseEase)
+	m.Body.SetVelocityVector(pos.Sub(m.Body.Position()).Mult(1 / dt))
+	m.Body.SetPosition(pos)
+}
@@ -0,0 +1,82 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/jakecoffman/cp"
+)
+
+const (
+	pendulumArm     = 120
+	pendulumBobMass = 1
+	pendulumBobSize = 8
+	pendulumGravity = 400
+
+	// The solver loses energy at 60 steps per second, which damps the motion
+	// long before the chaos shows. Substeps and extra iterations keep it lively.
+	pendulumSubsteps   = 8
+	pendulumIterations = 30
+)
+
+// DoublePendulum is two bobs hanging from a fixed anchor by pivot joints.
+// It only depends on cp, so it can be stepped without a window and used as a
+// determinism fixture: the same start angles must always give the same state.
+type DoublePendulum struct {
+	// Space holds the bobs, Upper hanging from Anchor and Lower from Upper.
+	Space  *cp.Space
+	Upper  *cp.Body
+	Lower  *cp.Body
+	Anchor cp.Vector
+}
+
+// NewDoublePendulum builds in space a pendulum hanging from anchor whose
+// arms start at the given angles, measured in radians from the downward
+// vertical. It sets the gravity and the iterations of space.
+func NewDoublePendulum(space *cp.Space, anchor cp.Vector, theta1, theta2 float64) *DoublePendulum {
+	space.SetGravity(cp.Vector{Y: pendulumGravity})
+	space.Iterations = pendulumIterations
+
+	upperPos := anchor.Add(armVector(theta1))
+	lowerPos := upperPos.Add(armVector(theta2))
+
+	upper := addPendulumBob(space, upperPos)
+	lower := addPendulumBob(space, lowerPos)
+
+	space.AddConstraint(cp.NewPivotJoint(space.StaticBody, upper, anchor))
+	space.AddConstraint(cp.NewPivotJoint(upper, lower, upperPos))
+
+	return &DoublePendulum{
+		Space:  space,
+		Upper:  upper,
+		Lower:  lower,
+		Anchor: anchor,
+	}
+}
+
+func armVector(theta float64) cp.Vector {
+	return cp.Vector{X: math.Sin(theta), Y: math.Cos(theta)}.Mult(pendulumArm)
+}
+
+func addPendulumBob(space *cp.Space, pos cp.Vector) *cp.Body {
+	moment := cp.MomentForCircle(pendulumBobMass, 0, pendulumBobSize, cp.Vector{})
+	body := space.AddBody(cp.NewBody(pendulumBobMass, moment))
+	body.SetPosition(pos)
+	shape := space.AddShape(cp.NewCircle(body, pendulumBobSize, cp.Vector{}))
+	// Both bobs share a group so they never collide with each other.
+	shape.SetFilter(cp.NewShapeFilter(1, cp.ALL_CATEGORIES, cp.ALL_CATEGORIES))
+	return body
+}
+
+// Step steps the pendulum by dt, in substeps.
+func (p *DoublePendulum) Step(dt float64) {
+	for i := 0; i < pendulumSubsteps; i++ {
+		p.Space.Step(dt / pendulumSubsteps)
+	}
+}
+
+// Angles returns both arm angles, wrapped to (-Pi, Pi].
+func (p *DoublePendulum) Angles() (theta1, theta2 float64) {
+	arm1 := p.Upper.Position().Sub(p.Anchor)
+	arm2 := p.Lower.Position().Sub(p.Upper.Position())
+	return math.Atan2(arm1.X, arm1.Y), math.Atan2(arm2.X, arm2.Y)
+}
@@ -0,0 +1,92 @@
+// Package physics holds the Hello Chipmunk space and the cp helpers shared
+// by the scenes: building bodies, geometry, and saving and restoring bodies.
+// It doesn't depend on ebiten, so it runs and tests without a window.
+package physics
+
+import (
+	"math"
+
+	"github.com/jakecoffman/cp"
+)
+
+// Density is the mass of a square pixel of the bodies AddBox and AddBall
+// make, a box of 20 by 20 pixels weighing 1.
+const Density = 1.0 / 400
+
+// The helpers below add the bodies and shapes the scenes are made of with the
+// defaults they mostly share, the mass from the area and a friction that
+// rolls rather than slides. The shape returned is there to change what
+// differs, its elasticity, collision type or color.
+
+// AddBox adds a dynamic box of the given size centered on pos.
+func AddBox(space *cp.Space, pos cp.Vector, width, height float64) (*cp.Body, *cp.Shape) {
+	mass := width * height * Density
+	body := space.AddBody(cp.NewBody(mass, cp.MomentForBox(mass, width, height)))
+	body.SetPosition(pos)
+	shape := space.AddShape(cp.NewBox(body, width, height, 0))
+	shape.SetFriction(0.8)
+	return body, shape
+}
+
+// AddBall adds a dynamic ball of the given radius centered on pos.
+func AddBall(space *cp.Space, pos cp.Vector, radius float64) (*cp.Body, *cp.Shape) {
+	mass := math.Pi * radius * radius * Density
+	body := space.AddBody(cp.NewBody(mass, cp.MomentForCircle(mass, 0, radius, cp.Vector{})))
+	body.SetPosition(pos)
+	shape := space.AddShape(cp.NewCircle(body, radius, cp.Vector{}))
+	shape.SetFriction(0.7)
+	return body, shape
+}
+
+// AddStaticSegment adds a segment from a to b, as thick as twice radius, to
+// the space's static body, with a friction of 1: the bodies resting on it
+// only slide down steep slopes.
+func AddStaticSegment(space *cp.Space, a, b cp.Vector, radius float64) *cp.Shape {
+	shape := space.AddShape(cp.NewSegment(space.StaticBody, a, b, radius))
+	shape.SetFriction(1)
+	return shape
+}
+
+// AddWalls adds the four sides of bb as static segments, a closed box for
+// the bodies to stay in, and returns them: the one along bb.B, along bb.T,
+// along bb.L and along bb.R.
+func AddWalls(space *cp.Space, bb cp.BB) []*cp.Shape {
+	return []*cp.Shape{
+		AddStaticSegment(space, cp.Vector{X: bb.L, Y: bb.B}, cp.Vector{X: bb.R, Y: bb.B}, 0),
+		AddStaticSegment(space, cp.Vector{X: bb.L, Y: bb.T}, cp.Vector{X: bb.R, Y: bb.T}, 0),
+		AddStaticSegment(space, cp.Vector{X: bb.L, Y: bb.B}, cp.Vector{X: bb.L, Y: bb.T}, 0),
+		AddStaticSegment(space, cp.Vector{X: bb.R, Y: bb.B}, cp.Vector{X: bb.R, Y: bb.T}, 0),
+	}
+}
+
+// SegmentDistance is the distance from p to the segment [a, b].
+func SegmentDistance(p, a, b cp.Vector) float64 {
+	ab := b.Sub(a)
+	if ab.LengthSq() == 0 {
+		return p.Distance(a)
+	}
+	t := cp.Clamp01(p.Sub(a).Dot(ab) / ab.LengthSq())
+	return p.Distance(a.Lerp(b, t))
+}
+
+// DouglasPeucker simplifies a polyline: it keeps the ends, and the point
+// farthest from the line between them if it is farther than tolerance, then
+// does the same on both sides of that point.
+func DouglasPeucker(points []cp.Vector, tolerance float64) []cp.Vector {
+	if len(points) < 3 {
+		return points
+	}
+	first, last := points[0], points[len(points)-1]
+	farthest, dist := 0, 0.0
+	for i := 1; i < len(points)-1; i++ {
+		if d := SegmentDistance(points[i], first, last); d > dist {
+			farthest, dist = i, d
+		}
+	}
+	if dist <= tolerance {
+		return []cp.Vector{first, last}
+	}
+	left := DouglasPeucker(points[:farthest+1], tolerance)
+	right := DouglasPeucker(points[farthest:], tolerance)
+	return append(left[:len(left)-1:len(left)-1], right...)
+}
@@ -0,0 +1,603 @@
+package physics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/jakecoffman/cp"
+)
+
+const (
+	// PortableFormat names the portable scene files, for a tool reading
+	// JSON files to tell them from others.
+	PortableFormat = "chipmunk-scene"
+	// PortableVersion is the version of the portable scene files written
+	// now, the first; one made by a later build isn't read.
+	PortableVersion = 1
+)
+
+// allBits is what the portable format writes for a filter's categories or
+// mask with all its bits set: it keeps 32 bits, as Chipmunk does in C and a
+// JSON number holds exactly.
+const allBits = math.MaxUint32
+
+// PortableScene is a space in the portable format, for other tools to
+// read: its parameters, the materials of its shapes, its bodies with their
+// shapes, and the joints between them. Vectors are [x, y] arrays, angles in
+// radians, y going down. physics/scene.schema.json is its JSON Schema, and
+// the README describes it.
+type PortableScene struct {
+	Format    string             `json:"format"`
+	Version   int                `json:"version"`
+	Space     PortableSpace      `json:"space"`
+	Materials []PortableMaterial `json:"materials"`
+	// Bodies has the static body first, numbered 0, then the others,
+	// numbered from 1.
+	Bodies []PortableBody  `json:"bodies"`
+	Joints []PortableJoint `json:"joints"`
+}
+
+// PortableSpace are the parameters of the space.
+type PortableSpace struct {
+	Gravity    [2]float64 `json:"gravity"`
+	Damping    float64    `json:"damping"`
+	Iterations uint       `json:"iterations"`
+}
+
+// PortableMaterial is the surface of shapes, which refer to it by name.
+type PortableMaterial struct {
+	Name       string  `json:"name"`
+	Friction   float64 `json:"friction"`
+	Elasticity float64 `json:"elasticity"`
+}
+
+// PortableBody is a body and its shapes. Type is "static", "dynamic" or
+// "kinematic"; only a dynamic body has a mass and a moment, no moment being
+// an infinite one, which JSON has no number for.
+type PortableBody struct {
+	ID              int             `json:"id"`
+	Type            string          `json:"type"`
+	Mass            float64         `json:"mass,omitempty"`
+	Moment          *float64        `json:"moment,omitempty"`
+	Position        [2]float64      `json:"position"`
+	Angle           float64         `json:"angle"`
+	Velocity        [2]float64      `json:"velocity"`
+	AngularVelocity float64         `json:"angular_velocity"`
+	Shapes          []PortableShape `json:"shapes"`
+}
+
+// PortableShape is a shape of a body, in the body's coordinates. Type is
+// "circle", with a Center, "segment", with A and B, or "polygon", with its
+// Vertices, counterclockwise on screen; the radius rounds a segment's ends
+// and a polygon's corners. Mass is left out when the body's mass was set
+// rather than summed from its shapes', and Filter when it collides with
+// everything.
+type PortableShape struct {
+	Type          string          `json:"type"`
+	Radius        float64         `json:"radius"`
+	Center        *[2]float64     `json:"center,omitempty"`
+	A             *[2]float64     `json:"a,omitempty"`
+	B             *[2]float64     `json:"b,omitempty"`
+	Vertices      [][2]float64    `json:"vertices,omitempty"`
+	Material      string          `json:"material"`
+	Mass          float64         `json:"mass,omitempty"`
+	Sensor        bool            `json:"sensor,omitempty"`
+	CollisionType uint            `json:"collision_type,omitempty"`
+	Filter        *PortableFilter `json:"filter,omitempty"`
+}
+
+// PortableFilter is a shape's collision filter: shapes of the same non-zero
+// group don't collide, nor do those whose categories aren't in the other's
+// mask. Categories and Mask keep 32 bits.
+type PortableFilter struct {
+	Group      uint   `json:"group"`
+	Categories uint32 `json:"categories"`
+	Mask       uint32 `json:"mask"`
+}
+
+// PortableJoint is a joint between the bodies numbered BodyA and BodyB, its
+// anchors in their coordinates. Which of the other fields it has depends on
+// its Type:
+//
+//   - "pin": AnchorA, AnchorB and Distance.
+//   - "slide": AnchorA, AnchorB, Min and Max, distances.
+//   - "pivot": AnchorA and AnchorB.
+//   - "groove": GrooveA and GrooveB on A, AnchorB.
+//   - "damped_spring": AnchorA, AnchorB, RestLength, Stiffness and Damping.
+//   - "damped_rotary_spring": RestAngle, Stiffness and Damping.
+//   - "rotary_limit": Min and Max, angles.
+//   - "ratchet": Angle, Phase and Ratchet.
+//   - "gear": Phase and Ratio.
+//   - "simple_motor": Rate.
+//
+// No MaxForce or MaxBias is an infinite one, no ErrorBias or CollideBodies
+// Chipmunk's default.
+type PortableJoint struct {
+	Type          string      `json:"type"`
+	BodyA         int         `json:"body_a"`
+	BodyB         int         `json:"body_b"`
+	AnchorA       *[2]float64 `json:"anchor_a,omitempty"`
+	AnchorB       *[2]float64 `json:"anchor_b,omitempty"`
+	GrooveA       *[2]float64 `json:"groove_a,omitempty"`
+	GrooveB       *[2]float64 `json:"groove_b,omitempty"`
+	Distance      *float64    `json:"distance,omitempty"`
+	Min           *float64    `json:"min,omitempty"`
+	Max           *float64    `json:"max,omitempty"`
+	RestLength    *float64    `json:"rest_length,omitempty"`
+	RestAngle     *float64    `json:"rest_angle,omitempty"`
+	Stiffness     *float64    `json:"stiffness,omitempty"`
+	Damping       *float64    `json:"damping,omitempty"`
+	Angle         *float64    `json:"angle,omitempty"`
+	Phase         *float64    `json:"phase,omitempty"`
+	Ratchet       *float64    `json:"ratchet,omitempty"`
+	Ratio         *float64    `json:"ratio,omitempty"`
+	Rate          *float64    `json:"rate,omitempty"`
+	MaxForce      *float64    `json:"max_force,omitempty"`
+	MaxBias       *float64    `json:"max_bias,omitempty"`
+	ErrorBias     *float64    `json:"error_bias,omitempty"`
+	CollideBodies *bool       `json:"collide_bodies,omitempty"`
+}
+
+// portableBodyTypes names the body types in the portable format.
+var portableBodyTypes = map[int]string{
+	cp.BODY_DYNAMIC:   "dynamic",
+	cp.BODY_KINEMATIC: "kinematic",
+	cp.BODY_STATIC:    "static",
+}
+
+// ExportScene exports the space's parameters, its static body and the
+// given bodies, like NewLevel, with the joints between them: a joint to a
+// body left out, e.g. a mouse's, is left out too, and so are the custom
+// forces of springs and the callbacks of joints, which are code.
+func ExportScene(space *cp.Space, bodies []*cp.Body) PortableScene {
+	scene := PortableScene{
+		Format:  PortableFormat,
+		Version: PortableVersion,
+		Space: PortableSpace{
+			Gravity:    portableVector(space.Gravity()),
+			Damping:    space.Damping(),
+			Iterations: space.Iterations,
+		},
+		Materials: []PortableMaterial{},
+		Joints:    []PortableJoint{},
+	}
+	materials := map[[2]float64]string{}
+	ids := map[uintptr]int{}
+	for i, body := range append([]*cp.Body{space.StaticBody}, bodies...) {
+		ids[reflect.ValueOf(body).Pointer()] = i
+		scene.Bodies = append(scene.Bodies, exportBody(i, body, &scene, materials))
+	}
+
+	// cp has no getters for a joint's bodies, they're read from the fields.
+	space.EachConstraint(func(c *cp.Constraint) {
+		v := reflect.ValueOf(c).Elem()
+		a, okA := ids[v.FieldByName("a").Pointer()]
+		b, okB := ids[v.FieldByName("b").Pointer()]
+		if !okA || !okB {
+			return
+		}
+		if j, ok := exportJoint(c, a, b); ok {
+			scene.Joints = append(scene.Joints, j)
+		}
+	})
+	return scene
+}
+
+func exportBody(id int, body *cp.Body, scene *PortableScene, materials map[[2]float64]string) PortableBody {
+	state := SaveBody(body)
+	b := PortableBody{
+		ID:              id,
+		Type:            portableBodyTypes[state.BodyType],
+		Position:        portableVector(state.Position),
+		Angle:           state.Angle,
+		Velocity:        portableVector(state.Velocity),
+		AngularVelocity: state.AngularVelocity,
+		Shapes:          []PortableShape{},
+	}
+	if b.Type == "dynamic" {
+		b.Mass = state.Mass
+		if !infinite(state.Moment) {
+			b.Moment = portableNumber(state.Moment)
+		}
+	}
+	for _, s := range state.Shapes {
+		key := [2]float64{s.Friction, s.Elasticity}
+		name, ok := materials[key]
+		if !ok {
+			name = fmt.Sprintf("material%d", len(materials)+1)
+			materials[key] = name
+			scene.Materials = append(scene.Materials, PortableMaterial{Name: name, Friction: s.Friction, Elasticity: s.Elasticity})
+		}
+		shape := PortableShape{
+			Radius:        s.Radius,
+			Material:      name,
+			Mass:          s.Mass,
+			Sensor:        s.Sensor,
+			CollisionType: uint(s.CollisionType),
+		}
+		switch s.Class.(type) {
+		case *cp.Circle:
+			shape.Type, shape.Center = "circle", portableVectorOf(s.Offset)
+		case *cp.Segment:
+			shape.Type, shape.A, shape.B = "segment", portableVectorOf(s.A), portableVectorOf(s.B)
+		case *cp.PolyShape:
+			shape.Type = "polygon"
+			for _, v := range s.Verts {
+				shape.Vertices = append(shape.Vertices, portableVector(v))
+			}
+		}
+		if s.Filter != cp.SHAPE_FILTER_ALL {
+			shape.Filter = &PortableFilter{
+				Group:      s.Filter.Group,
+				Categories: portableBits(s.Filter.Categories),
+				Mask:       portableBits(s.Filter.Mask),
+			}
+		}
+		b.Shapes = append(b.Shapes, shape)
+	}
+	return b
+}
+
+// exportJoint returns the joint c between the bodies numbered a and b, false
+// for a class the format doesn't have.
+func exportJoint(c *cp.Constraint, a, b int) (PortableJoint, bool) {
+	j := PortableJoint{
+		BodyA:         a,
+		BodyB:         b,
+		ErrorBias:     portableNumber(c.ErrorBias()),
+		CollideBodies: portableBool(reflect.ValueOf(c).Elem().FieldByName("collideBodies").Bool()),
+	}
+	if f := c.MaxForce(); !infinite(f) {
+		j.MaxForce = portableNumber(f)
+	}
+	if f := c.MaxBias(); !infinite(f) {
+		j.MaxBias = portableNumber(f)
+	}
+	switch class := c.Class.(type) {
+	case *cp.PinJoint:
+		j.Type, j.AnchorA, j.AnchorB = "pin", portableVectorOf(class.AnchorA), portableVectorOf(class.AnchorB)
+		j.Distance = portableNumber(class.Dist)
+	case *cp.SlideJoint:
+		j.Type, j.AnchorA, j.AnchorB = "slide", portableVectorOf(class.AnchorA), portableVectorOf(class.AnchorB)
+		j.Min, j.Max = portableNumber(class.Min), portableNumber(class.Max)
+	case *cp.PivotJoint:
+		j.Type, j.AnchorA, j.AnchorB = "pivot", portableVectorOf(class.AnchorA), portableVectorOf(class.AnchorB)
+	case *cp.GrooveJoint:
+		j.Type, j.GrooveA, j.GrooveB = "groove", portableVectorOf(class.GrooveA), portableVectorOf(class.GrooveB)
+		j.AnchorB = portableVectorOf(class.AnchorB)
+	case *cp.DampedSpring:
+		j.Type, j.AnchorA, j.AnchorB = "damped_spring", portableVectorOf(class.AnchorA), portableVectorOf(class.AnchorB)
+		j.RestLength = portableNumber(class.RestLength)
+		j.Stiffness, j.Damping = portableNumber(class.Stiffness), portableNumber(class.Damping)
+	case *cp.DampedRotarySpring:
+		j.Type, j.RestAngle = "damped_rotary_spring", portableNumber(class.RestAngle)
+		j.Stiffness, j.Damping = portableNumber(class.Stiffness), portableNumber(class.Damping)
+	case *cp.RotaryLimitJoint:
+		j.Type, j.Min, j.Max = "rotary_limit", portableNumber(class.Min), portableNumber(class.Max)
+	case *cp.RatchetJoint:
+		j.Type, j.Angle = "ratchet", portableNumber(class.Angle)
+		j.Phase, j.Ratchet = portableNumber(class.Phase), portableNumber(class.Ratchet)
+	case *cp.GearJoint:
+		// cp has no getters for a gear's phase and ratio, they're read
+		// from the fields.
+		v := reflect.ValueOf(class).Elem()
+		j.Type = "gear"
+		j.Phase, j.Ratio = portableNumber(v.FieldByName("phase").Float()), portableNumber(v.FieldByName("ratio").Float())
+	case *cp.SimpleMotor:
+		j.Type, j.Rate = "simple_motor", portableNumber(class.Rate)
+	default:
+		return j, false
+	}
+	return j, true
+}
+
+// Validate tells what in the scene can't be built: an unknown type, a shape
+// of an unknown material, a joint missing a field of its type or between
+// bodies the scene hasn't.
+func (s PortableScene) Validate() error {
+	materials := map[string]bool{}
+	for _, m := range s.Materials {
+		materials[m.Name] = true
+	}
+	ids := map[int]bool{}
+	for i, b := range s.Bodies {
+		if b.ID != i {
+			return fmt.Errorf("body %d is numbered %d", i, b.ID)
+		}
+		ids[b.ID] = true
+		bodyType, ok := portableBodyType(b.Type)
+		switch {
+		case !ok:
+			return fmt.Errorf("body %d: unknown type %q", b.ID, b.Type)
+		case i == 0 && bodyType != cp.BODY_STATIC:
+			return errors.New("body 0 isn't the static body")
+		case i > 0 && bodyType == cp.BODY_STATIC:
+			return fmt.Errorf("body %d: only body 0 is static", b.ID)
+		case bodyType == cp.BODY_DYNAMIC && !(b.Mass > 0):
+			return fmt.Errorf("body %d: dynamic without a positive mass", b.ID)
+		case b.Moment != nil && !(*b.Moment > 0):
+			return fmt.Errorf("body %d: moment %g isn't positive", b.ID, *b.Moment)
+		}
+		for _, shape := range b.Shapes {
+			if _, err := shape.state(nil); err != nil {
+				return fmt.Errorf("body %d: %w", b.ID, err)
+			}
+			if !materials[shape.Material] {
+				return fmt.Errorf("body %d: unknown material %q", b.ID, shape.Material)
+			}
+		}
+	}
+	for i, j := range s.Joints {
+		if !ids[j.BodyA] || !ids[j.BodyB] {
+			return fmt.Errorf("joint %d: no body %d or %d", i, j.BodyA, j.BodyB)
+		}
+		// Making it between bodies of its own checks its fields.
+		if _, err := j.build(cp.NewBody(1, 1), cp.NewBody(1, 1)); err != nil {
+			return fmt.Errorf("joint %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Build adds the scene to the space, setting its parameters, and returns
+// the bodies it added, without the static one, like Level.Build. What
+// Validate rejects is skipped.
+func (s PortableScene) Build(space *cp.Space) []*cp.Body {
+	space.SetGravity(cp.Vector{X: s.Space.Gravity[0], Y: s.Space.Gravity[1]})
+	space.SetDamping(s.Space.Damping)
+	if s.Space.Iterations > 0 {
+		space.Iterations = s.Space.Iterations
+	}
+	materials := map[string]PortableMaterial{}
+	for _, m := range s.Materials {
+		materials[m.Name] = m
+	}
+	built := map[int]*cp.Body{}
+	var bodies []*cp.Body
+	for i, b := range s.Bodies {
+		bodyType, ok := portableBodyType(b.Type)
+		if !ok || (i == 0) != (bodyType == cp.BODY_STATIC) {
+			continue
+		}
+		state := BodyState{
+			BodyType:        bodyType,
+			Mass:            b.Mass,
+			Moment:          math.Inf(1),
+			Position:        cp.Vector{X: b.Position[0], Y: b.Position[1]},
+			Angle:           b.Angle,
+			Velocity:        cp.Vector{X: b.Velocity[0], Y: b.Velocity[1]},
+			AngularVelocity: b.AngularVelocity,
+		}
+		if b.Moment != nil {
+			state.Moment = *b.Moment
+		}
+		if bodyType != cp.BODY_DYNAMIC {
+			state.Mass = math.Inf(1)
+		}
+		for _, shape := range b.Shapes {
+			m, ok := materials[shape.Material]
+			if !ok {
+				continue
+			}
+			if shapeState, err := shape.state(&m); err == nil {
+				state.Shapes = append(state.Shapes, shapeState)
+			}
+		}
+		body := state.Restore(space)
+		built[b.ID] = body
+		if body != space.StaticBody {
+			bodies = append(bodies, body)
+		}
+	}
+	for _, j := range s.Joints {
+		a, b := built[j.BodyA], built[j.BodyB]
+		if a == nil || b == nil {
+			continue
+		}
+		if c, err := j.build(a, b); err == nil {
+			space.AddConstraint(c)
+		}
+	}
+	return bodies
+}
+
+// portableBodyType returns the cp body type named t.
+func portableBodyType(t string) (int, bool) {
+	for bodyType, name := range portableBodyTypes {
+		if name == t {
+			return bodyType, true
+		}
+	}
+	return 0, false
+}
+
+// state returns the shape as a ShapeState, of material m, none to only
+// check its geometry.
+func (shape PortableShape) state(m *PortableMaterial) (ShapeState, error) {
+	s := ShapeState{
+		Radius:        shape.Radius,
+		Mass:          shape.Mass,
+		Sensor:        shape.Sensor,
+		CollisionType: cp.CollisionType(shape.CollisionType),
+		Filter:        cp.SHAPE_FILTER_ALL,
+	}
+	if m != nil {
+		s.Friction, s.Elasticity = m.Friction, m.Elasticity
+	}
+	if f := shape.Filter; f != nil {
+		s.Filter = cp.ShapeFilter{Group: f.Group, Categories: cpBits(f.Categories), Mask: cpBits(f.Mask)}
+	}
+	switch shape.Type {
+	case "circle":
+		if shape.Center == nil {
+			return s, errors.New("circle without center")
+		}
+		s.Class, s.Offset = &cp.Circle{}, cp.Vector{X: shape.Center[0], Y: shape.Center[1]}
+	case "segment":
+		if shape.A == nil || shape.B == nil {
+			return s, errors.New("segment without a and b")
+		}
+		s.Class = &cp.Segment{}
+		s.A, s.B = cp.Vector{X: shape.A[0], Y: shape.A[1]}, cp.Vector{X: shape.B[0], Y: shape.B[1]}
+	case "polygon":
+		if len(shape.Vertices) < 3 {
+			return s, fmt.Errorf("polygon of %d vertices", len(shape.Vertices))
+		}
+		s.Class = &cp.PolyShape{}
+		for _, v := range shape.Vertices {
+			s.Verts = append(s.Verts, cp.Vector{X: v[0], Y: v[1]})
+		}
+	default:
+		return s, fmt.Errorf("unknown shape type %q", shape.Type)
+	}
+	return s, nil
+}
+
+// build returns the joint between a and b, or what it is missing.
+func (j PortableJoint) build(a, b *cp.Body) (*cp.Constraint, error) {
+	var missing []string
+	number := func(name string, f *float64) float64 {
+		if f == nil {
+			missing = append(missing, name)
+			return 0
+		}
+		return *f
+	}
+	vector := func(name string, v *[2]float64) cp.Vector {
+		if v == nil {
+			missing = append(missing, name)
+			return cp.Vector{}
+		}
+		return cp.Vector{X: v[0], Y: v[1]}
+	}
+	var c *cp.Constraint
+	switch j.Type {
+	case "pin":
+		c = cp.NewPinJoint(a, b, vector("anchor_a", j.AnchorA), vector("anchor_b", j.AnchorB))
+		c.Class.(*cp.PinJoint).Dist = number("distance", j.Distance)
+	case "slide":
+		c = cp.NewSlideJoint(a, b, vector("anchor_a", j.AnchorA), vector("anchor_b", j.AnchorB), number("min", j.Min), number("max", j.Max))
+	case "pivot":
+		c = cp.NewPivotJoint2(a, b, vector("anchor_a", j.AnchorA), vector("anchor_b", j.AnchorB))
+	case "groove":
+		c = cp.NewGrooveJoint(a, b, vector("groove_a", j.GrooveA), vector("groove_b", j.GrooveB), vector("anchor_b", j.AnchorB))
+	case "damped_spring":
+		c = cp.NewDampedSpring(a, b, vector("anchor_a", j.AnchorA), vector("anchor_b", j.AnchorB),
+			number("rest_length", j.RestLength), number("stiffness", j.Stiffness), number("damping", j.Damping))
+	case "damped_rotary_spring":
+		c = cp.NewDampedRotarySpring(a, b, number("rest_angle", j.RestAngle), number("stiffness", j.Stiffness), number("damping", j.Damping))
+	case "rotary_limit":
+		c = cp.NewRotaryLimitJoint(a, b, number("min", j.Min), number("max", j.Max))
+	case "ratchet":
+		c = cp.NewRatchetJoint(a, b, number("phase", j.Phase), number("ratchet", j.Ratchet))
+		c.Class.(*cp.RatchetJoint).Angle = number("angle", j.Angle)
+	case "gear":
+		c = cp.NewGearJoint(a, b, number("phase", j.Phase), number("ratio", j.Ratio))
+	case "simple_motor":
+		c = cp.NewSimpleMotor(a, b, number("rate", j.Rate))
+	default:
+		return nil, fmt.Errorf("unknown joint type %q", j.Type)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s joint without %s", j.Type, strings.Join(missing, ", "))
+	}
+	for name, f := range map[string]*float64{"max_force": j.MaxForce, "max_bias": j.MaxBias, "error_bias": j.ErrorBias} {
+		if f != nil && *f < 0 {
+			return nil, fmt.Errorf("%s joint with a negative %s", j.Type, name)
+		}
+	}
+	if j.MaxForce != nil {
+		c.SetMaxForce(*j.MaxForce)
+	}
+	if j.MaxBias != nil {
+		c.SetMaxBias(*j.MaxBias)
+	}
+	if j.ErrorBias != nil {
+		c.SetErrorBias(*j.ErrorBias)
+	}
+	if j.CollideBodies != nil {
+		c.SetCollideBodies(*j.CollideBodies)
+	}
+	return c, nil
+}
+
+// DecodeScene decodes a portable scene in JSON and validates it.
+func DecodeScene(data []byte) (PortableScene, error) {
+	var s PortableScene
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, err
+	}
+	switch {
+	case s.Format != PortableFormat:
+		return s, fmt.Errorf("not a %s file", PortableFormat)
+	case s.Version > PortableVersion:
+		return s, fmt.Errorf("scene version %d is newer than this build's, %d", s.Version, PortableVersion)
+	}
+	return s, s.Validate()
+}
+
+// ReadScene reads the portable scene file at path.
+func ReadScene(path string) (PortableScene, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return PortableScene{}, err
+	}
+	s, err := DecodeScene(data)
+	if err != nil {
+		return s, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// WriteScene writes the portable scene to the file at path.
+func WriteScene(path string, s PortableScene) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// infinite tells if f is infinite to cp, which has its INFINITY for it.
+func infinite(f float64) bool {
+	return f >= cp.INFINITY
+}
+
+func portableVector(v cp.Vector) [2]float64 {
+	return [2]float64{v.X, v.Y}
+}
+
+func portableVectorOf(v cp.Vector) *[2]float64 {
+	p := portableVector(v)
+	return &p
+}
+
+func portableNumber(f float64) *float64 {
+	return &f
+}
+
+func portableBool(b bool) *bool {
+	return &b
+}
+
+// portableBits keeps the 32 bits of a filter's categories or mask, all of
+// them when all of cp's are set.
+func portableBits(bits uint) uint32 {
+	if bits == cp.ALL_CATEGORIES {
+		return allBits
+	}
+	return uint32(bits)
+}
+
+// cpBits is the reverse of portableBits.
+func cpBits(bits uint32) uint {
+	if bits == allBits {
+		return cp.ALL_CATEGORIES
+	}
+	return uint(bits)
+}
@@ -0,0 +1,218 @@
+package physics_test
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+// portableSpace is a space with something of each kind the portable format
+// has: a material shared by the box and the rod, the three shapes, an infinite moment, a filter, and
+// joints of several types, one of them to the static body.
+func portableSpace() (*cp.Space, []*cp.Body) {
+	space := cp.NewSpace()
+	space.SetGravity(cp.Vector{Y: 500})
+	space.SetDamping(0.9)
+	space.Iterations = 20
+	physics.AddWalls(space, cp.BB{R: 800, T: 600})
+
+	box, boxShape := physics.AddBox(space, cp.Vector{X: 100, Y: 100}, 40, 20)
+	boxShape.SetFilter(cp.ShapeFilter{Group: 3, Categories: 1, Mask: cp.ALL_CATEGORIES &^ 2})
+	ball, ballShape := physics.AddBall(space, cp.Vector{X: 200, Y: 100}, 15)
+	ballShape.SetFriction(0.2)
+	ballShape.SetElasticity(0.9)
+	ballShape.SetCollisionType(7)
+	ball.SetMoment(math.Inf(1))
+	ball.SetVelocity(10, -5)
+	rod := space.AddBody(cp.NewBody(2, 50))
+	rod.SetPosition(cp.Vector{X: 300, Y: 200})
+	rod.SetAngle(0.5)
+	rodShape := space.AddShape(cp.NewSegment(rod, cp.Vector{X: -20}, cp.Vector{X: 20}, 3))
+	rodShape.SetFriction(boxShape.Friction())
+	rodShape.SetElasticity(boxShape.Elasticity())
+
+	pivot := space.AddConstraint(cp.NewPivotJoint(space.StaticBody, box, cp.Vector{X: 100, Y: 80}))
+	pivot.SetMaxForce(1000)
+	pin := space.AddConstraint(cp.NewPinJoint(box, ball, cp.Vector{X: 10}, cp.Vector{}))
+	pin.SetCollideBodies(false)
+	space.AddConstraint(cp.NewDampedSpring(ball, rod, cp.Vector{}, cp.Vector{X: 20}, 80, 30, 2))
+	space.AddConstraint(cp.NewGearJoint(box, rod, 0.25, 3))
+	space.AddConstraint(cp.NewSimpleMotor(space.StaticBody, rod, 1.5))
+	return space, []*cp.Body{box, ball, rod}
+}
+
+// TestPortableRoundTrip exports a space, reads the JSON back into another
+// space and exports that: the two exports are the same.
+func TestPortableRoundTrip(t *testing.T) {
+	space, bodies := portableSpace()
+	scene := physics.ExportScene(space, bodies)
+	if len(scene.Bodies) != 4 || len(scene.Joints) != 5 || len(scene.Materials) != 3 {
+		t.Fatalf("exported %d bodies, %d joints and %d materials, want 4, 5 and 3", len(scene.Bodies), len(scene.Joints), len(scene.Materials))
+	}
+	data, err := json.Marshal(scene)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := physics.DecodeScene(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebuilt := cp.NewSpace()
+	built := decoded.Build(rebuilt)
+	if len(built) != 3 {
+		t.Fatalf("built %d bodies, want 3", len(built))
+	}
+	again, err := json.Marshal(physics.ExportScene(rebuilt, built))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("the rebuilt space exports as\n%s\nnot as\n%s", again, data)
+	}
+	if rebuilt.Iterations != 20 || rebuilt.Damping() != 0.9 {
+		t.Errorf("the rebuilt space has %d iterations and a damping of %g", rebuilt.Iterations, rebuilt.Damping())
+	}
+	if !math.IsInf(built[1].Moment(), 1) {
+		t.Errorf("the ball's moment is %g, not infinite", built[1].Moment())
+	}
+}
+
+// TestPortableJSON checks what other tools read: numbers where JSON has
+// none, and filters in 32 bits.
+func TestPortableJSON(t *testing.T) {
+	space, bodies := portableSpace()
+	data, err := json.Marshal(physics.ExportScene(space, bodies))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var generic struct {
+		Bodies []struct {
+			Moment *float64 `json:"moment"`
+			Shapes []struct {
+				Filter *struct {
+					Categories uint64 `json:"categories"`
+					Mask       uint64 `json:"mask"`
+				} `json:"filter"`
+			} `json:"shapes"`
+		} `json:"bodies"`
+		Joints []map[string]interface{} `json:"joints"`
+	}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		t.Fatal(err)
+	}
+	if generic.Bodies[2].Moment != nil {
+		t.Errorf("the ball's infinite moment is written as %g", *generic.Bodies[2].Moment)
+	}
+	filter := generic.Bodies[1].Shapes[0].Filter
+	if filter == nil || filter.Categories != 1 || filter.Mask != math.MaxUint32&^2 {
+		t.Errorf("the box's filter is %+v", filter)
+	}
+	if generic.Bodies[2].Shapes[0].Filter != nil {
+		t.Errorf("the ball's filter is written, it collides with everything")
+	}
+	for _, j := range generic.Joints {
+		if _, ok := j["max_bias"]; ok {
+			t.Errorf("an infinite max bias is written: %v", j)
+		}
+		if _, ok := j["max_force"]; ok != (j["type"] == "pivot") {
+			t.Errorf("the max force is written %v, only the pivot's isn't infinite: %v", ok, j)
+		}
+	}
+}
+
+func TestDecodeSceneRefuses(t *testing.T) {
+	space, bodies := portableSpace()
+	data, err := json.Marshal(physics.ExportScene(space, bodies))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct{ old, new, err string }{
+		{`"version":1`, `"version":2`, "newer"},
+		{`"format":"chipmunk-scene"`, `"format":"other"`, "not a chipmunk-scene file"},
+		{`"type":"gear"`, `"type":"weld"`, `unknown joint type "weld"`},
+		{`"distance":`, `"length":`, "pin joint without distance"},
+		{`"body_b":3`, `"body_b":9`, "no body"},
+		{`"material":"material2"`, `"material":"rubber"`, `unknown material "rubber"`},
+	} {
+		changed := strings.Replace(string(data), c.old, c.new, 1)
+		if changed == string(data) {
+			t.Fatalf("no %s in %s", c.old, data)
+		}
+		if _, err := physics.DecodeScene([]byte(changed)); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("with %s, decoding fails with %v, want %q", c.new, err, c.err)
+		}
+	}
+}
+
+// TestSceneSchema checks the schema against the decoder: a joint of each
+// type with only the fields the schema requires decodes, and one missing
+// any of them doesn't.
+func TestSceneSchema(t *testing.T) {
+	data, err := os.ReadFile("scene.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Defs struct {
+			Joint struct {
+				Properties struct {
+					Type struct {
+						Enum []string `json:"enum"`
+					} `json:"type"`
+				} `json:"properties"`
+				AllOf []struct {
+					If struct {
+						Properties struct {
+							Type struct {
+								Const string `json:"const"`
+							} `json:"type"`
+						} `json:"properties"`
+					} `json:"if"`
+					Then struct {
+						Required []string `json:"required"`
+					} `json:"then"`
+				} `json:"allOf"`
+			} `json:"joint"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	joint := schema.Defs.Joint
+	if len(joint.AllOf) != len(joint.Properties.Type.Enum) {
+		t.Fatalf("the schema has the fields of %d joint types out of %d", len(joint.AllOf), len(joint.Properties.Type.Enum))
+	}
+	for _, c := range joint.AllOf {
+		jointType, required := c.If.Properties.Type.Const, c.Then.Required
+		for skip := -1; skip < len(required); skip++ {
+			fields := []string{`"type":"` + jointType + `"`, `"body_a":0`, `"body_b":1`}
+			for i, name := range required {
+				value := "1"
+				if strings.HasPrefix(name, "anchor") || strings.HasPrefix(name, "groove") {
+					value = "[1,0]"
+				}
+				if i != skip {
+					fields = append(fields, `"`+name+`":`+value)
+				}
+			}
+			scene := `{"format":"chipmunk-scene","version":1,"space":{"gravity":[0,100],"damping":1,"iterations":10},"materials":[],` +
+				`"bodies":[{"id":0,"type":"static","position":[0,0],"angle":0,"velocity":[0,0],"angular_velocity":0,"shapes":[]},` +
+				`{"id":1,"type":"dynamic","mass":1,"moment":1,"position":[0,0],"angle":0,"velocity":[0,0],"angular_velocity":0,"shapes":[]}],` +
+				`"joints":[{` + strings.Join(fields, ",") + `}]}`
+			_, err := physics.DecodeScene([]byte(scene))
+			switch {
+			case skip < 0 && err != nil:
+				t.Errorf("a %s joint with %v doesn't decode: %v", jointType, required, err)
+			case skip >= 0 && err == nil:
+				t.Errorf("a %s joint without %s decodes", jointType, required[skip])
+			}
+		}
+	}
+}
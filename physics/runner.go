@@ -0,0 +1,233 @@
+package physics
+
+import (
+	"context"
+	"fmt"
+	"runtime/trace"
+	"sync"
+	"time"
+
+	"github.com/jakecoffman/cp"
+)
+
+// runnerMaxPending caps the steps a runner may be behind by: a space too
+// heavy to step in real time slows down instead of piling up steps.
+const runnerMaxPending = 5
+
+// BodySnapshot is where a body was after a step, for drawing it. Body only
+// identifies it: it belongs to the runner's goroutine, the snapshot is what
+// may be read.
+type BodySnapshot struct {
+	Body     *cp.Body
+	Position cp.Vector
+	Angle    float64
+	Velocity cp.Vector
+}
+
+// Snapshot is the state of a runner's space after a step. Once published it
+// doesn't change while it is being viewed.
+type Snapshot struct {
+	// Step is how many steps the space has made, and StepTime how long the
+	// last one took.
+	Step     int
+	StepTime time.Duration
+	// Bodies are the space's dynamic bodies, in the order of the space.
+	Bodies []BodySnapshot
+	// Stats counts what the space is made of.
+	Stats Stats
+}
+
+// Runner steps a space on a goroutine of its own, for a heavy space not to
+// hold up the game's Update and Draw. The game tells it how many fixed
+// steps to make with Advance, from its own clock, and so pausing the game
+// pauses it; it changes the space through Do, and draws from the latest
+// snapshot with View. The space is the runner's from Start to Stop: no
+// other goroutine may touch it, its bodies or its shapes.
+//
+// The snapshots are double buffered: the runner fills one while the other
+// is viewed, and swaps them after each step.
+type Runner struct {
+	space *cp.Space
+	dt    float64
+	// OnStep, if set, is called on the runner's goroutine after each step,
+	// e.g. to remove the bodies that fell off.
+	OnStep func(space *cp.Space)
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// pending is how many steps to make, and commands what to do to the
+	// space before the next one.
+	pending  int
+	commands []func(*cp.Space)
+	// busy is whether the goroutine is doing something, stopped whether it
+	// was asked to stop, and err why it stopped on its own.
+	busy    bool
+	stopped bool
+	err     error
+	started bool
+	done    chan struct{}
+
+	viewMu      sync.Mutex
+	front, back *Snapshot
+	steps       int
+	stepTime    time.Duration
+}
+
+// NewRunner returns a runner stepping the space by dt seconds at a time,
+// not started yet.
+func NewRunner(space *cp.Space, dt float64) *Runner {
+	r := &Runner{
+		space: space,
+		dt:    dt,
+		front: &Snapshot{},
+		back:  &Snapshot{},
+		done:  make(chan struct{}),
+	}
+	r.cond = sync.NewCond(&r.mu)
+	return r
+}
+
+// Start publishes the first snapshot and starts the goroutine.
+func (r *Runner) Start() {
+	r.started = true
+	r.publish()
+	r.front, r.back = r.back, r.front
+	go r.run()
+}
+
+// Stop stops the goroutine after what it is doing, and waits for it: the
+// space is the caller's again. It does nothing if the runner wasn't started.
+func (r *Runner) Stop() {
+	if !r.started {
+		return
+	}
+	r.mu.Lock()
+	r.stopped = true
+	r.cond.Broadcast()
+	r.mu.Unlock()
+	<-r.done
+}
+
+// Advance asks for n more steps, as many as the game's clock made. A
+// runner already too far behind skips them.
+func (r *Runner) Advance(n int) {
+	r.mu.Lock()
+	r.pending += n
+	if r.pending > runnerMaxPending {
+		r.pending = runnerMaxPending
+	}
+	r.cond.Broadcast()
+	r.mu.Unlock()
+}
+
+// Do calls f on the runner's goroutine before the next step, to change the
+// space, e.g. to add a body or push one.
+func (r *Runner) Do(f func(space *cp.Space)) {
+	r.mu.Lock()
+	r.commands = append(r.commands, f)
+	r.cond.Broadcast()
+	r.mu.Unlock()
+}
+
+// Sync waits for the runner to have made the steps and run the commands
+// asked for so far, e.g. to read the space from a test.
+func (r *Runner) Sync() {
+	r.mu.Lock()
+	for (r.pending > 0 || len(r.commands) > 0 || r.busy) && !r.stopped && r.err == nil {
+		r.cond.Wait()
+	}
+	r.mu.Unlock()
+}
+
+// Space returns the space stepped, which only the runner's goroutine may
+// touch until it stopped, on its own or by Stop.
+func (r *Runner) Space() *cp.Space {
+	return r.space
+}
+
+// Err returns why the runner stopped on its own, a panic of the space or of
+// a command, nil while it runs.
+func (r *Runner) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// View calls f with the latest snapshot, which the runner doesn't change
+// until f returns. f shouldn't keep it.
+func (r *Runner) View(f func(s *Snapshot)) {
+	r.viewMu.Lock()
+	defer r.viewMu.Unlock()
+	f(r.front)
+}
+
+func (r *Runner) run() {
+	defer close(r.done)
+	defer func() {
+		if v := recover(); v != nil {
+			r.mu.Lock()
+			r.err = fmt.Errorf("physics runner: %v", v)
+			r.cond.Broadcast()
+			r.mu.Unlock()
+		}
+	}()
+	for {
+		r.mu.Lock()
+		r.busy = false
+		r.cond.Broadcast()
+		for r.pending == 0 && len(r.commands) == 0 && !r.stopped {
+			r.cond.Wait()
+		}
+		if r.stopped {
+			r.mu.Unlock()
+			return
+		}
+		commands := r.commands
+		r.commands = nil
+		step := r.pending > 0
+		if step {
+			r.pending--
+		}
+		r.busy = true
+		r.mu.Unlock()
+
+		for _, f := range commands {
+			f(r.space)
+		}
+		if step {
+			start := time.Now()
+			// A region of an execution trace, see the game's -trace.
+			region := trace.StartRegion(context.Background(), "physics runner step")
+			r.space.Step(r.dt)
+			region.End()
+			r.stepTime = time.Since(start)
+			r.steps++
+			if r.OnStep != nil {
+				r.OnStep(r.space)
+			}
+		}
+		r.publish()
+		r.viewMu.Lock()
+		r.front, r.back = r.back, r.front
+		r.viewMu.Unlock()
+	}
+}
+
+// publish fills the back snapshot from the space, reusing its memory.
+func (r *Runner) publish() {
+	s := r.back
+	s.Step, s.StepTime = r.steps, r.stepTime
+	s.Stats = SpaceStats(r.space)
+	s.Bodies = s.Bodies[:0]
+	r.space.EachBody(func(body *cp.Body) {
+		if body.GetType() != cp.BODY_DYNAMIC {
+			return
+		}
+		s.Bodies = append(s.Bodies, BodySnapshot{
+			Body:     body,
+			Position: body.Position(),
+			Angle:    body.Angle(),
+			Velocity: body.Velocity(),
+		})
+	})
+}
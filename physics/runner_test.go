@@ -0,0 +1,83 @@
+package physics_test
+
+import (
+	"testing"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+// TestRunnerMatchesStepping steps a seeded space on a runner, a few steps
+// at a time as the game's clock gives them, and checks that it ends where
+// the same space stepped in place does: the goroutine changes when the
+// steps are made, not what they make.
+func TestRunnerMatchesStepping(t *testing.T) {
+	want := seededSpace(1)
+	for i := 0; i < 120; i++ {
+		want.Step(1.0 / 60)
+	}
+
+	space := seededSpace(1)
+	r := physics.NewRunner(space, 1.0/60)
+	r.Start()
+	for i := 0; i < 120; i += 2 {
+		r.Advance(2)
+		r.Sync()
+	}
+	r.Stop()
+	if got, want := physics.Hash(space), physics.Hash(want); got != want {
+		t.Errorf("the runner's space hashes %016x, stepped in place %016x", got, want)
+	}
+}
+
+func TestRunnerSnapshots(t *testing.T) {
+	space := cp.NewSpace()
+	space.SetGravity(cp.Vector{Y: 100})
+	r := physics.NewRunner(space, 1.0/60)
+	r.Start()
+	defer r.Stop()
+
+	var body *cp.Body
+	r.Do(func(space *cp.Space) {
+		body, _ = physics.AddBox(space, cp.Vector{X: 10, Y: 20}, 4, 4)
+	})
+	r.Sync()
+	r.View(func(s *physics.Snapshot) {
+		if s.Step != 0 || len(s.Bodies) != 1 || s.Bodies[0].Body != body || s.Bodies[0].Position != (cp.Vector{X: 10, Y: 20}) {
+			t.Errorf("after adding a body, the snapshot is %+v", s)
+		}
+	})
+
+	// Viewing while the runner steps: the snapshot viewed doesn't change,
+	// which the race detector checks too.
+	r.Advance(60)
+	for i := 0; i < 100; i++ {
+		r.View(func(s *physics.Snapshot) {
+			step, y := s.Step, s.Bodies[0].Position.Y
+			for j := 0; j < 100; j++ {
+				if s.Step != step || s.Bodies[0].Position.Y != y {
+					t.Fatalf("the snapshot changed while viewed")
+				}
+			}
+		})
+	}
+	r.Sync()
+	r.View(func(s *physics.Snapshot) {
+		// Never more steps behind than the runner allows.
+		if s.Step < 1 || s.Step > 60 || s.Bodies[0].Position.Y <= 20 {
+			t.Errorf("after the steps, the snapshot is %+v", s)
+		}
+	})
+}
+
+func TestRunnerPanic(t *testing.T) {
+	r := physics.NewRunner(cp.NewSpace(), 1.0/60)
+	r.Start()
+	r.Do(func(*cp.Space) { panic("boom") })
+	r.Sync()
+	if r.Err() == nil {
+		t.Error("a panicking command didn't stop the runner with an error")
+	}
+	r.Stop()
+}
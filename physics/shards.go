@@ -0,0 +1,139 @@
+package physics
+
+import (
+	"context"
+	"runtime/trace"
+	"sync"
+	"time"
+
+	"github.com/jakecoffman/cp"
+)
+
+// Shards splits a world too big for one space to step in a frame into
+// spaces, one for each strip of it across x, stepped together on goroutines
+// of their own: a step takes as long as the slowest strip's, not as long as
+// all of them. The shapes of different strips don't collide, which suits
+// worlds of regions apart, walled off from each other, whose bodies only go
+// from one to another now and then.
+//
+// After each step, the bodies that left their strip, by more than the
+// margin not to go back and forth on an edge, are handed off: removed from
+// its space with their shapes and added to the space of the strip they are
+// in, their velocities kept. The hand-off is made in the order of the
+// spaces and of their bodies, so the world steps the same every time.
+// Bodies held by constraints stay in their space, the constraints with them.
+type Shards struct {
+	// Spaces are the strips' spaces, left to right. They are the caller's
+	// between steps, to add static geometry to each, or change a body.
+	Spaces []*cp.Space
+	// edges are where the strips meet, left to right, margin how far a body
+	// may be past one and stay in its strip.
+	edges  []float64
+	margin float64
+	// StepTimes are how long each space took in the last step, and HandedOff
+	// how many bodies went from a space to another since the start.
+	StepTimes []time.Duration
+	HandedOff int
+	// leaving are the bodies leaving a space at the hand-off, kept from one
+	// step to the next.
+	leaving []*cp.Body
+}
+
+// NewShards returns the shards of the strips between left and right, n of
+// them as wide as each other, with the spaces newSpace makes: the bodies
+// left of left are in the first, right of right in the last.
+func NewShards(n int, left, right, margin float64, newSpace func() *cp.Space) *Shards {
+	s := &Shards{
+		Spaces:    make([]*cp.Space, n),
+		margin:    margin,
+		StepTimes: make([]time.Duration, n),
+	}
+	for i := range s.Spaces {
+		s.Spaces[i] = newSpace()
+	}
+	for i := 1; i < n; i++ {
+		s.edges = append(s.edges, left+(right-left)*float64(i)/float64(n))
+	}
+	return s
+}
+
+// Shard returns the index of the strip p is in.
+func (s *Shards) Shard(p cp.Vector) int {
+	i := 0
+	for i < len(s.edges) && p.X >= s.edges[i] {
+		i++
+	}
+	return i
+}
+
+// Add adds body and its shapes to the space of the strip it is in, and
+// returns the space.
+func (s *Shards) Add(body *cp.Body, shapes ...*cp.Shape) *cp.Space {
+	space := s.Spaces[s.Shard(body.Position())]
+	space.AddBody(body)
+	for _, shape := range shapes {
+		space.AddShape(shape)
+	}
+	return space
+}
+
+// Step steps every space by dt, each on a goroutine, then hands off the
+// bodies that left their strip.
+func (s *Shards) Step(dt float64) {
+	var wg sync.WaitGroup
+	for i, space := range s.Spaces {
+		wg.Add(1)
+		go func(i int, space *cp.Space) {
+			defer wg.Done()
+			start := time.Now()
+			// A region of an execution trace, see the game's -trace.
+			defer trace.StartRegion(context.Background(), "physics shard step").End()
+			space.Step(dt)
+			s.StepTimes[i] = time.Since(start)
+		}(i, space)
+	}
+	wg.Wait()
+	s.handOff()
+}
+
+// handOff moves the bodies that left their strip to the space of the one
+// they are in.
+func (s *Shards) handOff() {
+	for i, space := range s.Spaces {
+		s.leaving = s.leaving[:0]
+		space.EachBody(func(body *cp.Body) {
+			if body.GetType() == cp.BODY_DYNAMIC && s.outside(i, body.Position()) && !hasConstraints(body) {
+				s.leaving = append(s.leaving, body)
+			}
+		})
+		for _, body := range s.leaving {
+			to := s.Spaces[s.Shard(body.Position())]
+			var shapes []*cp.Shape
+			body.EachShape(func(shape *cp.Shape) { shapes = append(shapes, shape) })
+			for _, shape := range shapes {
+				space.RemoveShape(shape)
+			}
+			space.RemoveBody(body)
+			to.AddBody(body)
+			for _, shape := range shapes {
+				to.AddShape(shape)
+			}
+			s.HandedOff++
+		}
+	}
+}
+
+// outside reports whether p is past the edges of strip i by more than the
+// margin.
+func (s *Shards) outside(i int, p cp.Vector) bool {
+	if i > 0 && p.X < s.edges[i-1]-s.margin {
+		return true
+	}
+	return i < len(s.edges) && p.X >= s.edges[i]+s.margin
+}
+
+func hasConstraints(body *cp.Body) bool {
+	found := false
+	body.EachConstraint(func(*cp.Constraint) { found = true })
+	return found
+}
@@ -0,0 +1,86 @@
+package physics_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+// TestShardsHandOff slides a ball right across the strips' edges and checks
+// that it is handed off to the space of each strip it goes into, its
+// velocity kept, once it is past the margin.
+func TestShardsHandOff(t *testing.T) {
+	s := physics.NewShards(3, 0, 300, 10, cp.NewSpace)
+	body, _ := physics.AddBall(s.Spaces[0], cp.Vector{X: 50, Y: 0}, 4)
+	body.SetVelocity(600, 0)
+	if got := s.Shard(body.Position()); got != 0 {
+		t.Fatalf("the ball at x 50 is in strip %d, want 0", got)
+	}
+	in := func() int {
+		found := -1
+		for i, space := range s.Spaces {
+			space.EachBody(func(b *cp.Body) {
+				if b == body {
+					found = i
+				}
+			})
+		}
+		return found
+	}
+	for i := 0; i < 30; i++ {
+		s.Step(1.0 / 60)
+		x := body.Position().X
+		want := 0
+		switch {
+		case x >= 210:
+			want = 2
+		case x >= 110:
+			want = 1
+		}
+		// Between an edge and the margin, either strip will do.
+		if got := in(); got != want && (x < 100 || x >= 110) && (x < 200 || x >= 210) {
+			t.Fatalf("the ball at x %.0f is in space %d, want %d", x, got, want)
+		}
+	}
+	if s.HandedOff != 2 || in() != 2 || body.Velocity().X != 600 {
+		t.Errorf("the ball was handed off %d times to space %d, at %v, want twice to 2 at 600", s.HandedOff, in(), body.Velocity())
+	}
+}
+
+// TestShardsDeterministic steps the same sharded world twice and checks
+// that it ends the same: the spaces step on goroutines, in any order, but
+// the hand-off is made in order.
+func TestShardsDeterministic(t *testing.T) {
+	run := func() []uint64 {
+		s := physics.NewShards(4, 0, 800, 8, cp.NewSpace)
+		for _, space := range s.Spaces {
+			space.SetGravity(cp.Vector{Y: 300})
+			physics.AddStaticSegment(space, cp.Vector{X: 0, Y: 300}, cp.Vector{X: 400, Y: 560}, 2)
+			physics.AddStaticSegment(space, cp.Vector{X: 400, Y: 560}, cp.Vector{X: 800, Y: 300}, 2)
+		}
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 200; i++ {
+			body := cp.NewBody(1, cp.MomentForCircle(1, 0, 5, cp.Vector{}))
+			body.SetPosition(cp.Vector{X: 800 * r.Float64(), Y: 300 * r.Float64()})
+			body.SetVelocity(200*r.Float64()-100, 0)
+			s.Add(body, cp.NewCircle(body, 5, cp.Vector{}))
+		}
+		for i := 0; i < 300; i++ {
+			s.Step(1.0 / 60)
+		}
+		var hashes []uint64
+		for _, space := range s.Spaces {
+			hashes = append(hashes, physics.Hash(space))
+		}
+		return hashes
+	}
+	first, second := run(), run()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("space %d hashes %016x, then %016x", i, first[i], second[i])
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package physics
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/jakecoffman/cp"
+)
+
+// BodyState is what it takes to rebuild a body with its shapes: to paste a
+// copy of it, to bring it back once removed, or to save it in a level.
+type BodyState struct {
+	BodyType        int          `json:"body_type"`
+	Mass            float64      `json:"mass"`
+	Moment          float64      `json:"moment"`
+	Position        cp.Vector    `json:"position"`
+	Angle           float64      `json:"angle"`
+	Velocity        cp.Vector    `json:"velocity"`
+	AngularVelocity float64      `json:"angular_velocity"`
+	Shapes          []ShapeState `json:"shapes"`
+}
+
+// ShapeState is a shape of a BodyState. Only the geometry of its class is
+// set: Offset for a circle, A and B for a segment, Verts for a polygon. In
+// JSON the class is a kind, "circle", "segment" or "poly", and the user data
+// is left out.
+type ShapeState struct {
+	Class         cp.ShapeClass    `json:"-"`
+	Radius        float64          `json:"radius"`
+	Offset        cp.Vector        `json:"offset"`
+	A             cp.Vector        `json:"a"`
+	B             cp.Vector        `json:"b"`
+	Verts         []cp.Vector      `json:"verts,omitempty"`
+	Mass          float64          `json:"mass"`
+	Sensor        bool             `json:"sensor"`
+	Friction      float64          `json:"friction"`
+	Elasticity    float64          `json:"elasticity"`
+	Filter        cp.ShapeFilter   `json:"filter"`
+	CollisionType cp.CollisionType `json:"collision_type"`
+	UserData      interface{}      `json:"-"`
+}
+
+// shapeKinds names the shape classes in JSON.
+var shapeKinds = map[string]func() cp.ShapeClass{
+	"circle":  func() cp.ShapeClass { return &cp.Circle{} },
+	"segment": func() cp.ShapeClass { return &cp.Segment{} },
+	"poly":    func() cp.ShapeClass { return &cp.PolyShape{} },
+}
+
+func (state ShapeState) MarshalJSON() ([]byte, error) {
+	// plain has the fields without the methods, not to recurse.
+	type plain ShapeState
+	var kind string
+	switch state.Class.(type) {
+	case *cp.Circle:
+		kind = "circle"
+	case *cp.Segment:
+		kind = "segment"
+	case *cp.PolyShape:
+		kind = "poly"
+	default:
+		return nil, fmt.Errorf("can't save a %T shape", state.Class)
+	}
+	return json.Marshal(struct {
+		Kind string `json:"kind"`
+		plain
+	}{kind, plain(state)})
+}
+
+func (state *ShapeState) UnmarshalJSON(data []byte) error {
+	type plain ShapeState
+	var v struct {
+		Kind string `json:"kind"`
+		plain
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	class, ok := shapeKinds[v.Kind]
+	if !ok {
+		return fmt.Errorf("unknown shape kind %q", v.Kind)
+	}
+	*state = ShapeState(v.plain)
+	state.Class = class()
+	return nil
+}
+
+// SaveBody returns the state of the body and its shapes.
+func SaveBody(body *cp.Body) BodyState {
+	state := BodyState{
+		BodyType:        body.GetType(),
+		Mass:            body.Mass(),
+		Moment:          body.Moment(),
+		Position:        body.Position(),
+		Angle:           body.Angle(),
+		Velocity:        body.Velocity(),
+		AngularVelocity: body.AngularVelocity(),
+	}
+	body.EachShape(func(shape *cp.Shape) {
+		state.Shapes = append(state.Shapes, saveShape(shape))
+	})
+	return state
+}
+
+func saveShape(shape *cp.Shape) ShapeState {
+	state := ShapeState{
+		Class:         shape.Class,
+		Mass:          shape.Mass(),
+		Sensor:        shape.Sensor(),
+		Friction:      shape.Friction(),
+		Elasticity:    shape.Elasticity(),
+		Filter:        shape.Filter,
+		CollisionType: CollisionType(shape),
+		UserData:      shape.UserData,
+	}
+	switch class := shape.Class.(type) {
+	case *cp.Circle:
+		// A circle's center of gravity is its offset.
+		state.Radius, state.Offset = class.Radius(), shape.CenterOfGravity()
+	case *cp.Segment:
+		state.Radius, state.A, state.B = class.Radius(), class.A(), class.B()
+	case *cp.PolyShape:
+		state.Radius = class.Radius()
+		for i := 0; i < class.Count(); i++ {
+			state.Verts = append(state.Verts, class.Vert(i))
+		}
+	}
+	return state
+}
+
+// CollisionType returns the collision type of the shape. cp has a setter but
+// no getter for it, so it's read from the field.
+func CollisionType(shape *cp.Shape) cp.CollisionType {
+	return cp.CollisionType(reflect.ValueOf(shape).Elem().FieldByName("collisionType").Uint())
+}
+
+// Apply puts the body back in that state, without touching its shapes.
+func (state BodyState) Apply(body *cp.Body) {
+	body.SetPosition(state.Position)
+	body.SetAngle(state.Angle)
+	body.SetVelocityVector(state.Velocity)
+	body.SetAngularVelocity(state.AngularVelocity)
+}
+
+// Restore adds a body in that state to the space, with its shapes. The
+// space's static body stands for a saved static body, which has no state
+// worth restoring.
+func (state BodyState) Restore(space *cp.Space) *cp.Body {
+	body := space.StaticBody
+	if state.BodyType != cp.BODY_STATIC {
+		body = space.AddBody(cp.NewBody(state.Mass, state.Moment))
+		body.SetType(state.BodyType)
+		body.SetPosition(state.Position)
+		body.SetAngle(state.Angle)
+		body.SetVelocityVector(state.Velocity)
+		body.SetAngularVelocity(state.AngularVelocity)
+	}
+	for _, s := range state.Shapes {
+		var shape *cp.Shape
+		switch s.Class.(type) {
+		case *cp.Circle:
+			shape = cp.NewCircle(body, s.Radius, s.Offset)
+		case *cp.Segment:
+			shape = cp.NewSegment(body, s.A, s.B, s.Radius)
+		case *cp.PolyShape:
+			shape = cp.NewPolyShapeRaw(body, len(s.Verts), s.Verts, s.Radius)
+		default:
+			continue
+		}
+		if s.Mass > 0 {
+			shape.SetMass(s.Mass)
+		}
+		shape.SetSensor(s.Sensor)
+		shape.SetFriction(s.Friction)
+		shape.SetElasticity(s.Elasticity)
+		shape.SetFilter(s.Filter)
+		shape.SetCollisionType(s.CollisionType)
+		shape.UserData = s.UserData
+		space.AddShape(shape)
+	}
+	if body.GetType() == cp.BODY_DYNAMIC {
+		// Shapes with a mass add it to the body, set it back to the saved
+		// total.
+		body.SetMass(state.Mass)
+		body.SetMoment(state.Moment)
+	}
+	return body
+}
@@ -0,0 +1,68 @@
+package physics
+
+import (
+	"reflect"
+
+	"github.com/jakecoffman/cp"
+)
+
+// Stats counts what a space is made of, to tell what a step costs, and sums
+// the energy and momentum of its dynamic bodies, to tell what the solver
+// does with them.
+type Stats struct {
+	// Bodies are the bodies added to the space, sleeping ones included.
+	Bodies      int
+	Shapes      int
+	Constraints int
+	// Arbiters are the pairs of shapes touching in the last step.
+	Arbiters int
+	// Kinetic is the kinetic energy, of translation and rotation, and
+	// Potential the potential energy in the space's gravity, from the
+	// origin: only their changes mean anything.
+	Kinetic   float64
+	Potential float64
+	// Momentum is the linear momentum.
+	Momentum cp.Vector
+}
+
+// Add returns the counts and sums of both.
+func (s Stats) Add(o Stats) Stats {
+	return Stats{
+		Bodies:      s.Bodies + o.Bodies,
+		Shapes:      s.Shapes + o.Shapes,
+		Constraints: s.Constraints + o.Constraints,
+		Arbiters:    s.Arbiters + o.Arbiters,
+		Kinetic:     s.Kinetic + o.Kinetic,
+		Potential:   s.Potential + o.Potential,
+		Momentum:    s.Momentum.Add(o.Momentum),
+	}
+}
+
+// Energy is the kinetic and potential energy.
+func (s Stats) Energy() float64 {
+	return s.Kinetic + s.Potential
+}
+
+// SpaceStats counts the space's bodies, shapes, constraints and arbiters,
+// and sums the energy and momentum of its dynamic bodies. cp has no getter
+// for the arbiters of the last step, so they're counted from the field,
+// like CollisionType reads its own.
+func SpaceStats(space *cp.Space) Stats {
+	var s Stats
+	gravity := space.Gravity()
+	space.EachBody(func(body *cp.Body) {
+		s.Bodies++
+		if body.GetType() != cp.BODY_DYNAMIC {
+			return
+		}
+		m, v, w := body.Mass(), body.Velocity(), body.AngularVelocity()
+		s.Kinetic += m*v.LengthSq()/2 + body.Moment()*w*w/2
+		// Gravity pulls towards lower potential.
+		s.Potential -= m * gravity.Dot(body.Position())
+		s.Momentum = s.Momentum.Add(v.Mult(m))
+	})
+	space.EachShape(func(*cp.Shape) { s.Shapes++ })
+	space.EachConstraint(func(*cp.Constraint) { s.Constraints++ })
+	s.Arbiters = reflect.ValueOf(space).Elem().FieldByName("arbiters").Len()
+	return s
+}
@@ -0,0 +1,56 @@
+package physics_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+func TestSpaceStats(t *testing.T) {
+	space := cp.NewSpace()
+	space.SetGravity(cp.Vector{Y: 100})
+	physics.AddStaticSegment(space, cp.Vector{X: 0, Y: 100}, cp.Vector{X: 200, Y: 100}, 0)
+	a, _ := physics.AddBall(space, cp.Vector{X: 50, Y: 50}, 10)
+	b, _ := physics.AddBox(space, cp.Vector{X: 150, Y: 50}, 20, 20)
+	space.AddConstraint(cp.NewPinJoint(a, b, cp.Vector{}, cp.Vector{}))
+	a.SetVelocity(10, 0)
+	b.SetAngularVelocity(2)
+
+	s := physics.SpaceStats(space)
+	if s.Bodies != 2 || s.Shapes != 3 || s.Constraints != 1 || s.Arbiters != 0 {
+		t.Errorf("counted %+v, want 2 bodies, 3 shapes, 1 constraint and no arbiters", s)
+	}
+	kinetic := a.Mass()*100/2 + b.Moment()*4/2
+	potential := -(a.Mass() + b.Mass()) * 100 * 50
+	if math.Abs(s.Kinetic-kinetic) > 1e-9 || math.Abs(s.Potential-potential) > 1e-9 {
+		t.Errorf("energies %g and %g, want %g and %g", s.Kinetic, s.Potential, kinetic, potential)
+	}
+	if want := (cp.Vector{X: a.Mass() * 10}); s.Momentum.Distance(want) > 1e-9 {
+		t.Errorf("momentum %v, want %v", s.Momentum, want)
+	}
+	if sum := s.Add(s); sum.Shapes != 6 || sum.Energy() != 2*s.Energy() {
+		t.Errorf("the sum of twice %+v is %+v", s, sum)
+	}
+}
+
+func TestFreeFallKeepsEnergy(t *testing.T) {
+	space := cp.NewSpace()
+	space.SetGravity(cp.Vector{Y: 300})
+	physics.AddBall(space, cp.Vector{}, 10)
+	start := physics.SpaceStats(space)
+	for i := 0; i < 60; i++ {
+		space.Step(1.0 / 60)
+	}
+	end := physics.SpaceStats(space)
+	// Semi-implicit Euler gains a step's worth of energy, a fraction of what
+	// the fall turns into kinetic energy.
+	if d := math.Abs(end.Energy() - start.Energy()); d > 0.02*end.Kinetic {
+		t.Errorf("the energy went from %g to %g falling, kinetic %g", start.Energy(), end.Energy(), end.Kinetic)
+	}
+	if end.Momentum.Y <= 0 || end.Momentum.X != 0 {
+		t.Errorf("momentum %v after falling, want straight down", end.Momentum)
+	}
+}
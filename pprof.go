@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"runtime/trace"
+	"sync"
+	"time"
+)
+
+// pprofAddr is the address of the profiling endpoint, shared by the game and
+// the headless build.
+var pprofAddr = flag.String("pprof", "", "address to serve net/http/pprof on while running, e.g. :6060")
+
+// The execution trace flags, shared by the game and the headless build.
+var (
+	traceFile     = flag.String("trace", "", "file to write an execution trace of the start of the run to, for go tool trace")
+	traceDuration = flag.Duration("traceduration", 5*time.Second, "how long the -trace execution trace lasts")
+)
+
+// stopTracing stops the execution trace, once, if one was started.
+var stopTracing = func() {}
+
+// startPprof serves the profiles of net/http/pprof on -pprof, if set, on a
+// goroutine of its own. A server that can't start is logged, the game runs
+// without it.
+func startPprof() {
+	if *pprofAddr == "" {
+		return
+	}
+	logMain.Infof("Serving the profiles on %s under /debug/pprof/", *pprofAddr)
+	go func() {
+		if err := http.ListenAndServe(*pprofAddr, nil); err != nil {
+			logMain.Errorf("-pprof: %v", err)
+		}
+	}()
+}
+
+// startTrace writes an execution trace to -trace, if set, for the first
+// -traceduration of the run, or until stopTracing if the run ends sooner.
+func startTrace() {
+	if *traceFile == "" {
+		return
+	}
+	f, err := os.Create(*traceFile)
+	if err != nil {
+		logMain.Fatalf("-trace: %v", err)
+	}
+	if err := trace.Start(f); err != nil {
+		f.Close()
+		logMain.Fatalf("-trace: %v", err)
+	}
+	logMain.Infof("Tracing the execution to %s for %v", *traceFile, *traceDuration)
+	var once sync.Once
+	stopTracing = func() {
+		once.Do(func() {
+			trace.Stop()
+			if err := f.Close(); err != nil {
+				logMain.Errorf("-trace: %v", err)
+				return
+			}
+			logMain.Infof("Traced the execution to %s, see go tool trace", *traceFile)
+		})
+	}
+	time.AfterFunc(*traceDuration, stopTracing)
+}
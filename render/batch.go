@@ -0,0 +1,183 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// batchMaxQuads is how many quads a DrawTriangles call can draw, 6 indices
+// each.
+const batchMaxQuads = ebiten.MaxIndicesNum / 6
+
+// whitePixel is what the lines and rectangles are drawn from, tinted by the
+// colors of their vertices. It is in the corner of the outlines' atlas, for
+// them all to be drawn from the same image, in the middle of white pixels
+// for its edges not to be sampled.
+var whitePixel = outlines.atlas.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+
+// quadBatch collects the lines and rectangles of a canvas as quads, for
+// them to be drawn with one DrawTriangles call rather than a DrawImage
+// each: its slices are kept from one frame to the next, and drawing a
+// space of thousands of lines allocates nothing once they have grown.
+type quadBatch struct {
+	// dst is the image the quads are for.
+	dst      *ebiten.Image
+	vertices []ebiten.Vertex
+	indices  []uint16
+	op       ebiten.DrawTrianglesOptions
+	// stats are the drawing since they were last taken.
+	stats BatchStats
+	// part is set for a part of a batch built on a worker goroutine, see
+	// buildParts: it has vertices only, never draws, and doesn't render the
+	// outlines it misses in the atlas but notes them.
+	part   bool
+	missed []outlineKey
+}
+
+// BatchStats are what the screen's batch drew since they were last taken,
+// and its buffers.
+type BatchStats struct {
+	// Quads are the quads drawn, Calls the DrawTriangles calls they took.
+	Quads, Calls int
+	// Capacity is how many quads the buffers hold without growing, Grows
+	// how many times they grew since the start: once a scene is drawn,
+	// they are reset each frame and grow no more.
+	Capacity, Grows int
+}
+
+// TakeBatchStats returns the stats of the screen's batch, and starts its
+// counts of quads and calls over.
+func TakeBatchStats() BatchStats {
+	s := screenBatch.stats
+	s.Capacity = cap(screenBatch.vertices) / 4
+	screenBatch.stats.Quads, screenBatch.stats.Calls = 0, 0
+	return s
+}
+
+// screenBatch is the batch of ScreenCanvas, whose drawing is all on the
+// game's goroutine.
+var screenBatch quadBatch
+
+// add adds the quad of corners a, b, c and d, in order around it, to draw
+// on dst, tinted by clr.
+func (q *quadBatch) add(dst *ebiten.Image, ax, ay, bx, by, cx, cy, dx, dy float64, clr color.Color) {
+	r, g, b, a := clr.RGBA()
+	if a == 0 {
+		return
+	}
+	// The vertices take straight alpha, RGBA is premultiplied.
+	fr, fg, fb, fa := float32(r)/float32(a), float32(g)/float32(a), float32(b)/float32(a), float32(a)/0xffff
+	q.addImage(dst, ax, ay, bx, by, cx, cy, dx, dy, whitePixel.Bounds(), fr, fg, fb, fa)
+}
+
+// addImage adds the quad of corners a, b, c and d, in order around it,
+// drawing src of the atlas stretched over it, to draw on dst. The quads for
+// another image, or those of a full batch, are drawn first.
+func (q *quadBatch) addImage(dst *ebiten.Image, ax, ay, bx, by, cx, cy, dx, dy float64, src image.Rectangle, fr, fg, fb, fa float32) {
+	if !q.part && (dst != q.dst || len(q.indices)+6 > batchMaxQuads*6) {
+		q.flush()
+		q.dst = dst
+	}
+	n := uint16(len(q.vertices))
+	if len(q.vertices)+4 > cap(q.vertices) {
+		q.stats.Grows++
+	}
+	q.vertices = append(q.vertices,
+		ebiten.Vertex{DstX: float32(ax), DstY: float32(ay), SrcX: float32(src.Min.X), SrcY: float32(src.Min.Y), ColorR: fr, ColorG: fg, ColorB: fb, ColorA: fa},
+		ebiten.Vertex{DstX: float32(bx), DstY: float32(by), SrcX: float32(src.Max.X), SrcY: float32(src.Min.Y), ColorR: fr, ColorG: fg, ColorB: fb, ColorA: fa},
+		ebiten.Vertex{DstX: float32(cx), DstY: float32(cy), SrcX: float32(src.Max.X), SrcY: float32(src.Max.Y), ColorR: fr, ColorG: fg, ColorB: fb, ColorA: fa},
+		ebiten.Vertex{DstX: float32(dx), DstY: float32(dy), SrcX: float32(src.Min.X), SrcY: float32(src.Max.Y), ColorR: fr, ColorG: fg, ColorB: fb, ColorA: fa},
+	)
+	if !q.part {
+		q.indices = append(q.indices, n, n+1, n+2, n, n+2, n+3)
+	}
+}
+
+// addVertices adds the quads of vs, 4 vertices each in order around them,
+// to draw on dst, e.g. a part's.
+func (q *quadBatch) addVertices(dst *ebiten.Image, vs []ebiten.Vertex) {
+	for len(vs) > 0 {
+		if dst != q.dst || len(q.indices)+6 > batchMaxQuads*6 {
+			q.flush()
+			q.dst = dst
+		}
+		quads := len(vs) / 4
+		if room := batchMaxQuads - len(q.indices)/6; quads > room {
+			quads = room
+		}
+		if len(q.vertices)+4*quads > cap(q.vertices) {
+			q.stats.Grows++
+		}
+		n := uint16(len(q.vertices))
+		q.vertices = append(q.vertices, vs[:4*quads]...)
+		for i := 0; i < quads; i++ {
+			q.indices = append(q.indices, n, n+1, n+2, n, n+2, n+3)
+			n += 4
+		}
+		vs = vs[4*quads:]
+	}
+}
+
+// line adds a line one pixel wide, as ebitenutil.DrawLine draws it: the
+// pixel from (x1, y1) stretched to (x2, y2).
+func (q *quadBatch) line(dst *ebiten.Image, x1, y1, x2, y2 float64, clr color.Color) {
+	length := math.Hypot(x2-x1, y2-y1)
+	if length == 0 {
+		return
+	}
+	// The side of the pixel across the line.
+	nx, ny := -(y2-y1)/length, (x2-x1)/length
+	q.add(dst, x1, y1, x2, y2, x2+nx, y2+ny, x1+nx, y1+ny, clr)
+}
+
+func (q *quadBatch) rect(dst *ebiten.Image, x, y, width, height float64, clr color.Color) {
+	q.add(dst, x, y, x+width, y, x+width, y+height, x, y+height, clr)
+}
+
+// outline adds the outline of k rendered in the atlas, centered on (x, y)
+// and turned by angle, or returns false if it is too big to be.
+func (q *quadBatch) outline(dst *ebiten.Image, k outlineKey, x, y, angle float64) bool {
+	src, ok := q.region(k)
+	if !ok {
+		return false
+	}
+	// The half sides of the image, along and across the angle.
+	w, h := float64(src.Dx())/2, float64(src.Dy())/2
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	ux, uy := w*cos, w*sin
+	vx, vy := -h*sin, h*cos
+	q.addImage(dst,
+		x-ux-vx, y-uy-vy,
+		x+ux-vx, y+uy-vy,
+		x+ux+vx, y+uy+vy,
+		x-ux+vx, y-uy+vy,
+		src, 1, 1, 1, 1)
+	return true
+}
+
+// region returns where the outline of k is in the atlas. A part only finds
+// those rendered already, and notes the others, for them to be rendered
+// once the parts are merged: the atlas is the game goroutine's.
+func (q *quadBatch) region(k outlineKey) (image.Rectangle, bool) {
+	if !q.part {
+		return outlines.region(k, q)
+	}
+	r, ok := outlines.regions[k]
+	if !ok {
+		q.missed = append(q.missed, k)
+	}
+	return r, ok
+}
+
+// flush draws the quads and empties the batch, keeping its memory.
+func (q *quadBatch) flush() {
+	if len(q.indices) > 0 {
+		q.dst.DrawTriangles(q.vertices, q.indices, outlines.atlas, &q.op)
+		q.stats.Quads += len(q.indices) / 6
+		q.stats.Calls++
+	}
+	q.vertices, q.indices = q.vertices[:0], q.indices[:0]
+}
@@ -0,0 +1,92 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// TestQuadBatchLine checks the quads of lines against what ebitenutil draws:
+// the pixel at the start stretched to the end, in straight alpha. Nothing is
+// flushed, the batch has no image.
+func TestQuadBatchLine(t *testing.T) {
+	tests := []struct {
+		name           string
+		x1, y1, x2, y2 float64
+		want           [4][2]float32
+	}{
+		{"right", 0, 0, 10, 0, [4][2]float32{{0, 0}, {10, 0}, {10, 1}, {0, 1}}},
+		{"down", 5, 5, 5, 9, [4][2]float32{{5, 5}, {5, 9}, {4, 9}, {4, 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q quadBatch
+			q.line(nil, tt.x1, tt.y1, tt.x2, tt.y2, color.NRGBA{R: 0xff, A: 0x80})
+			if len(q.vertices) != 4 || len(q.indices) != 6 {
+				t.Fatalf("%d vertices and %d indices, want 4 and 6", len(q.vertices), len(q.indices))
+			}
+			for i, v := range q.vertices {
+				if got := [2]float32{v.DstX, v.DstY}; got != tt.want[i] {
+					t.Errorf("vertex %d at %v, want %v", i, got, tt.want[i])
+				}
+				if v.ColorR != 1 || v.ColorG != 0 || v.ColorB != 0 || v.ColorA < 0.5 || v.ColorA > 0.51 {
+					t.Errorf("vertex %d colored %v %v %v %v, want red at half alpha", i, v.ColorR, v.ColorG, v.ColorB, v.ColorA)
+				}
+			}
+		})
+	}
+}
+
+// TestQuadBatchSkips checks that what wouldn't show isn't batched.
+func TestQuadBatchSkips(t *testing.T) {
+	var q quadBatch
+	q.line(nil, 3, 3, 3, 3, color.White)
+	q.rect(nil, 0, 0, 10, 10, color.Transparent)
+	if len(q.vertices) != 0 {
+		t.Errorf("%d vertices for a point and a transparent rectangle, want none", len(q.vertices))
+	}
+}
+
+// TestRasterizeOutline checks that a circle's outline covers its edge, not
+// its middle nor its corners, and that a capsule's reaches its ends.
+func TestRasterizeOutline(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	circle := rasterizeOutline(outlineKey{kind: outlineCircle, halfRadius: 16, stroke: 1, clr: red})
+	if got := circle.Bounds().Size(); got.X != 20 || got.Y != 20 {
+		t.Fatalf("circle of radius 8 in %v, want 20x20", got)
+	}
+	for _, p := range []struct {
+		x, y int
+		on   bool
+	}{{10, 10, false}, {0, 0, false}, {17, 9, true}, {2, 10, true}, {9, 2, true}} {
+		if a := circle.RGBAAt(p.x, p.y).A; (a > 0x80) != p.on {
+			t.Errorf("circle at (%d, %d) has alpha %d, on the edge %v", p.x, p.y, a, p.on)
+		}
+	}
+	capsule := rasterizeOutline(outlineKey{kind: outlineCapsule, halfRadius: 9, length: 20, stroke: 1, clr: red})
+	if got := capsule.Bounds().Size(); got.X != 34 || got.Y != 14 {
+		t.Fatalf("capsule of radius 4.5 and length 20 in %v, want 34x14", got)
+	}
+	// Along the top, the middle, and past the ends' centers, round the caps.
+	for _, p := range [][2]int{{17, 2}, {31, 7}, {2, 7}} {
+		if c := capsule.RGBAAt(p[0], p[1]); c.A < 0x80 || c.R != c.A || c.G != 0 {
+			t.Errorf("capsule at %v is %v, want premultiplied red", p, c)
+		}
+	}
+}
+
+// TestShelfPacker checks that rectangles are placed side by side, then on
+// the next shelf, below the tallest, and that a full packer says so.
+func TestShelfPacker(t *testing.T) {
+	p := shelfPacker{size: image.Point{X: 10, Y: 10}}
+	want := []image.Rectangle{image.Rect(0, 0, 4, 3), image.Rect(4, 0, 8, 5), image.Rect(0, 5, 4, 9)}
+	for i, size := range []image.Point{{4, 3}, {4, 5}, {4, 4}} {
+		r, ok := p.place(size)
+		if !ok || r != want[i] {
+			t.Errorf("rectangle %d at %v, %v, want %v", i, r, ok, want[i])
+		}
+	}
+	if r, ok := p.place(image.Point{X: 7, Y: 2}); ok {
+		t.Errorf("a full packer placed a rectangle at %v", r)
+	}
+}
@@ -0,0 +1,133 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+// discardCanvas counts what it is asked to draw and draws nothing: the
+// benchmarks measure the debug drawer, not the GPU.
+type discardCanvas struct {
+	lines, rects int
+}
+
+func (c *discardCanvas) DrawLine(x1, y1, x2, y2 float64, clr color.Color) { c.lines++ }
+
+func (c *discardCanvas) DrawRect(x, y, width, height float64, clr color.Color) { c.rects++ }
+
+// outlineDiscardCanvas is a discardCanvas taking circles and capsules as
+// outlines, as the screen does.
+type outlineDiscardCanvas struct {
+	discardCanvas
+	outlines int
+}
+
+func (c *outlineDiscardCanvas) circleOutline(x, y, radius float64, clr color.Color) bool {
+	c.outlines++
+	return true
+}
+
+func (c *outlineDiscardCanvas) capsuleOutline(x1, y1, x2, y2, radius float64, clr color.Color) bool {
+	c.outlines++
+	return true
+}
+
+// gridSpace returns a space with n boxes and balls in a square grid on a
+// ground, and a pin joint for every tenth body, to draw every kind of
+// shape and constraints.
+func gridSpace(n int) *cp.Space {
+	const size = 8
+	columns := int(math.Ceil(math.Sqrt(float64(n))))
+	space := cp.NewSpace()
+	space.AddShape(cp.NewSegment(space.StaticBody, cp.Vector{}, cp.Vector{X: float64(columns * 2 * size)}, 1))
+	for i := 0; i < n; i++ {
+		pos := cp.Vector{X: float64(i%columns*2*size) + size, Y: float64(i/columns*2*size) + 2*size}
+		var body *cp.Body
+		if i%2 == 0 {
+			body, _ = physics.AddBox(space, pos, size, size)
+		} else {
+			body = space.AddBody(cp.NewBody(1, cp.MomentForCircle(1, 0, size/2, cp.Vector{})))
+			body.SetPosition(pos)
+			space.AddShape(cp.NewCircle(body, size/2, cp.Vector{}))
+		}
+		if i%10 == 0 {
+			space.AddConstraint(cp.NewPinJoint(space.StaticBody, body, pos.Sub(cp.Vector{Y: size}), cp.Vector{}))
+		}
+	}
+	return space
+}
+
+// BenchmarkDrawSpace measures the debug-draw pass of a space, through a
+// camera as most scenes draw.
+func BenchmarkDrawSpace(b *testing.B) {
+	for _, n := range []int{10, 1000, 10000} {
+		b.Run(fmt.Sprintf("bodies=%d", n), func(b *testing.B) {
+			space := gridSpace(n)
+			cam := NewCamera(800, 600)
+			canvas := &discardCanvas{}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				DrawSpaceOn(canvas, space, colornames.White, cam)
+			}
+			b.ReportMetric(float64(canvas.lines)/float64(b.N), "lines/op")
+		})
+		b.Run(fmt.Sprintf("bodies=%d/outlines", n), func(b *testing.B) {
+			space := gridSpace(n)
+			cam := NewCamera(800, 600)
+			canvas := &outlineDiscardCanvas{}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				DrawSpaceOn(canvas, space, colornames.White, cam)
+			}
+			b.ReportMetric(float64(canvas.lines)/float64(b.N), "lines/op")
+			b.ReportMetric(float64(canvas.outlines)/float64(b.N), "outlines/op")
+		})
+		b.Run(fmt.Sprintf("bodies=%d/points", n), func(b *testing.B) {
+			space := gridSpace(n)
+			cam := NewCamera(800, 600)
+			cam.Zoom = 0.25
+			canvas := &outlineDiscardCanvas{}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				DrawSpaceOn(canvas, space, colornames.White, cam)
+			}
+			b.ReportMetric(float64(canvas.lines)/float64(b.N), "lines/op")
+			b.ReportMetric(float64(canvas.rects)/float64(b.N), "rects/op")
+		})
+	}
+}
+
+// BenchmarkBuildBatch measures building the screen's batch of a space's
+// shapes on the game's goroutine and by 4 workers, the quads dropped rather
+// than drawn but those of a full batch.
+func BenchmarkBuildBatch(b *testing.B) {
+	defer func(n int) { BatchWorkers = n }(BatchWorkers)
+	for _, n := range []int{1000, 10000} {
+		for _, workers := range []int{1, 4} {
+			b.Run(fmt.Sprintf("bodies=%d/workers=%d", n, workers), func(b *testing.B) {
+				BatchWorkers = workers
+				space := gridSpace(n)
+				dst := ebiten.NewImage(800, 600)
+				canvas := ScreenCanvas{dst}
+				d := &drawer{canvas: canvas, outliner: canvas, shape: toFColor(colornames.White), flags: cp.DRAW_SHAPES, cam: NewCamera(800, 600)}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					d.drawShapes(space)
+					screenBatch.vertices, screenBatch.indices = screenBatch.vertices[:0], screenBatch.indices[:0]
+				}
+			})
+		}
+	}
+}
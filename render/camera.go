@@ -0,0 +1,61 @@
+package render
+
+import (
+	"math"
+
+	"github.com/jakecoffman/cp"
+)
+
+const (
+	// cameraMinZoom is far enough out for a whole stress scene, its bodies
+	// drawn as points, see PointSize.
+	cameraMinZoom = 0.05
+	cameraMaxZoom = 4
+	// cameraWheelZoom is the zoom factor of one notch of the mouse wheel.
+	cameraWheelZoom = 1.1
+)
+
+// Camera maps the world to the screen: the world point it looks at is drawn
+// at the center of the screen, scaled by the zoom.
+type Camera struct {
+	Center cp.Vector
+	Zoom   float64
+	// screenCenter is the middle of the screen, in screen pixels.
+	screenCenter cp.Vector
+}
+
+// NewCamera returns a camera for a screen of the given size showing the
+// world as is, world and screen coordinates being the same.
+func NewCamera(width, height float64) *Camera {
+	center := cp.Vector{X: width / 2, Y: height / 2}
+	return &Camera{Center: center, Zoom: 1, screenCenter: center}
+}
+
+func (c *Camera) ToScreen(p cp.Vector) cp.Vector {
+	return p.Sub(c.Center).Mult(c.Zoom).Add(c.screenCenter)
+}
+
+func (c *Camera) ToWorld(p cp.Vector) cp.Vector {
+	return p.Sub(c.screenCenter).Mult(1 / c.Zoom).Add(c.Center)
+}
+
+// ZoomAt multiplies the zoom by factor, keeping the world point under the
+// screen point p where it is on screen.
+func (c *Camera) ZoomAt(p cp.Vector, factor float64) {
+	world := c.ToWorld(p)
+	c.Zoom = cp.Clamp(c.Zoom*factor, cameraMinZoom, cameraMaxZoom)
+	c.Center = world.Sub(p.Sub(c.screenCenter).Mult(1 / c.Zoom))
+}
+
+// Pan moves the view by a distance given in screen pixels.
+func (c *Camera) Pan(d cp.Vector) {
+	c.Center = c.Center.Add(d.Mult(1 / c.Zoom))
+}
+
+// ZoomWheel zooms by notches of the mouse wheel, towards the world point
+// under the cursor at p, which stays under the cursor.
+func (c *Camera) ZoomWheel(p cp.Vector, notches float64) {
+	if notches != 0 {
+		c.ZoomAt(p, math.Pow(cameraWheelZoom, notches))
+	}
+}
@@ -0,0 +1,394 @@
+// Package render draws cp spaces with ebiten: a debug drawer for the shapes
+// and constraints, a camera, and sprites that follow bodies.
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/jakecoffman/cp"
+)
+
+// circleSegments is the number of lines used to approximate a circle outline.
+const circleSegments = 16
+
+// unitCircle are the points of the outline of a circle of radius 1, the
+// last one the first again.
+var unitCircle = func() (points [circleSegments + 1]cp.Vector) {
+	for i := range points {
+		points[i] = cp.ForAngle(2 * math.Pi * float64(i) / circleSegments)
+	}
+	return points
+}()
+
+// ShowCollisionPoints makes DrawSpace mark the contact points too. It is
+// toggled with the debug_draw action.
+var ShowCollisionPoints bool
+
+// PointSize is the size on screen, in pixels, under which a shape is drawn
+// as a point, a square as big as it is, rather than as a circle or a
+// polygon of lines: zoomed far out, thousands of bodies are as many quads,
+// and look the same. 0 draws every shape in full.
+var PointSize = 3.0
+
+// Canvas is what the debug drawer draws on, in screen pixels: lines one
+// pixel wide and filled rectangles. The screen is one, see ScreenCanvas, and
+// the tests draw on images.
+type Canvas interface {
+	DrawLine(x1, y1, x2, y2 float64, clr color.Color)
+	DrawRect(x, y, width, height float64, clr color.Color)
+}
+
+// ScreenCanvas is an ebiten image as a canvas. It draws as ebitenutil does,
+// but batched, see quadBatch: what it draws shows once flushed, by Flush or
+// by drawing on another image.
+type ScreenCanvas struct {
+	*ebiten.Image
+}
+
+func (c ScreenCanvas) DrawLine(x1, y1, x2, y2 float64, clr color.Color) {
+	batchCanvas{c.Image, &screenBatch}.DrawLine(x1, y1, x2, y2, clr)
+}
+
+func (c ScreenCanvas) DrawRect(x, y, width, height float64, clr color.Color) {
+	batchCanvas{c.Image, &screenBatch}.DrawRect(x, y, width, height, clr)
+}
+
+// Flush draws what was drawn on the canvas so far.
+func (c ScreenCanvas) Flush() {
+	screenBatch.flush()
+}
+
+func (c ScreenCanvas) circleOutline(x, y, radius float64, clr color.Color) bool {
+	return batchCanvas{c.Image, &screenBatch}.circleOutline(x, y, radius, clr)
+}
+
+func (c ScreenCanvas) capsuleOutline(x1, y1, x2, y2, radius float64, clr color.Color) bool {
+	return batchCanvas{c.Image, &screenBatch}.capsuleOutline(x1, y1, x2, y2, radius, clr)
+}
+
+// batchCanvas draws on dst through a batch, the screen's or a part of it.
+type batchCanvas struct {
+	dst *ebiten.Image
+	q   *quadBatch
+}
+
+func (c batchCanvas) DrawLine(x1, y1, x2, y2 float64, clr color.Color) {
+	c.q.line(c.dst, x1, y1, x2, y2, clr)
+}
+
+func (c batchCanvas) DrawRect(x, y, width, height float64, clr color.Color) {
+	c.q.rect(c.dst, x, y, width, height, clr)
+}
+
+func (c batchCanvas) circleOutline(x, y, radius float64, clr color.Color) bool {
+	k, ok := newOutlineKey(outlineCircle, radius, 0, clr)
+	return ok && c.q.outline(c.dst, k, x, y, 0)
+}
+
+func (c batchCanvas) capsuleOutline(x1, y1, x2, y2, radius float64, clr color.Color) bool {
+	k, ok := newOutlineKey(outlineCapsule, radius, math.Hypot(x2-x1, y2-y1), clr)
+	return ok && c.q.outline(c.dst, k, (x1+x2)/2, (y1+y2)/2, math.Atan2(y2-y1, x2-x1))
+}
+
+// flusher is a canvas drawing in batches, flushed once the space is drawn.
+type flusher interface {
+	Flush()
+}
+
+// outliner is a canvas drawing circles and capsules from outlines rendered
+// once, see outlineCache, rather than from lines. It returns false for those
+// it can't, which are drawn with lines then.
+type outliner interface {
+	circleOutline(x, y, radius float64, clr color.Color) bool
+	capsuleOutline(x1, y1, x2, y2, radius float64, clr color.Color) bool
+}
+
+// drawer renders a space with lines on a canvas. It implements cp.Drawer so
+// that cp.DrawShape and cp.DrawConstraint do the per-class work for us.
+type drawer struct {
+	canvas Canvas
+	// outliner is the canvas, if it is one.
+	outliner outliner
+	shape    cp.FColor
+	flags    uint
+	// cam, when set, maps the world to the screen.
+	cam *Camera
+	// statics is set when the static body's shapes were drawn from their
+	// layer, see drawStatics.
+	statics bool
+	// fill and fillColor are the last color drawn with, for the lines of a
+	// color not to make a color.Color each.
+	fill      cp.FColor
+	fillColor color.Color
+}
+
+// DrawSpace draws every shape and constraint of the space in the given color.
+func DrawSpace(screen *ebiten.Image, space *cp.Space, clr color.Color) {
+	DrawSpaceFrom(screen, space, clr, nil)
+}
+
+// DrawSpaceFrom is DrawSpace seen through a camera.
+func DrawSpaceFrom(screen *ebiten.Image, space *cp.Space, clr color.Color, cam *Camera) {
+	DrawSpaceOn(ScreenCanvas{screen}, space, clr, cam)
+}
+
+// DrawSpaceOn is DrawSpaceFrom on any canvas. The camera may be nil.
+func DrawSpaceOn(canvas Canvas, space *cp.Space, clr color.Color, cam *Camera) {
+	d := &drawer{
+		canvas: canvas,
+		shape:  toFColor(clr),
+		flags:  cp.DRAW_SHAPES | cp.DRAW_CONSTRAINTS,
+		cam:    cam,
+	}
+	d.outliner, _ = canvas.(outliner)
+	if ShowCollisionPoints {
+		d.flags |= cp.DRAW_COLLISION_POINTS
+	}
+	if screen, ok := canvas.(ScreenCanvas); ok {
+		switch {
+		case RedrawRegions:
+			drawFrame(screen.Image, space, d)
+			d.flags &^= cp.DRAW_SHAPES
+		case CacheStatics:
+			d.statics = drawStatics(screen.Image, space, d)
+		}
+	}
+	d.drawSpace(space)
+	if f, ok := canvas.(flusher); ok {
+		f.Flush()
+	}
+}
+
+// drawSpace is cp.DrawSpace, except that the collision points are only drawn
+// when asked for by the flags, which cp ignores for them.
+func (d *drawer) drawSpace(space *cp.Space) {
+	if d.flags&cp.DRAW_SHAPES != 0 {
+		d.drawShapes(space)
+	}
+	if d.flags&cp.DRAW_CONSTRAINTS != 0 {
+		space.EachConstraint(func(constraint *cp.Constraint) {
+			cp.DrawConstraint(constraint, d)
+		})
+	}
+	if d.flags&cp.DRAW_COLLISION_POINTS != 0 {
+		// Each arbiter is seen from both of its bodies, drawing its points
+		// twice does no harm.
+		space.EachBody(func(body *cp.Body) {
+			body.EachArbiter(func(arb *cp.Arbiter) {
+				set := arb.ContactPointSet()
+				for i := 0; i < set.Count; i++ {
+					d.DrawDot(4, set.Points[i].PointA, d.CollisionPointColor(), nil)
+				}
+			})
+		})
+	}
+}
+
+// drawShapes draws the shapes of space, but for those of the static body
+// drawn from their layer. On the screen, they are split among workers, see
+// buildParts, when there are enough.
+func (d *drawer) drawShapes(space *cp.Space) {
+	screen, ok := d.canvas.(ScreenCanvas)
+	if !ok || BatchWorkers < 2 {
+		space.EachShape(func(shape *cp.Shape) {
+			if d.statics && shape.Body() == space.StaticBody {
+				return
+			}
+			if !d.drawPoint(shape) {
+				cp.DrawShape(shape, d)
+			}
+		})
+		return
+	}
+	partShapes = partShapes[:0]
+	space.EachShape(func(shape *cp.Shape) {
+		if !d.statics || shape.Body() != space.StaticBody {
+			partShapes = append(partShapes, shape)
+		}
+	})
+	d.buildParts(screen.Image, partShapes)
+}
+
+// drawPoint draws the shape as a point if it is smaller than PointSize on
+// screen, and reports whether it did.
+func (d *drawer) drawPoint(shape *cp.Shape) bool {
+	bb := shape.BB()
+	size := math.Max(bb.R-bb.L, bb.T-bb.B) * d.zoom()
+	if size >= PointSize {
+		return false
+	}
+	size = math.Max(size, 1)
+	p := d.toScreen(bb.Center())
+	d.canvas.DrawRect(p.X-size/2, p.Y-size/2, size, size, d.color(d.ShapeColor(shape, nil)))
+	return true
+}
+
+// DrawCircle draws a circle outline, e.g. to highlight a shape.
+func DrawCircle(screen *ebiten.Image, center cp.Vector, radius float64, clr color.Color) {
+	canvas := ScreenCanvas{screen}
+	d := &drawer{canvas: canvas, outliner: canvas}
+	c := toFColor(clr)
+	d.DrawCircle(center, 0, radius, c, c, nil)
+	canvas.Flush()
+}
+
+// DrawRectOutline draws the outline of the rectangle with corners a and b.
+func DrawRectOutline(screen *ebiten.Image, a, b cp.Vector, clr color.Color) {
+	ebitenutil.DrawLine(screen, a.X, a.Y, b.X, a.Y, clr)
+	ebitenutil.DrawLine(screen, b.X, a.Y, b.X, b.Y, clr)
+	ebitenutil.DrawLine(screen, b.X, b.Y, a.X, b.Y, clr)
+	ebitenutil.DrawLine(screen, a.X, b.Y, a.X, a.Y, clr)
+}
+
+// DrawTooltip draws text in a box below and to the right of the screen point
+// p, kept on the screen.
+func DrawTooltip(screen *ebiten.Image, p cp.Vector, text string) {
+	// The debug font is 6x16.
+	lines := strings.Split(text, "\n")
+	w, h := 0.0, float64(16*len(lines))
+	for _, line := range lines {
+		w = math.Max(w, float64(6*len(line)))
+	}
+	const pad, offset = 4, 16
+	sw, sh := screen.Size()
+	x := math.Min(p.X+offset, float64(sw)-w-2*pad)
+	y := math.Min(p.Y+offset, float64(sh)-h-2*pad)
+	ebitenutil.DrawRect(screen, x, y, w+2*pad, h+2*pad, color.RGBA{A: 0xc0})
+	ebitenutil.DebugPrintAt(screen, text, int(x+pad), int(y+pad))
+}
+
+func toFColor(clr color.Color) cp.FColor {
+	r, g, b, a := clr.RGBA()
+	return cp.FColor{R: float32(r) / 0xffff, G: float32(g) / 0xffff, B: float32(b) / 0xffff, A: float32(a) / 0xffff}
+}
+
+func toColor(c cp.FColor) color.Color {
+	return color.RGBA64{R: uint16(c.R * 0xffff), G: uint16(c.G * 0xffff), B: uint16(c.B * 0xffff), A: uint16(c.A * 0xffff)}
+}
+
+// toScreen maps a world point to the screen.
+func (d *drawer) toScreen(p cp.Vector) cp.Vector {
+	if d.cam == nil {
+		return p
+	}
+	return d.cam.ToScreen(p)
+}
+
+// toWorld maps a screen point to the world.
+func (d *drawer) toWorld(p cp.Vector) cp.Vector {
+	if d.cam == nil {
+		return p
+	}
+	return d.cam.ToWorld(p)
+}
+
+// screenRect returns the whole pixels of the screen bb covers, and
+// regionPadding around them.
+func (d *drawer) screenRect(bb cp.BB) image.Rectangle {
+	a, b := d.toScreen(cp.Vector{X: bb.L, Y: bb.B}), d.toScreen(cp.Vector{X: bb.R, Y: bb.T})
+	return image.Rect(
+		int(math.Floor(math.Min(a.X, b.X)))-regionPadding, int(math.Floor(math.Min(a.Y, b.Y)))-regionPadding,
+		int(math.Ceil(math.Max(a.X, b.X)))+regionPadding, int(math.Ceil(math.Max(a.Y, b.Y)))+regionPadding,
+	)
+}
+
+func (d *drawer) color(c cp.FColor) color.Color {
+	if d.fillColor == nil || c != d.fill {
+		d.fill, d.fillColor = c, toColor(c)
+	}
+	return d.fillColor
+}
+
+func (d *drawer) line(a, b cp.Vector, c cp.FColor) {
+	a, b = d.toScreen(a), d.toScreen(b)
+	d.canvas.DrawLine(a.X, a.Y, b.X, b.Y, d.color(c))
+}
+
+// zoom is how many screen pixels a world unit is.
+func (d *drawer) zoom() float64 {
+	if d.cam == nil {
+		return 1
+	}
+	return d.cam.Zoom
+}
+
+func (d *drawer) DrawCircle(pos cp.Vector, angle, radius float64, outline, fill cp.FColor, data interface{}) {
+	if p := d.toScreen(pos); d.outliner == nil || !d.outliner.circleOutline(p.X, p.Y, radius*d.zoom(), d.color(fill)) {
+		prev := pos.Add(cp.Vector{X: radius})
+		for i := 1; i <= circleSegments; i++ {
+			next := pos.Add(unitCircle[i].Mult(radius))
+			d.line(prev, next, fill)
+			prev = next
+		}
+	}
+	// A radius line makes the rotation visible.
+	d.line(pos, pos.Add(cp.ForAngle(angle).Mult(radius)), outline)
+}
+
+func (d *drawer) DrawSegment(a, b cp.Vector, fill cp.FColor, data interface{}) {
+	d.line(a, b, fill)
+}
+
+func (d *drawer) DrawFatSegment(a, b cp.Vector, radius float64, outline, fill cp.FColor, data interface{}) {
+	if radius < 1 {
+		d.line(a, b, fill)
+		return
+	}
+	if d.outliner != nil {
+		sa, sb := d.toScreen(a), d.toScreen(b)
+		if d.outliner.capsuleOutline(sa.X, sa.Y, sb.X, sb.Y, radius*d.zoom(), d.color(fill)) {
+			return
+		}
+	}
+	n := b.Sub(a).Perp().Normalize().Mult(radius)
+	d.line(a.Add(n), b.Add(n), fill)
+	d.line(a.Sub(n), b.Sub(n), fill)
+}
+
+func (d *drawer) DrawPolygon(count int, verts []cp.Vector, radius float64, outline, fill cp.FColor, data interface{}) {
+	for i := 0; i < count; i++ {
+		d.line(verts[i], verts[(i+1)%count], fill)
+	}
+}
+
+func (d *drawer) DrawDot(size float64, pos cp.Vector, fill cp.FColor, data interface{}) {
+	pos = d.toScreen(pos)
+	d.canvas.DrawRect(pos.X-size/2, pos.Y-size/2, size, size, d.color(fill))
+}
+
+func (d *drawer) Flags() uint {
+	return d.flags
+}
+
+func (d *drawer) OutlineColor() cp.FColor {
+	return d.shape
+}
+
+// ShapeColor uses the shape's UserData when it is a color, so that scenes
+// can tell their shapes apart without drawing them by hand.
+func (d *drawer) ShapeColor(shape *cp.Shape, data interface{}) cp.FColor {
+	if shape.Body().IsSleeping() {
+		return cp.FColor{R: 0.4, G: 0.4, B: 0.4, A: 1}
+	}
+	if clr, ok := shape.UserData.(color.Color); ok {
+		return toFColor(clr)
+	}
+	return d.shape
+}
+
+func (d *drawer) ConstraintColor() cp.FColor {
+	return cp.FColor{R: 0.5, G: 1, B: 0.5, A: 1}
+}
+
+func (d *drawer) CollisionPointColor() cp.FColor {
+	return cp.FColor{R: 1, A: 1}
+}
+
+func (d *drawer) Data() interface{} {
+	return nil
+}
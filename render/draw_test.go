@@ -0,0 +1,36 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+// TestDrawPoints zooms out of boxes and balls until they are smaller than
+// PointSize on screen, and checks that each is then a point, a rectangle
+// and no line, while a long ground stays lines.
+func TestDrawPoints(t *testing.T) {
+	space := cp.NewSpace()
+	space.AddShape(cp.NewSegment(space.StaticBody, cp.Vector{}, cp.Vector{X: 400}, 1))
+	physics.AddBox(space, cp.Vector{X: 100, Y: -10}, 8, 8)
+	physics.AddBall(space, cp.Vector{X: 200, Y: -10}, 4)
+	for _, tc := range []struct {
+		zoom         float64
+		lines, rects int
+	}{
+		// The ground's 2 sides, the box's 4 and the ball's 16 and radius.
+		{zoom: 1, lines: 2 + 4 + 17},
+		{zoom: 0.25, lines: 2, rects: 2},
+	} {
+		cam := NewCamera(800, 600)
+		cam.Zoom = tc.zoom
+		canvas := &discardCanvas{}
+		DrawSpaceOn(canvas, space, colornames.White, cam)
+		if canvas.lines != tc.lines || canvas.rects != tc.rects {
+			t.Errorf("at zoom %g, %d lines and %d rectangles drawn, want %d and %d", tc.zoom, canvas.lines, canvas.rects, tc.lines, tc.rects)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package render
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/config"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+// update rewrites the goldens with what is drawn now, after a change of the
+// drawing that is meant:
+//
+//	go test ./render -run Golden -update
+var update = flag.Bool("update", false, "rewrite the golden images of testdata")
+
+const (
+	// goldenWidth and goldenHeight are the size of the golden images, half
+	// the screen's, for them to stay small.
+	goldenWidth  = 400
+	goldenHeight = 300
+	// goldenStep is the step of the game, a 60th of a second.
+	goldenStep = 1.0 / 60
+	// goldenColorTolerance is how far a channel may be from the golden's,
+	// out of 255. A pixel matches one of the golden around it: a step of cp
+	// computed in another order, e.g. with fused multiply-adds, may move a
+	// line by a pixel. goldenMaxMismatch is how many pixels may not match
+	// still, fewer than a short line has.
+	goldenColorTolerance = 16
+	goldenMaxMismatch    = 8
+)
+
+// imageCanvas draws on an image in software, without ebiten's main loop,
+// the lines a pixel wide as ebitenutil draws them, without smoothing.
+type imageCanvas struct {
+	*image.RGBA
+}
+
+func newImageCanvas() imageCanvas {
+	img := image.NewRGBA(image.Rect(0, 0, goldenWidth, goldenHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	return imageCanvas{img}
+}
+
+func (c imageCanvas) plot(x, y int, clr color.Color) {
+	draw.Draw(c.RGBA, image.Rect(x, y, x+1, y+1), image.NewUniform(clr), image.Point{}, draw.Over)
+}
+
+func (c imageCanvas) DrawLine(x1, y1, x2, y2 float64, clr color.Color) {
+	n := int(math.Ceil(math.Max(math.Abs(x2-x1), math.Abs(y2-y1))))
+	for i := 0; i <= n; i++ {
+		t := 0.0
+		if n > 0 {
+			t = float64(i) / float64(n)
+		}
+		c.plot(int(math.Floor(x1+t*(x2-x1))), int(math.Floor(y1+t*(y2-y1))), clr)
+	}
+}
+
+func (c imageCanvas) DrawRect(x, y, width, height float64, clr color.Color) {
+	r := image.Rect(int(math.Round(x)), int(math.Round(y)), int(math.Round(x+width)), int(math.Round(y+height)))
+	draw.Draw(c.RGBA, r, image.NewUniform(clr), image.Point{}, draw.Over)
+}
+
+// helloSpace returns the Hello Chipmunk space of the default config with
+// its ball, stepped steps times.
+func helloSpace(steps int) *cp.Space {
+	p := config.Default().HelloParams
+	space := physics.NewHelloSpace(p)
+	body, shape := physics.NewHelloBall(p, physics.HelloStart)
+	space.AddBody(body)
+	space.AddShape(shape)
+	for i := 0; i < steps; i++ {
+		space.Step(goldenStep)
+	}
+	return space
+}
+
+// chainSpace returns a chain of balls hanging from a pin, swinging onto a
+// box on the ground, stepped steps times: circles, segments, polygons and
+// constraints, and contacts.
+func chainSpace(steps int) *cp.Space {
+	space := cp.NewSpace()
+	space.SetGravity(cp.Vector{Y: 300})
+	ground := space.AddShape(cp.NewSegment(space.StaticBody, cp.Vector{X: 0, Y: 560}, cp.Vector{X: 800, Y: 560}, 0))
+	ground.SetFriction(1)
+
+	box := space.AddBody(cp.NewBody(1, cp.MomentForBox(1, 60, 60)))
+	box.SetPosition(cp.Vector{X: 500, Y: 530})
+	space.AddShape(cp.NewBox(box, 60, 60, 0)).SetFriction(0.8)
+	box.EachShape(func(shape *cp.Shape) { shape.UserData = colornames.Orange })
+
+	prev, anchor := space.StaticBody, cp.Vector{X: 300, Y: 100}
+	for i := 1; i <= 6; i++ {
+		pos := cp.Vector{X: 300 + 40*float64(i), Y: 100}
+		ball := space.AddBody(cp.NewBody(1, cp.MomentForCircle(1, 0, 10, cp.Vector{})))
+		ball.SetPosition(pos)
+		space.AddShape(cp.NewCircle(ball, 10, cp.Vector{})).SetFriction(0.5)
+		space.AddConstraint(cp.NewPinJoint(prev, ball, prev.WorldToLocal(anchor), cp.Vector{}))
+		prev, anchor = ball, pos
+	}
+	for i := 0; i < steps; i++ {
+		space.Step(goldenStep)
+	}
+	return space
+}
+
+func TestGolden(t *testing.T) {
+	wholeScreen := func() *Camera {
+		cam := NewCamera(goldenWidth, goldenHeight)
+		cam.Center, cam.Zoom = cp.Vector{X: 400, Y: 300}, 0.5
+		return cam
+	}
+	tests := []struct {
+		name     string
+		space    *cp.Space
+		cam      *Camera
+		contacts bool
+	}{
+		{"hello_start", helloSpace(0), wholeScreen(), false},
+		{"hello_rolling", helloSpace(150), wholeScreen(), false},
+		// Without a camera, the top left quarter of the screen.
+		{"hello_corner", helloSpace(150), nil, false},
+		{"hello_zoomed", helloSpace(150), func() *Camera {
+			// Following the ball, where hello_rolling has it.
+			cam := NewCamera(goldenWidth, goldenHeight)
+			cam.Pan(cp.Vector{X: 256, Y: 186})
+			cam.ZoomAt(cp.Vector{X: goldenWidth / 2, Y: goldenHeight / 2}, 3)
+			return cam
+		}(), false},
+		{"chain_swinging", chainSpace(60), wholeScreen(), true},
+	}
+	defer func(show bool) { ShowCollisionPoints = show }(ShowCollisionPoints)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ShowCollisionPoints = tt.contacts
+			canvas := newImageCanvas()
+			DrawSpaceOn(canvas, tt.space, colornames.White, tt.cam)
+			compareGolden(t, tt.name, canvas.RGBA)
+		})
+	}
+}
+
+// compareGolden compares img with the golden image name of testdata, or
+// rewrites the golden with -update. A failed image is written next to the
+// temporary files, to look at.
+func compareGolden(t *testing.T, name string, img *image.RGBA) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".png")
+	if *update {
+		if err := writePNG(path, img); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%v, run with -update to make it", err)
+	}
+	defer f.Close()
+	golden, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	if golden.Bounds() != img.Bounds() {
+		t.Fatalf("%s is %v, the image %v", path, golden.Bounds(), img.Bounds())
+	}
+	// Both ways: a line missing from the image, or one too many.
+	if n := mismatches(img, golden) + mismatches(golden, img); n > goldenMaxMismatch {
+		failed := filepath.Join(os.TempDir(), "golden_"+name+".png")
+		if err := writePNG(failed, img); err != nil {
+			t.Log(err)
+		}
+		t.Errorf("%d pixels differ from %s, drawn to %s", n, path, failed)
+	}
+}
+
+// mismatches counts the pixels of a with no pixel close in color around them
+// in b, a pixel away at most.
+func mismatches(a, b image.Image) int {
+	r := a.Bounds()
+	n := 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			found := false
+			for dy := -1; dy <= 1 && !found; dy++ {
+				for dx := -1; dx <= 1 && !found; dx++ {
+					p := image.Pt(x+dx, y+dy)
+					found = p.In(r) && closeColors(a.At(x, y), b.At(p.X, p.Y))
+				}
+			}
+			if !found {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func closeColors(c1, c2 color.Color) bool {
+	r1, g1, b1, a1 := c1.RGBA()
+	r2, g2, b2, a2 := c2.RGBA()
+	near := func(v1, v2 uint32) bool {
+		return math.Abs(float64(v1>>8)-float64(v2>>8)) <= goldenColorTolerance
+	}
+	return near(r1, r2) && near(g1, g2) && near(b1, b2) && near(a1, a2)
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
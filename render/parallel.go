@@ -0,0 +1,101 @@
+package render
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+)
+
+const (
+	// batchPartMinShapes is the fewest shapes a worker is given: fewer are
+	// built faster than a goroutine is started and waited for.
+	batchPartMinShapes = 512
+	// batchMaxWorkers is the most workers a batch is split among.
+	batchMaxWorkers = 8
+)
+
+// BatchWorkers is how many goroutines the quads of a space's shapes on the
+// screen are built by, each a part of them, one after the other in the
+// order cp goes over them, before they are merged into the screen's batch
+// and drawn in one DrawTriangles call as before: once the draw calls are
+// few, going over thousands of shapes on the game's goroutine is what a
+// frame's drawing waits for. The CPUs by default, up to batchMaxWorkers; 1
+// builds the batch on the game's goroutine.
+var BatchWorkers = defaultBatchWorkers()
+
+func defaultBatchWorkers() int {
+	if n := runtime.NumCPU(); n < batchMaxWorkers {
+		return n
+	}
+	return batchMaxWorkers
+}
+
+// batchPart is a worker's part of the screen's batch, and its drawer.
+type batchPart struct {
+	q quadBatch
+	d drawer
+}
+
+var (
+	// batchParts are kept from one frame to the next, with their vertices.
+	batchParts []*batchPart
+	// partShapes are the shapes split among the workers.
+	partShapes []*cp.Shape
+)
+
+// buildParts draws shapes on dst as d does, split among BatchWorkers
+// goroutines, each drawing a run of them into its part, and merges the
+// parts in order into the screen's batch: the quads are the same as the
+// shapes drawn one after the other. An outline a worker finds no room for
+// in the atlas is drawn with lines, rendered in the atlas once the parts
+// are merged, for the next frame.
+func (d *drawer) buildParts(dst *ebiten.Image, shapes []*cp.Shape) {
+	workers := len(shapes) / batchPartMinShapes
+	if workers > BatchWorkers {
+		workers = BatchWorkers
+	}
+	if workers < 2 {
+		for _, shape := range shapes {
+			if !d.drawPoint(shape) {
+				cp.DrawShape(shape, d)
+			}
+		}
+		return
+	}
+	for len(batchParts) < workers {
+		batchParts = append(batchParts, &batchPart{q: quadBatch{part: true}})
+	}
+	var wg sync.WaitGroup
+	per := (len(shapes) + workers - 1) / workers
+	for i, p := range batchParts[:workers] {
+		run := shapes[i*per:]
+		if len(run) > per {
+			run = run[:per]
+		}
+		p.q.vertices, p.q.missed = p.q.vertices[:0], p.q.missed[:0]
+		canvas := batchCanvas{dst, &p.q}
+		p.d = drawer{canvas: canvas, outliner: canvas, shape: d.shape, flags: d.flags, cam: d.cam}
+		wg.Add(1)
+		go func(p *batchPart, run []*cp.Shape) {
+			defer wg.Done()
+			for _, shape := range run {
+				if !p.d.drawPoint(shape) {
+					cp.DrawShape(shape, &p.d)
+				}
+			}
+		}(p, run)
+	}
+	wg.Wait()
+	for _, p := range batchParts[:workers] {
+		screenBatch.addVertices(dst, p.q.vertices)
+	}
+	// After the merge: an atlas starting over flushes the quads of all the
+	// parts first.
+	for _, p := range batchParts[:workers] {
+		for _, k := range p.q.missed {
+			outlines.region(k, &screenBatch)
+		}
+	}
+}
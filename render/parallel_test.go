@@ -0,0 +1,63 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+)
+
+// TestBuildParts checks that the screen's batch built by workers has the
+// quads built on one goroutine, in the same order, and that the outlines
+// the workers missed in the atlas are in it after the merge.
+func TestBuildParts(t *testing.T) {
+	defer func(n int) { BatchWorkers = n }(BatchWorkers)
+	BatchWorkers = 4
+	space := gridSpace(4000)
+	var shapes []*cp.Shape
+	space.EachShape(func(shape *cp.Shape) { shapes = append(shapes, shape) })
+	dst := ebiten.NewImage(800, 600)
+	canvas := ScreenCanvas{dst}
+	d := &drawer{canvas: canvas, outliner: canvas, shape: toFColor(colornames.White), flags: cp.DRAW_SHAPES, cam: NewCamera(800, 600)}
+
+	outlines.reset()
+	d.buildParts(dst, shapes)
+	screenBatch.flush()
+	missed := 0
+	for _, p := range batchParts {
+		for _, k := range p.q.missed {
+			missed++
+			if _, ok := outlines.regions[k]; !ok {
+				t.Fatalf("the outline %+v a worker missed isn't in the atlas after the merge", k)
+			}
+		}
+	}
+	if missed == 0 {
+		t.Fatalf("the workers missed no outline in an empty atlas")
+	}
+
+	for _, shape := range shapes {
+		if !d.drawPoint(shape) {
+			cp.DrawShape(shape, d)
+		}
+	}
+	want, wantIndices := append([]ebiten.Vertex(nil), screenBatch.vertices...), append([]uint16(nil), screenBatch.indices...)
+	screenBatch.flush()
+	d.buildParts(dst, shapes)
+	got, gotIndices := screenBatch.vertices, screenBatch.indices
+	if len(got) != len(want) || len(gotIndices) != len(wantIndices) {
+		t.Fatalf("%d vertices and %d indices built by workers, want %d and %d", len(got), len(gotIndices), len(want), len(wantIndices))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("vertex %d is %+v built by workers, want %+v", i, got[i], want[i])
+		}
+	}
+	for i := range wantIndices {
+		if gotIndices[i] != wantIndices[i] {
+			t.Fatalf("index %d is %d built by workers, want %d", i, gotIndices[i], wantIndices[i])
+		}
+	}
+	screenBatch.flush()
+}
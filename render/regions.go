@@ -0,0 +1,244 @@
+package render
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+)
+
+const (
+	// regionPadding is the room around a shape's bounding box redrawn with
+	// it, in pixels: its outline's stroke and antialiasing, and the pixel a
+	// line is off to its side.
+	regionPadding = 2
+	// regionsMax is how many regions of a frame are redrawn at most, and
+	// regionsMaxArea the part of it they cover: past either, the frame is
+	// drawn anew, which is as fast then.
+	regionsMax     = 32
+	regionsMaxArea = 0.5
+	// frameLayersKept is how many spaces' frames are kept, those of the
+	// spaces drawn last.
+	frameLayersKept = 4
+)
+
+// RedrawRegions makes DrawSpace keep the shapes of a space it drew in a
+// frame of their own, and redraw only the regions of it where shapes moved,
+// were added or removed, or changed color, over the statics' layer: a scene
+// seen through a fixed camera, where few bodies move, costs the pixels of
+// those bodies a frame, not of all of them. The frame is drawn anew when the
+// camera moves, or when too much of it changed. The constraints and the
+// contact points are drawn every frame, over it. Off, the default, every
+// shape is drawn every frame.
+var RedrawRegions bool
+
+// frameKey is what a frame is drawn for, a change of which draws it anew:
+// its size, the camera, the color of the space's shapes, the statics and
+// the size of the points.
+type frameKey struct {
+	size    image.Point
+	cam     Camera
+	clr     cp.FColor
+	statics staticKey
+	points  float64
+}
+
+// shapeMark is a shape as it was last drawn in a frame: where, turned how
+// much, and in what color. seen is the frame's generation it was seen at.
+type shapeMark struct {
+	bb    cp.BB
+	angle float64
+	clr   cp.FColor
+	seen  int
+}
+
+// regionTracker tells the regions of a frame where shapes changed from one
+// frame to the next.
+type regionTracker struct {
+	marks      map[*cp.Shape]shapeMark
+	generation int
+	// regions are those of the last track, kept from one to the next.
+	regions []image.Rectangle
+}
+
+// track compares the shapes of space drawn by d with what they were at the
+// last track, and returns the regions of the screen they changed in, where
+// they were and where they are: bounds clips them, those out of it are
+// none. The static body's shapes are skipped with skipStatics.
+func (t *regionTracker) track(space *cp.Space, d *drawer, bounds image.Rectangle, skipStatics bool) []image.Rectangle {
+	if t.marks == nil {
+		t.marks = map[*cp.Shape]shapeMark{}
+	}
+	t.generation++
+	t.regions = t.regions[:0]
+	add := func(bb cp.BB) {
+		if r := d.screenRect(bb).Intersect(bounds); !r.Empty() {
+			t.regions = append(t.regions, r)
+		}
+	}
+	space.EachShape(func(shape *cp.Shape) {
+		if skipStatics && shape.Body() == space.StaticBody {
+			return
+		}
+		m := shapeMark{bb: shape.BB(), angle: shape.Body().Angle(), clr: d.ShapeColor(shape, nil), seen: t.generation}
+		old, ok := t.marks[shape]
+		t.marks[shape] = m
+		switch {
+		case !ok:
+			add(m.bb)
+		case old.bb != m.bb || old.angle != m.angle || old.clr != m.clr:
+			add(old.bb)
+			add(m.bb)
+		}
+	})
+	for shape, m := range t.marks {
+		if m.seen != t.generation {
+			add(m.bb)
+			delete(t.marks, shape)
+		}
+	}
+	return t.regions
+}
+
+// mergeRegions merges the regions that overlap into their union, in place,
+// for no pixel to be redrawn twice.
+func mergeRegions(rs []image.Rectangle) []image.Rectangle {
+	for i := 0; i < len(rs); i++ {
+		for j := i + 1; j < len(rs); j++ {
+			if !rs[i].Overlaps(rs[j]) {
+				continue
+			}
+			rs[i] = rs[i].Union(rs[j])
+			rs[j] = rs[len(rs)-1]
+			rs = rs[:len(rs)-1]
+			// The union may overlap those checked already.
+			j = i
+		}
+	}
+	return rs
+}
+
+// frameLayer is the shapes of a space drawn into an image the size of the
+// screen, kept from frame to frame and drawn on it in one DrawImage.
+type frameLayer struct {
+	space   *cp.Space
+	key     frameKey
+	image   *ebiten.Image
+	tracker regionTracker
+	// redraws counts the times it was drawn anew, regions the regions
+	// redrawn.
+	redraws, regions int
+}
+
+// frameLayers are the frames of the spaces drawn last, the last drawn last.
+var frameLayers []*frameLayer
+
+// frameLayerOf returns the frame of space, new if it has none.
+func frameLayerOf(space *cp.Space) *frameLayer {
+	for i, f := range frameLayers {
+		if f.space == space {
+			copy(frameLayers[i:], frameLayers[i+1:])
+			frameLayers[len(frameLayers)-1] = f
+			return f
+		}
+	}
+	if len(frameLayers) == frameLayersKept {
+		if old := frameLayers[0]; old.image != nil {
+			old.image.Dispose()
+		}
+		frameLayers = append(frameLayers[:0], frameLayers[1:]...)
+	}
+	f := &frameLayer{space: space}
+	frameLayers = append(frameLayers, f)
+	return f
+}
+
+// dropFrameLayers forgets the frames of the spaces drawn so far.
+func dropFrameLayers() {
+	for _, f := range frameLayers {
+		if f.image != nil {
+			f.image.Dispose()
+		}
+	}
+	frameLayers = frameLayers[:0]
+}
+
+// drawFrame draws the shapes of space on screen from its frame, redrawing
+// the regions of it that changed, or all of it.
+func drawFrame(screen *ebiten.Image, space *cp.Space, d *drawer) {
+	f := frameLayerOf(space)
+	var statics *staticLayer
+	if CacheStatics {
+		statics = staticLayerFor(space, d)
+	}
+	bounds := screen.Bounds()
+	k := frameKey{size: bounds.Size(), clr: d.shape, points: PointSize}
+	if d.cam != nil {
+		k.cam = *d.cam
+	}
+	if statics != nil {
+		k.statics = statics.key
+	}
+	regions := f.tracker.track(space, d, bounds, statics != nil)
+	full := k != f.key || f.image == nil || len(regions) > 4*regionsMax
+	if !full {
+		regions = mergeRegions(regions)
+		area := 0
+		for _, r := range regions {
+			area += r.Dx() * r.Dy()
+		}
+		full = len(regions) > regionsMax || float64(area) > regionsMaxArea*float64(bounds.Dx()*bounds.Dy())
+	}
+	if full {
+		f.key = k
+		if f.image != nil {
+			if w, h := f.image.Size(); w != k.size.X || h != k.size.Y {
+				f.image.Dispose()
+				f.image = nil
+			}
+		}
+		if f.image == nil {
+			f.image = ebiten.NewImage(k.size.X, k.size.Y)
+		}
+		f.redraw(f.image, statics, d, false)
+		f.redraws++
+	} else {
+		for _, r := range regions {
+			f.redraw(f.image.SubImage(r).(*ebiten.Image), statics, d, true)
+		}
+		f.regions += len(regions)
+	}
+	// Over what was drawn on the screen before.
+	screenBatch.flush()
+	screen.DrawImage(f.image, nil)
+}
+
+// redraw clears dst, the frame or a region of it, and draws the statics'
+// layer and the shapes in it, those around it only with query: ebiten clips
+// what is drawn on a sub-image to its bounds.
+func (f *frameLayer) redraw(dst *ebiten.Image, statics *staticLayer, d *drawer, query bool) {
+	dst.Clear()
+	if statics != nil {
+		statics.drawOn(dst, d)
+	}
+	canvas := ScreenCanvas{dst}
+	rd := &drawer{canvas: canvas, outliner: canvas, shape: d.shape, cam: d.cam}
+	draw := func(shape *cp.Shape) {
+		if statics != nil && shape.Body() == f.space.StaticBody {
+			return
+		}
+		if !rd.drawPoint(shape) {
+			cp.DrawShape(shape, rd)
+		}
+	}
+	if query {
+		r := dst.Bounds()
+		a, b := rd.toWorld(cp.Vector{X: float64(r.Min.X), Y: float64(r.Min.Y)}), rd.toWorld(cp.Vector{X: float64(r.Max.X), Y: float64(r.Max.Y)})
+		bb := cp.BB{L: math.Min(a.X, b.X), B: math.Min(a.Y, b.Y), R: math.Max(a.X, b.X), T: math.Max(a.Y, b.Y)}
+		f.space.BBQuery(bb, cp.SHAPE_FILTER_ALL, func(shape *cp.Shape, _ interface{}) { draw(shape) }, nil)
+	} else {
+		f.space.EachShape(draw)
+	}
+	canvas.Flush()
+}
@@ -0,0 +1,71 @@
+package render
+
+import (
+	"image"
+	"sort"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+	"golang.org/x/image/colornames"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+// TestTrackRegions checks that the tracker tells the regions of the shapes
+// added, moved, turned, recolored and removed, and none for those that
+// didn't change or are off the screen, the statics skipped.
+func TestTrackRegions(t *testing.T) {
+	space := cp.NewSpace()
+	physics.AddStaticSegment(space, cp.Vector{X: 0, Y: 500}, cp.Vector{X: 800, Y: 500}, 0)
+	ball, ballShape := physics.AddBall(space, cp.Vector{X: 100, Y: 100}, 10)
+	_, boxShape := physics.AddBox(space, cp.Vector{X: 300, Y: 100}, 20, 20)
+	d := &drawer{cam: NewCamera(800, 600)}
+	bounds := image.Rect(0, 0, 800, 600)
+	var tracker regionTracker
+	p := regionPadding
+	for _, tc := range []struct {
+		name   string
+		change func()
+		want   []image.Rectangle
+	}{
+		{"first", func() {}, []image.Rectangle{image.Rect(90-p, 90-p, 110+p, 110+p), image.Rect(290-p, 90-p, 310+p, 110+p)}},
+		{"unchanged", func() {}, nil},
+		{"moved", func() { ball.SetPosition(cp.Vector{X: 120, Y: 100}) }, []image.Rectangle{image.Rect(90-p, 90-p, 110+p, 110+p), image.Rect(110-p, 90-p, 130+p, 110+p)}},
+		{"turned", func() { ball.SetAngle(1) }, []image.Rectangle{image.Rect(110-p, 90-p, 130+p, 110+p), image.Rect(110-p, 90-p, 130+p, 110+p)}},
+		{"recolored", func() { ballShape.UserData = colornames.Red }, []image.Rectangle{image.Rect(110-p, 90-p, 130+p, 110+p), image.Rect(110-p, 90-p, 130+p, 110+p)}},
+		{"off screen", func() { ball.SetPosition(cp.Vector{X: -100, Y: 100}) }, []image.Rectangle{image.Rect(110-p, 90-p, 130+p, 110+p)}},
+		{"removed", func() { space.RemoveShape(boxShape) }, []image.Rectangle{image.Rect(290-p, 90-p, 310+p, 110+p)}},
+	} {
+		tc.change()
+		ballShape.CacheBB()
+		// The shapes are gone over in no given order.
+		got := tracker.track(space, d, bounds, true)
+		sort.Slice(got, func(i, j int) bool { return got[i].Min.X < got[j].Min.X })
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: regions %v, want %v", tc.name, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: regions %v, want %v", tc.name, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+// TestMergeRegions checks that overlapping regions merge into their union,
+// a union overlapping a region checked before included, and that the
+// others are kept.
+func TestMergeRegions(t *testing.T) {
+	got := mergeRegions([]image.Rectangle{
+		image.Rect(0, 0, 10, 10),
+		image.Rect(100, 100, 110, 110),
+		image.Rect(20, 0, 30, 10),
+		image.Rect(5, 5, 25, 8),
+	})
+	want := []image.Rectangle{image.Rect(0, 0, 30, 10), image.Rect(100, 100, 110, 110)}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("merged into %v, want %v", got, want)
+	}
+}
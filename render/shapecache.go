@@ -0,0 +1,196 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// atlasSize is the side of the image the outlines are rendered in.
+	atlasSize = 1024
+	// outlineStroke is the width of the outlines, in screen pixels.
+	outlineStroke = 1
+	// outlineMaxRadius and outlineMaxLength are the largest circles and
+	// capsules rendered on screen, in pixels: the bigger ones are drawn with
+	// lines, not to fill the atlas with a few of them.
+	outlineMaxRadius = 128
+	outlineMaxLength = 512
+)
+
+// outlineKind is the kind of shape of an outline.
+type outlineKind uint8
+
+const (
+	outlineCircle outlineKind = iota
+	// outlineCapsule is a fat segment, lying along x.
+	outlineCapsule
+)
+
+// outlineKey identifies a rendered outline: its kind, radius in half screen
+// pixels, length between its ends' centers in screen pixels for a capsule,
+// stroke and color.
+type outlineKey struct {
+	kind       outlineKind
+	halfRadius int
+	length     int
+	stroke     int
+	clr        color.RGBA
+}
+
+func (k outlineKey) radius() float64 {
+	return float64(k.halfRadius) / 2
+}
+
+// size is the size of the outline's image: the shape, its stroke and a
+// pixel for the antialiasing, all around.
+func (k outlineKey) size() image.Point {
+	side := 2 * int(math.Ceil(k.radius()+float64(k.stroke)+1))
+	return image.Point{X: side + k.length, Y: side}
+}
+
+// rasterizeOutline renders the outline of the key's shape, antialiased, in
+// premultiplied alpha: a pixel is covered by how close its center is to the
+// shape's edge, within half the stroke.
+func rasterizeOutline(k outlineKey) *image.RGBA {
+	size := k.size()
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	r := k.radius()
+	// The centers of the ends, the same for a circle.
+	cy := float64(size.Y) / 2
+	ax := cy
+	bx := ax + float64(k.length)
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			nx := math.Max(ax, math.Min(bx, px))
+			d := math.Hypot(px-nx, py-cy)
+			coverage := float64(k.stroke)/2 + 0.5 - math.Abs(d-r)
+			if coverage <= 0 {
+				continue
+			}
+			coverage = math.Min(coverage, 1)
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8(float64(k.clr.R) * coverage),
+				G: uint8(float64(k.clr.G) * coverage),
+				B: uint8(float64(k.clr.B) * coverage),
+				A: uint8(float64(k.clr.A) * coverage),
+			})
+		}
+	}
+	return img
+}
+
+// shelfPacker places rectangles in rows, the shelves, left to right and top
+// to bottom, each shelf as tall as its tallest rectangle.
+type shelfPacker struct {
+	size image.Point
+	// x and y are where the next rectangle goes, shelf the height of the
+	// current shelf.
+	x, y, shelf int
+}
+
+// place returns where a rectangle of size goes, or false if the packer is
+// full.
+func (p *shelfPacker) place(size image.Point) (image.Rectangle, bool) {
+	if p.x+size.X > p.size.X {
+		p.x, p.y, p.shelf = 0, p.y+p.shelf, 0
+	}
+	if size.X > p.size.X || p.y+size.Y > p.size.Y {
+		return image.Rectangle{}, false
+	}
+	r := image.Rect(p.x, p.y, p.x+size.X, p.y+size.Y)
+	p.x += size.X
+	if size.Y > p.shelf {
+		p.shelf = size.Y
+	}
+	return r, true
+}
+
+// outlineCache keeps the outlines rendered so far in the atlas, for the
+// rasterizing of a shape to be paid once for all the shapes alike and all
+// the frames, rather than for each every frame, and the glyphs of the text
+// drawn, see DrawText. A full atlas starts over.
+type outlineCache struct {
+	atlas   *ebiten.Image
+	regions map[outlineKey]image.Rectangle
+	glyphs  map[glyphKey]glyph
+	packer  shelfPacker
+	// rendered counts the outlines rendered.
+	rendered int
+}
+
+// atlasReserved is the top left corner of the atlas, which the white pixel
+// of the lines is in the middle of.
+const atlasReserved = 3
+
+// newOutlineCache returns a cache whose atlas has the white pixel at (1, 1).
+func newOutlineCache() *outlineCache {
+	c := &outlineCache{atlas: ebiten.NewImage(atlasSize, atlasSize)}
+	white := c.atlas.SubImage(image.Rect(0, 0, atlasReserved, atlasReserved)).(*ebiten.Image)
+	white.Fill(color.White)
+	c.reset()
+	return c
+}
+
+func (c *outlineCache) reset() {
+	c.regions = map[outlineKey]image.Rectangle{}
+	c.glyphs = map[glyphKey]glyph{}
+	// The white pixel's corner is taken by the first shelf.
+	c.packer = shelfPacker{size: image.Point{X: atlasSize, Y: atlasSize}, x: atlasReserved, shelf: atlasReserved}
+}
+
+// region returns where the outline of k is in the atlas, rendering it there
+// first if it isn't yet.
+func (c *outlineCache) region(k outlineKey, q *quadBatch) (image.Rectangle, bool) {
+	if r, ok := c.regions[k]; ok {
+		return r, true
+	}
+	img := rasterizeOutline(k)
+	r, ok := c.place(img, q)
+	if !ok {
+		return image.Rectangle{}, false
+	}
+	c.regions[k] = r
+	c.rendered++
+	return r, true
+}
+
+// place copies img into the atlas, and returns where. The batch is flushed
+// before the atlas starts over, its quads reading the images being
+// replaced.
+func (c *outlineCache) place(img *image.RGBA, q *quadBatch) (image.Rectangle, bool) {
+	size := img.Bounds().Size()
+	r, ok := c.packer.place(size)
+	if !ok {
+		q.flush()
+		c.reset()
+		if r, ok = c.packer.place(size); !ok {
+			return image.Rectangle{}, false
+		}
+	}
+	c.atlas.SubImage(r).(*ebiten.Image).ReplacePixels(img.Pix)
+	return r, true
+}
+
+// outlines are the outlines of ScreenCanvas, whose atlas its batch draws
+// from.
+var outlines = newOutlineCache()
+
+// newOutlineKey returns the key of an outline of radius on screen, false
+// if it is too big to be cached.
+func newOutlineKey(kind outlineKind, radius, length float64, clr color.Color) (outlineKey, bool) {
+	if radius <= 0 || radius > outlineMaxRadius || length > outlineMaxLength {
+		return outlineKey{}, false
+	}
+	r, g, b, a := clr.RGBA()
+	return outlineKey{
+		kind:       kind,
+		halfRadius: int(math.Round(radius * 2)),
+		length:     int(math.Round(length)),
+		stroke:     outlineStroke,
+		clr:        color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)},
+	}, true
+}
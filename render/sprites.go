@@ -0,0 +1,85 @@
+package render
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+)
+
+// SpriteRegistry is the render registry: the image drawn for each body that
+// has one, centered on the body and turned with it. Bodies removed from the
+// space must be removed from here too, or their sprite stays on screen.
+type SpriteRegistry struct {
+	// bodies keeps the drawing order, the first added is drawn first.
+	bodies []*cp.Body
+	images map[*cp.Body]*ebiten.Image
+	// op is reset for each sprite rather than made.
+	op ebiten.DrawImageOptions
+}
+
+func NewSpriteRegistry() *SpriteRegistry {
+	return &SpriteRegistry{images: map[*cp.Body]*ebiten.Image{}}
+}
+
+func (r *SpriteRegistry) Add(body *cp.Body, img *ebiten.Image) {
+	if _, ok := r.images[body]; !ok {
+		r.bodies = append(r.bodies, body)
+	}
+	r.images[body] = img
+}
+
+// Image returns the body's image, nil if it has none.
+func (r *SpriteRegistry) Image(body *cp.Body) *ebiten.Image {
+	return r.images[body]
+}
+
+func (r *SpriteRegistry) Remove(body *cp.Body) {
+	if _, ok := r.images[body]; !ok {
+		return
+	}
+	delete(r.images, body)
+	for i, b := range r.bodies {
+		if b == body {
+			r.bodies = append(r.bodies[:i], r.bodies[i+1:]...)
+			break
+		}
+	}
+}
+
+func (r *SpriteRegistry) Draw(screen *ebiten.Image, cam *Camera) {
+	for _, body := range r.bodies {
+		img := r.images[body]
+		w, h := img.Size()
+		pos := cam.ToScreen(body.Position())
+		r.op.GeoM.Reset()
+		r.op.GeoM.Translate(-float64(w)/2, -float64(h)/2)
+		r.op.GeoM.Rotate(body.Angle())
+		r.op.GeoM.Scale(cam.Zoom, cam.Zoom)
+		r.op.GeoM.Translate(pos.X, pos.Y)
+		screen.DrawImage(img, &r.op)
+	}
+}
+
+// NewBoxSprite returns a filled rectangle.
+func NewBoxSprite(width, height float64, clr color.Color) *ebiten.Image {
+	img := ebiten.NewImage(int(width), int(height))
+	img.Fill(clr)
+	return img
+}
+
+// NewBallSprite returns a filled disc.
+func NewBallSprite(radius float64, clr color.Color) *ebiten.Image {
+	size := int(2 * radius)
+	disc := image.NewRGBA(image.Rect(0, 0, size, size))
+	center := cp.Vector{X: radius, Y: radius}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if center.Distance(cp.Vector{X: float64(x) + 0.5, Y: float64(y) + 0.5}) <= radius {
+				disc.Set(x, y, clr)
+			}
+		}
+	}
+	return ebiten.NewImageFromImage(disc)
+}
@@ -0,0 +1,209 @@
+package render
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+)
+
+const (
+	// staticLayerMaxSize is the largest side of a static layer, in pixels:
+	// the statics of a level bigger than that at the zoom are drawn every
+	// frame.
+	staticLayerMaxSize = 4096
+	// staticLayerPadding is the room around the statics' bounding box, in
+	// pixels, for their outlines' antialiasing.
+	staticLayerPadding = 4
+	// staticLayersKept is how many spaces' layers are kept, those of the
+	// spaces drawn last: a scene draws a few spaces at most.
+	staticLayersKept = 8
+)
+
+// CacheStatics makes DrawSpace draw the shapes of a space's static body
+// from a layer they are rendered in once, rather than every frame, see
+// staticLayer. Off, every shape is drawn every frame.
+var CacheStatics = true
+
+// staticKey is what a static layer is rendered for, a change of which
+// renders it anew: the zoom, the color of the space's shapes, and the
+// static body's shapes, their count and the sum of their hash ids. cp
+// numbers the shapes as they are added, a shape removed and another added
+// change the sum.
+type staticKey struct {
+	zoom   float64
+	clr    cp.FColor
+	shapes int
+	ids    cp.HashValue
+}
+
+// newStaticKey returns the key of the space's statics drawn by d. It goes
+// over the static body's shapes, not drawing them, which is cheap.
+func newStaticKey(space *cp.Space, d *drawer) staticKey {
+	k := staticKey{zoom: d.zoom(), clr: d.shape}
+	space.StaticBody.EachShape(func(shape *cp.Shape) {
+		k.shapes++
+		k.ids += shape.HashId()
+	})
+	return k
+}
+
+// staticBounds returns where the layer of the static body's shapes is, in
+// world units times the zoom, whole pixels around their bounding box, or
+// false if they are none, or too big for a layer.
+func staticBounds(space *cp.Space, zoom float64) (image.Rectangle, bool) {
+	var bb cp.BB
+	first := true
+	space.StaticBody.EachShape(func(shape *cp.Shape) {
+		if first {
+			bb, first = shape.BB(), false
+			return
+		}
+		bb = bb.Merge(shape.BB())
+	})
+	if first {
+		return image.Rectangle{}, false
+	}
+	r := image.Rect(
+		int(math.Floor(bb.L*zoom))-staticLayerPadding, int(math.Floor(bb.B*zoom))-staticLayerPadding,
+		int(math.Ceil(bb.R*zoom))+staticLayerPadding, int(math.Ceil(bb.T*zoom))+staticLayerPadding,
+	)
+	if r.Dx() > staticLayerMaxSize || r.Dy() > staticLayerMaxSize {
+		return image.Rectangle{}, false
+	}
+	return r, true
+}
+
+// staticLayer is the static body's shapes of a space rendered into an
+// image, drawn on the screen each frame in one DrawImage rather than shape
+// by shape: walls, terrain and levels cost a frame nothing, only the bodies
+// that move do. The layer is in world units times the zoom, the camera
+// panning only moves it; it is rendered anew when the zoom changes, or the
+// statics do, see staticKey.
+type staticLayer struct {
+	space *cp.Space
+	key   staticKey
+	// bounds are where the layer is, in world units times the zoom, none
+	// if the statics are drawn every frame; image is as big or bigger, and
+	// view the part of it in use.
+	bounds image.Rectangle
+	image  *ebiten.Image
+	view   *ebiten.Image
+	// renders counts the times it was rendered.
+	renders int
+}
+
+var (
+	// staticLayers are the layers of the spaces drawn last, the last drawn
+	// last.
+	staticLayers []*staticLayer
+	// staticOp is reset for each layer drawn rather than made.
+	staticOp ebiten.DrawImageOptions
+)
+
+// staticLayerOf returns the layer of space, new if it has none.
+func staticLayerOf(space *cp.Space) *staticLayer {
+	for i, l := range staticLayers {
+		if l.space == space {
+			copy(staticLayers[i:], staticLayers[i+1:])
+			staticLayers[len(staticLayers)-1] = l
+			return l
+		}
+	}
+	if len(staticLayers) == staticLayersKept {
+		if old := staticLayers[0]; old.image != nil {
+			old.image.Dispose()
+		}
+		staticLayers = append(staticLayers[:0], staticLayers[1:]...)
+	}
+	l := &staticLayer{space: space}
+	staticLayers = append(staticLayers, l)
+	return l
+}
+
+// DropLayers forgets the static and frame layers of the spaces drawn so
+// far, and the spaces with them, e.g. for a scene being made anew.
+func DropLayers() {
+	for _, l := range staticLayers {
+		if l.image != nil {
+			l.image.Dispose()
+		}
+	}
+	staticLayers = staticLayers[:0]
+	dropFrameLayers()
+}
+
+// render renders the statics for k, into the image kept if big enough.
+func (l *staticLayer) render(k staticKey, d *drawer) {
+	l.key = k
+	l.renders++
+	var ok bool
+	if l.bounds, ok = staticBounds(l.space, k.zoom); !ok {
+		l.view = nil
+		return
+	}
+	size := l.bounds.Size()
+	if l.image != nil {
+		if w, h := l.image.Size(); w < size.X || h < size.Y {
+			l.image.Dispose()
+			l.image = nil
+		}
+	}
+	if l.image == nil {
+		l.image = ebiten.NewImage(size.X, size.Y)
+	}
+	l.image.Clear()
+	l.view = l.image.SubImage(image.Rectangle{Max: size}).(*ebiten.Image)
+	// The layer's camera puts the corner of the bounds at the image's.
+	canvas := ScreenCanvas{l.image}
+	ld := &drawer{
+		canvas:   canvas,
+		outliner: canvas,
+		shape:    d.shape,
+		cam:      &Camera{Zoom: k.zoom, screenCenter: cp.Vector{X: float64(-l.bounds.Min.X), Y: float64(-l.bounds.Min.Y)}},
+	}
+	l.space.StaticBody.EachShape(func(shape *cp.Shape) {
+		if !ld.drawPoint(shape) {
+			cp.DrawShape(shape, ld)
+		}
+	})
+	canvas.Flush()
+}
+
+// staticLayerFor returns the layer of space's statics drawn by d, rendering
+// it first if the statics or the zoom changed, or nil if they are too big
+// for one and for d to draw.
+func staticLayerFor(space *cp.Space, d *drawer) *staticLayer {
+	l := staticLayerOf(space)
+	if k := newStaticKey(space, d); k != l.key || l.renders == 0 {
+		l.render(k, d)
+	}
+	if l.view == nil {
+		return nil
+	}
+	return l
+}
+
+// drawOn draws the layer on dst where d puts the statics.
+func (l *staticLayer) drawOn(dst *ebiten.Image, d *drawer) {
+	// The layer's corner, in whole pixels not to blur it.
+	corner := d.toScreen(cp.Vector{}).Add(cp.Vector{X: float64(l.bounds.Min.X), Y: float64(l.bounds.Min.Y)})
+	staticOp.GeoM.Reset()
+	staticOp.GeoM.Translate(math.Round(corner.X), math.Round(corner.Y))
+	dst.DrawImage(l.view, &staticOp)
+}
+
+// drawStatics draws the static body's shapes of space on screen from its
+// layer, and reports whether it did: the statics too big for a layer are
+// for d to draw.
+func drawStatics(screen *ebiten.Image, space *cp.Space, d *drawer) bool {
+	l := staticLayerFor(space, d)
+	if l == nil {
+		return false
+	}
+	// Under what was drawn on the screen before.
+	screenBatch.flush()
+	l.drawOn(screen, d)
+	return true
+}
@@ -0,0 +1,64 @@
+package render
+
+import (
+	"image"
+	"testing"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+)
+
+// TestStaticKey checks that the key of a space's statics changes with them,
+// a segment removed and another added included, and with the zoom, but not
+// with the bodies moving or the camera panning.
+func TestStaticKey(t *testing.T) {
+	space := cp.NewSpace()
+	physics.AddStaticSegment(space, cp.Vector{X: 0, Y: 100}, cp.Vector{X: 200, Y: 100}, 0)
+	wall := physics.AddStaticSegment(space, cp.Vector{X: 0, Y: 0}, cp.Vector{X: 0, Y: 100}, 0)
+	body, _ := physics.AddBall(space, cp.Vector{X: 50, Y: 50}, 5)
+	d := &drawer{cam: NewCamera(800, 600)}
+	key := newStaticKey(space, d)
+
+	body.SetPosition(cp.Vector{X: 80, Y: 20})
+	d.cam.Pan(cp.Vector{X: 30, Y: -10})
+	if got := newStaticKey(space, d); got != key {
+		t.Errorf("the key changed from %+v to %+v with a body moved and the camera panned", key, got)
+	}
+	space.RemoveShape(wall)
+	physics.AddStaticSegment(space, cp.Vector{X: 200, Y: 0}, cp.Vector{X: 200, Y: 100}, 0)
+	moved := newStaticKey(space, d)
+	if moved == key {
+		t.Errorf("the key stayed %+v with a wall moved", key)
+	}
+	d.cam.Zoom = 2
+	if got := newStaticKey(space, d); got == moved {
+		t.Errorf("the key stayed %+v zoomed in", got)
+	}
+}
+
+// TestStaticBounds checks the bounds of a layer, around the statics at the
+// zoom, and that there are none for statics too big for a layer.
+func TestStaticBounds(t *testing.T) {
+	space := cp.NewSpace()
+	if _, ok := staticBounds(space, 1); ok {
+		t.Errorf("a space without statics has a layer")
+	}
+	physics.AddStaticSegment(space, cp.Vector{X: 10, Y: 20}, cp.Vector{X: 110, Y: 70}, 0)
+	p := staticLayerPadding
+	for _, tc := range []struct {
+		zoom float64
+		want image.Rectangle
+	}{
+		{1, image.Rect(10-p, 20-p, 110+p, 70+p)},
+		{0.5, image.Rect(5-p, 10-p, 55+p, 35+p)},
+	} {
+		if got, ok := staticBounds(space, tc.zoom); !ok || got != tc.want {
+			t.Errorf("at zoom %g, the layer is at %v, want %v", tc.zoom, got, tc.want)
+		}
+	}
+	physics.AddStaticSegment(space, cp.Vector{}, cp.Vector{X: staticLayerMaxSize}, 0)
+	if r, ok := staticBounds(space, 1); ok {
+		t.Errorf("statics wider than a layer have one at %v", r)
+	}
+}
@@ -0,0 +1,135 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// glyphKey identifies a glyph rendered in the atlas.
+type glyphKey struct {
+	face font.Face
+	r    rune
+}
+
+// glyph is a glyph rendered in the atlas: its region, empty for one that
+// draws nothing, e.g. a space, the offset of the region's top left corner
+// from the dot, and how far the dot moves on.
+type glyph struct {
+	region  image.Rectangle
+	offset  image.Point
+	advance fixed.Int26_6
+}
+
+// rasterizeGlyph renders r in face, white, in a whole number of pixels
+// around it.
+func rasterizeGlyph(face font.Face, r rune) (*image.RGBA, glyph) {
+	bounds, advance, _ := face.GlyphBounds(r)
+	g := glyph{
+		offset:  image.Point{X: bounds.Min.X.Floor(), Y: bounds.Min.Y.Floor()},
+		advance: advance,
+	}
+	size := image.Point{X: bounds.Max.X.Ceil() - g.offset.X, Y: bounds.Max.Y.Ceil() - g.offset.Y}
+	if size.X <= 0 || size.Y <= 0 {
+		return nil, g
+	}
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.White,
+		Face: face,
+		Dot:  fixed.P(-g.offset.X, -g.offset.Y),
+	}
+	d.DrawString(string(r))
+	return img, g
+}
+
+// glyph returns r of face rendered in the atlas, rendering it there first
+// if it isn't yet.
+func (c *outlineCache) glyph(face font.Face, r rune, q *quadBatch) glyph {
+	k := glyphKey{face, r}
+	if g, ok := c.glyphs[k]; ok {
+		return g
+	}
+	img, g := rasterizeGlyph(face, r)
+	if img != nil {
+		g.region, _ = c.place(img, q)
+	}
+	c.glyphs[k] = g
+	return g
+}
+
+// DrawText draws text in face at (x, y), its top left corner, as
+// ebitenutil.DebugPrintAt does, its lines lineHeight apart. The glyphs are
+// rendered once, into the atlas of the debug drawer's outlines, and drawn
+// as quads of the screen's batch in one DrawTriangles call: drawing a
+// text every frame allocates nothing, and text is bytes for a HUD to write
+// its numbers into a buffer kept from frame to frame, see TextBuffer,
+// rather than format a string a frame.
+func DrawText(dst *ebiten.Image, text []byte, face font.Face, x, y, lineHeight int, clr color.Color) {
+	r, g, b, a := clr.RGBA()
+	if a == 0 {
+		return
+	}
+	// The vertices take straight alpha, RGBA is premultiplied.
+	fr, fg, fb, fa := float32(r)/float32(a), float32(g)/float32(a), float32(b)/float32(a), float32(a)/0xffff
+	top := y + face.Metrics().Ascent.Ceil()
+	var dot fixed.Int26_6
+	prev := rune(-1)
+	for len(text) > 0 {
+		c, n := utf8.DecodeRune(text)
+		text = text[n:]
+		if c == '\n' {
+			dot, prev = 0, -1
+			top += lineHeight
+			continue
+		}
+		if prev >= 0 {
+			dot += face.Kern(prev, c)
+		}
+		prev = c
+		gl := outlines.glyph(face, c, &screenBatch)
+		if !gl.region.Empty() {
+			gx, gy := float64(x+dot.Round()+gl.offset.X), float64(top+gl.offset.Y)
+			w, h := float64(gl.region.Dx()), float64(gl.region.Dy())
+			screenBatch.addImage(dst, gx, gy, gx+w, gy, gx+w, gy+h, gx, gy+h, gl.region, fr, fg, fb, fa)
+		}
+		dot += gl.advance
+	}
+	screenBatch.flush()
+}
+
+// TextBuffer is text written a piece at a time, numbers with strconv's
+// Append functions, into a buffer kept from one frame to the next: once it
+// has grown, writing the same HUD every frame allocates nothing, where
+// fmt.Sprintf makes a string each time.
+type TextBuffer []byte
+
+// Reset empties the buffer, keeping its memory.
+func (b *TextBuffer) Reset() *TextBuffer {
+	*b = (*b)[:0]
+	return b
+}
+
+// Text writes s.
+func (b *TextBuffer) Text(s string) *TextBuffer {
+	*b = append(*b, s...)
+	return b
+}
+
+// Int writes n.
+func (b *TextBuffer) Int(n int) *TextBuffer {
+	*b = strconv.AppendInt(*b, int64(n), 10)
+	return b
+}
+
+// Float writes f with prec digits after the point.
+func (b *TextBuffer) Float(f float64, prec int) *TextBuffer {
+	*b = strconv.AppendFloat(*b, f, 'f', prec, 64)
+	return b
+}
@@ -0,0 +1,61 @@
+package render
+
+import (
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+func testFace(t *testing.T) font.Face {
+	f, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: 12, DPI: 72})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return face
+}
+
+// TestRasterizeGlyph checks that a letter is rendered in white within its
+// bounds, above the baseline, and that a space takes room but draws
+// nothing.
+func TestRasterizeGlyph(t *testing.T) {
+	face := testFace(t)
+	img, g := rasterizeGlyph(face, 'H')
+	if img == nil || g.offset.Y >= 0 || g.advance <= 0 {
+		t.Fatalf("H rendered in %v at %v, advancing %v", img.Bounds(), g.offset, g.advance)
+	}
+	var covered int
+	for i := 0; i < len(img.Pix); i += 4 {
+		if a := img.Pix[i+3]; a > 0 {
+			covered++
+			if img.Pix[i] != a {
+				t.Fatalf("H has a pixel %v, want premultiplied white", img.Pix[i:i+4])
+			}
+		}
+	}
+	if covered == 0 {
+		t.Error("H covers no pixel")
+	}
+	if img, g := rasterizeGlyph(face, ' '); img != nil || g.advance <= 0 {
+		t.Errorf("a space rendered %v, advancing %v, want nothing but room", img, g.advance)
+	}
+}
+
+// TestTextBuffer checks what the buffer writes, and that writing it again
+// allocates nothing.
+func TestTextBuffer(t *testing.T) {
+	var b TextBuffer
+	write := func() { b.Reset().Text("FPS ").Float(59.94, 1).Text(", steps ").Int(-2) }
+	write()
+	if got, want := string(b), "FPS 59.9, steps -2"; got != want {
+		t.Errorf("wrote %q, want %q", got, want)
+	}
+	if allocs := testing.AllocsPerRun(100, write); allocs != 0 {
+		t.Errorf("writing the buffer again made %v allocations, want none", allocs)
+	}
+}
@@ -0,0 +1,63 @@
+package render
+
+import (
+	"image"
+	"path"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/tiled"
+)
+
+// TileMap draws the tile layers of a Tiled map, with the images of its
+// tilesets. Flipped tiles are drawn unflipped.
+type TileMap struct {
+	m      *tiled.Map
+	images map[*tiled.Tileset]*ebiten.Image
+}
+
+// NewTileMap gets the images of the map's tilesets from load, by their
+// paths in the map's file system, e.g. assets.Image.
+func NewTileMap(m *tiled.Map, load func(name string) (*ebiten.Image, error)) (*TileMap, error) {
+	t := &TileMap{m: m, images: map[*tiled.Tileset]*ebiten.Image{}}
+	for _, ts := range m.Tilesets {
+		img, err := load(path.Join(ts.Dir, ts.Image.Source))
+		if err != nil {
+			return nil, err
+		}
+		t.images[ts] = img
+	}
+	return t, nil
+}
+
+// Draw draws the tile layers in order, the map's top left corner at origin.
+func (t *TileMap) Draw(screen *ebiten.Image, origin cp.Vector) {
+	m := t.m
+	op := &ebiten.DrawImageOptions{}
+	for _, l := range m.Layers {
+		for i, gid := range l.GIDs {
+			ts, id, ok := m.Tile(gid)
+			if !ok {
+				continue
+			}
+			img := t.images[ts]
+			columns := ts.Columns
+			if columns == 0 {
+				columns = (img.Bounds().Dx() - 2*ts.Margin + ts.Spacing) / (ts.TileWidth + ts.Spacing)
+			}
+			x := ts.Margin + int(id)%columns*(ts.TileWidth+ts.Spacing)
+			y := ts.Margin + int(id)/columns*(ts.TileHeight+ts.Spacing)
+			tile := img.SubImage(image.Rect(x, y, x+ts.TileWidth, y+ts.TileHeight)).(*ebiten.Image)
+
+			op.GeoM.Reset()
+			// Tiles bigger than the map's grid stick out at the top, like in
+			// Tiled.
+			op.GeoM.Translate(
+				origin.X+float64(i%m.Width*m.TileWidth),
+				origin.Y+float64((i/m.Width+1)*m.TileHeight-ts.TileHeight),
+			)
+			screen.DrawImage(tile, op)
+		}
+	}
+}
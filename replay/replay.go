@@ -0,0 +1,370 @@
+// Package replay stores recorded sessions: the input of every fixed step of
+// the game, behind a header with what else it takes to play them again, the
+// seed of the random numbers, a hash of the config and the version of the
+// format. The frames are delta encoded, a frame like the one before taking
+// a few bits, and the whole file is gzipped, for long sessions to stay small
+// enough to attach to a bug report. It doesn't depend on ebiten.
+//
+// After the gzip header, a file is:
+//
+//	magic "CPRP", uvarint version
+//	varint seed, 8 bytes little endian config hash, float64 step
+//	uvarint count and strings of the actions, then of the scenes
+//	records, each a uvarint count of frames repeating the previous one,
+//	then a byte of what changed and the changes; a byte of 0 ends them
+//
+// A string is its uvarint length and its bytes.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Version is the version of the files written. Files of a later version
+// aren't read.
+const Version = 1
+
+var magic = []byte("CPRP")
+
+// maxStrings and maxString bound the lists of names of a header and the
+// names, far over what a game has, for a corrupt file to be an error
+// rather than gigabytes allocated.
+const (
+	maxStrings = 1 << 10
+	maxString  = 1 << 10
+)
+
+// What a record changes, its byte. changeEnd only ends the records.
+const (
+	changeActions = 1 << iota
+	changeCursor
+	changeButtons
+	changeScene
+	changeEnd = 0
+)
+
+// Header is what a session needs to be played again besides its input.
+type Header struct {
+	Version int
+	// Seed is what math/rand was seeded with, and ConfigHash a hash of the
+	// config the session ran with.
+	Seed       int64
+	ConfigHash uint64
+	// Step is the fixed step of a frame, in seconds.
+	Step float64
+	// Actions are the names of the actions of Frame.Actions, bit i for
+	// Actions[i], and Scenes the names of the scenes of Frame.Scene.
+	Actions []string
+	Scenes  []string
+}
+
+// Frame is the input of a fixed step.
+type Frame struct {
+	// Actions has a bit set for each action held, see Header.Actions.
+	Actions uint64
+	// Cursor is where the mouse is, in screen pixels, and Buttons a bit set
+	// for each mouse button held, left, right and middle.
+	Cursor  [2]int32
+	Buttons uint8
+	// Scene is the scene stepped, an index of Header.Scenes.
+	Scene uint32
+}
+
+// Writer writes a session, a frame at a time.
+type Writer struct {
+	gz   *gzip.Writer
+	buf  *bufio.Writer
+	file *os.File
+	// last is the last frame written, and repeats how many frames like it
+	// came since.
+	last    Frame
+	repeats uint64
+	scratch [binary.MaxVarintLen64]byte
+}
+
+// Create creates the file at path and writes the header to it.
+func Create(path string, h Header) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := NewWriter(f, h)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	w.file = f
+	return w, nil
+}
+
+// NewWriter writes the header to w, at Version whatever h.Version is.
+func NewWriter(w io.Writer, h Header) (*Writer, error) {
+	gz := gzip.NewWriter(w)
+	rw := &Writer{gz: gz, buf: bufio.NewWriter(gz)}
+	rw.buf.Write(magic)
+	rw.uvarint(Version)
+	rw.varint(h.Seed)
+	binary.Write(rw.buf, binary.LittleEndian, h.ConfigHash)
+	binary.Write(rw.buf, binary.LittleEndian, math.Float64bits(h.Step))
+	for _, list := range [][]string{h.Actions, h.Scenes} {
+		rw.uvarint(uint64(len(list)))
+		for _, s := range list {
+			rw.uvarint(uint64(len(s)))
+			rw.buf.WriteString(s)
+		}
+	}
+	return rw, rw.flush()
+}
+
+func (w *Writer) uvarint(v uint64) {
+	w.buf.Write(w.scratch[:binary.PutUvarint(w.scratch[:], v)])
+}
+
+func (w *Writer) varint(v int64) {
+	w.buf.Write(w.scratch[:binary.PutVarint(w.scratch[:], v)])
+}
+
+// flush reports the first error of the writes so far: bufio keeps it.
+func (w *Writer) flush() error {
+	return w.buf.Flush()
+}
+
+// WriteFrame writes the next frame: nothing yet if it is like the one
+// before, its changes otherwise, the cursor as its move and the actions as
+// those toggled.
+func (w *Writer) WriteFrame(f Frame) error {
+	if f == w.last {
+		w.repeats++
+		return nil
+	}
+	var change byte
+	if f.Actions != w.last.Actions {
+		change |= changeActions
+	}
+	if f.Cursor != w.last.Cursor {
+		change |= changeCursor
+	}
+	if f.Buttons != w.last.Buttons {
+		change |= changeButtons
+	}
+	if f.Scene != w.last.Scene {
+		change |= changeScene
+	}
+	w.uvarint(w.repeats)
+	w.buf.WriteByte(change)
+	if change&changeActions != 0 {
+		w.uvarint(f.Actions ^ w.last.Actions)
+	}
+	if change&changeCursor != 0 {
+		w.varint(int64(f.Cursor[0] - w.last.Cursor[0]))
+		w.varint(int64(f.Cursor[1] - w.last.Cursor[1]))
+	}
+	if change&changeButtons != 0 {
+		w.buf.WriteByte(f.Buttons)
+	}
+	if change&changeScene != 0 {
+		w.uvarint(uint64(f.Scene))
+	}
+	w.last, w.repeats = f, 0
+	if w.buf.Buffered() < w.buf.Size()/2 {
+		return nil
+	}
+	return w.flush()
+}
+
+// Close ends the session and flushes it, closing the file of Create.
+func (w *Writer) Close() error {
+	w.uvarint(w.repeats)
+	w.buf.WriteByte(changeEnd)
+	err := w.flush()
+	if cerr := w.gz.Close(); err == nil {
+		err = cerr
+	}
+	if w.file != nil {
+		if cerr := w.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Reader reads a session, a frame at a time.
+type Reader struct {
+	Header Header
+	r      *bufio.Reader
+	file   *os.File
+	// last is the last frame read, repeats how many frames like it are
+	// still to come, and next the frame after them, if pending.
+	last    Frame
+	repeats uint64
+	next    Frame
+	pending bool
+	ended   bool
+}
+
+// Open opens the session file at path and reads its header.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r.file = f
+	return r, nil
+}
+
+// NewReader reads the header of the session read from r.
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	rr := &Reader{r: bufio.NewReader(gz)}
+	got := make([]byte, len(magic))
+	if _, err := io.ReadFull(rr.r, got); err != nil || !bytes.Equal(got, magic) {
+		return nil, errors.New("not a replay")
+	}
+	h := &rr.Header
+	version, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		return nil, err
+	}
+	if version > Version {
+		return nil, fmt.Errorf("replay version %d is newer than this build's, %d", version, Version)
+	}
+	h.Version = int(version)
+	if h.Seed, err = binary.ReadVarint(rr.r); err != nil {
+		return nil, err
+	}
+	var step uint64
+	if err := binary.Read(rr.r, binary.LittleEndian, &h.ConfigHash); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(rr.r, binary.LittleEndian, &step); err != nil {
+		return nil, err
+	}
+	h.Step = math.Float64frombits(step)
+	for _, list := range []*[]string{&h.Actions, &h.Scenes} {
+		if *list, err = rr.strings(); err != nil {
+			return nil, err
+		}
+	}
+	return rr, nil
+}
+
+func (r *Reader) strings() ([]string, error) {
+	n, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxStrings {
+		return nil, fmt.Errorf("a list of %d names, more than %d", n, maxStrings)
+	}
+	var list []string
+	for i := uint64(0); i < n; i++ {
+		size, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return nil, err
+		}
+		if size > maxString {
+			return nil, fmt.Errorf("a name of %d bytes, more than %d", size, maxString)
+		}
+		s := make([]byte, size)
+		if _, err := io.ReadFull(r.r, s); err != nil {
+			return nil, err
+		}
+		list = append(list, string(s))
+	}
+	return list, nil
+}
+
+// ReadFrame returns the next frame, io.EOF after the last one.
+func (r *Reader) ReadFrame() (Frame, error) {
+	for {
+		switch {
+		case r.repeats > 0:
+			r.repeats--
+			return r.last, nil
+		case r.pending:
+			r.last, r.pending = r.next, false
+			return r.last, nil
+		case r.ended:
+			return Frame{}, io.EOF
+		}
+		if err := r.readRecord(); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return Frame{}, err
+		}
+	}
+}
+
+// readRecord reads how many frames repeat the last one, and the frame after
+// them unless the session ends there.
+func (r *Reader) readRecord() error {
+	repeats, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return err
+	}
+	change, err := r.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	r.repeats = repeats
+	if change == changeEnd {
+		r.ended = true
+		return nil
+	}
+	f := r.last
+	if change&changeActions != 0 {
+		toggled, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return err
+		}
+		f.Actions ^= toggled
+	}
+	if change&changeCursor != 0 {
+		for i := range f.Cursor {
+			d, err := binary.ReadVarint(r.r)
+			if err != nil {
+				return err
+			}
+			f.Cursor[i] += int32(d)
+		}
+	}
+	if change&changeButtons != 0 {
+		if f.Buttons, err = r.r.ReadByte(); err != nil {
+			return err
+		}
+	}
+	if change&changeScene != 0 {
+		scene, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return err
+		}
+		f.Scene = uint32(scene)
+	}
+	r.next, r.pending = f, true
+	return nil
+}
+
+// Close closes the file of Open.
+func (r *Reader) Close() error {
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package replay_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/replay"
+)
+
+// TestRoundTrip writes a session and reads it back: runs of repeated
+// frames, actions toggled on and off, the cursor moving both ways, the
+// buttons and the scene changing, and repeated frames up to the end.
+func TestRoundTrip(t *testing.T) {
+	h := replay.Header{
+		Seed:       -42,
+		ConfigHash: 0xdeadbeefcafe,
+		Step:       1.0 / 60,
+		Actions:    []string{"left", "right", "jump"},
+		Scenes:     []string{"Hello Chipmunk", "Sandbox"},
+	}
+	still := replay.Frame{Cursor: [2]int32{400, 300}}
+	var frames []replay.Frame
+	for i := 0; i < 10; i++ {
+		frames = append(frames, still)
+	}
+	frames = append(frames,
+		replay.Frame{Actions: 1 | 4, Cursor: [2]int32{410, 290}},
+		replay.Frame{Actions: 4, Cursor: [2]int32{-5, 1 << 20}, Buttons: 1},
+		replay.Frame{Actions: 4, Cursor: [2]int32{-5, 1 << 20}, Buttons: 1},
+		replay.Frame{Actions: 1 << 63, Cursor: [2]int32{0, 0}, Buttons: 3, Scene: 1},
+		still,
+	)
+	for i := 0; i < 7; i++ {
+		frames = append(frames, still)
+	}
+
+	var buf bytes.Buffer
+	w, err := replay.NewWriter(&buf, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range frames {
+		if err := w.WriteFrame(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := replay.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Version = replay.Version
+	if !reflect.DeepEqual(r.Header, h) {
+		t.Errorf("read the header %+v, wrote %+v", r.Header, h)
+	}
+	var got []replay.Frame
+	for {
+		f, err := r.ReadFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, f)
+	}
+	if !reflect.DeepEqual(got, frames) {
+		t.Errorf("read %d frames\n%v\nwrote %d\n%v", len(got), got, len(frames), frames)
+	}
+}
+
+// TestCorruptHeader reads headers claiming lists and names too long to be
+// true: an error, not gigabytes allocated.
+func TestCorruptHeader(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		sizes []uint64
+		err   string
+	}{
+		{"huge list", []uint64{1 << 40}, "names, more than"},
+		{"huge name", []uint64{1, 1 << 40}, "bytes, more than"},
+	} {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write([]byte("CPRP"))
+		var scratch [binary.MaxVarintLen64]byte
+		gz.Write(scratch[:binary.PutUvarint(scratch[:], replay.Version)])
+		gz.Write(scratch[:binary.PutVarint(scratch[:], 1)])
+		binary.Write(gz, binary.LittleEndian, uint64(0))
+		binary.Write(gz, binary.LittleEndian, math.Float64bits(1.0/60))
+		for _, size := range c.sizes {
+			gz.Write(scratch[:binary.PutUvarint(scratch[:], size)])
+		}
+		gz.Close()
+		if _, err := replay.NewReader(&buf); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: reading fails with %v, want %q", c.name, err, c.err)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+// Package script builds cp spaces from Lua scripts, so that a scene can be
+// authored without Go code. A script creates the bodies, shapes and joints
+// when it's loaded, can react to collisions, and can define an update
+// function run before each step. It doesn't depend on ebiten.
+//
+// The functions a script can call are:
+//
+//	gravity(x, y)
+//	wall(x1, y1, x2, y2)                 a static segment
+//	ball(x, y, radius, mass) -> body
+//	box(x, y, width, height, mass) -> body
+//	pin(a, b, x, y)                      joins a and b at a world point, b may be nil for the world
+//	rope(a, b, length)                   joins the centers of a and b, b may be nil for the world
+//	color(body, name)                    a color name from CSS, e.g. "tomato"
+//	collision_type(body, n)
+//	on_collision(type_a, type_b, function(a, b) ... end)
+//	position(body) -> x, y
+//	velocity(body) -> x, y
+//	impulse(body, x, y)
+//	remove(body)
+package script
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jakecoffman/cp"
+	lua "github.com/yuin/gopher-lua"
+	"golang.org/x/image/colornames"
+)
+
+// Script is a loaded script and the space it builds.
+type Script struct {
+	L     *lua.LState
+	space *cp.Space
+	// bodies gives each body the same Lua value every time, so scripts can
+	// compare them.
+	bodies map[*cp.Body]*lua.LUserData
+	// err is the first error of a Lua function called back during a step,
+	// when it can't be returned.
+	err error
+}
+
+// Load runs the script src, named name in error messages, to build space.
+// The script must be closed when the space isn't used anymore.
+func Load(space *cp.Space, name, src string) (*Script, error) {
+	s := &Script{L: lua.NewState(), space: space, bodies: map[*cp.Body]*lua.LUserData{}}
+	for fname, f := range map[string]lua.LGFunction{
+		"gravity":        s.gravity,
+		"wall":           s.wall,
+		"ball":           s.ball,
+		"box":            s.box,
+		"pin":            s.pin,
+		"rope":           s.rope,
+		"color":          s.color,
+		"collision_type": s.collisionType,
+		"on_collision":   s.onCollision,
+		"position":       s.position,
+		"velocity":       s.velocity,
+		"impulse":        s.impulse,
+		"remove":         s.remove,
+	} {
+		s.L.SetGlobal(fname, s.L.NewFunction(f))
+	}
+	fn, err := s.L.Load(strings.NewReader(src), name)
+	if err == nil {
+		s.L.Push(fn)
+		err = s.L.PCall(0, lua.MultRet, nil)
+	}
+	if err != nil {
+		s.L.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Script) Close() {
+	s.L.Close()
+}
+
+// Update calls the script's update function with dt, if it has one. It
+// returns the first error of the script since it was loaded.
+func (s *Script) Update(dt float64) error {
+	if s.err != nil {
+		return s.err
+	}
+	if update, ok := s.L.GetGlobal("update").(*lua.LFunction); ok {
+		s.call(update, lua.LNumber(dt))
+	}
+	return s.err
+}
+
+// call calls a Lua function, keeping its error for Update to return.
+func (s *Script) call(fn *lua.LFunction, args ...lua.LValue) {
+	if s.err != nil {
+		return
+	}
+	if err := s.L.CallByParam(lua.P{Fn: fn, Protect: true}, args...); err != nil {
+		s.err = err
+	}
+}
+
+// value returns the Lua value of body.
+func (s *Script) value(body *cp.Body) *lua.LUserData {
+	ud, ok := s.bodies[body]
+	if !ok {
+		ud = s.L.NewUserData()
+		ud.Value = body
+		s.bodies[body] = ud
+	}
+	return ud
+}
+
+// checkBody returns the body argument n, the space's static body for an
+// optional nil one.
+func (s *Script) checkBody(L *lua.LState, n int, optional bool) *cp.Body {
+	if optional && L.Get(n) == lua.LNil {
+		return s.space.StaticBody
+	}
+	body, ok := L.CheckUserData(n).Value.(*cp.Body)
+	if !ok {
+		L.ArgError(n, "body expected")
+	}
+	return body
+}
+
+func checkVector(L *lua.LState, n int) cp.Vector {
+	return cp.Vector{X: float64(L.CheckNumber(n)), Y: float64(L.CheckNumber(n + 1))}
+}
+
+func (s *Script) gravity(L *lua.LState) int {
+	s.space.SetGravity(checkVector(L, 1))
+	return 0
+}
+
+func (s *Script) wall(L *lua.LState) int {
+	shape := s.space.AddShape(cp.NewSegment(s.space.StaticBody, checkVector(L, 1), checkVector(L, 3), 0))
+	shape.SetFriction(1)
+	return 0
+}
+
+func (s *Script) ball(L *lua.LState) int {
+	pos, radius, mass := checkVector(L, 1), float64(L.CheckNumber(3)), float64(L.CheckNumber(4))
+	body := s.space.AddBody(cp.NewBody(mass, cp.MomentForCircle(mass, 0, radius, cp.Vector{})))
+	body.SetPosition(pos)
+	shape := s.space.AddShape(cp.NewCircle(body, radius, cp.Vector{}))
+	shape.SetFriction(0.7)
+	L.Push(s.value(body))
+	return 1
+}
+
+func (s *Script) box(L *lua.LState) int {
+	pos, size, mass := checkVector(L, 1), checkVector(L, 3), float64(L.CheckNumber(5))
+	body := s.space.AddBody(cp.NewBody(mass, cp.MomentForBox(mass, size.X, size.Y)))
+	body.SetPosition(pos)
+	shape := s.space.AddShape(cp.NewBox(body, size.X, size.Y, 0))
+	shape.SetFriction(0.7)
+	L.Push(s.value(body))
+	return 1
+}
+
+func (s *Script) pin(L *lua.LState) int {
+	a, b, pivot := s.checkBody(L, 1, false), s.checkBody(L, 2, true), checkVector(L, 3)
+	s.space.AddConstraint(cp.NewPivotJoint(a, b, pivot))
+	return 0
+}
+
+func (s *Script) rope(L *lua.LState) int {
+	a, b, length := s.checkBody(L, 1, false), s.checkBody(L, 2, true), float64(L.CheckNumber(3))
+	s.space.AddConstraint(cp.NewSlideJoint(a, b, cp.Vector{}, cp.Vector{}, 0, length))
+	return 0
+}
+
+func (s *Script) color(L *lua.LState) int {
+	body, name := s.checkBody(L, 1, false), L.CheckString(2)
+	clr, ok := colornames.Map[name]
+	if !ok {
+		L.ArgError(2, fmt.Sprintf("unknown color %q", name))
+	}
+	body.EachShape(func(shape *cp.Shape) {
+		shape.UserData = clr
+	})
+	return 0
+}
+
+func (s *Script) collisionType(L *lua.LState) int {
+	body, t := s.checkBody(L, 1, false), cp.CollisionType(L.CheckInt(2))
+	body.EachShape(func(shape *cp.Shape) {
+		shape.SetCollisionType(t)
+	})
+	return 0
+}
+
+// onCollision calls the function with both bodies when shapes of the two
+// collision types start touching. It's called during the step, where
+// bodies can't be removed, remove waits for the step to end.
+func (s *Script) onCollision(L *lua.LState) int {
+	a, b := cp.CollisionType(L.CheckInt(1)), cp.CollisionType(L.CheckInt(2))
+	fn := L.CheckFunction(3)
+	handler := s.space.NewCollisionHandler(a, b)
+	handler.BeginFunc = func(arb *cp.Arbiter, _ *cp.Space, _ interface{}) bool {
+		bodyA, bodyB := arb.Bodies()
+		s.call(fn, s.value(bodyA), s.value(bodyB))
+		return true
+	}
+	return 0
+}
+
+func (s *Script) position(L *lua.LState) int {
+	p := s.checkBody(L, 1, false).Position()
+	L.Push(lua.LNumber(p.X))
+	L.Push(lua.LNumber(p.Y))
+	return 2
+}
+
+func (s *Script) velocity(L *lua.LState) int {
+	v := s.checkBody(L, 1, false).Velocity()
+	L.Push(lua.LNumber(v.X))
+	L.Push(lua.LNumber(v.Y))
+	return 2
+}
+
+func (s *Script) impulse(L *lua.LState) int {
+	body, j := s.checkBody(L, 1, false), checkVector(L, 2)
+	body.ApplyImpulseAtWorldPoint(j, body.Position())
+	return 0
+}
+
+func (s *Script) remove(L *lua.LState) int {
+	body := s.checkBody(L, 1, false)
+	if body == s.space.StaticBody {
+		L.ArgError(1, "can't remove the world")
+	}
+	s.space.AddPostStepCallback(func(space *cp.Space, _, _ interface{}) {
+		if !space.ContainsBody(body) {
+			return
+		}
+		body.EachShape(func(shape *cp.Shape) {
+			space.RemoveShape(shape)
+		})
+		body.EachConstraint(func(c *cp.Constraint) {
+			space.RemoveConstraint(c)
+		})
+		space.RemoveBody(body)
+		delete(s.bodies, body)
+	}, body, nil)
+	return 0
+}
+
+// Status returns the script's status global, a line for the scene to show.
+func (s *Script) Status() string {
+	if status, ok := s.L.GetGlobal("status").(lua.LString); ok {
+		return string(status)
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+//go:build headless
+
+package main
+
+import (
+	"math"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/jakecoffman/cp"
+
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/netsync"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/physics"
+	"github.com/rangzen/Ebitengine-Chipmunk-HelloWorld/stream"
+)
+
+const (
+	// serveSnapshotSteps is how many steps apart the snapshots are sent, as
+	// the Networked scene's host does.
+	serveSnapshotSteps = 3
+	// serveMaxBodies caps the bodies of a snapshot.
+	serveMaxBodies = 2000
+	// serveSpawnRadius is the radius of the balls the clients spawn.
+	serveSpawnRadius = 10
+	// serveMaxSpawned caps the balls spawned, the oldest removed first, as
+	// the Networked scene's host does: a client clicking away doesn't grow
+	// the space for ever.
+	serveMaxSpawned = 500
+	// serveFallMargin is how far under the static shapes a body is gone
+	// for good, and removed.
+	serveFallMargin = 1000
+)
+
+// serve steps space in real time, tps steps a second, and serves it over
+// WebSocket at addr's /ws to the render clients, the game run with -join,
+// until interrupted. The clients drag its dynamic bodies, and spawn balls
+// where there are none, up to serveMaxSpawned; web pages of origins may join too, see
+// stream.Listen. A simulation slower than real time is only slower: the
+// clients play it as it comes.
+func serve(addr string, space *cp.Space, tps int, origins []string) error {
+	server, err := stream.Listen(addr, origins...)
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+	logMain.Infof("Serving the simulation on ws://%s/ws, join it with -join", server.Addr())
+
+	players := netsync.NewPlayers(space)
+	// spawned are the balls spawned, the oldest first, some of them maybe
+	// fallen off and removed since.
+	var spawned []*cp.Body
+	players.Spawn = func(_ int, p cp.Vector) {
+		body, _ := physics.AddBall(space, p, serveSpawnRadius)
+		spawned = append(spawned, body)
+		if len(spawned) > serveMaxSpawned {
+			if space.ContainsBody(spawned[0]) {
+				removeBody(space, spawned[0])
+			}
+			spawned = spawned[1:]
+		}
+	}
+	encoder := netsync.Encoder{MaxBodies: serveMaxBodies}
+	// The bottom of the static shapes, y going down.
+	floor := 0.0
+	space.StaticBody.EachShape(func(shape *cp.Shape) {
+		floor = math.Max(floor, shape.BB().T)
+	})
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	ticker := time.NewTicker(time.Second / time.Duration(tps))
+	defer ticker.Stop()
+	timeStep := 1.0 / float64(tps)
+	var busy time.Duration
+	for step := 1; ; step++ {
+		select {
+		case <-interrupt:
+			logMain.Infof("Interrupted after %d steps", step-1)
+			return nil
+		case <-ticker.C:
+		}
+		start := time.Now()
+		if err := players.Receive(server); err != nil {
+			logMain.Warnf("%v", err)
+		}
+		players.Step(timeStep)
+		space.Step(timeStep)
+		if step%serveSnapshotSteps == 0 {
+			if err := encoder.Publish(server, space, step); err != nil {
+				return err
+			}
+		}
+		if step%tps == 0 {
+			removeFallen(space, floor+serveFallMargin)
+			logPhysics.Debugf("Step %d: %d clients, %.0f%% of the time stepping", step, server.Clients(), 100*busy.Seconds())
+			busy = 0
+		}
+		busy += time.Since(start)
+	}
+}
+
+// removeFallen removes the dynamic bodies under y, with their shapes and
+// constraints: they fell off the world.
+func removeFallen(space *cp.Space, y float64) {
+	var fallen []*cp.Body
+	space.EachBody(func(body *cp.Body) {
+		if body.GetType() == cp.BODY_DYNAMIC && body.Position().Y > y {
+			fallen = append(fallen, body)
+		}
+	})
+	for _, body := range fallen {
+		removeBody(space, body)
+	}
+}
+
+// removeBody removes a body with its shapes and constraints, outside of a
+// step.
+func removeBody(space *cp.Space, body *cp.Body) {
+	var constraints []*cp.Constraint
+	body.EachConstraint(func(c *cp.Constraint) { constraints = append(constraints, c) })
+	for _, c := range constraints {
+		space.RemoveConstraint(c)
+	}
+	var shapes []*cp.Shape
+	body.EachShape(func(shape *cp.Shape) { shapes = append(shapes, shape) })
+	for _, shape := range shapes {
+		space.RemoveShape(shape)
+	}
+	space.RemoveBody(body)
+}
@@ -0,0 +1,30 @@
+//go:build !js
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ReadFile returns what is stored under name, the contents of the file of
+// that name in the directory, see SetDir.
+func ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(dir, name))
+}
+
+// WriteFile stores data under name, in the file.
+func WriteFile(name string, data []byte) error {
+	return os.WriteFile(filepath.Join(dir, name), data, 0o644)
+}
+
+// ModTime returns when what is stored under name was modified, the zero
+// time if nothing is.
+func ModTime(name string) time.Time {
+	info, err := os.Stat(filepath.Join(dir, name))
+	if err != nil {
+		return zeroTime
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"fmt"
+	"syscall/js"
+	"time"
+)
+
+// localPrefix is before the names in the local storage, which the page
+// shares with the others of its origin.
+const localPrefix = "hello-chipmunk/"
+
+// localStorage returns the page's local storage, or an error if the
+// browser has none or denies it, e.g. for a private window.
+func localStorage() (storage js.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("local storage: %v", r)
+		}
+	}()
+	storage = js.Global().Get("localStorage")
+	if !storage.Truthy() {
+		return js.Undefined(), fmt.Errorf("local storage: not available")
+	}
+	return storage, nil
+}
+
+// ReadFile returns what is stored under name in the local storage.
+func ReadFile(name string) (data []byte, err error) {
+	storage, err := localStorage()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: %v", name, r)
+		}
+	}()
+	v := storage.Call("getItem", localPrefix+name)
+	if v.IsNull() {
+		return nil, notExist(name)
+	}
+	return []byte(v.String()), nil
+}
+
+// WriteFile stores data under name in the local storage, an error if it is
+// full.
+func WriteFile(name string, data []byte) (err error) {
+	storage, err := localStorage()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: %v", name, r)
+		}
+	}()
+	storage.Call("setItem", localPrefix+name, string(data))
+	return nil
+}
+
+// ModTime returns the zero time: the local storage keeps no times, and
+// nothing but the game changes it.
+func ModTime(string) time.Time {
+	return zeroTime
+}
@@ -0,0 +1,29 @@
+// Package storage keeps the game's settings, the config and the key
+// bindings: in files of the working directory, and in a browser, which has
+// none, in the page's local storage, under the same names. It doesn't
+// depend on ebiten, so the headless build uses it too.
+package storage
+
+import (
+	"io/fs"
+	"time"
+)
+
+// notExist is the error of reading name when there is nothing stored under
+// it, which errors.Is finds fs.ErrNotExist in, as for a file.
+func notExist(name string) error {
+	return &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+}
+
+// zeroTime is the modification time of what has none.
+var zeroTime time.Time
+
+// dir is the directory of the files, the working directory if empty.
+var dir string
+
+// SetDir keeps the files in d rather than the working directory, e.g. the
+// app's files directory on a phone, where the working directory isn't the
+// app's. A browser's local storage has none.
+func SetDir(d string) {
+	dir = d
+}
@@ -0,0 +1,173 @@
+package stream
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// dialTimeout is how long connecting and the handshake may take.
+	dialTimeout = 5 * time.Second
+	// connQueue is how many messages may wait to be received from the
+	// server: past it, the oldest are dropped, a receiver too slow for them
+	// only cares about the last.
+	connQueue = 16
+	// maxServerFrame caps what the server may send, a whole world.
+	maxServerFrame = 1 << 26
+)
+
+// Conn is a connection to a WebSocket server, e.g. a Server's /ws, as its
+// client.
+type Conn struct {
+	conn     net.Conn
+	messages chan []byte
+	// gone is closed once the reader is done, the server gone.
+	gone chan struct{}
+	// writing keeps the frames of Send and of the reader's answers apart.
+	writing sync.Mutex
+	once    sync.Once
+}
+
+// Dial connects to the WebSocket server at rawURL, e.g.
+// "ws://localhost:8090/ws", and receives its messages on a goroutine of its
+// own. Only ws is supported, not wss.
+func Dial(rawURL string) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ws" {
+		return nil, fmt.Errorf("stream: %s: only ws:// URLs are supported", rawURL)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "80")
+	}
+	conn, err := net.DialTimeout("tcp", host, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+	r, err := handshake(conn, u)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("stream: %s: %w", rawURL, err)
+	}
+	conn.SetDeadline(time.Time{})
+	c := &Conn{conn: conn, messages: make(chan []byte, connQueue), gone: make(chan struct{})}
+	go c.read(r)
+	return c, nil
+}
+
+// handshake asks the server to upgrade the connection, RFC 6455 4.1, and
+// returns the reader of its frames.
+func handshake(conn net.Conn, u *url.URL) (*bufio.Reader, error) {
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+	_, err := fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", u.RequestURI(), u.Host, key)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, &http.Request{Method: http.MethodGet})
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("the server answered %s", resp.Status)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return nil, errors.New("the server's handshake doesn't match")
+	}
+	return r, nil
+}
+
+// Send sends msg, a text message, to the server.
+func (c *Conn) Send(msg []byte) error {
+	return c.writeFrame(opText, msg)
+}
+
+// Receive returns a message of the server without waiting, false if there
+// is none.
+func (c *Conn) Receive() ([]byte, bool) {
+	select {
+	case msg := <-c.messages:
+		return msg, true
+	default:
+		return nil, false
+	}
+}
+
+// Closed reports whether the server closed the connection, or it broke.
+func (c *Conn) Closed() bool {
+	select {
+	case <-c.gone:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	c.writeFrame(opClose, nil)
+	c.close()
+	return nil
+}
+
+// read reads the server's frames, the text messages into messages, and
+// answers the pings, until the connection closes.
+func (c *Conn) read(r *bufio.Reader) {
+	defer close(c.gone)
+	defer c.close()
+	for {
+		op, payload, err := readFrame(r, false, maxServerFrame)
+		if err != nil {
+			return
+		}
+		switch op {
+		case opText:
+			for sent := false; !sent; {
+				select {
+				case c.messages <- payload:
+					sent = true
+				default:
+					// Full: the oldest goes.
+					select {
+					case <-c.messages:
+					default:
+					}
+				}
+			}
+		case opClose:
+			c.writeFrame(opClose, nil)
+			return
+		case opPing:
+			if c.writeFrame(opPong, payload) != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	c.writing.Lock()
+	defer c.writing.Unlock()
+	return writeMaskedFrame(c.conn, op, payload)
+}
+
+func (c *Conn) close() {
+	c.once.Do(func() { c.conn.Close() })
+}
@@ -0,0 +1,431 @@
+// Package stream serves messages to browsers over WebSocket, as they are
+// published: the game publishes the state of its world at every step, for
+// a dashboard to show it from another machine. The clients' text messages
+// are received too, and Dial connects to a server as a client, for games
+// to talk both ways. It implements the little of RFC 6455 text messages
+// need, without a dependency, and serves a dashboard of its own at its
+// root. It doesn't depend on ebiten.
+package stream
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// clientQueue is how many messages may wait for a client: a slow client
+// misses messages rather than slowing down the publisher.
+const clientQueue = 8
+
+// receivedQueue is how many messages of the clients may wait to be
+// received: past it, they are dropped rather than wait for the receiver.
+const receivedQueue = 256
+
+// acceptGUID is what the handshake appends to the client's key, RFC 6455
+// 1.3.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// The opcodes of the frames used, RFC 6455 5.2.
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xa
+)
+
+//go:embed dashboard.html
+var dashboard []byte
+
+// Server pushes the messages published to the WebSocket clients connected
+// to its /ws, and serves the dashboard at /.
+type Server struct {
+	srv *http.Server
+	ln  net.Listener
+
+	mu      sync.Mutex
+	clients map[*client]bool
+	// lastID numbers the clients as they connect.
+	lastID int
+	// origins are the origins of other web pages allowed to connect.
+	origins []string
+	// receiving is set by the first Receive: until then nobody reads the
+	// clients, whose messages and departures aren't kept.
+	receiving int32
+	// received are the messages of the clients, and their departures after
+	// their last message; gone the departures that found it full.
+	received chan Message
+	gone     []int
+}
+
+// Message is a text message of a client, or the news that it left.
+type Message struct {
+	// Client is the client's number, from 1 in the order they connected.
+	Client int
+	Data   []byte
+	// Gone tells that the client left, Data nil: it sends no more.
+	Gone bool
+}
+
+// client is a connection, and the messages waiting for it.
+type client struct {
+	id       int
+	conn     net.Conn
+	messages chan []byte
+	// gone is closed once the reader is done, the client gone.
+	gone chan struct{}
+	// writing keeps the frames of the writer and of the reader's answers
+	// apart.
+	writing sync.Mutex
+	// once closes the connection once, whichever of its reader, its writer
+	// or Close does.
+	once sync.Once
+}
+
+// Listen starts serving on addr, e.g. ":8090", on a goroutine of its own.
+// A web page connects to it if it is the server's own, the dashboard, or of
+// one of origins, e.g. "http://localhost:8000", "*" allowing any: another
+// page, which any site the players open could be, is refused. Clients that
+// aren't browsers send no origin, and connect.
+func Listen(addr string, origins ...string) (*Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{ln: ln, clients: map[*client]bool{}, origins: origins, received: make(chan Message, receivedQueue)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(dashboard)
+	})
+	mux.HandleFunc("/ws", s.serveWS)
+	s.srv = &http.Server{Handler: mux}
+	go s.srv.Serve(ln)
+	return s, nil
+}
+
+// Addr is the address served on, with the port picked if addr had none.
+func (s *Server) Addr() net.Addr {
+	return s.ln.Addr()
+}
+
+// Clients returns how many clients are connected, for the publisher not to
+// make messages nobody reads.
+func (s *Server) Clients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+// Publish sends msg, a text message, to every client whose queue isn't
+// full. It doesn't wait for them, and msg mustn't change afterwards.
+func (s *Server) Publish(msg []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		select {
+		case c.messages <- msg:
+		default:
+		}
+	}
+}
+
+// Receive returns a message of a client, or the news that one left, after
+// its last message, without waiting: false if there is none. Only what the
+// clients sent since the first call is received.
+func (s *Server) Receive() (Message, bool) {
+	atomic.StoreInt32(&s.receiving, 1)
+	select {
+	case msg := <-s.received:
+		return msg, true
+	default:
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.gone) == 0 {
+		return Message{}, false
+	}
+	id := s.gone[0]
+	s.gone = s.gone[1:]
+	return Message{Client: id, Gone: true}, true
+}
+
+// queue keeps a message of a client to be received, if anyone receives:
+// it is dropped if too many wait.
+func (s *Server) queue(msg Message) {
+	if atomic.LoadInt32(&s.receiving) == 0 {
+		return
+	}
+	select {
+	case s.received <- msg:
+	default:
+	}
+}
+
+// leave keeps the news that the client id left, if anyone receives, after
+// its messages. It's never dropped, unlike them, the receiver would wait for
+// the client forever: if the queue is full, it is kept apart, and received
+// once the queue, with the client's last messages, is empty.
+func (s *Server) leave(id int) {
+	if atomic.LoadInt32(&s.receiving) == 0 {
+		return
+	}
+	select {
+	case s.received <- Message{Client: id, Gone: true}:
+	default:
+		s.mu.Lock()
+		s.gone = append(s.gone, id)
+		s.mu.Unlock()
+	}
+}
+
+// Close stops serving and disconnects the clients.
+func (s *Server) Close() error {
+	err := s.srv.Close()
+	s.mu.Lock()
+	clients := s.clients
+	s.clients = map[*client]bool{}
+	s.mu.Unlock()
+	for c := range clients {
+		c.close()
+	}
+	return err
+}
+
+// serveWS upgrades the request to a WebSocket connection, RFC 6455 4.2,
+// and pushes the messages to it until either end closes it.
+func (s *Server) serveWS(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerHas(r.Header, "Connection", "upgrade") || !headerHas(r.Header, "Upgrade", "websocket") || key == "" {
+		http.Error(w, "a WebSocket handshake is expected", http.StatusBadRequest)
+		return
+	}
+	if !s.allowed(r) {
+		http.Error(w, "connections from other web pages aren't allowed", http.StatusForbidden)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "the connection can't be taken over", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return
+	}
+
+	c := &client{conn: conn, messages: make(chan []byte, clientQueue), gone: make(chan struct{})}
+	s.mu.Lock()
+	s.lastID++
+	c.id = s.lastID
+	s.clients[c] = true
+	s.mu.Unlock()
+	go c.read(rw.Reader, s)
+	c.write()
+	s.mu.Lock()
+	delete(s.clients, c)
+	s.mu.Unlock()
+}
+
+// allowed reports whether the request is from a client Listen lets
+// connect: one without an origin, the server's own page, whose origin is
+// the host asked for, or a page of an allowed origin.
+func (s *Server) allowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, o := range s.origins {
+		if o == "*" || strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptKey is the handshake's answer to the client's key.
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// headerHas reports whether a header of r has token in its comma separated
+// list, in any case.
+func headerHas(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// write writes the messages to the connection, until it fails or the reader
+// closes it.
+func (c *client) write() {
+	defer c.close()
+	for {
+		select {
+		case msg := <-c.messages:
+			if err := c.writeFrame(opText, msg); err != nil {
+				return
+			}
+		case <-c.gone:
+			return
+		}
+	}
+}
+
+// read reads the client's frames, the text messages queued on s, and
+// answers the pings. When the client is gone, it tells s, after its last
+// message, and ends the writer.
+func (c *client) read(r *bufio.Reader, s *Server) {
+	defer close(c.gone)
+	defer s.leave(c.id)
+	for {
+		op, payload, err := readFrame(r, true, maxClientFrame)
+		if err != nil {
+			return
+		}
+		switch op {
+		case opText:
+			s.queue(Message{Client: c.id, Data: payload})
+		case opClose:
+			c.writeFrame(opClose, nil)
+			return
+		case opPing:
+			if c.writeFrame(opPong, payload) != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *client) writeFrame(op byte, payload []byte) error {
+	c.writing.Lock()
+	defer c.writing.Unlock()
+	return writeFrame(c.conn, op, payload)
+}
+
+func (c *client) close() {
+	c.once.Do(func() { c.conn.Close() })
+}
+
+// writeFrame writes a final, unmasked frame, as a server does, RFC 6455 5.2.
+func writeFrame(w io.Writer, op byte, payload []byte) error {
+	if _, err := w.Write(frameHeader(op, len(payload), false)); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// writeMaskedFrame writes a final frame masked with a random key, as a
+// client does, RFC 6455 5.3.
+func writeMaskedFrame(w io.Writer, op byte, payload []byte) error {
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame := append(frameHeader(op, len(payload), true), mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := w.Write(frame)
+	return err
+}
+
+// frameHeader is the header of a final frame of n bytes, without the mask
+// key that follows it if masked.
+func frameHeader(op byte, n int, masked bool) []byte {
+	var header []byte
+	switch {
+	case n < 126:
+		header = []byte{0, byte(n)}
+	case n <= 0xffff:
+		header = []byte{0, 126, 0, 0}
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header = make([]byte, 10)
+		header[1] = 127
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+	header[0] = 0x80 | op
+	if masked {
+		header[1] |= 0x80
+	}
+	return header
+}
+
+// maxClientFrame caps what a client may send: its messages are short.
+const maxClientFrame = 1 << 16
+
+// readFrame reads a frame, masked if it is a client's, as they all are,
+// and none of the server's, and returns its unmasked payload of at most
+// max bytes.
+func readFrame(r *bufio.Reader, masked bool, max uint64) (op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	op = head[0] & 0x0f
+	if head[1]&0x80 == 0 && masked {
+		return 0, nil, errors.New("stream: unmasked client frame")
+	}
+	if head[1]&0x80 != 0 && !masked {
+		return 0, nil, errors.New("stream: masked server frame")
+	}
+	n := uint64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > max {
+		return 0, nil, errors.New("stream: frame too large")
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return op, payload, nil
+}
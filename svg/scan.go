@@ -0,0 +1,112 @@
+package svg
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/jakecoffman/cp"
+)
+
+// scanner reads the numbers of path data and of points lists, which SVG
+// lets run together: "M1-2.5.5" is M 1 -2.5 0.5.
+type scanner struct {
+	s   string
+	pos int
+}
+
+func newScanner(s string) *scanner {
+	return &scanner{s: s}
+}
+
+func (s *scanner) done() bool {
+	return s.pos >= len(s.s)
+}
+
+func (s *scanner) peek() byte {
+	return s.s[s.pos]
+}
+
+// skipSpace skips white space and a comma.
+func (s *scanner) skipSpace() {
+	comma := false
+	for !s.done() {
+		switch c := s.peek(); {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		case c == ',' && !comma:
+			comma = true
+		default:
+			return
+		}
+		s.pos++
+	}
+}
+
+// number reads a number: a sign, digits with a single dot, and an exponent.
+func (s *scanner) number() (float64, error) {
+	s.skipSpace()
+	start := s.pos
+	digits := func() {
+		for !s.done() && s.peek() >= '0' && s.peek() <= '9' {
+			s.pos++
+		}
+	}
+	sign := func() {
+		if !s.done() && (s.peek() == '-' || s.peek() == '+') {
+			s.pos++
+		}
+	}
+	sign()
+	digits()
+	if !s.done() && s.peek() == '.' {
+		s.pos++
+		digits()
+	}
+	if !s.done() && (s.peek() == 'e' || s.peek() == 'E') {
+		s.pos++
+		sign()
+		digits()
+	}
+	if s.pos == start {
+		if s.done() {
+			return 0, fmt.Errorf("missing number")
+		}
+		return 0, fmt.Errorf("%q isn't a number", s.peek())
+	}
+	return strconv.ParseFloat(s.s[start:s.pos], 64)
+}
+
+func (s *scanner) point() (cp.Vector, error) {
+	x, err := s.number()
+	if err != nil {
+		return cp.Vector{}, err
+	}
+	y, err := s.number()
+	return cp.Vector{X: x, Y: y}, err
+}
+
+// flag reads an arc flag, a single 0 or 1 that needs no separator.
+func (s *scanner) flag() (bool, error) {
+	s.skipSpace()
+	if s.done() {
+		return false, fmt.Errorf("missing flag")
+	}
+	c := s.peek()
+	if c != '0' && c != '1' {
+		return false, fmt.Errorf("%q isn't a flag", c)
+	}
+	s.pos++
+	return c == '1', nil
+}
+
+// numbers reads all the numbers left.
+func (s *scanner) numbers() ([]float64, error) {
+	var nums []float64
+	for s.skipSpace(); !s.done(); s.skipSpace() {
+		n, err := s.number()
+		if err != nil {
+			return nil, err
+		}
+		nums = append(nums, n)
+	}
+	return nums, nil
+}
@@ -0,0 +1,432 @@
+// Package svg reads the shapes of an SVG drawing, e.g. a level drawn in
+// Inkscape, as polylines, and turns them into chains of static cp segments.
+// Paths, polylines, polygons, lines and rectangles are read, with the
+// transforms of their groups; curves and arcs are flattened into lines. It
+// doesn't depend on ebiten.
+package svg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jakecoffman/cp"
+)
+
+// DefaultTolerance is how far, in the drawing's units, the flattened lines
+// may stray from the curves they replace.
+const DefaultTolerance = 0.5
+
+// maxDepth is how many times a curve may be split in two while flattening,
+// a bound for curves that don't get flat, e.g. with NaN coordinates.
+const maxDepth = 16
+
+// Load reads the SVG file name of fsys and returns its shapes as polylines,
+// see Parse.
+func Load(fsys fs.FS, name string, tolerance float64) ([][]cp.Vector, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	lines, err := Parse(f, tolerance)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return lines, nil
+}
+
+// Parse reads an SVG document and returns its shapes as polylines, in
+// document order. A closed shape ends with its first point. Curves are
+// flattened within tolerance, in the units of the shape before its
+// transforms. What isn't drawn, definitions, is skipped, and so is the
+// styling: every shape counts, filled or not.
+func Parse(r io.Reader, tolerance float64) ([][]cp.Vector, error) {
+	if tolerance <= 0 {
+		return nil, fmt.Errorf("tolerance must be positive, not %g", tolerance)
+	}
+	var lines [][]cp.Vector
+	// The transforms of the open elements, the last is the current one.
+	stack := []matrix{identity}
+	skip := 0
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+			if skip > 0 {
+				skip--
+			}
+		case xml.StartElement:
+			if skip > 0 || tok.Name.Local == "defs" || tok.Name.Local == "clipPath" || tok.Name.Local == "mask" {
+				skip++
+				stack = append(stack, identity)
+				continue
+			}
+			m, err := parseTransform(attr(tok, "transform"))
+			if err != nil {
+				return nil, err
+			}
+			m = stack[len(stack)-1].mul(m)
+			stack = append(stack, m)
+
+			shapes, err := element(tok, tolerance)
+			if err != nil {
+				return nil, fmt.Errorf("%s %q: %w", tok.Name.Local, attr(tok, "id"), err)
+			}
+			for _, line := range shapes {
+				if len(line) < 2 {
+					continue
+				}
+				for i, p := range line {
+					line[i] = m.apply(p)
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+}
+
+// AddSegments adds the polylines to the space's static body as chains of
+// segments of the given radius, and returns them.
+func AddSegments(space *cp.Space, lines [][]cp.Vector, radius float64) []*cp.Shape {
+	var shapes []*cp.Shape
+	for _, line := range lines {
+		for i := 1; i < len(line); i++ {
+			if line[i-1] == line[i] {
+				continue
+			}
+			shape := space.AddShape(cp.NewSegment(space.StaticBody, line[i-1], line[i], radius))
+			shape.SetFriction(1)
+			shapes = append(shapes, shape)
+		}
+	}
+	return shapes
+}
+
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func floatAttr(e xml.StartElement, name string) (float64, error) {
+	s := strings.TrimSuffix(strings.TrimSpace(attr(e, name)), "px")
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// element returns the polylines of a shape element, none for the others.
+func element(e xml.StartElement, tolerance float64) ([][]cp.Vector, error) {
+	switch e.Name.Local {
+	case "path":
+		return parsePath(attr(e, "d"), tolerance)
+	case "polyline", "polygon":
+		nums, err := newScanner(attr(e, "points")).numbers()
+		if err != nil {
+			return nil, err
+		}
+		var line []cp.Vector
+		for i := 0; i+1 < len(nums); i += 2 {
+			line = append(line, cp.Vector{X: nums[i], Y: nums[i+1]})
+		}
+		if e.Name.Local == "polygon" && len(line) > 2 {
+			line = append(line, line[0])
+		}
+		return [][]cp.Vector{line}, nil
+	case "line", "rect":
+		var v [4]float64
+		names := [4]string{"x1", "y1", "x2", "y2"}
+		if e.Name.Local == "rect" {
+			names = [4]string{"x", "y", "width", "height"}
+		}
+		for i, name := range names {
+			var err error
+			if v[i], err = floatAttr(e, name); err != nil {
+				return nil, err
+			}
+		}
+		if e.Name.Local == "line" {
+			return [][]cp.Vector{{{X: v[0], Y: v[1]}, {X: v[2], Y: v[3]}}}, nil
+		}
+		// Rounded corners are drawn square.
+		x, y, w, h := v[0], v[1], v[2], v[3]
+		return [][]cp.Vector{{{X: x, Y: y}, {X: x + w, Y: y}, {X: x + w, Y: y + h}, {X: x, Y: y + h}, {X: x, Y: y}}}, nil
+	}
+	return nil, nil
+}
+
+// parsePath returns the subpaths of a path's data, its commands made
+// absolute and flattened.
+func parsePath(data string, tolerance float64) ([][]cp.Vector, error) {
+	var (
+		lines [][]cp.Vector
+		line  []cp.Vector
+		// cur is the current point, start the subpath's first, ctrl the
+		// last control point, reflected by the smooth curves.
+		cur, start, ctrl cp.Vector
+		prev             byte
+	)
+	end := func() {
+		if len(line) > 1 {
+			lines = append(lines, line)
+		}
+		line = nil
+	}
+	to := func(p cp.Vector) {
+		if line == nil {
+			line = []cp.Vector{cur}
+		}
+		line = append(line, p)
+		cur = p
+	}
+
+	s := newScanner(data)
+	for {
+		s.skipSpace()
+		if s.done() {
+			break
+		}
+		cmd := prev
+		if c := s.peek(); (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+			cmd = c
+			s.pos++
+		} else if prev == 0 {
+			return nil, fmt.Errorf("path starts with %q, not a command", c)
+		}
+		rel := cmd >= 'a'
+		abs := func(p cp.Vector) cp.Vector {
+			if rel {
+				return cur.Add(p)
+			}
+			return p
+		}
+
+		var err error
+		next := cmd
+		switch cmd | 0x20 {
+		case 'm':
+			var p cp.Vector
+			if p, err = s.point(); err != nil {
+				break
+			}
+			end()
+			cur = abs(p)
+			start = cur
+			// Coordinates after a move are lines.
+			next = 'L' | cmd&0x20
+		case 'l':
+			var p cp.Vector
+			if p, err = s.point(); err == nil {
+				to(abs(p))
+			}
+		case 'h', 'v':
+			var n float64
+			if n, err = s.number(); err != nil {
+				break
+			}
+			p := cur
+			if cmd|0x20 == 'h' {
+				p.X = n
+				if rel {
+					p.X += cur.X
+				}
+			} else {
+				p.Y = n
+				if rel {
+					p.Y += cur.Y
+				}
+			}
+			to(p)
+		case 'c', 's':
+			var c1, c2, p cp.Vector
+			if cmd|0x20 == 'c' {
+				if c1, err = s.point(); err != nil {
+					break
+				}
+				c1 = abs(c1)
+			} else {
+				c1 = cur
+				if p := prev | 0x20; p == 'c' || p == 's' {
+					c1 = cur.Mult(2).Sub(ctrl)
+				}
+			}
+			if c2, err = s.point(); err != nil {
+				break
+			}
+			if p, err = s.point(); err != nil {
+				break
+			}
+			c2, p = abs(c2), abs(p)
+			for _, q := range cubic(cur, c1, c2, p, tolerance) {
+				to(q)
+			}
+			ctrl = c2
+		case 'q', 't':
+			var c, p cp.Vector
+			if cmd|0x20 == 'q' {
+				if c, err = s.point(); err != nil {
+					break
+				}
+				c = abs(c)
+			} else {
+				c = cur
+				if p := prev | 0x20; p == 'q' || p == 't' {
+					c = cur.Mult(2).Sub(ctrl)
+				}
+			}
+			if p, err = s.point(); err != nil {
+				break
+			}
+			p = abs(p)
+			// A quadratic curve is a cubic one with these controls.
+			c1 := cur.Add(c.Sub(cur).Mult(2.0 / 3))
+			c2 := p.Add(c.Sub(p).Mult(2.0 / 3))
+			for _, q := range cubic(cur, c1, c2, p, tolerance) {
+				to(q)
+			}
+			ctrl = c
+		case 'a':
+			var rx, ry, angle float64
+			var large, sweep bool
+			var p cp.Vector
+			if rx, err = s.number(); err != nil {
+				break
+			}
+			if ry, err = s.number(); err != nil {
+				break
+			}
+			if angle, err = s.number(); err != nil {
+				break
+			}
+			if large, err = s.flag(); err != nil {
+				break
+			}
+			if sweep, err = s.flag(); err != nil {
+				break
+			}
+			if p, err = s.point(); err != nil {
+				break
+			}
+			p = abs(p)
+			for _, q := range arc(cur, p, rx, ry, angle, large, sweep, tolerance) {
+				to(q)
+			}
+		case 'z':
+			if line != nil {
+				to(start)
+			}
+			end()
+			cur = start
+		default:
+			return nil, fmt.Errorf("unknown path command %q", cmd)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("path command %q: %w", cmd, err)
+		}
+		prev = cmd
+		if next != cmd {
+			prev = next
+		}
+	}
+	end()
+	return lines, nil
+}
+
+// cubic flattens the cubic Bézier curve from p0 to p3, and returns its
+// points after p0.
+func cubic(p0, p1, p2, p3 cp.Vector, tolerance float64) []cp.Vector {
+	var points []cp.Vector
+	var split func(p0, p1, p2, p3 cp.Vector, depth int)
+	split = func(p0, p1, p2, p3 cp.Vector, depth int) {
+		// The curve is within its control polygon, close enough to the chord
+		// when both controls are.
+		if depth >= maxDepth || (distance(p1, p0, p3) <= tolerance && distance(p2, p0, p3) <= tolerance) {
+			points = append(points, p3)
+			return
+		}
+		// de Casteljau at the middle.
+		p01, p12, p23 := p0.Lerp(p1, 0.5), p1.Lerp(p2, 0.5), p2.Lerp(p3, 0.5)
+		p012, p123 := p01.Lerp(p12, 0.5), p12.Lerp(p23, 0.5)
+		mid := p012.Lerp(p123, 0.5)
+		split(p0, p01, p012, mid, depth+1)
+		split(mid, p123, p23, p3, depth+1)
+	}
+	split(p0, p1, p2, p3, 0)
+	return points
+}
+
+// distance is how far p is from the segment a b.
+func distance(p, a, b cp.Vector) float64 {
+	if a == b {
+		return p.Distance(a)
+	}
+	return p.Distance(p.ClosestPointOnSegment(a, b))
+}
+
+// arc flattens the elliptical arc from p0 to p, as SVG defines it, and
+// returns its points after p0.
+func arc(p0, p cp.Vector, rx, ry, angle float64, large, sweep bool, tolerance float64) []cp.Vector {
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || p0 == p {
+		return []cp.Vector{p}
+	}
+	// From the end points to the center, SVG 1.1 appendix F.6.5.
+	phi := angle * math.Pi / 180
+	rot := cp.ForAngle(phi)
+	d := p0.Sub(p).Mult(0.5).Unrotate(rot)
+	// Radii too small to reach are scaled up.
+	if l := d.X*d.X/(rx*rx) + d.Y*d.Y/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+	num := rx*rx*ry*ry - rx*rx*d.Y*d.Y - ry*ry*d.X*d.X
+	den := rx*rx*d.Y*d.Y + ry*ry*d.X*d.X
+	k := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		k = -k
+	}
+	c := cp.Vector{X: k * rx * d.Y / ry, Y: -k * ry * d.X / rx}
+	center := rot.Rotate(c).Add(p0.Add(p).Mult(0.5))
+
+	u := cp.Vector{X: (d.X - c.X) / rx, Y: (d.Y - c.Y) / ry}
+	v := cp.Vector{X: (-d.X - c.X) / rx, Y: (-d.Y - c.Y) / ry}
+	start := u.ToAngle()
+	sweepAngle := math.Mod(v.ToAngle()-start, 2*math.Pi)
+	if sweep && sweepAngle < 0 {
+		sweepAngle += 2 * math.Pi
+	} else if !sweep && sweepAngle > 0 {
+		sweepAngle -= 2 * math.Pi
+	}
+
+	// The chord of an angle step strays 1-cos(step/2) of the radius.
+	r := math.Max(rx, ry)
+	step := math.Pi / 2
+	if tolerance < r {
+		step = math.Min(step, 2*math.Acos(1-tolerance/r))
+	}
+	n := int(math.Ceil(math.Abs(sweepAngle) / step))
+	points := make([]cp.Vector, 0, n)
+	for i := 1; i < n; i++ {
+		a := start + sweepAngle*float64(i)/float64(n)
+		q := cp.Vector{X: rx * math.Cos(a), Y: ry * math.Sin(a)}
+		points = append(points, rot.Rotate(q).Add(center))
+	}
+	// The end is exact.
+	return append(points, p)
+}
@@ -0,0 +1,90 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jakecoffman/cp"
+)
+
+// matrix is an affine transform as SVG writes it, matrix(a b c d e f):
+// x' = a x + c y + e, y' = b x + d y + f.
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// mul returns m applied after n, the transform of an element n in a group m.
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m matrix) apply(p cp.Vector) cp.Vector {
+	return cp.Vector{
+		X: m[0]*p.X + m[2]*p.Y + m[4],
+		Y: m[1]*p.X + m[3]*p.Y + m[5],
+	}
+}
+
+// parseTransform reads a transform attribute, a list of functions applied
+// right to left.
+func parseTransform(attr string) (matrix, error) {
+	m := identity
+	rest := strings.TrimSpace(attr)
+	for rest != "" {
+		open := strings.IndexByte(rest, '(')
+		closing := strings.IndexByte(rest, ')')
+		if open < 0 || closing < open {
+			return m, fmt.Errorf("transform %q: missing parenthesis", attr)
+		}
+		name := strings.TrimSpace(rest[:open])
+		args, err := newScanner(rest[open+1 : closing]).numbers()
+		if err != nil {
+			return m, fmt.Errorf("transform %q: %w", attr, err)
+		}
+		rest = strings.TrimLeft(rest[closing+1:], " \t\n\r,")
+
+		arg := func(i int, def float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return def
+		}
+		var t matrix
+		switch name {
+		case "matrix":
+			if len(args) != 6 {
+				return m, fmt.Errorf("transform %q: matrix needs 6 numbers", attr)
+			}
+			copy(t[:], args)
+		case "translate":
+			t = matrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			sx := arg(0, 1)
+			t = matrix{sx, 0, 0, arg(1, sx), 0, 0}
+		case "rotate":
+			a := arg(0, 0) * math.Pi / 180
+			cos, sin := math.Cos(a), math.Sin(a)
+			cx, cy := arg(1, 0), arg(2, 0)
+			// Around (cx, cy): translate there, rotate, translate back.
+			t = matrix{1, 0, 0, 1, cx, cy}.
+				mul(matrix{cos, sin, -sin, cos, 0, 0}).
+				mul(matrix{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			t = matrix{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = matrix{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			return m, fmt.Errorf("transform %q: unknown function %q", attr, name)
+		}
+		m = m.mul(t)
+	}
+	return m, nil
+}
@@ -0,0 +1,289 @@
+// Package tiled reads the maps of the Tiled editor, TMX files, and turns
+// their collision geometry into static cp shapes: the objects of the object
+// layers, and the collision shapes drawn on the tiles of the tilesets. It
+// doesn't depend on ebiten, the tile layers are drawn by the render package.
+package tiled
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/jakecoffman/cp"
+)
+
+// gidFlips are the bits of a tile's global id that flip it, they're
+// ignored.
+const gidFlips = 0xe0000000
+
+// Map is a Tiled map, orthogonal, with its tilesets, tile layers and
+// object layers.
+type Map struct {
+	Width      int        `xml:"width,attr"`
+	Height     int        `xml:"height,attr"`
+	TileWidth  int        `xml:"tilewidth,attr"`
+	TileHeight int        `xml:"tileheight,attr"`
+	Tilesets   []*Tileset `xml:"tileset"`
+	Layers     []*Layer   `xml:"layer"`
+	Groups     []*Group   `xml:"objectgroup"`
+	// Dir is the directory of the map file in its file system, which the
+	// paths in it are relative to.
+	Dir string `xml:"-"`
+}
+
+// Tileset is a tileset of the map, embedded in it or read from a TSX file.
+type Tileset struct {
+	FirstGID   uint32 `xml:"firstgid,attr"`
+	Source     string `xml:"source,attr"`
+	TileWidth  int    `xml:"tilewidth,attr"`
+	TileHeight int    `xml:"tileheight,attr"`
+	TileCount  int    `xml:"tilecount,attr"`
+	Columns    int    `xml:"columns,attr"`
+	Spacing    int    `xml:"spacing,attr"`
+	Margin     int    `xml:"margin,attr"`
+	Image      struct {
+		Source string `xml:"source,attr"`
+	} `xml:"image"`
+	// Tiles are the tiles with more than an image, e.g. collision shapes.
+	Tiles []struct {
+		ID      uint32 `xml:"id,attr"`
+		Objects Group  `xml:"objectgroup"`
+	} `xml:"tile"`
+	// Dir is the directory of the tileset file, which its image is relative
+	// to.
+	Dir string `xml:"-"`
+}
+
+// Layer is a tile layer, the global ids of its tiles row by row, 0 for no
+// tile.
+type Layer struct {
+	Name string `xml:"name,attr"`
+	Data struct {
+		Encoding    string `xml:"encoding,attr"`
+		Compression string `xml:"compression,attr"`
+		Text        string `xml:",chardata"`
+	} `xml:"data"`
+	GIDs []uint32 `xml:"-"`
+}
+
+// Group is an object layer, or the collision shapes of a tile.
+type Group struct {
+	Name    string   `xml:"name,attr"`
+	Objects []Object `xml:"object"`
+}
+
+// Object is a rectangle, a polygon or a polyline. Points are relative to
+// X and Y.
+type Object struct {
+	Name     string  `xml:"name,attr"`
+	X        float64 `xml:"x,attr"`
+	Y        float64 `xml:"y,attr"`
+	Width    float64 `xml:"width,attr"`
+	Height   float64 `xml:"height,attr"`
+	Polygon  *Points `xml:"polygon"`
+	Polyline *Points `xml:"polyline"`
+}
+
+// Points are the points of a polygon or a polyline, as Tiled writes them:
+// "x1,y1 x2,y2 ...".
+type Points struct {
+	Points string `xml:"points,attr"`
+}
+
+// Load reads the map file name of fsys and the tileset files it refers to.
+func Load(fsys fs.FS, name string) (*Map, error) {
+	m := &Map{Dir: path.Dir(name)}
+	if err := readXML(fsys, name, m); err != nil {
+		return nil, err
+	}
+	for _, ts := range m.Tilesets {
+		ts.Dir = m.Dir
+		if ts.Source == "" {
+			continue
+		}
+		// The map keeps the first id, the file has the rest.
+		first, source := ts.FirstGID, path.Join(m.Dir, ts.Source)
+		if err := readXML(fsys, source, ts); err != nil {
+			return nil, err
+		}
+		ts.FirstGID, ts.Dir = first, path.Dir(source)
+	}
+	for _, l := range m.Layers {
+		gids, err := l.decode()
+		if err != nil {
+			return nil, fmt.Errorf("%s: layer %q: %w", name, l.Name, err)
+		}
+		if len(gids) != m.Width*m.Height {
+			return nil, fmt.Errorf("%s: layer %q has %d tiles, not %dx%d", name, l.Name, len(gids), m.Width, m.Height)
+		}
+		l.GIDs = gids
+	}
+	return m, nil
+}
+
+func readXML(fsys fs.FS, name string, v interface{}) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// decode returns the global ids of the layer's data, in CSV or in base64,
+// compressed or not.
+func (l *Layer) decode() ([]uint32, error) {
+	text := strings.TrimSpace(l.Data.Text)
+	switch l.Data.Encoding {
+	case "csv":
+		var gids []uint32
+		for _, field := range strings.Split(text, ",") {
+			gid, err := strconv.ParseUint(strings.TrimSpace(field), 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			gids = append(gids, uint32(gid))
+		}
+		return gids, nil
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			return nil, err
+		}
+		var r io.Reader = bytes.NewReader(data)
+		switch l.Data.Compression {
+		case "":
+		case "zlib":
+			if r, err = zlib.NewReader(r); err != nil {
+				return nil, err
+			}
+		case "gzip":
+			if r, err = gzip.NewReader(r); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported compression %q", l.Data.Compression)
+		}
+		if data, err = io.ReadAll(r); err != nil {
+			return nil, err
+		}
+		gids := make([]uint32, len(data)/4)
+		for i := range gids {
+			gids[i] = binary.LittleEndian.Uint32(data[4*i:])
+		}
+		return gids, nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", l.Data.Encoding)
+}
+
+// Tile returns the tileset of the global id and the id of the tile within
+// it, or false for no tile.
+func (m *Map) Tile(gid uint32) (*Tileset, uint32, bool) {
+	gid &^= gidFlips
+	if gid == 0 {
+		return nil, 0, false
+	}
+	// The tilesets are in the order of their first ids.
+	for i := len(m.Tilesets) - 1; i >= 0; i-- {
+		if ts := m.Tilesets[i]; gid >= ts.FirstGID {
+			return ts, gid - ts.FirstGID, true
+		}
+	}
+	return nil, 0, false
+}
+
+// vertices returns the object's outline, in map coordinates, and whether it
+// is closed.
+func (o Object) vertices() ([]cp.Vector, bool, error) {
+	origin := cp.Vector{X: o.X, Y: o.Y}
+	var points *Points
+	closed := true
+	switch {
+	case o.Polygon != nil:
+		points = o.Polygon
+	case o.Polyline != nil:
+		points, closed = o.Polyline, false
+	default:
+		return []cp.Vector{
+			origin,
+			origin.Add(cp.Vector{X: o.Width}),
+			origin.Add(cp.Vector{X: o.Width, Y: o.Height}),
+			origin.Add(cp.Vector{Y: o.Height}),
+		}, true, nil
+	}
+	var verts []cp.Vector
+	for _, pair := range strings.Fields(points.Points) {
+		var p cp.Vector
+		if _, err := fmt.Sscanf(pair, "%g,%g", &p.X, &p.Y); err != nil {
+			return nil, false, fmt.Errorf("object %q: point %q: %w", o.Name, pair, err)
+		}
+		verts = append(verts, origin.Add(p))
+	}
+	return verts, closed, nil
+}
+
+// AddCollision adds the map's collision geometry to the space's static body,
+// offset by origin: the outlines of the objects of the object layers, and
+// the collision shapes of every tile of the tile layers. Outlines are made
+// of segments, which unlike polygons can be concave. It returns the shapes
+// it added.
+func (m *Map) AddCollision(space *cp.Space, origin cp.Vector) ([]*cp.Shape, error) {
+	var shapes []*cp.Shape
+	addGroup := func(g *Group, offset cp.Vector) error {
+		for _, o := range g.Objects {
+			verts, closed, err := o.vertices()
+			if err != nil {
+				return err
+			}
+			if closed && len(verts) > 2 {
+				verts = append(verts, verts[0])
+			}
+			for i := 1; i < len(verts); i++ {
+				a, b := verts[i-1].Add(offset), verts[i].Add(offset)
+				shape := space.AddShape(cp.NewSegment(space.StaticBody, a, b, 0))
+				shape.SetFriction(1)
+				shapes = append(shapes, shape)
+			}
+		}
+		return nil
+	}
+
+	for _, g := range m.Groups {
+		if err := addGroup(g, origin); err != nil {
+			return shapes, err
+		}
+	}
+	for _, l := range m.Layers {
+		for i, gid := range l.GIDs {
+			ts, id, ok := m.Tile(gid)
+			if !ok {
+				continue
+			}
+			// A tile bigger than the grid sticks out at the top.
+			offset := origin.Add(cp.Vector{
+				X: float64(i % m.Width * m.TileWidth),
+				Y: float64((i/m.Width+1)*m.TileHeight - ts.TileHeight),
+			})
+			for _, t := range ts.Tiles {
+				if t.ID != id {
+					continue
+				}
+				if err := addGroup(&t.Objects, offset); err != nil {
+					return shapes, err
+				}
+			}
+		}
+	}
+	return shapes, nil
+}
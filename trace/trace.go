@@ -0,0 +1,205 @@
+// Package trace turns artwork into physics outlines: it traces the alpha
+// channel of an image with marching squares and simplifies the contours, so
+// that a sprite collides along what is drawn rather than along its box. It
+// doesn't depend on ebiten.
+package trace
+
+import (
+	"fmt"
+	"image"
+	// Artwork is mostly PNG images.
+	_ "image/png"
+	"io/fs"
+
+	"github.com/jakecoffman/cp"
+)
+
+// Load reads the image file name of fsys and returns its simplified
+// contours, see Outline.
+func Load(fsys fs.FS, name string, threshold uint8, tolerance float64) ([][]cp.Vector, image.Image, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return Outline(img, threshold, tolerance), img, nil
+}
+
+// Outline returns the contours of the image simplified, see Contours and
+// Simplify.
+func Outline(img image.Image, threshold uint8, tolerance float64) [][]cp.Vector {
+	contours := Contours(img, threshold)
+	for i, c := range contours {
+		contours[i] = Simplify(c, tolerance)
+	}
+	return contours
+}
+
+// Contours traces the edges between the pixels whose alpha is at least
+// threshold, the solid ones, and the others, with marching squares. Each
+// contour is closed, it ends with its first point, and goes around with the
+// solid on its left on screen, i.e. counterclockwise around a solid part and
+// clockwise around a hole. Points are in pixels from the image's top left
+// corner, through the middles between the centers of the pixels; pixels
+// touching only by a corner aren't connected.
+func Contours(img image.Image, threshold uint8) [][]cp.Vector {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	solid := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			_, _, _, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			solid[y*w+x] = a>>8 >= uint32(threshold)
+		}
+	}
+	at := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < w && y < h && solid[y*w+x]
+	}
+
+	// The cells are between the centers of four pixels, the image padded
+	// with empty ones so that every contour closes. The points are the
+	// middles of the cells' edges, in half pixels to be exact keys.
+	next := map[[2]int]([2]int){}
+	for y := -1; y < h; y++ {
+		for x := -1; x < w; x++ {
+			corners := [4]bool{at(x, y), at(x+1, y), at(x+1, y+1), at(x, y+1)}
+			// The middles of the top, right, bottom and left edges: edge i
+			// goes from corner i to corner i+1.
+			mids := [4][2]int{
+				{2*x + 2, 2*y + 1},
+				{2*x + 3, 2*y + 2},
+				{2*x + 2, 2*y + 3},
+				{2*x + 1, 2*y + 2},
+			}
+			for _, s := range cellSegments(corners) {
+				next[mids[s[0]]] = mids[s[1]]
+			}
+		}
+	}
+
+	var contours [][]cp.Vector
+	for len(next) > 0 {
+		var start [2]int
+		for start = range next {
+			break
+		}
+		var contour []cp.Vector
+		for p, ok := start, true; ok; {
+			contour = append(contour, cp.Vector{X: float64(p[0]) / 2, Y: float64(p[1]) / 2})
+			q, found := next[p]
+			delete(next, p)
+			p, ok = q, found
+		}
+		contours = append(contours, append(contour, contour[0]))
+	}
+	return contours
+}
+
+// cellSegments returns the segments of a cell with the given solid corners,
+// top left, top right, bottom right and bottom left, as pairs of edges. A
+// segment goes from an edge whose first corner is empty and second solid to
+// one whose first corner is solid and second empty, which keeps the solid
+// on its left.
+func cellSegments(corners [4]bool) [][2]int {
+	var in, out []int
+	for i := 0; i < 4; i++ {
+		a, b := corners[i], corners[(i+1)%4]
+		switch {
+		case !a && b:
+			in = append(in, i)
+		case a && !b:
+			out = append(out, i)
+		}
+	}
+	switch len(in) {
+	case 0:
+		return nil
+	case 1:
+		return [][2]int{{in[0], out[0]}}
+	}
+	// A saddle: two solid corners facing each other, kept apart, each
+	// segment going around one of them, from the edge before it to the one
+	// after it.
+	segments := make([][2]int, 0, 2)
+	for _, i := range in {
+		corner := (i + 1) % 4
+		segments = append(segments, [2]int{i, corner})
+	}
+	return segments
+}
+
+// Simplify removes the points of a closed contour that are within tolerance
+// of the line through their neighbours, with Douglas-Peucker. The result is
+// closed too.
+func Simplify(contour []cp.Vector, tolerance float64) []cp.Vector {
+	if len(contour) < 4 {
+		return contour
+	}
+	points := contour[:len(contour)-1]
+	// A closed contour has no ends to keep, its first point and the farthest
+	// from it are.
+	far := 0
+	for i, p := range points {
+		if p.DistanceSq(points[0]) > points[far].DistanceSq(points[0]) {
+			far = i
+		}
+	}
+	simplified := douglasPeucker(points[:far+1], tolerance)
+	simplified = append(simplified[:len(simplified)-1], douglasPeucker(append(points[far:], points[0]), tolerance)...)
+	return simplified
+}
+
+// douglasPeucker simplifies an open polyline, keeping its ends.
+func douglasPeucker(points []cp.Vector, tolerance float64) []cp.Vector {
+	if len(points) < 3 {
+		return append([]cp.Vector(nil), points...)
+	}
+	a, b := points[0], points[len(points)-1]
+	far, farDist := 0, -1.0
+	for i := 1; i < len(points)-1; i++ {
+		if d := points[i].Distance(points[i].ClosestPointOnSegment(a, b)); d > farDist {
+			far, farDist = i, d
+		}
+	}
+	if farDist <= tolerance {
+		return []cp.Vector{a, b}
+	}
+	left := douglasPeucker(points[:far+1], tolerance)
+	return append(left[:len(left)-1], douglasPeucker(points[far:], tolerance)...)
+}
+
+// AddSegments adds the contours to the body as chains of segments of the
+// given radius, offset by origin, and returns them. Segments follow concave
+// outlines and holes exactly, but are hollow: what gets through stays in.
+func AddSegments(space *cp.Space, body *cp.Body, contours [][]cp.Vector, origin cp.Vector, radius float64) []*cp.Shape {
+	var shapes []*cp.Shape
+	for _, c := range contours {
+		for i := 1; i < len(c); i++ {
+			shape := space.AddShape(cp.NewSegment(body, c[i-1].Add(origin), c[i].Add(origin), radius))
+			shape.SetFriction(1)
+			shapes = append(shapes, shape)
+		}
+	}
+	return shapes
+}
+
+// Hull returns the convex hull of the contours, offset by origin, which
+// makes a solid polygon for a dynamic body where the outline's hollows
+// don't matter.
+func Hull(contours [][]cp.Vector, origin cp.Vector) []cp.Vector {
+	var points []cp.Vector
+	for _, c := range contours {
+		for _, p := range c {
+			points = append(points, p.Add(origin))
+		}
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	// The hull is made in place, at the start.
+	return points[:cp.ConvexHull(len(points), points, nil, 0)]
+}